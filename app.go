@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -14,49 +16,37 @@ import (
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
+// storesAppName names the user config directory holding settings and per-workspace stores.
+const storesAppName = "notes"
+
 // App struct holds application services and state.
-// Services are initialized during startup and exposed to the frontend via Wails bindings.
+// Each open workspace has its own services, held by the workspace manager; bindings that act on
+// a workspace take its ID as the first argument, where "" means the active workspace.
 type App struct {
 	ctx                       context.Context
-	fs                        *service.FilesystemService
+	workspaces                *service.WorkspaceManager
 	notes                     *service.NoteService
-	graph                     *service.GraphService
-	search                    *service.SearchService
-	tasks                     *service.TaskService
 	themes                    *service.ThemeService
 	stores                    *service.Stores
-	indexing                  bool
 	userConfigDir             string
 	currentWorkspaceConfigDir string
 }
 
 // NewApp creates a new App application struct with all services initialized.
 func NewApp() *App {
-	fs, err := service.NewFilesystemService()
-	if err != nil {
-		panic(fmt.Sprintf("failed to create filesystem service: %v", err))
-	}
-
-	notes := service.NewNoteService(fs)
-	graph := service.NewGraphService()
-	search := service.NewSearchService()
 	themes := service.NewThemeService()
 
-	stores, err := service.NewStores("notes", "default", nil)
+	// Stores used while no workspace is open, and for application-wide settings.
+	stores, err := service.NewStores(storesAppName, service.DefaultWorkspaceName, nil)
 	if err != nil {
 		panic(fmt.Sprintf("failed to create stores: %v", err))
 	}
 
-	tasks := service.NewTaskService(stores.Task)
-
 	return &App{
-		fs:     fs,
-		notes:  notes,
-		graph:  graph,
-		search: search,
-		tasks:  tasks,
-		themes: themes,
-		stores: stores,
+		workspaces: service.NewWorkspaceManager(storesAppName, themes),
+		notes:      service.NewNoteService(nil),
+		themes:     themes,
+		stores:     stores,
 	}
 }
 
@@ -66,12 +56,10 @@ func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 	a.logInfo("Application starting")
 
-	if a.fs != nil {
-		a.fs.SetLogger(ctx)
-	}
-	if a.tasks != nil {
-		a.tasks.SetLogger(ctx)
-	}
+	a.workspaces.SetLogger(ctx)
+	a.workspaces.SetCommitHook(a.logAutoCommit)
+	a.workspaces.SetConfigHook(a.emitWorkspaceConfig)
+	a.workspaces.SetIndexProgressHook(a.emitIndexProgress)
 
 	userConfigDir, err := paths.UserConfigDir("KnowledgeLab")
 	if err != nil {
@@ -85,16 +73,15 @@ func (a *App) startup(ctx context.Context) {
 }
 
 // shutdown is called when the app is closing.
-// Resource cleanup order: filesystem service -> stores (database).
+// Resource cleanup order: open workspaces (pending history commits, watchers, stores) -> default stores.
 func (a *App) shutdown(ctx context.Context) {
 	a.logInfo("Application shutdown initiated")
 
-	if a.fs != nil {
-		start := time.Now()
-		a.fs.Close()
-		elapsed := time.Since(start)
-		a.logInfo("Filesystem closed (%dms)", elapsed.Milliseconds())
+	start := time.Now()
+	if err := a.workspaces.CloseAll(); err != nil {
+		a.logWarning("failed to close workspaces: %v", err)
 	}
+	a.logInfo("Workspaces closed (%dms)", time.Since(start).Milliseconds())
 
 	if a.stores != nil {
 		a.stores.Close(nil)
@@ -103,6 +90,11 @@ func (a *App) shutdown(ctx context.Context) {
 	a.logInfo("Application shutdown complete")
 }
 
+// workspace returns the open workspace with the given ID, or the active workspace for "".
+func (a *App) workspace(workspaceID string) (*service.WorkspaceSession, error) {
+	return a.workspaces.Get(workspaceID)
+}
+
 // CreateNewWorkspace scaffolds a new workspace at the selected directory path.
 // Creates the workspace directory, adds a welcome tutorial note, and opens the workspace.
 func (a *App) CreateNewWorkspace() (*domain.WorkspaceInfo, error) {
@@ -126,22 +118,128 @@ func (a *App) CreateNewWorkspace() (*domain.WorkspaceInfo, error) {
 	return a.OpenWorkspace(path)
 }
 
-// OpenWorkspace opens a workspace at the specified path and builds the initial index.
+// OpenWorkspace opens a workspace at the specified path, makes it the active workspace and builds
+// its initial index. Other open workspaces stay open; opening one that is already open only activates it.
 func (a *App) OpenWorkspace(path string) (*domain.WorkspaceInfo, error) {
-	info, err := a.fs.OpenWorkspace(path)
+	w, opened, err := a.workspaces.Open(path)
 	if err != nil {
 		return nil, a.wrapError("failed to open workspace", err)
 	}
 
-	go a.buildInitialIndex()
+	if opened {
+		w.Indexer.Start()
+	}
+
+	return w.Info, nil
+}
+
+// IndexStatus returns how far a workspace's index build has got.
+func (a *App) IndexStatus(workspaceID string) (service.IndexStatus, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return service.IndexStatus{}, a.wrapError("failed to get index status", err)
+	}
+	return w.Indexer.Status(), nil
+}
+
+// ReindexWorkspace rebuilds a workspace's indexes from the notes on disk in the background,
+// cancelling a build that is still running. Progress is reported through index:progress events.
+func (a *App) ReindexWorkspace(workspaceID string) error {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return a.wrapError("failed to reindex workspace", err)
+	}
+	w.Indexer.Start()
+	return nil
+}
+
+// ListOpenWorkspaces returns the open workspaces in the order they were opened.
+func (a *App) ListOpenWorkspaces() []domain.WorkspaceInfo {
+	return a.workspaces.List()
+}
+
+// GetActiveWorkspace returns the ID of the active workspace, or "" when no workspace is open.
+func (a *App) GetActiveWorkspace() string {
+	return a.workspaces.Active()
+}
+
+// SetActiveWorkspace makes an open workspace the one bindings act on when given an empty workspace ID.
+func (a *App) SetActiveWorkspace(workspaceID string) error {
+	if err := a.workspaces.SetActive(workspaceID); err != nil {
+		return a.wrapError("failed to activate workspace", err)
+	}
+	return nil
+}
+
+// GetWorkspaceConfig returns a workspace's settings: those from its .knowledgelab/config.toml,
+// plus the frontmatter policy and auto-commit delay kept on this machine.
+func (a *App) GetWorkspaceConfig(workspaceID string) (domain.WorkspaceConfig, error) {
+	config, err := a.workspaces.Config(workspaceID)
+	if err != nil {
+		return domain.WorkspaceConfig{}, a.wrapError("failed to get workspace config", err)
+	}
+	return config, nil
+}
+
+// SaveWorkspaceConfig validates and saves a workspace's settings, writing the portable ones to
+// its config.toml, and returns them as saved. Changes are applied to the open workspace at once.
+func (a *App) SaveWorkspaceConfig(workspaceID string, config domain.WorkspaceConfig) (domain.WorkspaceConfig, error) {
+	saved, err := a.workspaces.SaveConfig(workspaceID, config)
+	if err != nil {
+		return domain.WorkspaceConfig{}, a.wrapError("failed to save workspace config", err)
+	}
+	return saved, nil
+}
+
+// emitWorkspaceConfig tells the frontend that a workspace's config changed.
+func (a *App) emitWorkspaceConfig(workspaceID string, config domain.WorkspaceConfig) {
+	a.logInfo("Workspace config changed workspace=%s", workspaceID)
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, service.WorkspaceConfigChangedEvent, service.WorkspaceConfigChange{
+			WorkspaceID: workspaceID,
+			Config:      config,
+		})
+	}
+}
+
+// GetFrontmatterPolicy returns a workspace's policy for writing metadata into frontmatter.
+func (a *App) GetFrontmatterPolicy(workspaceID string) (domain.FrontmatterPolicy, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return "", a.wrapError("failed to get frontmatter policy", err)
+	}
+
+	policy, err := w.Stores.Metadata.GetFrontmatterPolicy(w.ID())
+	if err != nil {
+		return "", a.wrapError("failed to get frontmatter policy", err)
+	}
+	return policy, nil
+}
+
+// SetFrontmatterPolicy changes when saves may write metadata into frontmatter for a workspace.
+// Accepts "never", "preserve-existing" or "always"; the setting is persisted per workspace.
+func (a *App) SetFrontmatterPolicy(workspaceID string, policy domain.FrontmatterPolicy) error {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return a.wrapError("failed to set frontmatter policy", err)
+	}
 
-	return info, nil
+	if err := w.Stores.Metadata.SetFrontmatterPolicy(w.ID(), policy); err != nil {
+		return a.wrapError("failed to set frontmatter policy", err)
+	}
+	w.Notes.SetFrontmatterPolicy(policy)
+	return nil
 }
 
-// ListNotes returns a summary of all notes in the current workspace.
+// ListNotes returns a summary of all notes in a workspace.
 // Summaries include basic metadata without full content for performance.
-func (a *App) ListNotes() ([]domain.NoteSummary, error) {
-	summaries, err := a.notes.ListNotes()
+func (a *App) ListNotes(workspaceID string) ([]domain.NoteSummary, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to list notes", err)
+	}
+
+	summaries, err := w.Notes.ListNotes()
 	if err != nil {
 		return nil, a.wrapError("failed to list notes", err)
 	}
@@ -151,8 +249,13 @@ func (a *App) ListNotes() ([]domain.NoteSummary, error) {
 
 // GetNote retrieves the full content and metadata for a specific note by ID.
 // The ID is the note's relative path within the workspace.
-func (a *App) GetNote(id string) (*domain.Note, error) {
-	note, err := a.notes.GetNote(id)
+func (a *App) GetNote(workspaceID, id string) (*domain.Note, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to get note", err)
+	}
+
+	note, err := w.Notes.GetNote(id)
 	if err != nil {
 		return nil, a.wrapError("failed to get note", err)
 	}
@@ -160,183 +263,988 @@ func (a *App) GetNote(id string) (*domain.Note, error) {
 	return note, nil
 }
 
-// SaveNote creates or updates a note in the workspace.
+// SaveNote creates or updates a note in a workspace.
 // After saving, the note is re-indexed for search, graph, and task updates.
-func (a *App) SaveNote(note *domain.Note) error {
-	if err := a.notes.SaveNote(note); err != nil {
-		return a.wrapError("failed to save note", err)
+// If the note's hash no longer matches the file because it was changed on disk since the note
+// was loaded, both changes are merged: a clean merge is saved and reported as merged; otherwise
+// the file is left alone, the note is written to a conflict copy, and the result holds the conflict.
+func (a *App) SaveNote(workspaceID string, note *domain.Note) (*domain.SaveNoteResult, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to save note", err)
+	}
+
+	merged, err := a.saveNote(w, note)
+	if err != nil {
+		var conflict *domain.ErrConflict
+		if errors.As(err, &conflict) {
+			a.logWarning("not saving %s, local changes kept in %s: %v", note.ID, conflict.CopyPath, conflict)
+			return &domain.SaveNoteResult{Conflict: &conflict.NoteConflict}, nil
+		}
+		return nil, err
 	}
+	return &domain.SaveNoteResult{Note: note, Merged: merged}, nil
+}
 
-	if err := a.graph.IndexNote(note); err != nil {
-		return a.wrapError("failed to index note in graph", err)
+// saveNote writes a note to a workspace and re-indexes it. It reports whether the note was merged
+// with changes made on disk since it was loaded.
+func (a *App) saveNote(w *service.WorkspaceSession, note *domain.Note) (bool, error) {
+	parsed, merged, err := w.Notes.SaveNoteMerging(note)
+	if err != nil {
+		return false, a.wrapError("failed to save note", err)
 	}
 
-	if err := a.search.IndexNote(note); err != nil {
+	if err := a.indexSavedNote(w, parsed); err != nil {
+		return false, err
+	}
+	return merged, nil
+}
+
+// indexSavedNote adds a note just written by the app to the graph, search, metadata, schema, and
+// task indexes, using the links and tasks parsed from the file written.
+func (a *App) indexSavedNote(w *service.WorkspaceSession, parsed *service.ParsedNote) error {
+	note := parsed.Note
+	w.History.NoteChanged(note.ID)
+	w.Indexer.NoteChanged(note.ID)
+
+	w.Graph.IndexParsedNotes([]*domain.Note{note})
+
+	if err := w.Search.IndexNote(note); err != nil {
 		return a.wrapError("failed to index note in search", err)
 	}
 
-	tasks := a.notes.ExtractTasks(note.ID, note.Path, []byte(note.Content))
-	if err := a.tasks.IndexNote(note.ID, note.Path, tasks, note.ModifiedAt); err != nil {
-		return a.wrapError("failed to index tasks", err)
+	if err := w.Query.IndexNote(note); err != nil {
+		return a.wrapError("failed to index note metadata", err)
 	}
 
+	w.Schemas.IndexNote(note)
+
+	if err := w.Tasks.IndexNote(note.ID, note.Path, parsed.Tasks, note.ModifiedAt); err != nil {
+		return a.wrapError("failed to index tasks", err)
+	}
 	return nil
 }
 
-// DeleteNote removes a note from the workspace.
-// The note is removed from filesystem, graph, search, and task indexes.
-func (a *App) DeleteNote(id string) error {
-	if err := a.notes.DeleteNote(id); err != nil {
+// DeleteNote moves a note of a workspace into its trash, recording the notes linking to it.
+// The note is removed from graph, search, and task indexes; RestoreNote brings it back.
+func (a *App) DeleteNote(workspaceID, id string) error {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return a.wrapError("failed to delete note", err)
+	}
+
+	backlinks := []string{}
+	for _, link := range w.Graph.GetBacklinks(id) {
+		if link.Source != id && !slices.Contains(backlinks, link.Source) {
+			backlinks = append(backlinks, link.Source)
+		}
+	}
+	if _, err := w.Notes.TrashNote(id, backlinks); err != nil {
 		return a.wrapError("failed to delete note", err)
 	}
+	w.History.NoteChanged(id)
+
+	return a.unindexNotes(w, []string{id})
+}
+
+// unindexNotes removes notes that no longer exist under their IDs from the graph, search,
+// metadata, schema, and task indexes.
+func (a *App) unindexNotes(w *service.WorkspaceSession, noteIDs []string) error {
+	for _, id := range noteIDs {
+		w.Indexer.NoteChanged(id)
+		w.Graph.RemoveNote(id)
+		w.Search.RemoveNote(id)
+		w.Query.RemoveNote(id)
+		w.Schemas.RemoveNote(id)
+
+		if err := w.Tasks.RemoveNote(id); err != nil {
+			return a.wrapError("failed to remove tasks", err)
+		}
+	}
+	return nil
+}
+
+// ListTrash returns the notes in the trash of a workspace, most recently deleted first.
+func (a *App) ListTrash(workspaceID string) ([]domain.TrashEntry, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to list trash", err)
+	}
+
+	entries, err := w.Notes.ListTrash()
+	if err != nil {
+		return nil, a.wrapError("failed to list trash", err)
+	}
+	return entries, nil
+}
+
+// RestoreNote moves a note or folder out of the trash of a workspace, to its original path or, if
+// that is taken, to an alternate path beside it. The restored notes are re-indexed, and so are the
+// notes that linked to them when they were deleted, which reconnects their links.
+func (a *App) RestoreNote(workspaceID, entryID string) (*domain.RestoredNote, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to restore note", err)
+	}
+
+	restored, err := w.Notes.RestoreNote(entryID)
+	if err != nil {
+		return nil, a.wrapError("failed to restore note", err)
+	}
+
+	noteIDs := slices.Clone(restored.NoteIDs)
+	for _, id := range restored.Entry.Backlinks {
+		if _, err := w.FS.StatFile(id); err == nil && !slices.Contains(noteIDs, id) {
+			noteIDs = append(noteIDs, id)
+		}
+	}
+	if restored.Entry.Folder {
+		if err := w.Attachments.Refresh(); err != nil {
+			return nil, a.wrapError("failed to index restored attachments", err)
+		}
+	}
+	if err := a.reindexNotes(w, noteIDs); err != nil {
+		return nil, err
+	}
+	a.recordHistory(w, append(slices.Clone(restored.NoteIDs), restored.Relinked...))
 
-	a.graph.RemoveNote(id)
-	a.search.RemoveNote(id)
+	return restored, nil
+}
 
-	if err := a.tasks.RemoveNote(id); err != nil {
-		return a.wrapError("failed to remove tasks", err)
+// EmptyTrash permanently deletes every note in the trash of a workspace and returns how many were deleted.
+func (a *App) EmptyTrash(workspaceID string) (int, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return 0, a.wrapError("failed to empty trash", err)
 	}
 
+	purged, err := w.Notes.EmptyTrash()
+	if err != nil {
+		return purged, a.wrapError("failed to empty trash", err)
+	}
+	return purged, nil
+}
+
+// ListFolderTree returns the folders of a workspace as a tree with the number of notes in each.
+func (a *App) ListFolderTree(workspaceID string) (*domain.FolderNode, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to list folders", err)
+	}
+
+	tree, err := w.Notes.ListFolderTree()
+	if err != nil {
+		return nil, a.wrapError("failed to list folders", err)
+	}
+	return tree, nil
+}
+
+// CreateFolder creates a folder, with any missing parents, in a workspace and returns its cleaned path.
+func (a *App) CreateFolder(workspaceID, folder string) (string, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return "", a.wrapError("failed to create folder", err)
+	}
+
+	created, err := w.Notes.CreateFolder(folder)
+	if err != nil {
+		return "", a.wrapError("failed to create folder", err)
+	}
+	return created, nil
+}
+
+// RenameFolder renames a folder of a workspace within its parent folder. See MoveFolder.
+func (a *App) RenameFolder(workspaceID, folder, name string, dryRun bool) (*service.FolderMoveResult, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to rename folder", err)
+	}
+
+	result, err := w.Notes.RenameFolder(folder, name, dryRun)
+	if err != nil {
+		return nil, a.wrapError("failed to rename folder", err)
+	}
+	return result, a.indexFolderMove(w, result)
+}
+
+// MoveFolder moves a folder of a workspace with everything in it, rewrites the path-based links
+// to and from the notes it holds, and re-indexes every moved or rewritten note.
+// With dryRun set, nothing is written and the result previews the per-note diffs.
+func (a *App) MoveFolder(workspaceID, oldPath, newPath string, dryRun bool) (*service.FolderMoveResult, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to move folder", err)
+	}
+
+	result, err := w.Notes.MoveFolder(oldPath, newPath, dryRun)
+	if err != nil {
+		return nil, a.wrapError("failed to move folder", err)
+	}
+	return result, a.indexFolderMove(w, result)
+}
+
+// indexFolderMove drops the old IDs of the notes an applied folder move affected from the indexes
+// and indexes the notes under their new IDs.
+func (a *App) indexFolderMove(w *service.WorkspaceSession, result *service.FolderMoveResult) error {
+	if !result.Applied {
+		return nil
+	}
+
+	if err := a.unindexNotes(w, result.OldIDs()); err != nil {
+		return err
+	}
+	if err := a.reindexNotes(w, result.NoteIDs()); err != nil {
+		return err
+	}
+	a.recordHistory(w, result.OldIDs())
+	a.recordHistory(w, result.NoteIDs())
 	return nil
 }
 
-// CreateNote creates a new note with the specified title in an optional folder.
+// DeleteFolder moves a folder of a workspace with everything in it into the trash as one entry,
+// recording the notes outside it that link into it. RestoreNote brings the folder back.
+func (a *App) DeleteFolder(workspaceID, folder string) (*domain.TrashEntry, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to delete folder", err)
+	}
+
+	prefix := strings.TrimSuffix(folder, "/") + "/"
+	backlinks := []string{}
+	for _, node := range w.Graph.GetGraph().Nodes {
+		if !strings.HasPrefix(node.ID, prefix) {
+			continue
+		}
+		for _, link := range w.Graph.GetBacklinks(node.ID) {
+			if !strings.HasPrefix(link.Source, prefix) && !slices.Contains(backlinks, link.Source) {
+				backlinks = append(backlinks, link.Source)
+			}
+		}
+	}
+
+	entry, err := w.Notes.TrashFolder(folder, backlinks)
+	if err != nil {
+		return nil, a.wrapError("failed to delete folder", err)
+	}
+	a.recordHistory(w, entry.Notes)
+
+	if err := a.unindexNotes(w, entry.Notes); err != nil {
+		return nil, err
+	}
+	if err := w.Attachments.Refresh(); err != nil {
+		return nil, a.wrapError("failed to index attachments", err)
+	}
+	return entry, nil
+}
+
+// CreateNote creates a new note with the specified title in an optional folder of a workspace.
 // Returns the created note with generated ID and default content.
-func (a *App) CreateNote(title, folder string) (*domain.Note, error) {
-	note, err := a.notes.CreateNote(title, folder)
+func (a *App) CreateNote(workspaceID, title, folder string) (*domain.Note, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to create note", err)
+	}
+
+	parsed, err := w.Notes.CreateTypedNote(title, folder, "", nil)
 	if err != nil {
 		return nil, a.wrapError("failed to create note", err)
 	}
 
-	if err := a.graph.IndexNote(note); err != nil {
-		return nil, a.wrapError("failed to index new note in graph", err)
+	if err := a.indexSavedNote(w, parsed); err != nil {
+		return nil, err
 	}
+	return parsed.Note, nil
+}
 
-	if err := a.search.IndexNote(note); err != nil {
-		return nil, a.wrapError("failed to index new note in search", err)
+// CreateTypedNote creates a new note of the given type, filling in the defaults declared by the type's schema.
+// Returns an error if the workspace defines no schema for the type.
+func (a *App) CreateTypedNote(workspaceID, title, folder, noteType string) (*domain.Note, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to create note", err)
 	}
 
-	tasks := a.notes.ExtractTasks(note.ID, note.Path, []byte(note.Content))
-	if err := a.tasks.IndexNote(note.ID, note.Path, tasks, note.ModifiedAt); err != nil {
-		return nil, a.wrapError("failed to index tasks", err)
+	if _, ok := w.Schemas.Schema(noteType); !ok {
+		return nil, a.wrapError("failed to create note", &domain.ErrNotFound{Resource: "note type", ID: noteType})
 	}
 
-	return note, nil
+	parsed, err := w.Notes.CreateTypedNote(title, folder, noteType, w.Schemas.Defaults(noteType))
+	if err != nil {
+		return nil, a.wrapError("failed to create note", err)
+	}
+
+	if err := a.indexSavedNote(w, parsed); err != nil {
+		return nil, err
+	}
+	return parsed.Note, nil
 }
 
 // GetBacklinks returns all notes that link to the specified note.
 // Used to display backlinks panel in the UI.
-func (a *App) GetBacklinks(noteID string) ([]domain.Link, error) {
-	links := a.graph.GetBacklinks(noteID)
+func (a *App) GetBacklinks(workspaceID, noteID string) ([]domain.Link, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to get backlinks", err)
+	}
+
+	links := w.Graph.GetBacklinks(noteID)
 	return links, nil
 }
 
-// GetGraph returns the complete note graph structure.
-// Includes all notes as nodes and links as edges.
-func (a *App) GetGraph() (*service.Graph, error) {
-	graph := a.graph.GetGraph()
+// GetGraph returns the complete note graph structure of a workspace.
+// Includes all notes as nodes with metadata and links collapsed into weighted edges.
+func (a *App) GetGraph(workspaceID string) (*service.Graph, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to get graph", err)
+	}
+
+	graph := w.Graph.GetGraph()
 	return graph, nil
 }
 
-// Search performs a full-text search with optional filters.
+// GetGraphChunk returns up to size graph nodes starting at cursor, with the edges leaving them.
+// Large graphs can be streamed by requesting chunks until the returned chunk is done.
+func (a *App) GetGraphChunk(workspaceID string, cursor, size int) (*service.GraphChunk, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to get graph", err)
+	}
+
+	chunk := w.Graph.GetGraphChunk(cursor, size)
+	return chunk, nil
+}
+
+// Search performs a full-text search with optional filters in a workspace.
 // Supports filtering by tags, path prefix, and date range.
-func (a *App) Search(query service.SearchQuery) ([]service.SearchResult, error) {
-	results, err := a.search.Search(query)
+func (a *App) Search(workspaceID string, query service.SearchQuery) ([]service.SearchResult, error) {
+	w, err := a.workspace(workspaceID)
 	if err != nil {
 		return nil, a.wrapError("failed to search", err)
 	}
 
+	results, err := w.Search.Search(query)
+	if err != nil {
+		return nil, a.wrapError("failed to search", err)
+	}
+
+	return results, nil
+}
+
+// SearchWorkspaces runs a search across several open workspaces, or all of them when workspaceIDs
+// is empty. Results are merged by score and tagged with the workspace they come from.
+func (a *App) SearchWorkspaces(query service.SearchQuery, workspaceIDs []string) ([]service.WorkspaceSearchResult, error) {
+	results, err := a.workspaces.Search(query, workspaceIDs)
+	if err != nil {
+		return nil, a.wrapError("failed to search workspaces", err)
+	}
+
 	return results, nil
 }
 
 // GetNotesWithTag returns all notes that contain the specified tag.
-func (a *App) GetNotesWithTag(tagName string) ([]string, error) {
-	noteIDs := a.graph.GetNotesWithTag(tagName)
+func (a *App) GetNotesWithTag(workspaceID, tagName string) ([]string, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to get notes with tag", err)
+	}
+
+	noteIDs := w.Graph.GetNotesWithTag(tagName)
 	return noteIDs, nil
 }
 
-// GetAllTags returns all unique tags across all notes in the workspace.
-func (a *App) GetAllTags() ([]string, error) {
-	tags := a.graph.GetAllTags()
+// GetNotesWithTagHierarchy returns all notes with the specified tag,
+// optionally including notes tagged with nested descendants (e.g., "project/alpha" for "project").
+func (a *App) GetNotesWithTagHierarchy(workspaceID, tagName string, includeDescendants bool) ([]string, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to get notes with tag", err)
+	}
+
+	noteIDs := w.Graph.GetNotesWithTagHierarchy(tagName, includeDescendants)
+	return noteIDs, nil
+}
+
+// GetTagTree returns the nested tag hierarchy with rolled-up note counts.
+func (a *App) GetTagTree(workspaceID string) ([]domain.TagNode, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to get tag tree", err)
+	}
+
+	tree := w.Graph.GetTagTree()
+	return tree, nil
+}
+
+// RenameTag renames a tag (and its nested descendants) in every note of a workspace.
+// Rewrites frontmatter and inline tags, then re-indexes the modified notes.
+// With dryRun set, nothing is written and the result previews the per-note diffs.
+func (a *App) RenameTag(workspaceID, oldTag, newTag string, dryRun bool) (*service.TagOperationResult, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to rename tag", err)
+	}
+
+	result, err := w.Notes.RenameTag(oldTag, newTag, dryRun)
+	return a.applyTagOperation(w, result, err, "failed to rename tag")
+}
+
+// MergeTags folds the source tags (and their nested descendants) into target
+// across a workspace, then re-indexes the modified notes.
+// With dryRun set, nothing is written and the result previews the per-note diffs.
+func (a *App) MergeTags(workspaceID string, sources []string, target string, dryRun bool) (*service.TagOperationResult, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to merge tags", err)
+	}
+
+	result, err := w.Notes.MergeTags(sources, target, dryRun)
+	return a.applyTagOperation(w, result, err, "failed to merge tags")
+}
+
+// DeleteTag removes a tag (and its nested descendants) from every note in a workspace,
+// then re-indexes the modified notes.
+// With dryRun set, nothing is written and the result previews the per-note diffs.
+func (a *App) DeleteTag(workspaceID, tag string, dryRun bool) (*service.TagOperationResult, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to delete tag", err)
+	}
+
+	result, err := w.Notes.DeleteTag(tag, dryRun)
+	return a.applyTagOperation(w, result, err, "failed to delete tag")
+}
+
+// applyTagOperation re-indexes the notes touched by an applied tag operation. When the
+// operation failed part way, the notes it had already written are still re-indexed and
+// returned with the error.
+func (a *App) applyTagOperation(w *service.WorkspaceSession, result *service.TagOperationResult, opErr error, msg string) (*service.TagOperationResult, error) {
+	if result == nil {
+		return nil, a.wrapError(msg, opErr)
+	}
+	if !result.Applied {
+		return result, nil
+	}
+
+	if err := a.reindexNotes(w, result.NoteIDs()); err != nil {
+		return nil, err
+	}
+	a.recordHistory(w, result.NoteIDs())
+
+	if opErr != nil {
+		return result, a.wrapError(msg, opErr)
+	}
+	return result, nil
+}
+
+// GetAllTags returns all unique tags across all notes in a workspace.
+func (a *App) GetAllTags(workspaceID string) ([]string, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to get tags", err)
+	}
+
+	tags := w.Graph.GetAllTags()
 	return tags, nil
 }
 
 // GetAllTagsWithCounts returns all tags with occurrence counts and note IDs.
 // Results are sorted by tag name.
-func (a *App) GetAllTagsWithCounts() ([]domain.TagInfo, error) {
-	tagInfos := a.graph.GetAllTagsWithCounts()
+//
+// Deprecated: Use GetTagTree, which also reports the tag hierarchy and rolled-up counts.
+func (a *App) GetAllTagsWithCounts(workspaceID string) ([]domain.TagInfo, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to get tags", err)
+	}
+
+	tagInfos := w.Graph.GetAllTagsWithCounts()
 	return tagInfos, nil
 }
 
 // GetTagInfo returns information about a specific tag including count and note IDs.
-func (a *App) GetTagInfo(tagName string) (*domain.TagInfo, error) {
-	tagInfo := a.graph.GetTagInfo(tagName)
+func (a *App) GetTagInfo(workspaceID, tagName string) (*domain.TagInfo, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to get tag", err)
+	}
+
+	tagInfo := w.Graph.GetTagInfo(tagName)
 	if tagInfo == nil {
 		return nil, &domain.ErrNotFound{Resource: "tag", ID: tagName}
 	}
 	return tagInfo, nil
 }
 
+// RunQuery evaluates a metadata query (e.g. `TABLE status, due FROM #project WHERE status != "done" SORT due`)
+// against the frontmatter and inline fields of all indexed notes of a workspace.
+func (a *App) RunQuery(workspaceID, query string) (*service.QueryResult, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to run query", err)
+	}
+
+	result, err := w.Query.RunQuery(query)
+	if err != nil {
+		return nil, a.wrapError("failed to run query", err)
+	}
+	return result, nil
+}
+
+// GetTypeSchemas returns the note type schemas defined in a workspace's types.toml.
+func (a *App) GetTypeSchemas(workspaceID string) ([]service.TypeSchema, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to get note type schemas", err)
+	}
+
+	return w.Schemas.Schemas(), nil
+}
+
+// ReloadTypeSchemas re-reads types.toml and revalidates every note against the new schemas.
+func (a *App) ReloadTypeSchemas(workspaceID string) ([]service.TypeSchema, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to reload note type schemas", err)
+	}
+
+	if err := w.LoadTypeSchemas(); err != nil {
+		return nil, a.wrapError("failed to reload note type schemas", err)
+	}
+
+	summaries, err := w.Notes.ListNotes()
+	if err != nil {
+		return nil, a.wrapError("failed to list notes", err)
+	}
+
+	noteIDs := make([]string, len(summaries))
+	for i, summary := range summaries {
+		noteIDs[i] = summary.ID
+	}
+
+	w.Schemas.Clear()
+	if err := a.reindexNotes(w, noteIDs); err != nil {
+		return nil, err
+	}
+
+	return w.Schemas.Schemas(), nil
+}
+
+// GetDiagnostics returns the schema diagnostics for a note, empty when the note matches its type.
+func (a *App) GetDiagnostics(workspaceID, noteID string) ([]service.Diagnostic, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to get diagnostics", err)
+	}
+
+	return w.Schemas.Diagnostics(noteID), nil
+}
+
+// GetAllDiagnostics returns the schema diagnostics for every note in a workspace.
+func (a *App) GetAllDiagnostics(workspaceID string) ([]service.Diagnostic, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to get diagnostics", err)
+	}
+
+	return w.Schemas.AllDiagnostics(), nil
+}
+
 // RenderMarkdown converts markdown content to HTML.
-// Used by the frontend for preview mode rendering.
+// Used by the frontend for preview mode rendering; query blocks run against the active workspace.
 func (a *App) RenderMarkdown(markdown string) (string, error) {
-	html, err := a.notes.RenderMarkdown(markdown)
+	notes := a.notes
+	if w, err := a.workspace(""); err == nil {
+		notes = w.Notes
+	}
+
+	html, err := notes.RenderMarkdown(markdown)
 	if err != nil {
 		return "", a.wrapError("failed to render markdown", err)
 	}
 	return html, nil
 }
 
-// buildInitialIndex loads all notes and builds graph and search indexes.
-func (a *App) buildInitialIndex() {
-	a.indexing = true
-	defer func() { a.indexing = false }()
+// RenderNoteMarkdown converts a note's markdown to HTML for preview,
+// resolving relative image and attachment paths from the note's folder.
+func (a *App) RenderNoteMarkdown(workspaceID, noteID, markdown string) (string, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return "", a.wrapError("failed to render markdown", err)
+	}
+
+	html, err := w.Notes.RenderNoteMarkdown(noteID, markdown)
+	if err != nil {
+		return "", a.wrapError("failed to render markdown", err)
+	}
+	return html, nil
+}
 
-	overallStart := time.Now()
-	a.logInfo("Starting initial workspace index build")
+// ListAttachments returns every image, PDF and other attachment file in a workspace.
+func (a *App) ListAttachments(workspaceID string) ([]domain.Attachment, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to list attachments", err)
+	}
+	return w.Attachments.List(), nil
+}
 
-	listStart := time.Now()
-	summaries, err := a.notes.ListNotes()
+// ImportAttachment copies a file into a workspace's attachment folder for a note
+// and returns the link text to insert, e.g. "![[diagram.png]]".
+func (a *App) ImportAttachment(workspaceID, srcPath, noteID string) (string, error) {
+	w, err := a.workspace(workspaceID)
 	if err != nil {
-		a.logError("failed to list notes during indexing: %v", err)
-		return
+		return "", a.wrapError("failed to import attachment", err)
 	}
-	a.logInfo("Listed %d notes (%dms)", len(summaries), time.Since(listStart).Milliseconds())
 
-	noteLoadStart := time.Now()
-	notes := make([]domain.Note, 0, len(summaries))
-	for i, summary := range summaries {
-		note, err := a.notes.GetNote(summary.ID)
-		if err != nil {
-			a.logWarning("failed to load note %s: %v", summary.ID, err)
-			continue
+	link, err := w.Attachments.Import(srcPath, noteID)
+	if err != nil {
+		return "", a.wrapError("failed to import attachment", err)
+	}
+	return link, nil
+}
+
+// GetOrphanedAttachments returns the attachments that no note links to or embeds.
+func (a *App) GetOrphanedAttachments(workspaceID string) ([]domain.Attachment, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to find orphaned attachments", err)
+	}
+	return w.Graph.OrphanedAttachments(), nil
+}
+
+// RenameAttachment moves an attachment and rewrites the links to it in every note,
+// then re-indexes the modified notes.
+// With dryRun set, nothing is written and the result previews the per-note diffs.
+func (a *App) RenameAttachment(workspaceID, oldPath, newPath string, dryRun bool) (*service.AttachmentRenameResult, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to rename attachment", err)
+	}
+
+	result, err := w.Notes.RenameAttachment(oldPath, newPath, dryRun)
+	if err != nil {
+		return nil, a.wrapError("failed to rename attachment", err)
+	}
+
+	if result.Applied {
+		if err := a.reindexNotes(w, result.NoteIDs()); err != nil {
+			return nil, err
 		}
+		a.recordHistory(w, result.NoteIDs())
+	}
+
+	return result, nil
+}
+
+// GetAttachmentFolder returns the folder imported attachments are copied into for a workspace.
+func (a *App) GetAttachmentFolder(workspaceID string) (string, error) {
+	config, err := a.workspaces.Config(workspaceID)
+	if err != nil {
+		return "", a.wrapError("failed to get attachment folder", err)
+	}
+	return config.AttachmentFolder, nil
+}
+
+// SetAttachmentFolder changes where imported attachments are copied for a workspace, saving it
+// to the workspace's config.toml. A folder starting with "./" is relative to the note the
+// attachment is imported for.
+func (a *App) SetAttachmentFolder(workspaceID, folder string) error {
+	config, err := a.workspaces.Config(workspaceID)
+	if err != nil {
+		return a.wrapError("failed to set attachment folder", err)
+	}
+
+	config.AttachmentFolder = folder
+	if _, err := a.workspaces.SaveConfig(workspaceID, config); err != nil {
+		return a.wrapError("failed to set attachment folder", err)
+	}
+	return nil
+}
+
+// ImportObsidianVault imports an Obsidian vault into targetFolder of a workspace
+// ("" for the root), emitting service.ImportProgressEvent events while it runs.
+// Imported notes and attachments are indexed once the import completes.
+// With dryRun set, nothing is written and the report previews the import.
+func (a *App) ImportObsidianVault(workspaceID, vaultPath, targetFolder string, dryRun bool) (*service.ImportReport, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to import obsidian vault", err)
+	}
+
+	report, err := w.Importer.ImportObsidianVault(vaultPath, targetFolder, dryRun, a.emitImportProgress)
+	if err != nil {
+		return nil, a.wrapError("failed to import obsidian vault", err)
+	}
+
+	return a.indexImport(w, report)
+}
+
+// ImportLogseqGraph imports a Logseq graph into targetFolder of a workspace
+// ("" for the root), emitting service.ImportProgressEvent events while it runs.
+// Journals are renamed to the workspace's daily note convention.
+// With dryRun set, nothing is written and the report previews the import.
+func (a *App) ImportLogseqGraph(workspaceID, graphPath, targetFolder string, dryRun bool) (*service.ImportReport, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to import logseq graph", err)
+	}
+
+	report, err := w.Importer.ImportLogseqGraph(graphPath, targetFolder, dryRun, a.emitImportProgress)
+	if err != nil {
+		return nil, a.wrapError("failed to import logseq graph", err)
+	}
+
+	return a.indexImport(w, report)
+}
+
+// ExportSite writes the selected notes of a workspace to outputDir as a static HTML site
+// with linked pages, backlinks, the chosen theme and a client-side search index.
+func (a *App) ExportSite(workspaceID, outputDir string, options service.SiteExportOptions) (*service.ExportReport, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to export site", err)
+	}
+
+	report, err := w.Exporter.ExportSite(outputDir, options)
+	if err != nil {
+		return nil, a.wrapError("failed to export site", err)
+	}
+
+	a.logInfo("Exported %d notes to %s (%d unresolved links)", len(report.Notes), report.Output, len(report.UnresolvedLinks))
+	return report, nil
+}
+
+// ExportNotes exports the selected notes of a workspace for sharing outside the app,
+// as a standalone HTML file, an EPUB book or a folder of portable CommonMark files.
+func (a *App) ExportNotes(workspaceID, outputPath string, options service.DocumentExportOptions) (*service.ExportReport, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to export notes", err)
+	}
+
+	report, err := w.Exporter.ExportNotes(outputPath, options)
+	if err != nil {
+		return nil, a.wrapError("failed to export notes", err)
+	}
+
+	a.logInfo("Exported %d notes as %s to %s (%d unresolved links)", len(report.Notes), options.Format, report.Output, len(report.UnresolvedLinks))
+	return report, nil
+}
+
+// HasGitHistory reports whether a workspace is in a git repository.
+// Note versions then come from git commits; in other workspaces they are snapshots kept on save.
+func (a *App) HasGitHistory(workspaceID string) bool {
+	w, err := a.workspace(workspaceID)
+	return err == nil && w.History.IsRepository()
+}
+
+// ListNoteVersions returns the prior versions of a note, newest first: the commits that changed it
+// (following renames) in a git workspace, or its snapshots otherwise. A limit of 0 returns all of them.
+func (a *App) ListNoteVersions(workspaceID, noteID string, limit int) ([]domain.NoteVersion, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to list note versions", err)
+	}
 
-		if err := a.graph.IndexNote(note); err != nil {
-			a.logWarning("failed to index note %s in graph: %v", summary.ID, err)
+	var versions []domain.NoteVersion
+	if w.History.IsRepository() {
+		versions, err = w.History.NoteHistory(noteID, limit)
+	} else {
+		versions, err = w.Notes.ListNoteVersions(noteID)
+		if limit > 0 && len(versions) > limit {
+			versions = versions[:limit]
 		}
+	}
+	if err != nil {
+		return nil, a.wrapError("failed to list note versions", err)
+	}
+	return versions, nil
+}
+
+// GetNoteVersion returns a note's content as of one of its versions.
+func (a *App) GetNoteVersion(workspaceID, noteID, hash string) (string, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return "", a.wrapError("failed to get note version", err)
+	}
+
+	var content string
+	if w.History.IsRepository() {
+		content, err = w.History.NoteVersionContent(noteID, hash)
+	} else {
+		content, err = w.Notes.GetNoteVersion(noteID, hash)
+	}
+	if err != nil {
+		return "", a.wrapError("failed to get note version", err)
+	}
+	return content, nil
+}
+
+// DiffNoteVersions returns the line diff of a note between two of its versions.
+// An empty toHash compares against the note as it is on disk.
+func (a *App) DiffNoteVersions(workspaceID, noteID, fromHash, toHash string) ([]service.DiffLine, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to diff note versions", err)
+	}
+
+	var diff []service.DiffLine
+	if w.History.IsRepository() {
+		diff, err = w.History.DiffNoteVersions(noteID, fromHash, toHash)
+	} else {
+		diff, err = w.Notes.DiffNoteVersions(noteID, fromHash, toHash)
+	}
+	if err != nil {
+		return nil, a.wrapError("failed to diff note versions", err)
+	}
+	return diff, nil
+}
+
+// RestoreNoteVersion replaces a note with its content as of one of its versions, then re-indexes it.
+// In a git workspace the restore is itself committed when auto-commit is on; otherwise the
+// replaced content is kept as a snapshot.
+func (a *App) RestoreNoteVersion(workspaceID, noteID, hash string) (*domain.Note, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to restore note version", err)
+	}
 
-		tasks := a.notes.ExtractTasks(note.ID, note.Path, []byte(note.Content))
-		if err := a.tasks.IndexNote(note.ID, note.Path, tasks, note.ModifiedAt); err != nil {
-			a.logWarning("failed to index tasks for note %s: %v", summary.ID, err)
+	if w.History.IsRepository() {
+		if err := w.History.RestoreNoteVersion(noteID, hash); err != nil {
+			return nil, a.wrapError("failed to restore note version", err)
 		}
+		w.History.NoteChanged(noteID)
+	} else if _, err := w.Notes.RestoreNoteVersion(noteID, hash); err != nil {
+		return nil, a.wrapError("failed to restore note version", err)
+	}
+
+	if err := a.reindexNotes(w, []string{noteID}); err != nil {
+		return nil, err
+	}
+
+	note, err := w.Notes.GetNote(noteID)
+	if err != nil {
+		return nil, a.wrapError("failed to get note", err)
+	}
+	return note, nil
+}
+
+// GetAutoCommitDelay returns how many seconds after the last save changed notes are committed
+// to git in a workspace; 0 means auto-commit is off.
+func (a *App) GetAutoCommitDelay(workspaceID string) (int, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return 0, a.wrapError("failed to get auto-commit delay", err)
+	}
+
+	delay, err := w.Stores.Metadata.GetAutoCommitDelay(w.ID())
+	if err != nil {
+		return 0, a.wrapError("failed to get auto-commit delay", err)
+	}
+	return delay, nil
+}
 
-		notes = append(notes, *note)
+// SetAutoCommitDelay turns automatic git commits on for a workspace, committing
+// changed notes the given number of seconds after the last save, or off with 0.
+// The setting is persisted per workspace.
+func (a *App) SetAutoCommitDelay(workspaceID string, seconds int) error {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return a.wrapError("failed to set auto-commit delay", err)
+	}
 
-		if (i+1)%50 == 0 {
-			a.logInfo("Indexed %d/%d notes...", i+1, len(summaries))
+	if err := w.Stores.Metadata.SetAutoCommitDelay(w.ID(), seconds); err != nil {
+		return a.wrapError("failed to set auto-commit delay", err)
+	}
+	w.History.SetAutoCommit(time.Duration(seconds) * time.Second)
+	return nil
+}
+
+// recordHistory reports notes changed by a bulk operation for the next automatic commit.
+func (a *App) recordHistory(w *service.WorkspaceSession, noteIDs []string) {
+	for _, id := range noteIDs {
+		w.History.NoteChanged(id)
+	}
+}
+
+// logAutoCommit logs the outcome of an automatic commit.
+func (a *App) logAutoCommit(hash string, err error) {
+	if err != nil {
+		a.logWarning("failed to commit note changes: %v", err)
+	} else if hash != "" {
+		a.logInfo("Committed note changes %s", hash)
+	}
+}
+
+// indexImport indexes the attachments and notes written by an import.
+func (a *App) indexImport(w *service.WorkspaceSession, report *service.ImportReport) (*service.ImportReport, error) {
+	a.logInfo("Import from %s: %d notes, %d attachments, %d unresolved links (dry run: %t)",
+		report.Source, len(report.Notes), len(report.Attachments), len(report.UnresolvedLinks), report.DryRun)
+
+	if report.DryRun {
+		return report, nil
+	}
+
+	if err := w.Attachments.Refresh(); err != nil {
+		return nil, a.wrapError("failed to index imported attachments", err)
+	}
+	if err := a.reindexNotes(w, report.Notes); err != nil {
+		return nil, err
+	}
+	a.recordHistory(w, report.Notes)
+
+	return report, nil
+}
+
+// emitImportProgress forwards import progress to the frontend.
+func (a *App) emitImportProgress(progress service.ImportProgress) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, service.ImportProgressEvent, progress)
+	}
+}
+
+// reindexNotes reloads the given notes from disk and refreshes their graph, search, metadata, schema,
+// and task indexes. Like an index build, it adds all the notes to the graph, task, and search
+// indexes at once, with their tasks persisted in one transaction.
+func (a *App) reindexNotes(w *service.WorkspaceSession, noteIDs []string) error {
+	notes := make([]*domain.Note, 0, len(noteIDs))
+	tasks := make([]service.NoteTasks, 0, len(noteIDs))
+	for _, id := range noteIDs {
+		w.Indexer.NoteChanged(id)
+		parsed, err := w.Notes.LoadNote(id)
+		if err != nil {
+			return a.wrapError("failed to reload note", err)
+		}
+		note := parsed.Note
+		notes = append(notes, note)
+		tasks = append(tasks, service.NoteTasks{NoteID: note.ID, NotePath: note.Path, Tasks: parsed.Tasks, ModifiedAt: note.ModifiedAt})
+
+		if err := w.Query.IndexNote(note); err != nil {
+			return a.wrapError("failed to index note metadata", err)
 		}
+
+		w.Schemas.IndexNote(note)
 	}
-	a.logInfo("Loaded and indexed notes (%dms)", time.Since(noteLoadStart).Milliseconds())
 
-	searchStart := time.Now()
-	if err := a.search.IndexAll(notes); err != nil {
-		a.logError("failed to build search index: %v", err)
-	} else {
-		a.logInfo("Search index built (%dms)", time.Since(searchStart).Milliseconds())
+	w.Graph.IndexParsedNotes(notes)
+
+	if err := w.Tasks.IndexNotes(tasks); err != nil {
+		return a.wrapError("failed to index tasks", err)
+	}
+
+	if err := w.Search.IndexNotes(notes); err != nil {
+		return a.wrapError("failed to index notes in search", err)
 	}
 
-	a.logInfo("Initial index build complete: indexed %d notes (%dms total)", len(notes), time.Since(overallStart).Milliseconds())
+	return nil
+}
+
+// emitIndexProgress forwards index build progress to the frontend.
+func (a *App) emitIndexProgress(status service.IndexStatus) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, service.IndexProgressEvent, status)
+	}
 }
 
 // SelectDirectory opens a native directory picker dialog.
@@ -402,9 +1310,28 @@ func (a *App) SaveSettings(settings service.Settings) error {
 	return nil
 }
 
+// workspaceStore returns the store of a workspace's UI state. With an empty ID and no open workspace,
+// it returns the default store, so the frontend can load its initial state before opening one.
+func (a *App) workspaceStore(workspaceID string) (*service.WorkspaceStore, error) {
+	if workspaceID == "" && a.workspaces.Active() == "" {
+		return a.stores.Workspace, nil
+	}
+
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return w.Stores.Workspace, nil
+}
+
 // LoadWorkspaceSnapshot loads workspace-specific UI state from disk.
-func (a *App) LoadWorkspaceSnapshot() (*service.WorkspaceSnapshot, error) {
-	snapshot, err := a.stores.Workspace.LoadSnapshot()
+func (a *App) LoadWorkspaceSnapshot(workspaceID string) (*service.WorkspaceSnapshot, error) {
+	store, err := a.workspaceStore(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to load workspace snapshot", err)
+	}
+
+	snapshot, err := store.LoadSnapshot()
 	if err != nil {
 		return nil, a.wrapError("failed to load workspace snapshot", err)
 	}
@@ -413,16 +1340,26 @@ func (a *App) LoadWorkspaceSnapshot() (*service.WorkspaceSnapshot, error) {
 
 // SaveWorkspaceSnapshot saves workspace-specific UI state to disk.
 // Frontend should debounce calls (500-1000ms) to avoid excessive writes.
-func (a *App) SaveWorkspaceSnapshot(snapshot service.WorkspaceSnapshot) error {
-	if err := a.stores.Workspace.SaveSnapshot(snapshot); err != nil {
+func (a *App) SaveWorkspaceSnapshot(workspaceID string, snapshot service.WorkspaceSnapshot) error {
+	store, err := a.workspaceStore(workspaceID)
+	if err != nil {
+		return a.wrapError("failed to save workspace snapshot", err)
+	}
+
+	if err := store.SaveSnapshot(snapshot); err != nil {
 		return a.wrapError("failed to save workspace snapshot", err)
 	}
 	return nil
 }
 
 // ClearRecentFiles removes all recent pages from the workspace snapshot and persists the change.
-func (a *App) ClearRecentFiles() (*service.WorkspaceSnapshot, error) {
-	snapshot, err := a.stores.Workspace.LoadSnapshot()
+func (a *App) ClearRecentFiles(workspaceID string) (*service.WorkspaceSnapshot, error) {
+	store, err := a.workspaceStore(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to load workspace snapshot", err)
+	}
+
+	snapshot, err := store.LoadSnapshot()
 	if err != nil {
 		return nil, a.wrapError("failed to load workspace snapshot", err)
 	}
@@ -430,7 +1367,7 @@ func (a *App) ClearRecentFiles() (*service.WorkspaceSnapshot, error) {
 	snapshot.UI.RecentPages = []string{}
 	snapshot.UI.ActivePage = ""
 
-	if err := a.stores.Workspace.SaveSnapshot(snapshot); err != nil {
+	if err := store.SaveSnapshot(snapshot); err != nil {
 		return nil, a.wrapError("failed to save workspace snapshot", err)
 	}
 
@@ -456,17 +1393,13 @@ func (a *App) SaveAppSnapshot(snapshot service.AppSnapshot) error {
 	return nil
 }
 
-// CloseWorkspace closes the current workspace and cleans up resources.
-// Should be called before opening a new workspace or when the user explicitly closes the workspace.
-func (a *App) CloseWorkspace() error {
+// CloseWorkspace closes a workspace, committing pending history and releasing its watcher and stores.
+// If it was the active workspace, the most recently opened remaining workspace becomes active.
+func (a *App) CloseWorkspace(workspaceID string) error {
 	a.logInfo("Closing workspace")
 
-	a.indexing = false
-
-	if a.fs != nil {
-		if err := a.fs.Close(); err != nil {
-			a.logWarning("Error closing filesystem service: %v", err)
-		}
+	if err := a.workspaces.Close(workspaceID); err != nil {
+		return a.wrapError("failed to close workspace", err)
 	}
 
 	a.logInfo("Workspace closed")
@@ -492,10 +1425,15 @@ func (a *App) InitWorkspaceConfigDir(workspaceRoot string) (string, error) {
 	return configDir, nil
 }
 
-// GetAllTasks returns all tasks across all notes, optionally filtered.
+// GetAllTasks returns all tasks across all notes of a workspace, optionally filtered.
 // Supports filtering by completion status, note ID, and date ranges.
-func (a *App) GetAllTasks(filter domain.TaskFilter) (*domain.TaskInfo, error) {
-	taskInfo, err := a.tasks.GetAllTasks(filter)
+func (a *App) GetAllTasks(workspaceID string, filter domain.TaskFilter) (*domain.TaskInfo, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to get tasks", err)
+	}
+
+	taskInfo, err := w.Tasks.GetAllTasks(filter)
 	if err != nil {
 		return nil, a.wrapError("failed to get tasks", err)
 	}
@@ -503,8 +1441,13 @@ func (a *App) GetAllTasks(filter domain.TaskFilter) (*domain.TaskInfo, error) {
 }
 
 // GetTasksForNote returns all tasks in a specific note.
-func (a *App) GetTasksForNote(noteID string) ([]domain.Task, error) {
-	tasks, err := a.tasks.GetTasksForNote(noteID)
+func (a *App) GetTasksForNote(workspaceID, noteID string) ([]domain.Task, error) {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return nil, a.wrapError("failed to get tasks for note", err)
+	}
+
+	tasks, err := w.Tasks.GetTasksForNote(noteID)
 	if err != nil {
 		return nil, a.wrapError("failed to get tasks for note", err)
 	}
@@ -513,8 +1456,13 @@ func (a *App) GetTasksForNote(noteID string) ([]domain.Task, error) {
 
 // ToggleTaskInNote toggles a task's completion status at the specified line number.
 // Re-parses and re-indexes the note after the toggle.
-func (a *App) ToggleTaskInNote(noteID string, lineNumber int) error {
-	note, err := a.notes.GetNote(noteID)
+func (a *App) ToggleTaskInNote(workspaceID, noteID string, lineNumber int) error {
+	w, err := a.workspace(workspaceID)
+	if err != nil {
+		return a.wrapError("failed to get note", err)
+	}
+
+	note, err := w.Notes.GetNote(noteID)
 	if err != nil {
 		return a.wrapError("failed to get note", err)
 	}
@@ -547,7 +1495,8 @@ func (a *App) ToggleTaskInNote(noteID string, lineNumber int) error {
 		}
 
 		note.Content = strings.Join(lines, "\n")
-		return a.SaveNote(note)
+		_, err := a.saveNote(w, note)
+		return err
 	}
 	return a.wrapError("line is not a task", fmt.Errorf("line %d does not contain a task", lineNumber))
 }
@@ -616,6 +1565,10 @@ func (a *App) wrapError(msg string, err error) error {
 		return fmt.Errorf("%s: invalid frontmatter in '%s': %s", msg, e.Path, e.Reason)
 	case *domain.ErrWorkspaceNotOpen:
 		return fmt.Errorf("%s: no workspace is open", msg)
+	case *domain.ErrQuerySyntax:
+		return fmt.Errorf("%s: syntax error at line %d, column %d: %s", msg, e.Line, e.Column, e.Message)
+	case *domain.ErrQueryEval:
+		return fmt.Errorf("%s: error at line %d, column %d: %s", msg, e.Line, e.Column, e.Message)
 	default:
 		return fmt.Errorf("%s: %w", msg, err)
 	}
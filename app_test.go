@@ -1,6 +1,9 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"notes/backend/paths"
@@ -77,8 +80,8 @@ func TestApp_SettingsAndSnapshotMethods(t *testing.T) {
 		t.Run("type assertions", func(t *testing.T) {
 			var _ func() (*service.Settings, error) = app.LoadSettings
 			var _ func(service.Settings) error = app.SaveSettings
-			var _ func() (*service.WorkspaceSnapshot, error) = app.LoadWorkspaceSnapshot
-			var _ func(service.WorkspaceSnapshot) error = app.SaveWorkspaceSnapshot
+			var _ func(string) (*service.WorkspaceSnapshot, error) = app.LoadWorkspaceSnapshot
+			var _ func(string, service.WorkspaceSnapshot) error = app.SaveWorkspaceSnapshot
 		})
 	})
 
@@ -102,7 +105,7 @@ func TestApp_SettingsAndSnapshotMethods(t *testing.T) {
 	})
 
 	t.Run("LoadWorkspaceSnapshot returns defaults", func(t *testing.T) {
-		snapshot, err := app.LoadWorkspaceSnapshot()
+		snapshot, err := app.LoadWorkspaceSnapshot("")
 		if err != nil {
 			t.Fatalf("LoadWorkspaceSnapshot() error = %v", err)
 		}
@@ -150,12 +153,12 @@ func TestApp_SettingsAndSnapshotMethods(t *testing.T) {
 		testSnapshot.UI.SidebarWidth = 350
 		testSnapshot.UI.PinnedPages = []string{"page1.md", "page2.md"}
 
-		err := app.SaveWorkspaceSnapshot(testSnapshot)
+		err := app.SaveWorkspaceSnapshot("", testSnapshot)
 		if err != nil {
 			t.Fatalf("SaveWorkspaceSnapshot() error = %v", err)
 		}
 
-		loaded, err := app.LoadWorkspaceSnapshot()
+		loaded, err := app.LoadWorkspaceSnapshot("")
 		if err != nil {
 			t.Fatalf("LoadWorkspaceSnapshot() error = %v", err)
 		}
@@ -178,11 +181,11 @@ func TestApp_SettingsAndSnapshotMethods(t *testing.T) {
 		snapshot.UI.ActivePage = "existing.md"
 		snapshot.UI.RecentPages = []string{"existing.md", "older.md"}
 
-		if err := app.SaveWorkspaceSnapshot(snapshot); err != nil {
+		if err := app.SaveWorkspaceSnapshot("", snapshot); err != nil {
 			t.Fatalf("setup SaveWorkspaceSnapshot() error = %v", err)
 		}
 
-		cleared, err := app.ClearRecentFiles()
+		cleared, err := app.ClearRecentFiles("")
 		if err != nil {
 			t.Fatalf("ClearRecentFiles() error = %v", err)
 		}
@@ -195,7 +198,7 @@ func TestApp_SettingsAndSnapshotMethods(t *testing.T) {
 			t.Fatalf("expected active page to be empty, got %q", cleared.UI.ActivePage)
 		}
 
-		loaded, err := app.LoadWorkspaceSnapshot()
+		loaded, err := app.LoadWorkspaceSnapshot("")
 		if err != nil {
 			t.Fatalf("LoadWorkspaceSnapshot() error = %v", err)
 		}
@@ -260,3 +263,44 @@ func TestApp_ConfigDirMethods(t *testing.T) {
 func fileExists(path string) bool {
 	return path != ""
 }
+
+// BenchmarkApp_ReindexNotes reindexes every note of a 300-note workspace, as after a bulk edit,
+// followed by the search that brings the search index up to date.
+func BenchmarkApp_ReindexNotes(b *testing.B) {
+	const n = 300
+	config := b.TempDir()
+	b.Setenv("HOME", config)
+	b.Setenv("XDG_CONFIG_HOME", config)
+
+	root := b.TempDir()
+	ids := make([]string, 0, n)
+	for i := range n {
+		id := fmt.Sprintf("note-%03d.md", i)
+		content := fmt.Sprintf("---\ntags: [area-%d]\n---\n# Note %d\n\nSee [[note-%03d]] and [[note-%03d]]. #topic/%d\n\n"+
+			"- [ ] Follow up on note %d\n- [x] Review note %d\n\nSome words to search for in note %d.\n",
+			i%10, i, (i+1)%n, (i+7)%n, i%20, i, i, i)
+		if err := os.WriteFile(filepath.Join(root, id), []byte(content), 0644); err != nil {
+			b.Fatalf("WriteFile() error = %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	app := &App{workspaces: service.NewWorkspaceManager("notes-bench", service.NewThemeService())}
+	w, _, err := app.workspaces.Open(root)
+	if err != nil {
+		b.Fatalf("Open() error = %v", err)
+	}
+	defer app.workspaces.CloseAll()
+	w.Indexer.Start()
+	w.Indexer.Wait()
+
+	for b.Loop() {
+		if err := app.reindexNotes(w, ids); err != nil {
+			b.Fatalf("reindexNotes() error = %v", err)
+		}
+		if _, err := w.Search.Search(service.SearchQuery{Query: "words"}); err != nil {
+			b.Fatalf("Search() error = %v", err)
+		}
+	}
+	b.ReportMetric(float64(n*b.N)/b.Elapsed().Seconds(), "notes/s")
+}
@@ -5,31 +5,85 @@ import "time"
 // Note represents a single note/document in the workspace.
 // Notes are stored as Markdown files on disk and may contain frontmatter, wikilinks, tags, and outline blocks.
 type Note struct {
-	ID          string         `json:"id"`                          // Unique identifier (typically file path relative to workspace)
-	Title       string         `json:"title"`                       // Note title (from frontmatter or first heading)
-	Path        string         `json:"path"`                        // Relative path within workspace
-	Content     string         `json:"content"`                     // Full Markdown content
-	Frontmatter map[string]any `json:"frontmatter"`                 // Additional YAML frontmatter fields (beyond standard fields)
-	Aliases     []string       `json:"aliases"`                     // Alternative note titles for wikilink resolution
-	Type        string         `json:"type"`                        // Note type or template identifier (e.g., "daily", "meeting", "project")
-	Blocks      []Block        `json:"blocks"`                      // Outline-style blocks
-	Links       []Link         `json:"links"`                       // Outgoing links found in content
-	Tags        []Tag          `json:"tags"`                        // Tags found in content and frontmatter
-	CreatedAt   time.Time      `json:"createdAt" ts_type:"string"`  // Note creation time (from frontmatter or file metadata)
-	ModifiedAt  time.Time      `json:"modifiedAt" ts_type:"string"` // Last modification time (auto-updated on save)
+	ID              string            `json:"id"`                          // Unique identifier (typically file path relative to workspace)
+	Title           string            `json:"title"`                       // Note title (from frontmatter or first heading)
+	Path            string            `json:"path"`                        // Relative path within workspace
+	Content         string            `json:"content"`                     // Full Markdown content
+	Frontmatter     map[string]any    `json:"frontmatter"`                 // Additional YAML frontmatter fields (beyond standard fields)
+	Properties      map[string]string `json:"properties"`                  // Logseq-style page properties (key:: value lines at the top of the body)
+	Aliases         []string          `json:"aliases"`                     // Alternative note titles for wikilink resolution
+	Type            string            `json:"type"`                        // Note type or template identifier (e.g., "daily", "meeting", "project")
+	Blocks          []Block           `json:"blocks"`                      // Outline-style blocks
+	Links           []Link            `json:"links"`                       // Outgoing links found in content
+	Tags            []Tag             `json:"tags"`                        // Tags found in content and frontmatter
+	InlineTags      []string          `json:"inlineTags"`                  // Tags written in the body (#tag or tags:: property)
+	FrontmatterTags []string          `json:"frontmatterTags"`             // Tags listed under the frontmatter tags key
+	CreatedAt       time.Time         `json:"createdAt" ts_type:"string"`  // Note creation time (from frontmatter or file metadata)
+	ModifiedAt      time.Time         `json:"modifiedAt" ts_type:"string"` // Last modification time (auto-updated on save)
+	Hash            string            `json:"hash"`                        // SHA-256 of the file as loaded or last saved; saves based on a stale hash are refused
+}
+
+// NoteConflict describes a save refused because the note changed on disk since it was loaded,
+// and the changes could not be merged.
+type NoteConflict struct {
+	NoteID   string      `json:"noteId"`
+	BaseHash string      `json:"baseHash"` // Hash of the version the save was based on
+	DiskHash string      `json:"diskHash"` // Hash of the version now on disk
+	Local    string      `json:"local"`    // File content the save would have written
+	Disk     string      `json:"disk"`     // File content now on disk
+	Merged   string      `json:"merged"`   // Disk content with the local changes that merged cleanly; conflicting lines are as on disk
+	Hunks    []MergeHunk `json:"hunks"`    // Regions changed differently on both sides; empty when the base version was unknown
+	CopyPath string      `json:"copyPath"` // Conflict copy holding the local content, e.g. "note (conflict 2026-10-16).md"
+}
+
+// MergeHunk is a region of a note that was changed one way locally and another way on disk.
+type MergeHunk struct {
+	Line  int      `json:"line"`  // 1-based line in NoteConflict.Merged where the hunk's disk lines start
+	Base  []string `json:"base"`  // Lines of the version both sides started from
+	Local []string `json:"local"` // Lines as changed locally
+	Disk  []string `json:"disk"`  // Lines as changed on disk
+}
+
+// SaveNoteResult reports the outcome of saving a note: either the note as saved, or a conflict.
+type SaveNoteResult struct {
+	Note     *Note         `json:"note"`     // The saved note with its new hash; nil on conflict
+	Merged   bool          `json:"merged"`   // The note changed on disk and Note is the clean merge of both versions
+	Conflict *NoteConflict `json:"conflict"` // Set when the note changed on disk and could not be merged
+}
+
+// TrashEntry describes a note, or a folder of notes, moved to the workspace trash by a delete.
+type TrashEntry struct {
+	ID           string    `json:"id"`           // Name of the entry's folder in the trash
+	OriginalPath string    `json:"originalPath"` // Workspace-relative path the note or folder was deleted from
+	Folder       bool      `json:"folder"`       // The entry holds a whole folder
+	Notes        []string  `json:"notes"`        // IDs the notes had before deletion: the note itself, or those inside the folder
+	Title        string    `json:"title"`        // Note title, or folder name, at deletion
+	DeletedAt    time.Time `json:"deletedAt"`    // When the note was deleted
+	CreatedAt    time.Time `json:"createdAt"`    // Note creation time, restored with the note
+	Backlinks    []string  `json:"backlinks"`    // IDs of the notes that linked to the note at deletion
+	Size         int64     `json:"size"`         // File size in bytes
+}
+
+// RestoredNote reports where a note or folder was restored from the trash.
+type RestoredNote struct {
+	Entry    TrashEntry `json:"entry"`    // The trash entry the note was restored from
+	Path     string     `json:"path"`     // Path the note was restored to; differs from the original path when that was taken
+	NoteIDs  []string   `json:"noteIds"`  // IDs of the restored notes
+	Relinked []string   `json:"relinked"` // IDs of the notes whose links were rewritten to the restored path
 }
 
 // Block represents an outline-style content block within a note.
 // Blocks enable outline editing where each block can be independently referenced and linked.
 type Block struct {
-	ID       string    `json:"id"`       // Unique block identifier
-	NoteID   string    `json:"noteId"`   // Parent note ID
-	Content  string    `json:"content"`  // Block content (single paragraph/list item)
-	Level    int       `json:"level"`    // Nesting level (0 = top-level)
-	Parent   string    `json:"parent"`   // Parent block ID (empty for top-level)
-	Children []string  `json:"children"` // Child block IDs
-	Position int       `json:"position"` // Position within parent
-	Type     BlockType `json:"type"`     // Block type (paragraph, heading, list, etc.)
+	ID         string            `json:"id"`         // Unique block identifier
+	NoteID     string            `json:"noteId"`     // Parent note ID
+	Content    string            `json:"content"`    // Block content (single paragraph/list item)
+	Level      int               `json:"level"`      // Nesting level (0 = top-level)
+	Parent     string            `json:"parent"`     // Parent block ID (empty for top-level)
+	Children   []string          `json:"children"`   // Child block IDs
+	Position   int               `json:"position"`   // Position within parent
+	Type       BlockType         `json:"type"`       // Block type (paragraph, heading, list, etc.)
+	Properties map[string]string `json:"properties"` // Logseq-style block properties (key:: value lines under the block)
 }
 
 // BlockType categorizes different types of outline blocks.
@@ -78,6 +132,27 @@ type TagInfo struct {
 	NoteIDs []string `json:"noteIds"` // IDs of notes containing this tag
 }
 
+// TagNode represents a tag within the nested tag hierarchy.
+// Nested tags use forward slashes (e.g., "project/alpha" is a child of "project").
+// Intermediate tags that are never used directly still appear as nodes with a zero Count.
+type TagNode struct {
+	Name       string    `json:"name"`       // Last path segment (e.g., "alpha")
+	FullName   string    `json:"fullName"`   // Full tag path (e.g., "project/alpha")
+	Count      int       `json:"count"`      // Number of notes tagged with exactly this tag
+	TotalCount int       `json:"totalCount"` // Number of distinct notes tagged with this tag or any descendant
+	NoteIDs    []string  `json:"noteIds"`    // IDs of notes tagged with exactly this tag
+	Children   []TagNode `json:"children"`   // Child tags sorted by name
+}
+
+// FolderNode represents a folder within the workspace folder tree.
+type FolderNode struct {
+	Name           string       `json:"name"`           // Last path segment; empty for the workspace root
+	Path           string       `json:"path"`           // Workspace-relative path with forward slashes; empty for the workspace root
+	NoteCount      int          `json:"noteCount"`      // Number of notes directly in the folder
+	TotalNoteCount int          `json:"totalNoteCount"` // Number of notes in the folder and all its subfolders
+	Children       []FolderNode `json:"children"`       // Subfolders sorted by name
+}
+
 // DailyNote represents a date-based journal entry.
 // Daily notes follow a naming convention (e.g., "2025-01-27.md")
 // and provide quick access to journaling workflows.
@@ -98,12 +173,87 @@ type Workspace struct {
 }
 
 // WorkspaceConfig holds workspace-specific settings and preferences.
+// The portable settings are stored in the workspace's config.toml; the frontmatter policy and
+// auto-commit delay are kept per machine.
 type WorkspaceConfig struct {
-	DailyNoteFormat string   `json:"dailyNoteFormat"` // Date format for daily notes (e.g., "2006-01-02")
-	DailyNoteFolder string   `json:"dailyNoteFolder"` // Folder for daily notes (empty = workspace root)
-	DefaultTags     []string `json:"defaultTags"`     // Tags to auto-add to new notes
+	Version           int               `json:"version"`           // config.toml format version
+	DailyNoteFormat   string            `json:"dailyNoteFormat"`   // Date format for daily notes (e.g., "2006-01-02")
+	DailyNoteFolder   string            `json:"dailyNoteFolder"`   // Folder for daily notes (empty = workspace root)
+	DefaultTags       []string          `json:"defaultTags"`       // Tags to auto-add to new notes
+	IgnorePatterns    []string          `json:"ignorePatterns"`    // File patterns to ignore (e.g., .git, node_modules)
+	AttachmentFolder  string            `json:"attachmentFolder"`  // Folder imported attachments are copied into ("./" prefix = relative to the note)
+	TemplateFolder    string            `json:"templateFolder"`    // Folder holding note templates (empty = none)
+	LinkStyle         LinkStyle         `json:"linkStyle"`         // How links inserted by the app are written
+	FrontmatterPolicy FrontmatterPolicy `json:"frontmatterPolicy"` // When saves may write metadata into frontmatter
+	AutoCommitDelay   int               `json:"autoCommitDelay"`   // Seconds after the last save before changed notes are committed to git (0 = off)
+	TrashRetention    int               `json:"trashRetention"`    // Days deleted notes are kept in the trash (0 = until emptied)
 }
 
+// LinkStyle controls how links inserted by the application, e.g. for imported attachments, are written.
+type LinkStyle string
+
+const (
+	LinkStyleWikilink LinkStyle = "wikilink" // [[target]], resolved by name
+	LinkStyleMarkdown LinkStyle = "markdown" // [label](relative/path), readable by any Markdown tool
+)
+
+// Valid reports whether s is a known link style.
+func (s LinkStyle) Valid() bool {
+	switch s {
+	case LinkStyleWikilink, LinkStyleMarkdown:
+		return true
+	}
+	return false
+}
+
+// FrontmatterPolicy controls when saves write the application's own metadata into frontmatter:
+// created/modified timestamps, and the title of newly created notes.
+// Timestamps that are not written to the file are kept in the database instead.
+type FrontmatterPolicy string
+
+const (
+	FrontmatterNever            FrontmatterPolicy = "never"             // Never write metadata keys; user-edited fields are still saved
+	FrontmatterPreserveExisting FrontmatterPolicy = "preserve-existing" // Update metadata keys a file already has, never add new ones
+	FrontmatterAlways           FrontmatterPolicy = "always"            // Write created/modified to every saved note, adding frontmatter if needed
+)
+
+// Valid reports whether p is a known policy.
+func (p FrontmatterPolicy) Valid() bool {
+	switch p {
+	case FrontmatterNever, FrontmatterPreserveExisting, FrontmatterAlways:
+		return true
+	}
+	return false
+}
+
+// Attachment is a non-Markdown file in the workspace, such as an image or PDF, that notes link to or embed.
+type Attachment struct {
+	Path       string    `json:"path"`                        // Relative path within workspace
+	Name       string    `json:"name"`                        // File name including extension
+	Size       int64     `json:"size"`                        // File size in bytes
+	MediaType  string    `json:"mediaType"`                   // MIME type derived from the extension
+	ModifiedAt time.Time `json:"modifiedAt" ts_type:"string"` // Last modification time
+}
+
+// NoteVersion is a prior version of a note: a commit that changed it in a git workspace,
+// or a snapshot kept on save in other workspaces.
+type NoteVersion struct {
+	Hash    string    `json:"hash"`                  // Commit hash, or content hash for snapshots
+	Message string    `json:"message"`               // First line of the commit message; empty for snapshots
+	Author  string    `json:"author"`                // Commit author name
+	Email   string    `json:"email"`                 // Commit author email
+	Time    time.Time `json:"time" ts_type:"string"` // Commit author time, or when the snapshot was taken
+	Path    string    `json:"path"`                  // Note path at this version, relative to the workspace
+	Change  string    `json:"change"`                // "added", "modified" or "renamed"
+}
+
+// Kinds of NoteVersion changes.
+const (
+	NoteAdded    = "added"
+	NoteModified = "modified"
+	NoteRenamed  = "renamed"
+)
+
 // NoteSummary provides a lightweight note representation for lists and indexes.
 // Used when loading all notes to avoid loading full content into memory.
 type NoteSummary struct {
@@ -30,6 +30,15 @@ func (e *ErrWorkspaceNotOpen) Error() string {
 	return "no workspace is currently open"
 }
 
+// ErrNoRepository indicates a history operation in a workspace that is not inside a git repository.
+type ErrNoRepository struct {
+	Path string // Workspace root
+}
+
+func (e *ErrNoRepository) Error() string {
+	return fmt.Sprintf("workspace is not in a git repository: %s", e.Path)
+}
+
 // ErrInvalidFrontmatter indicates frontmatter parsing failure.
 type ErrInvalidFrontmatter struct {
 	Path   string
@@ -49,3 +58,35 @@ type ErrAlreadyExists struct {
 func (e *ErrAlreadyExists) Error() string {
 	return fmt.Sprintf("%s already exists: %s", e.Resource, e.ID)
 }
+
+// ErrQuerySyntax indicates a metadata query could not be parsed.
+type ErrQuerySyntax struct {
+	Line    int    // 1-based line of the offending token
+	Column  int    // 1-based column of the offending token
+	Message string // Description of what was expected or found
+}
+
+func (e *ErrQuerySyntax) Error() string {
+	return fmt.Sprintf("query syntax error at line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// ErrQueryEval indicates a metadata query parsed but could not be evaluated, e.g. a division by zero
+// or an operator applied to values of the wrong type.
+type ErrQueryEval struct {
+	Line    int    // 1-based line of the offending operator or field
+	Column  int    // 1-based column of the offending operator or field
+	Message string // Description of the problem
+}
+
+func (e *ErrQueryEval) Error() string {
+	return fmt.Sprintf("query error at line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// ErrConflict indicates a note changed on disk since the version a save was based on was loaded.
+type ErrConflict struct {
+	NoteConflict
+}
+
+func (e *ErrConflict) Error() string {
+	return fmt.Sprintf("note changed on disk since it was loaded: %s", e.NoteID)
+}
@@ -26,10 +26,8 @@ func UserConfigDir(appName string) (string, error) {
 	return configDir, nil
 }
 
-// WorkspaceConfigDir returns the workspace configuration directory path.
-// This directory lives inside the workspace root and is intended for portable, version-controllable configuration.
-// Creates the directory with 0755 permissions if it doesn't exist.
-func WorkspaceConfigDir(workspaceRoot, appName string) (string, error) {
+// WorkspaceConfigPath returns the workspace configuration directory path without creating it.
+func WorkspaceConfigPath(workspaceRoot, appName string) (string, error) {
 	if workspaceRoot == "" {
 		return "", fmt.Errorf("workspaceRoot cannot be empty")
 	}
@@ -43,7 +41,17 @@ func WorkspaceConfigDir(workspaceRoot, appName string) (string, error) {
 		dirName = "." + lowerName
 	}
 
-	configDir := filepath.Join(workspaceRoot, dirName)
+	return filepath.Join(workspaceRoot, dirName), nil
+}
+
+// WorkspaceConfigDir returns the workspace configuration directory path.
+// This directory lives inside the workspace root and is intended for portable, version-controllable configuration.
+// Creates the directory with 0755 permissions if it doesn't exist.
+func WorkspaceConfigDir(workspaceRoot, appName string) (string, error) {
+	configDir, err := WorkspaceConfigPath(workspaceRoot, appName)
+	if err != nil {
+		return "", err
+	}
 
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create workspace config directory: %w", err)
@@ -303,3 +303,25 @@ func TestWorkspaceConfigDir_CaseConversion(t *testing.T) {
 		})
 	}
 }
+
+func TestWorkspaceConfigPath_DoesNotCreate(t *testing.T) {
+	workspaceRoot := t.TempDir()
+
+	result, err := WorkspaceConfigPath(workspaceRoot, "KnowledgeLab")
+	if err != nil {
+		t.Fatalf("WorkspaceConfigPath failed: %v", err)
+	}
+
+	expected := filepath.Join(workspaceRoot, ".knowledgelab")
+	if result != expected {
+		t.Errorf("expected %s, got %s", expected, result)
+	}
+
+	if _, err := os.Stat(result); !os.IsNotExist(err) {
+		t.Errorf("directory should not be created, stat err = %v", err)
+	}
+
+	if _, err := WorkspaceConfigPath("", "KnowledgeLab"); err == nil {
+		t.Error("expected error for empty workspace root")
+	}
+}
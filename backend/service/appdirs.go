@@ -22,6 +22,8 @@ type AppDirs struct {
 	DBPath string
 	// AppSnapshotPath is the full path to the app.toml file (global app state)
 	AppSnapshotPath string
+	// SnapshotDir is the directory holding compressed note snapshots, next to the database
+	SnapshotDir string
 }
 
 // NewAppDirs creates a new AppDirs instance with all paths initialized.
@@ -42,6 +44,7 @@ func NewAppDirs(appName, workspaceName string, logger *runtimeLogger) (*AppDirs,
 		WorkspacePath:   filepath.Join(workspaceRoot, "workspace.toml"),
 		DBPath:          filepath.Join(workspaceRoot, "graph.db"),
 		AppSnapshotPath: filepath.Join(configRoot, "app.toml"),
+		SnapshotDir:     filepath.Join(workspaceRoot, "snapshots"),
 	}
 
 	if logger != nil {
@@ -67,6 +67,11 @@ func TestNewAppDirs(t *testing.T) {
 				t.Errorf("DBPath = %v, want %v", dirs.DBPath, expectedDBPath)
 			}
 
+			expectedSnapshotDir := filepath.Join(expectedWorkspaceRoot, "snapshots")
+			if dirs.SnapshotDir != expectedSnapshotDir {
+				t.Errorf("SnapshotDir = %v, want %v", dirs.SnapshotDir, expectedSnapshotDir)
+			}
+
 			expectedAppSnapshotPath := filepath.Join(expectedConfigRoot, "app.toml")
 			if dirs.AppSnapshotPath != expectedAppSnapshotPath {
 				t.Errorf("AppSnapshotPath = %v, want %v", dirs.AppSnapshotPath, expectedAppSnapshotPath)
@@ -0,0 +1,642 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"notes/backend/domain"
+)
+
+// AttachmentURLPrefix is the URL path under which the asset handler serves workspace attachments,
+// followed by the workspace ID and the attachment's path in that workspace.
+const AttachmentURLPrefix = "/workspace-files/"
+
+// DefaultAttachmentFolder is the folder imported attachments are copied into when none is configured.
+const DefaultAttachmentFolder = "attachments"
+
+// AttachmentService indexes the non-Markdown files of a workspace (images, PDFs, media) and
+// resolves the links notes use to reference them.
+// It also serves attachments to the webview as an http.Handler.
+type AttachmentService struct {
+	mu        sync.RWMutex
+	fs        *FilesystemService
+	folder    string
+	linkStyle domain.LinkStyle
+	// files maps slash-separated relative paths to attachment metadata
+	files map[string]domain.Attachment
+}
+
+// NewAttachmentService creates an attachment service that imports into DefaultAttachmentFolder.
+func NewAttachmentService(fs *FilesystemService) *AttachmentService {
+	return &AttachmentService{
+		fs:        fs,
+		folder:    DefaultAttachmentFolder,
+		linkStyle: domain.LinkStyleWikilink,
+		files:     make(map[string]domain.Attachment),
+	}
+}
+
+// Folder returns the folder imported attachments are copied into.
+func (s *AttachmentService) Folder() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.folder
+}
+
+// SetFolder sets the folder imported attachments are copied into.
+// A folder starting with "./" is relative to the importing note; "" means the workspace root.
+func (s *AttachmentService) SetFolder(folder string) error {
+	folder, err := normalizeAttachmentFolder(folder)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.folder = folder
+	return nil
+}
+
+// SetLinkStyle sets how links to imported attachments are written. An unknown style is ignored.
+func (s *AttachmentService) SetLinkStyle(style domain.LinkStyle) {
+	if !style.Valid() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.linkStyle = style
+}
+
+// Refresh rescans the workspace for attachments.
+func (s *AttachmentService) Refresh() error {
+	paths, err := s.fs.LoadAttachmentFiles()
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string]domain.Attachment, len(paths))
+	for _, relPath := range paths {
+		attachment, err := s.stat(relPath)
+		if err != nil {
+			continue
+		}
+		files[attachment.Path] = attachment
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files = files
+	return nil
+}
+
+// Clear empties the attachment index.
+func (s *AttachmentService) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files = make(map[string]domain.Attachment)
+}
+
+// List returns every indexed attachment sorted by path.
+func (s *AttachmentService) List() []domain.Attachment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	attachments := make([]domain.Attachment, 0, len(s.files))
+	for _, attachment := range s.files {
+		attachments = append(attachments, attachment)
+	}
+	sort.Slice(attachments, func(i, j int) bool {
+		return attachments[i].Path < attachments[j].Path
+	})
+	return attachments
+}
+
+// Get returns the indexed attachment at a relative path.
+func (s *AttachmentService) Get(relPath string) (domain.Attachment, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	attachment, ok := s.files[cleanAttachmentPath(relPath)]
+	return attachment, ok
+}
+
+// ResolveWikilink resolves the target of a [[wikilink]] to an attachment path.
+// Targets are tried as a workspace path, then relative to the linking note's folder, then as
+// the shortest indexed path ending in the target ("image.png" finds "assets/image.png").
+// Unknown targets resolve to their cleaned form so broken links still point somewhere.
+func (s *AttachmentService) ResolveWikilink(target, fromNote string) string {
+	relative := cleanAttachmentPath(path.Join(noteFolder(fromNote), filepath.ToSlash(strings.TrimSpace(target))))
+	target = cleanAttachmentPath(target)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.files[target]; ok {
+		return target
+	}
+	if _, ok := s.files[relative]; ok {
+		return relative
+	}
+
+	best := ""
+	for candidate := range s.files {
+		if !strings.HasSuffix(candidate, "/"+target) {
+			continue
+		}
+		if best == "" || len(candidate) < len(best) || (len(candidate) == len(best) && candidate < best) {
+			best = candidate
+		}
+	}
+	if best != "" {
+		return best
+	}
+	return target
+}
+
+// Import copies a file from outside the workspace into the attachment folder and returns the
+// link text to insert into the note, e.g. "![[diagram.png]]".
+// Importing a file identical to an existing attachment of the same name reuses it; otherwise a
+// numeric suffix keeps names unique ("diagram 1.png").
+func (s *AttachmentService) Import(srcPath, noteID string) (string, error) {
+	if !isAttachmentFile(srcPath) {
+		return "", &domain.ErrInvalidPath{Path: srcPath, Reason: "unsupported attachment type"}
+	}
+
+	content, err := os.ReadFile(srcPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", &domain.ErrNotFound{Resource: "file", ID: srcPath}
+		}
+		return "", fmt.Errorf("failed to read attachment: %w", err)
+	}
+
+	dir := s.importFolder(noteID)
+	ext := filepath.Ext(srcPath)
+	stem := strings.TrimSuffix(filepath.Base(srcPath), ext)
+
+	var relPath string
+	for i := 0; ; i++ {
+		name := stem + ext
+		if i > 0 {
+			name = fmt.Sprintf("%s %d%s", stem, i, ext)
+		}
+		relPath = path.Join(dir, name)
+
+		existing, err := s.fs.ReadFile(filepath.FromSlash(relPath))
+		if err != nil {
+			break
+		}
+		if bytes.Equal(existing, content) {
+			break
+		}
+	}
+
+	if _, err := s.fs.StatFile(filepath.FromSlash(relPath)); err != nil {
+		if err := s.fs.CopyFile(srcPath, filepath.FromSlash(relPath)); err != nil {
+			return "", err
+		}
+	}
+
+	attachment, err := s.stat(relPath)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files[attachment.Path] = attachment
+	return s.linkText(attachment.Path, noteID), nil
+}
+
+// Move renames an attachment on disk and in the index.
+func (s *AttachmentService) Move(oldPath, newPath string) error {
+	oldPath = cleanAttachmentPath(oldPath)
+	newPath = cleanAttachmentPath(newPath)
+
+	if err := s.fs.MoveFile(filepath.FromSlash(oldPath), filepath.FromSlash(newPath)); err != nil {
+		return err
+	}
+
+	attachment, err := s.stat(newPath)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.files, oldPath)
+	s.files[newPath] = attachment
+	return nil
+}
+
+// ServeHTTP serves attachment files of this service's workspace requested under AttachmentURLPrefix,
+// so rendered notes can load local images and media. Requests for other workspaces are not found.
+func (s *AttachmentService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	workspace, err := s.fs.GetCurrentWorkspace()
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	workspaceID, relPath, ok := ParseAttachmentURL(r.URL.Path)
+	if !ok || workspaceID != workspace.ID || !isAttachmentFile(relPath) {
+		http.NotFound(w, r)
+		return
+	}
+
+	file, err := s.fs.OpenFile(filepath.FromSlash(relPath))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", attachmentMediaType(relPath))
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+// linkText returns the link to insert into noteID for an attachment. Wikilinks use the bare file
+// name when it is unique in the workspace, the full path otherwise; Markdown links use the path
+// relative to the note. Media and PDFs are embedded.
+// Caller must hold the lock.
+func (s *AttachmentService) linkText(relPath, noteID string) string {
+	if s.linkStyle == domain.LinkStyleMarkdown {
+		link := "[" + markdownLabelEscaper.Replace(path.Base(relPath)) + "](" + relativeURL(noteID, relPath) + ")"
+		if isEmbeddableAttachment(relPath) {
+			return "!" + link
+		}
+		return link
+	}
+
+	target := relPath
+	name := path.Base(relPath)
+	unique := true
+	for candidate := range s.files {
+		if candidate != relPath && path.Base(candidate) == name {
+			unique = false
+			break
+		}
+	}
+	if unique {
+		target = name
+	}
+
+	if isEmbeddableAttachment(relPath) {
+		return "![[" + target + "]]"
+	}
+	return "[[" + target + "]]"
+}
+
+// importFolder returns the folder an attachment imported for noteID is copied into.
+func (s *AttachmentService) importFolder(noteID string) string {
+	folder := s.Folder()
+	if folder == "." || strings.HasPrefix(folder, "./") {
+		return path.Join(noteFolder(noteID), strings.TrimPrefix(strings.TrimPrefix(folder, "."), "/"))
+	}
+	return folder
+}
+
+// stat builds the metadata for an attachment at a slash-separated relative path.
+func (s *AttachmentService) stat(relPath string) (domain.Attachment, error) {
+	relPath = cleanAttachmentPath(relPath)
+	info, err := s.fs.StatFile(filepath.FromSlash(relPath))
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+
+	return domain.Attachment{
+		Path:       relPath,
+		Name:       path.Base(relPath),
+		Size:       info.Size(),
+		MediaType:  attachmentMediaType(relPath),
+		ModifiedAt: info.ModTime(),
+	}, nil
+}
+
+// AttachmentURL returns the URL the asset handler serves a workspace's attachment under.
+func AttachmentURL(workspaceID, relPath string) string {
+	segments := strings.Split(cleanAttachmentPath(relPath), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return AttachmentURLPrefix + url.PathEscape(workspaceID) + "/" + strings.Join(segments, "/")
+}
+
+// ParseAttachmentURL splits a decoded URL path built by AttachmentURL into the workspace ID
+// and the attachment's cleaned path. It reports false for paths outside AttachmentURLPrefix.
+func ParseAttachmentURL(urlPath string) (workspaceID, relPath string, ok bool) {
+	rest, ok := strings.CutPrefix(urlPath, AttachmentURLPrefix)
+	if !ok {
+		return "", "", false
+	}
+	workspaceID, rest, ok = strings.Cut(rest, "/")
+	if !ok || workspaceID == "" {
+		return "", "", false
+	}
+	relPath = cleanAttachmentPath(rest)
+	if relPath == "" {
+		return "", "", false
+	}
+	return workspaceID, relPath, true
+}
+
+// resolveMarkdownDestination resolves the destination of a Markdown link or image to a
+// workspace-relative path. Destinations are relative to the linking note unless they start
+// with "/". Returns false for URLs and in-page anchors.
+func resolveMarkdownDestination(dest, fromNote string) (string, bool) {
+	dest = strings.TrimSpace(dest)
+	if dest == "" || strings.HasPrefix(dest, "#") || strings.Contains(dest, ":") {
+		return "", false
+	}
+
+	if unescaped, err := url.PathUnescape(dest); err == nil {
+		dest = unescaped
+	}
+	if i := strings.IndexAny(dest, "?#"); i >= 0 {
+		dest = dest[:i]
+	}
+
+	if strings.HasPrefix(dest, "/") {
+		return cleanAttachmentPath(dest), true
+	}
+	return cleanAttachmentPath(path.Join(noteFolder(fromNote), dest)), true
+}
+
+// cleanAttachmentPath normalizes a relative path to slash-separated form without a leading slash.
+func cleanAttachmentPath(relPath string) string {
+	relPath = strings.TrimSpace(filepath.ToSlash(relPath))
+	cleaned := path.Clean("/" + relPath)
+	return strings.TrimPrefix(cleaned, "/")
+}
+
+// normalizeAttachmentFolder validates a configured attachment folder.
+// Absolute paths and paths escaping the workspace are rejected.
+func normalizeAttachmentFolder(folder string) (string, error) {
+	folder = strings.TrimSpace(filepath.ToSlash(folder))
+	if folder == "" || folder == "/" {
+		return "", nil
+	}
+	if path.IsAbs(folder) || filepath.IsAbs(folder) {
+		return "", &domain.ErrInvalidPath{Path: folder, Reason: "attachment folder must be relative to the workspace"}
+	}
+
+	relative := folder == "." || strings.HasPrefix(folder, "./")
+	cleaned := path.Clean(folder)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", &domain.ErrInvalidPath{Path: folder, Reason: "attachment folder must be inside the workspace"}
+	}
+
+	if relative {
+		if cleaned == "." {
+			return ".", nil
+		}
+		return "./" + cleaned, nil
+	}
+	return cleaned, nil
+}
+
+// isEmbeddableAttachment reports whether an attachment renders inline when embedded.
+func isEmbeddableAttachment(relPath string) bool {
+	mediaType := attachmentMediaType(relPath)
+	return strings.HasPrefix(mediaType, "image/") ||
+		strings.HasPrefix(mediaType, "audio/") ||
+		strings.HasPrefix(mediaType, "video/") ||
+		mediaType == "application/pdf"
+}
+
+// AttachmentRenameResult reports the notes whose links change when an attachment is renamed.
+type AttachmentRenameResult struct {
+	OldPath string       `json:"oldPath"`
+	NewPath string       `json:"newPath"`
+	Changes []NoteChange `json:"changes"` // Per-note diffs of the rewritten links
+	Applied bool         `json:"applied"` // False when the rename ran as a dry run
+}
+
+// NoteIDs returns the IDs of all notes whose links were rewritten.
+func (r *AttachmentRenameResult) NoteIDs() []string {
+	ids := make([]string, 0, len(r.Changes))
+	for _, change := range r.Changes {
+		ids = append(ids, change.NoteID)
+	}
+	return ids
+}
+
+// attachmentWikilinkPattern matches [[target]], ![[target#fragment|label]] and similar wikilinks.
+var attachmentWikilinkPattern = regexp.MustCompile(`(!?)\[\[([^\[\]|#\n]+)(#[^\[\]|\n]*)?(\|[^\[\]\n]*)?\]\]`)
+
+// attachmentMarkdownLinkPattern matches [text](dest) and ![alt](dest "title") links.
+var attachmentMarkdownLinkPattern = regexp.MustCompile(`(!?)\[([^\[\]\n]*)\]\((<[^>\n]*>|[^)\s]+)(\s+"[^"\n]*")?\)`)
+
+// RenameAttachment moves an attachment and rewrites every wikilink, embed and Markdown link
+// that points at it. Links outside code keep their form: bare names stay bare when the new name
+// is unique, and Markdown links stay relative to their note.
+// With dryRun set, nothing is moved or written and the result only previews the changes.
+func (s *NoteService) RenameAttachment(oldPath, newPath string, dryRun bool) (*AttachmentRenameResult, error) {
+	if s.attachments == nil {
+		return nil, fmt.Errorf("attachments are not available")
+	}
+
+	oldPath = cleanAttachmentPath(oldPath)
+	newPath = cleanAttachmentPath(newPath)
+
+	if _, ok := s.attachments.Get(oldPath); !ok {
+		return nil, &domain.ErrNotFound{Resource: "attachment", ID: oldPath}
+	}
+	if newPath == "" || !isAttachmentFile(newPath) {
+		return nil, &domain.ErrInvalidPath{Path: newPath, Reason: "unsupported attachment type"}
+	}
+
+	result := &AttachmentRenameResult{OldPath: oldPath, NewPath: newPath, Changes: []NoteChange{}}
+	if oldPath == newPath {
+		result.Applied = !dryRun
+		return result, nil
+	}
+	if _, err := s.fs.StatFile(filepath.FromSlash(newPath)); err == nil {
+		return nil, &domain.ErrAlreadyExists{Resource: "attachment", ID: newPath}
+	}
+
+	files, err := s.fs.LoadMarkdownFiles()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	type pendingWrite struct {
+		path    string
+		content []byte
+	}
+	writes := []pendingWrite{}
+
+	for _, relPath := range files {
+		content, err := s.fs.ReadFile(relPath)
+		if err != nil {
+			return nil, err
+		}
+
+		updated, changed := s.rewriteAttachmentLinks(content, relPath, oldPath, newPath)
+		if !changed {
+			continue
+		}
+
+		result.Changes = append(result.Changes, NoteChange{
+			NoteID: relPath,
+			Diff:   ChangedLines(DiffLines(string(content), string(updated))),
+		})
+		writes = append(writes, pendingWrite{path: relPath, content: updated})
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	if err := s.attachments.Move(oldPath, newPath); err != nil {
+		return nil, err
+	}
+	for _, w := range writes {
+		if err := s.fs.WriteFile(w.path, w.content); err != nil {
+			return nil, err
+		}
+	}
+	result.Applied = true
+
+	return result, nil
+}
+
+// rewriteAttachmentLinks points the links in a note that resolve to oldPath at newPath.
+// Links inside code blocks and code spans are left alone.
+func (s *NoteService) rewriteAttachmentLinks(content []byte, noteID, oldPath, newPath string) ([]byte, bool) {
+	bodyStart := 0
+	if _, _, start, ok := splitFrontmatter(content); ok {
+		bodyStart = start
+	}
+	codeRanges := s.codeRanges(content[bodyStart:])
+	inCode := func(pos int) bool {
+		pos -= bodyStart
+		for _, r := range codeRanges {
+			if pos >= r.start && pos < r.end {
+				return true
+			}
+		}
+		return false
+	}
+
+	type replacement struct {
+		start, end int
+		text       string
+	}
+	replacements := []replacement{}
+
+	for _, m := range attachmentWikilinkPattern.FindAllSubmatchIndex(content, -1) {
+		if inCode(m[0]) {
+			continue
+		}
+		target := string(content[m[4]:m[5]])
+		if !isAttachmentFile(target) || s.attachments.ResolveWikilink(target, noteID) != oldPath {
+			continue
+		}
+		replacements = append(replacements, replacement{m[4], m[5], s.renamedWikilinkTarget(target, oldPath, newPath)})
+	}
+
+	for _, m := range attachmentMarkdownLinkPattern.FindAllSubmatchIndex(content, -1) {
+		if inCode(m[0]) {
+			continue
+		}
+		dest := string(content[m[6]:m[7]])
+		bracketed := strings.HasPrefix(dest, "<") && strings.HasSuffix(dest, ">")
+		if bracketed {
+			dest = dest[1 : len(dest)-1]
+		}
+		if target, ok := resolveMarkdownDestination(dest, noteID); !ok || target != oldPath {
+			continue
+		}
+		replacements = append(replacements, replacement{m[6], m[7], renamedMarkdownDestination(dest, noteID, newPath, bracketed)})
+	}
+
+	if len(replacements) == 0 {
+		return content, false
+	}
+
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].start < replacements[j].start
+	})
+
+	var buf bytes.Buffer
+	last := 0
+	for _, r := range replacements {
+		if r.start < last {
+			continue
+		}
+		buf.Write(content[last:r.start])
+		buf.WriteString(r.text)
+		last = r.end
+	}
+	buf.Write(content[last:])
+	return buf.Bytes(), true
+}
+
+// renamedWikilinkTarget returns the wikilink target for a renamed attachment.
+// A bare file name stays bare unless another attachment shares the new name.
+func (s *NoteService) renamedWikilinkTarget(written, oldPath, newPath string) string {
+	if strings.Contains(cleanAttachmentPath(written), "/") {
+		return newPath
+	}
+
+	name := path.Base(newPath)
+	for _, attachment := range s.attachments.List() {
+		if attachment.Path != oldPath && attachment.Name == name {
+			return newPath
+		}
+	}
+	return name
+}
+
+// renamedMarkdownDestination returns the Markdown link destination for a renamed attachment,
+// relative to the linking note unless the original destination was workspace-absolute.
+func renamedMarkdownDestination(written, noteID, newPath string, bracketed bool) string {
+	dest := newPath
+	if strings.HasPrefix(written, "/") {
+		dest = "/" + newPath
+	} else if rel, err := filepath.Rel(filepath.FromSlash(noteFolder(noteID)), filepath.FromSlash(newPath)); err == nil {
+		dest = filepath.ToSlash(rel)
+	}
+
+	switch {
+	case bracketed:
+		return "<" + dest + ">"
+	case strings.Contains(written, "%"):
+		segments := strings.Split(dest, "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		return strings.Join(segments, "/")
+	default:
+		return strings.ReplaceAll(dest, " ", "%20")
+	}
+}
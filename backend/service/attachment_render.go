@@ -0,0 +1,277 @@
+package service
+
+import (
+	"html"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"go.abhg.dev/goldmark/wikilink"
+)
+
+// kindAttachment is the AST node kind for wikilinks that point at attachments.
+var kindAttachment = ast.NewNodeKind("Attachment")
+
+// renderNoteKey carries the ID of the note being rendered, used to resolve relative links.
+var renderNoteKey = parser.NewContextKey()
+
+// renderLinksKey carries the LinkResolver used when rendering outside the app, e.g. for export.
+var renderLinksKey = parser.NewContextKey()
+
+// LinkResolver maps the links of a rendered note to URLs, for rendering outside the app.
+// Without one, attachments point at the asset handler and wikilinks to notes stay literal text.
+type LinkResolver interface {
+	// NoteURL returns the URL of the note a wikilink target or workspace note path refers to,
+	// or false to render the link as plain text.
+	NoteURL(target string) (string, bool)
+	// AttachmentURL returns the URL of a workspace attachment.
+	AttachmentURL(relPath string) string
+	// EmbedNote returns the HTML of an embedded note, or of one block when fragment is "^block-id",
+	// or false to render the embed as a link.
+	EmbedNote(target, fragment string) (string, bool)
+}
+
+// attachmentNode replaces a wikilink to an attachment so it can be rendered as an image, player or link.
+type attachmentNode struct {
+	ast.BaseInline
+	path   string // Resolved workspace-relative path
+	url    string // URL the attachment is served from
+	target string // Target as written in the wikilink
+	label  string // Link text; equals target unless the wikilink has a |label
+	page   string // Fragment after #, passed to PDF viewers (e.g. "page=3")
+	embed  bool
+}
+
+func (n *attachmentNode) Kind() ast.NodeKind {
+	return kindAttachment
+}
+
+func (n *attachmentNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Path": n.path, "Label": n.label}, nil)
+}
+
+// kindNoteEmbed is the AST node kind for a note embedded by a LinkResolver.
+var kindNoteEmbed = ast.NewNodeKind("NoteEmbed")
+
+// noteEmbedNode holds the rendered HTML of an embedded note. It replaces the paragraph
+// when the embed stands alone, and sits inline otherwise.
+type noteEmbedNode struct {
+	ast.BaseBlock
+	html string
+}
+
+func (n *noteEmbedNode) Kind() ast.NodeKind {
+	return kindNoteEmbed
+}
+
+func (n *noteEmbedNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// attachmentExtension points local images and attachment links at the asset handler.
+// Wikilinks to notes are left as literal [[...]] text for the frontend to handle,
+// unless a LinkResolver is set in the parser context.
+type attachmentExtension struct {
+	notes *NoteService
+}
+
+func (e *attachmentExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithInlineParsers(util.Prioritized(&wikilink.Parser{}, 199)),
+		parser.WithASTTransformers(util.Prioritized(&attachmentTransformer{notes: e.notes}, 100)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&attachmentRenderer{Config: gmhtml.NewConfig()}, 100)))
+}
+
+// attachmentTransformer rewrites attachment destinations to asset handler URLs.
+type attachmentTransformer struct {
+	notes *NoteService
+}
+
+func (t *attachmentTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	noteID, _ := pc.Get(renderNoteKey).(string)
+	links, _ := pc.Get(renderLinksKey).(LinkResolver)
+	wikilinks := []*wikilink.Node{}
+
+	workspaceID := ""
+	if workspace, err := t.notes.fs.GetCurrentWorkspace(); err == nil {
+		workspaceID = workspace.ID
+	}
+	attachmentURL := func(relPath string) string {
+		if links != nil {
+			return links.AttachmentURL(relPath)
+		}
+		return AttachmentURL(workspaceID, relPath)
+	}
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch node := n.(type) {
+		case *wikilink.Node:
+			wikilinks = append(wikilinks, node)
+			return ast.WalkSkipChildren, nil
+		case *ast.Image:
+			if target, ok := resolveMarkdownDestination(string(node.Destination), noteID); ok && isAttachmentFile(target) {
+				node.Destination = []byte(attachmentURL(target))
+			}
+		case *ast.Link:
+			target, ok := resolveMarkdownDestination(string(node.Destination), noteID)
+			switch {
+			case !ok:
+			case isAttachmentFile(target):
+				node.Destination = []byte(attachmentURL(target))
+			case links != nil && isMarkdownFile(target):
+				if url, ok := links.NoteURL(target); ok {
+					node.Destination = []byte(url)
+				}
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, link := range wikilinks {
+		target := string(link.Target)
+		label := nodeText(link, source)
+		parent := link.Parent()
+
+		var replacement ast.Node
+		switch {
+		case isAttachmentFile(target):
+			resolved := cleanAttachmentPath(target)
+			if t.notes.attachments != nil {
+				resolved = t.notes.attachments.ResolveWikilink(target, noteID)
+			}
+			replacement = &attachmentNode{path: resolved, url: attachmentURL(resolved), target: target, label: label, page: string(link.Fragment), embed: link.Embed}
+		case links == nil:
+			replacement = ast.NewString([]byte(wikilinkSource(link, label)))
+		default:
+			replacement = resolvedWikilink(link, label, links)
+		}
+
+		if embed, ok := replacement.(*noteEmbedNode); ok && parent.Kind() == ast.KindParagraph && parent.ChildCount() == 1 {
+			parent.Parent().ReplaceChild(parent.Parent(), parent, embed)
+			continue
+		}
+		parent.ReplaceChild(parent, link, replacement)
+	}
+}
+
+// resolvedWikilink renders a wikilink to a note through a LinkResolver: embeds are inlined,
+// links point at the note's URL, and links to unknown notes become plain text.
+func resolvedWikilink(link *wikilink.Node, label string, links LinkResolver) ast.Node {
+	target := string(link.Target)
+	if link.Embed {
+		if html, ok := links.EmbedNote(target, string(link.Fragment)); ok {
+			return &noteEmbedNode{html: html}
+		}
+	}
+
+	url, ok := links.NoteURL(target)
+	if !ok {
+		return ast.NewString([]byte(label))
+	}
+
+	anchor := ast.NewLink()
+	anchor.Destination = []byte(url)
+	anchor.AppendChild(anchor, ast.NewString([]byte(label)))
+	return anchor
+}
+
+// wikilinkSource reconstructs the Markdown source of a wikilink.
+func wikilinkSource(link *wikilink.Node, label string) string {
+	var b strings.Builder
+	if link.Embed {
+		b.WriteString("!")
+	}
+	written := string(link.Target)
+	if len(link.Fragment) > 0 {
+		written += "#" + string(link.Fragment)
+	}
+	b.WriteString("[[")
+	b.WriteString(written)
+	if label != "" && label != written {
+		b.WriteString("|")
+		b.WriteString(label)
+	}
+	b.WriteString("]]")
+	return b.String()
+}
+
+// attachmentRenderer writes attachment wikilinks as HTML.
+// Embedded images, audio, video and PDFs render inline; other files render as links.
+type attachmentRenderer struct {
+	gmhtml.Config
+}
+
+// SetOption applies goldmark's HTML renderer options, e.g. XHTML output.
+func (r *attachmentRenderer) SetOption(name renderer.OptionName, value any) {
+	r.Config.SetOption(name, value)
+}
+
+func (r *attachmentRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindAttachment, r.render)
+	reg.Register(kindNoteEmbed, r.renderNoteEmbed)
+}
+
+// renderNoteEmbed writes the HTML of an embedded note.
+func (r *attachmentRenderer) renderNoteEmbed(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		w.WriteString(`<div class="note-embed">`)
+		w.WriteString(node.(*noteEmbedNode).html)
+		w.WriteString("</div>\n")
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *attachmentRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*attachmentNode)
+	src := html.EscapeString(n.url)
+
+	mediaType := attachmentMediaType(n.path)
+	switch {
+	case !n.embed:
+		writeAttachmentLink(w, src, n)
+	case strings.HasPrefix(mediaType, "image/"):
+		w.WriteString(`<img src="` + src + `"`)
+		if n.label != n.target {
+			w.WriteString(` alt="` + html.EscapeString(n.label) + `"`)
+		}
+		if r.XHTML {
+			w.WriteString(" />")
+		} else {
+			w.WriteString(">")
+		}
+	case strings.HasPrefix(mediaType, "audio/"):
+		w.WriteString(`<audio controls src="` + src + `"></audio>`)
+	case strings.HasPrefix(mediaType, "video/"):
+		w.WriteString(`<video controls src="` + src + `"></video>`)
+	case mediaType == "application/pdf":
+		if n.page != "" {
+			src += "#" + html.EscapeString(n.page)
+		}
+		w.WriteString(`<iframe class="attachment-pdf" src="` + src + `"></iframe>`)
+	default:
+		writeAttachmentLink(w, src, n)
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// writeAttachmentLink renders an attachment as a link labelled with the wikilink text.
+func writeAttachmentLink(w util.BufWriter, src string, n *attachmentNode) {
+	w.WriteString(`<a class="attachment-link" href="` + src + `">`)
+	w.WriteString(html.EscapeString(n.label))
+	w.WriteString("</a>")
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"notes/backend/domain"
+)
+
+// newTestAttachmentWorkspace opens a workspace containing files and returns services wired together
+// the way the app wires them.
+func newTestAttachmentWorkspace(t *testing.T, files map[string]string) (*AttachmentService, *NoteService, *GraphService) {
+	t.Helper()
+
+	fs, err := NewFilesystemService()
+	if err != nil {
+		t.Fatalf("NewFilesystemService() error = %v", err)
+	}
+	t.Cleanup(func() { fs.Close() })
+
+	if _, err := fs.OpenWorkspace(t.TempDir()); err != nil {
+		t.Fatalf("OpenWorkspace() error = %v", err)
+	}
+
+	for path, content := range files {
+		if err := fs.WriteFile(path, []byte(content)); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", path, err)
+		}
+	}
+
+	attachments := NewAttachmentService(fs)
+	if err := attachments.Refresh(); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	notes := NewNoteService(fs)
+	notes.SetAttachmentService(attachments)
+
+	graph := NewGraphService()
+	graph.SetAttachments(attachments)
+
+	return attachments, notes, graph
+}
+
+func indexTestNotes(t *testing.T, notes *NoteService, graph *GraphService) {
+	t.Helper()
+
+	files, err := notes.fs.LoadMarkdownFiles()
+	if err != nil {
+		t.Fatalf("LoadMarkdownFiles() error = %v", err)
+	}
+	for _, path := range files {
+		note, err := notes.GetNote(path)
+		if err != nil {
+			t.Fatalf("GetNote(%s) error = %v", path, err)
+		}
+		if err := graph.IndexNote(note); err != nil {
+			t.Fatalf("IndexNote(%s) error = %v", path, err)
+		}
+	}
+}
+
+var attachmentTestFiles = map[string]string{
+	"notes/trip.md":          "# Trip\n\n![[beach.png]]\n\n![map](../maps/route%20map.pdf)\n\n[[Other note]]\n",
+	"notes/other note.md":    "# Other\n\n`![[unused.png]]` is only code\n",
+	"attachments/beach.png":  "png-bytes",
+	"maps/route map.pdf":     "pdf-bytes",
+	"attachments/unused.png": "unused",
+	"attachments/notes.txt":  "not an attachment",
+}
+
+func TestAttachmentService_Refresh(t *testing.T) {
+	attachments, _, _ := newTestAttachmentWorkspace(t, attachmentTestFiles)
+
+	var paths []string
+	for _, attachment := range attachments.List() {
+		paths = append(paths, attachment.Path)
+	}
+	want := []string{"attachments/beach.png", "attachments/unused.png", "maps/route map.pdf"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("List() = %v, want %v", paths, want)
+	}
+
+	beach, ok := attachments.Get("attachments/beach.png")
+	if !ok {
+		t.Fatal("Get(attachments/beach.png) not found")
+	}
+	if beach.MediaType != "image/png" || beach.Size != int64(len("png-bytes")) || beach.Name != "beach.png" {
+		t.Errorf("Get() = %+v, want image/png named beach.png with size 9", beach)
+	}
+}
+
+func TestAttachmentService_ResolveWikilink(t *testing.T) {
+	attachments, _, _ := newTestAttachmentWorkspace(t, map[string]string{
+		"a.png":               "root",
+		"notes/a.png":         "beside note",
+		"deep/nested/b.png":   "nested",
+		"x/deep/nested/b.png": "longer",
+	})
+
+	tests := []struct {
+		target   string
+		fromNote string
+		want     string
+	}{
+		{"a.png", "notes/n.md", "a.png"},
+		{"notes/a.png", "other.md", "notes/a.png"},
+		{"b.png", "n.md", "deep/nested/b.png"},
+		{"nested/b.png", "n.md", "deep/nested/b.png"},
+		{"../a.png", "notes/n.md", "a.png"},
+		{"missing.png", "n.md", "missing.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			if got := attachments.ResolveWikilink(tt.target, tt.fromNote); got != tt.want {
+				t.Errorf("ResolveWikilink(%q, %q) = %q, want %q", tt.target, tt.fromNote, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachmentService_Import(t *testing.T) {
+	attachments, _, _ := newTestAttachmentWorkspace(t, map[string]string{"notes/n.md": "# N\n"})
+
+	srcDir := t.TempDir()
+	writeSrc := func(name, content string) string {
+		path := filepath.Join(srcDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		return path
+	}
+
+	link, err := attachments.Import(writeSrc("diagram.png", "one"), "notes/n.md")
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if link != "![[diagram.png]]" {
+		t.Errorf("Import() = %q, want ![[diagram.png]]", link)
+	}
+
+	link, err = attachments.Import(writeSrc("diagram.png", "one"), "notes/n.md")
+	if err != nil || link != "![[diagram.png]]" {
+		t.Errorf("Import(identical) = %q, %v, want reuse of ![[diagram.png]]", link, err)
+	}
+
+	link, err = attachments.Import(writeSrc("diagram.png", "two"), "notes/n.md")
+	if err != nil || link != "![[diagram 1.png]]" {
+		t.Errorf("Import(different) = %q, %v, want ![[diagram 1.png]]", link, err)
+	}
+
+	if err := attachments.SetFolder("./assets"); err != nil {
+		t.Fatalf("SetFolder() error = %v", err)
+	}
+	link, err = attachments.Import(writeSrc("data.zip", "zip"), "notes/n.md")
+	if err != nil || link != "[[data.zip]]" {
+		t.Errorf("Import(zip) = %q, %v, want [[data.zip]]", link, err)
+	}
+	if _, ok := attachments.Get("notes/assets/data.zip"); !ok {
+		t.Error("Import() with ./assets did not copy beside the note")
+	}
+
+	link, err = attachments.Import(writeSrc("diagram.png", "three"), "n.md")
+	if err != nil || link != "![[assets/diagram.png]]" {
+		t.Errorf("Import(ambiguous name) = %q, %v, want ![[assets/diagram.png]]", link, err)
+	}
+
+	_, err = attachments.Import(writeSrc("script.sh", "echo"), "n.md")
+	var invalid *domain.ErrInvalidPath
+	if !errors.As(err, &invalid) {
+		t.Errorf("Import(script.sh) error = %v, want ErrInvalidPath", err)
+	}
+}
+
+func TestAttachmentService_ImportMarkdownLinks(t *testing.T) {
+	attachments, _, _ := newTestAttachmentWorkspace(t, map[string]string{"notes/n.md": "# N\n"})
+	attachments.SetLinkStyle(domain.LinkStyleMarkdown)
+
+	srcDir := t.TempDir()
+	for name, content := range map[string]string{"my diagram.png": "png", "data.zip": "zip"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	link, err := attachments.Import(filepath.Join(srcDir, "my diagram.png"), "notes/n.md")
+	if err != nil || link != "![my diagram.png](../attachments/my%20diagram.png)" {
+		t.Errorf("Import(png) = %q, %v", link, err)
+	}
+	link, err = attachments.Import(filepath.Join(srcDir, "data.zip"), "n.md")
+	if err != nil || link != "[data.zip](attachments/data.zip)" {
+		t.Errorf("Import(zip) = %q, %v", link, err)
+	}
+}
+
+func TestNormalizeAttachmentFolder(t *testing.T) {
+	tests := []struct {
+		folder  string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"attachments/", "attachments", false},
+		{"./", ".", false},
+		{"./assets//img", "./assets/img", false},
+		{"/abs", "", true},
+		{"../outside", "", true},
+		{"a/../../b", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.folder, func(t *testing.T) {
+			got, err := normalizeAttachmentFolder(tt.folder)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizeAttachmentFolder(%q) error = %v, wantErr %v", tt.folder, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("normalizeAttachmentFolder(%q) = %q, want %q", tt.folder, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphService_AttachmentNodes(t *testing.T) {
+	_, notes, graph := newTestAttachmentWorkspace(t, attachmentTestFiles)
+	indexTestNotes(t, notes, graph)
+
+	links := graph.GetOutgoingLinks("notes/trip.md")
+	var targets []string
+	for _, link := range links {
+		targets = append(targets, link.Target)
+	}
+	want := []string{"attachments/beach.png", "maps/route map.pdf", "Other note.md"}
+	if !slices.Equal(targets, want) {
+		t.Errorf("outgoing targets = %v, want %v", targets, want)
+	}
+	if links[0].Type != domain.LinkTypeEmbed || links[1].Type != domain.LinkTypeEmbed {
+		t.Errorf("attachment link types = %s, %s, want embed", links[0].Type, links[1].Type)
+	}
+
+	kinds := make(map[string]string)
+	for _, node := range graph.GetGraph().Nodes {
+		kinds[node.ID] = node.Kind
+	}
+	if kinds["attachments/unused.png"] != GraphNodeAttachment || kinds["notes/trip.md"] != GraphNodeNote {
+		t.Errorf("node kinds = %v, want attachment and note kinds", kinds)
+	}
+
+	var orphans []string
+	for _, attachment := range graph.OrphanedAttachments() {
+		orphans = append(orphans, attachment.Path)
+	}
+	if want := []string{"attachments/unused.png"}; !slices.Equal(orphans, want) {
+		t.Errorf("OrphanedAttachments() = %v, want %v", orphans, want)
+	}
+}
+
+func TestNoteService_RenameAttachment(t *testing.T) {
+	attachments, notes, _ := newTestAttachmentWorkspace(t, map[string]string{
+		"notes/trip.md":         "# Trip\n\n![[beach.png|Sunset]] and [[attachments/beach.png]]\n\n![b](../attachments/beach.png \"Beach\")\n\n`![[beach.png]]`\n",
+		"root.md":               "---\ncover: \"[[beach.png]]\"\n---\n\n![b](/attachments/beach.png)\n",
+		"other.md":              "# Other\n\n![[elsewhere.png]]\n",
+		"attachments/beach.png": "png",
+		"elsewhere.png":         "png",
+	})
+
+	result, err := notes.RenameAttachment("attachments/beach.png", "photos/sea side.png", true)
+	if err != nil {
+		t.Fatalf("RenameAttachment(dry run) error = %v", err)
+	}
+	if result.Applied {
+		t.Error("dry run reported Applied")
+	}
+	if want := []string{"notes/trip.md", "root.md"}; !slices.Equal(result.NoteIDs(), want) {
+		t.Errorf("NoteIDs() = %v, want %v", result.NoteIDs(), want)
+	}
+	if _, ok := attachments.Get("attachments/beach.png"); !ok {
+		t.Error("dry run moved the attachment")
+	}
+
+	if _, err := notes.RenameAttachment("attachments/beach.png", "photos/sea side.png", false); err != nil {
+		t.Fatalf("RenameAttachment() error = %v", err)
+	}
+
+	trip, _ := notes.fs.ReadFile("notes/trip.md")
+	wantTrip := "# Trip\n\n![[sea side.png|Sunset]] and [[photos/sea side.png]]\n\n![b](../photos/sea%20side.png \"Beach\")\n\n`![[beach.png]]`\n"
+	if string(trip) != wantTrip {
+		t.Errorf("trip.md = %q, want %q", trip, wantTrip)
+	}
+
+	root, _ := notes.fs.ReadFile("root.md")
+	wantRoot := "---\ncover: \"[[sea side.png]]\"\n---\n\n![b](/photos/sea%20side.png)\n"
+	if string(root) != wantRoot {
+		t.Errorf("root.md = %q, want %q", root, wantRoot)
+	}
+
+	if _, ok := attachments.Get("photos/sea side.png"); !ok {
+		t.Error("renamed attachment missing from index")
+	}
+	if _, err := notes.fs.StatFile("attachments/beach.png"); err == nil {
+		t.Error("old attachment file still exists")
+	}
+
+	_, err = notes.RenameAttachment("photos/sea side.png", "elsewhere.png", false)
+	var exists *domain.ErrAlreadyExists
+	if !errors.As(err, &exists) {
+		t.Errorf("RenameAttachment(onto existing) error = %v, want ErrAlreadyExists", err)
+	}
+}
+
+func TestNoteService_RenderAttachments(t *testing.T) {
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{
+		"img/beach.png": "png",
+		"docs/spec.pdf": "pdf",
+	})
+
+	html, err := notes.RenderNoteMarkdown("notes/n.md", "![[beach.png|Sunset]] ![[spec.pdf#page=2]] [[Note]] ![local](../img/beach.png) ![remote](https://example.com/a.png)\n")
+	if err != nil {
+		t.Fatalf("RenderNoteMarkdown() error = %v", err)
+	}
+
+	workspace, _ := notes.fs.GetCurrentWorkspace()
+	prefix := AttachmentURLPrefix + workspace.ID + "/"
+	for _, want := range []string{
+		`<img src="` + prefix + `img/beach.png" alt="Sunset">`,
+		`<iframe class="attachment-pdf" src="` + prefix + `docs/spec.pdf#page=2"></iframe>`,
+		`[[Note]]`,
+		`<img src="` + prefix + `img/beach.png" alt="local">`,
+		`<img src="https://example.com/a.png" alt="remote">`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("RenderNoteMarkdown() = %s, want it to contain %s", html, want)
+		}
+	}
+}
+
+func TestAttachmentService_ServeHTTP(t *testing.T) {
+	attachments, notes, _ := newTestAttachmentWorkspace(t, map[string]string{
+		"img/a b.png": "png-bytes",
+		"secret.md":   "# Secret\n",
+	})
+	workspace, _ := notes.fs.GetCurrentWorkspace()
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{AttachmentURL(workspace.ID, "img/a b.png"), http.StatusOK, "png-bytes"},
+		{AttachmentURLPrefix + workspace.ID + "/secret.md", http.StatusNotFound, ""},
+		{AttachmentURLPrefix + workspace.ID + "/../../etc/passwd.png", http.StatusNotFound, ""},
+		{AttachmentURL("other-workspace", "img/a b.png"), http.StatusNotFound, ""},
+		{AttachmentURLPrefix + "img/a%20b.png", http.StatusNotFound, ""},
+		{"/other/img/a%20b.png", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			attachments.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+			if tt.wantStatus == http.StatusOK && rec.Header().Get("Content-Type") != "image/png" {
+				t.Errorf("Content-Type = %q, want image/png", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import "strings"
+
+// DiffOp identifies the kind of change a DiffLine represents.
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+// DiffLine is a single line in a line-level diff between two texts.
+// Line numbers are 1-based; OldLine is 0 for inserted lines and NewLine is 0 for deleted lines.
+type DiffLine struct {
+	Op      DiffOp `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"oldLine"`
+	NewLine int    `json:"newLine"`
+}
+
+// NoteChange describes how a workspace-wide operation, such as a tag rename or a folder move,
+// changes a single note.
+type NoteChange struct {
+	NoteID string     `json:"noteId"`
+	Diff   []DiffLine `json:"diff"` // Changed lines only (deletions and insertions)
+}
+
+// DiffLines computes a line-level diff turning before into after.
+// Uses a longest-common-subsequence table after trimming the shared prefix and suffix,
+// which keeps typical note edits cheap.
+func DiffLines(before, after string) []DiffLine {
+	return diffLineSlices(splitLines(before), splitLines(after))
+}
+
+// ChangedLines filters a diff down to inserted and deleted lines.
+func ChangedLines(diff []DiffLine) []DiffLine {
+	changed := []DiffLine{}
+	for _, line := range diff {
+		if line.Op != DiffEqual {
+			changed = append(changed, line)
+		}
+	}
+	return changed
+}
+
+// splitLines splits text into lines without their terminators.
+// A trailing newline does not produce an extra empty line.
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	text = strings.TrimSuffix(text, "\n")
+	return strings.Split(text, "\n")
+}
+
+// diffLineSlices computes the diff between two line slices.
+func diffLineSlices(a, b []string) []DiffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	result := make([]DiffLine, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		result = append(result, DiffLine{Op: DiffEqual, Text: a[i], OldLine: i + 1, NewLine: i + 1})
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+
+	// lcs[i][j] holds the LCS length of midA[i:] and midB[j:]
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(midA) || j < len(midB) {
+		switch {
+		case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+			result = append(result, DiffLine{Op: DiffEqual, Text: midA[i], OldLine: prefix + i + 1, NewLine: prefix + j + 1})
+			i++
+			j++
+		case i < len(midA) && (j == len(midB) || lcs[i+1][j] >= lcs[i][j+1]):
+			result = append(result, DiffLine{Op: DiffDelete, Text: midA[i], OldLine: prefix + i + 1})
+			i++
+		default:
+			result = append(result, DiffLine{Op: DiffInsert, Text: midB[j], NewLine: prefix + j + 1})
+			j++
+		}
+	}
+
+	for k := 0; k < suffix; k++ {
+		oldIdx := len(a) - suffix + k
+		newIdx := len(b) - suffix + k
+		result = append(result, DiffLine{Op: DiffEqual, Text: a[oldIdx], OldLine: oldIdx + 1, NewLine: newIdx + 1})
+	}
+
+	return result
+}
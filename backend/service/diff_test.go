@@ -0,0 +1,82 @@
+package service
+
+import "testing"
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		before  string
+		after   string
+		wantOps []DiffOp
+	}{
+		{
+			name:    "identical",
+			before:  "a\nb\n",
+			after:   "a\nb\n",
+			wantOps: []DiffOp{DiffEqual, DiffEqual},
+		},
+		{
+			name:    "changed middle line",
+			before:  "a\nb\nc\n",
+			after:   "a\nB\nc\n",
+			wantOps: []DiffOp{DiffEqual, DiffDelete, DiffInsert, DiffEqual},
+		},
+		{
+			name:    "append",
+			before:  "a\n",
+			after:   "a\nb\n",
+			wantOps: []DiffOp{DiffEqual, DiffInsert},
+		},
+		{
+			name:    "delete first",
+			before:  "a\nb\n",
+			after:   "b\n",
+			wantOps: []DiffOp{DiffDelete, DiffEqual},
+		},
+		{
+			name:    "from empty",
+			before:  "",
+			after:   "a\n",
+			wantOps: []DiffOp{DiffInsert},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffLines(tt.before, tt.after)
+			if len(diff) != len(tt.wantOps) {
+				t.Fatalf("DiffLines() = %+v, want ops %v", diff, tt.wantOps)
+			}
+			for i, op := range tt.wantOps {
+				if diff[i].Op != op {
+					t.Errorf("line %d op = %s, want %s", i, diff[i].Op, op)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffLines_LineNumbers(t *testing.T) {
+	diff := DiffLines("a\nb\nc\nd\n", "a\nc\nx\nd\n")
+
+	var deleted, inserted []DiffLine
+	for _, line := range diff {
+		switch line.Op {
+		case DiffDelete:
+			deleted = append(deleted, line)
+		case DiffInsert:
+			inserted = append(inserted, line)
+		}
+	}
+
+	if len(deleted) != 1 || deleted[0].Text != "b" || deleted[0].OldLine != 2 {
+		t.Errorf("deleted = %+v, want b at old line 2", deleted)
+	}
+	if len(inserted) != 1 || inserted[0].Text != "x" || inserted[0].NewLine != 3 {
+		t.Errorf("inserted = %+v, want x at new line 3", inserted)
+	}
+
+	if changed := ChangedLines(diff); len(changed) != 2 {
+		t.Errorf("ChangedLines() = %d lines, want 2", len(changed))
+	}
+}
@@ -0,0 +1,372 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"notes/backend/domain"
+)
+
+// maxEmbedDepth limits how deeply embedded notes are inlined into an exported page.
+const maxEmbedDepth = 4
+
+// ExportSelection picks the notes to export. A note is exported when it matches any of the
+// criteria; an empty selection exports every note in the workspace.
+type ExportSelection struct {
+	NoteIDs   []string `json:"noteIds"`   // Notes to export by ID
+	Tags      []string `json:"tags"`      // Tags to export, including nested tags ("project" selects "project/alpha")
+	Folders   []string `json:"folders"`   // Folders to export, including subfolders
+	Published bool     `json:"published"` // Export notes with "publish: true" in their frontmatter
+}
+
+// empty reports whether the selection has no criteria.
+func (s ExportSelection) empty() bool {
+	return len(s.NoteIDs) == 0 && len(s.Tags) == 0 && len(s.Folders) == 0 && !s.Published
+}
+
+// matches reports whether a note meets any of the selection's criteria.
+func (s ExportSelection) matches(note *domain.Note) bool {
+	if slices.Contains(s.NoteIDs, note.ID) {
+		return true
+	}
+
+	for _, folder := range s.Folders {
+		folder = strings.Trim(filepath.ToSlash(strings.TrimSpace(folder)), "/")
+		if folder == "" || folder == "." || strings.HasPrefix(note.ID, folder+"/") {
+			return true
+		}
+	}
+
+	for _, selected := range s.Tags {
+		selected = normalizeTagName(selected)
+		for _, tag := range note.Tags {
+			if isTagOrDescendant(tag.Name, selected) {
+				return true
+			}
+		}
+	}
+
+	publish, _ := note.Frontmatter["publish"].(bool)
+	return s.Published && publish
+}
+
+// ExportLink is a link in an exported note that does not point at another exported note.
+type ExportLink struct {
+	NoteID string `json:"noteId"` // Note containing the link
+	Target string `json:"target"` // Link target as written
+}
+
+// ExportReport summarizes an export.
+type ExportReport struct {
+	Output          string       `json:"output"`          // Absolute path of the written folder or file
+	Notes           []string     `json:"notes"`           // Exported note IDs, in export order
+	Files           []string     `json:"files"`           // Written files, relative to Output when it is a folder
+	Attachments     []string     `json:"attachments"`     // Copied attachments, relative to the workspace
+	UnresolvedLinks []ExportLink `json:"unresolvedLinks"` // Links to missing or unexported notes, written as plain text
+}
+
+// ExportService writes notes out of the workspace in formats readable without the app.
+type ExportService struct {
+	fs     *FilesystemService
+	notes  *NoteService
+	graph  *GraphService
+	themes *ThemeService
+}
+
+// NewExportService creates an export service. Backlinks come from graph, which must be indexed.
+func NewExportService(fs *FilesystemService, notes *NoteService, graph *GraphService, themes *ThemeService) *ExportService {
+	return &ExportService{fs: fs, notes: notes, graph: graph, themes: themes}
+}
+
+// exportSet is the set of notes selected for an export, with the names links can use to reach them.
+type exportSet struct {
+	notes []*domain.Note            // Selected notes, sorted by ID
+	byID  map[string]*domain.Note   // Selected notes by lowercase ID
+	names map[string][]*domain.Note // Selected notes by lowercase title, alias and file name
+}
+
+// selectNotes loads the workspace's notes and keeps those matching selection.
+func (s *ExportService) selectNotes(selection ExportSelection) (*exportSet, error) {
+	notes, err := s.loadNotes()
+	if err != nil {
+		return nil, err
+	}
+	return newExportSet(notes, selection), nil
+}
+
+// loadNotes reads every note in the workspace.
+func (s *ExportService) loadNotes() ([]*domain.Note, error) {
+	summaries, err := s.notes.ListNotes()
+	if err != nil {
+		return nil, err
+	}
+
+	notes := make([]*domain.Note, 0, len(summaries))
+	for _, summary := range summaries {
+		note, err := s.notes.GetNote(summary.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", summary.ID, err)
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+// newExportSet builds the set of notes matching selection.
+func newExportSet(notes []*domain.Note, selection ExportSelection) *exportSet {
+	set := &exportSet{byID: map[string]*domain.Note{}, names: map[string][]*domain.Note{}}
+	for _, note := range notes {
+		if !selection.empty() && !selection.matches(note) {
+			continue
+		}
+
+		set.notes = append(set.notes, note)
+		set.byID[strings.ToLower(note.ID)] = note
+
+		names := append([]string{note.Title, strings.TrimSuffix(path.Base(note.ID), path.Ext(note.ID))}, note.Aliases...)
+		for _, name := range names {
+			key := strings.ToLower(strings.TrimSpace(name))
+			if key != "" && !slices.Contains(set.names[key], note) {
+				set.names[key] = append(set.names[key], note)
+			}
+		}
+	}
+
+	sort.Slice(set.notes, func(i, j int) bool {
+		return set.notes[i].ID < set.notes[j].ID
+	})
+	return set
+}
+
+// resolve finds the exported note a wikilink target or workspace note path refers to.
+// Paths are tried first, then titles, aliases and file names; ambiguous names pick the shortest path.
+func (set *exportSet) resolve(target string) (*domain.Note, bool) {
+	key := strings.ToLower(cleanAttachmentPath(strings.TrimSpace(target)))
+	if note, ok := set.byID[key]; ok {
+		return note, true
+	}
+	if note, ok := set.byID[key+".md"]; ok {
+		return note, true
+	}
+
+	key = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(target), ".md"))
+	var best *domain.Note
+	for _, note := range set.names[key] {
+		if best == nil || len(note.ID) < len(best.ID) || (len(note.ID) == len(best.ID) && note.ID < best.ID) {
+			best = note
+		}
+	}
+	return best, best != nil
+}
+
+// noteExport renders the notes of one export, collecting referenced attachments and unresolved links.
+type noteExport struct {
+	notes         *NoteService
+	set           *exportSet
+	report        *ExportReport
+	noteURL       func(page, noteID string) string  // URL of a note from a page
+	attachmentURL func(page, relPath string) string // URL of an attachment from a page
+	attachments   map[string]bool                   // Referenced attachment paths
+	xhtml         bool                              // Render XHTML instead of HTML
+}
+
+// render converts a note to HTML as part of the export's page for that note.
+func (e *noteExport) render(note *domain.Note) (string, error) {
+	return e.renderMarkdown(note.ID, note.Content, &exportLinks{export: e, page: note.ID, note: note.ID})
+}
+
+// renderMarkdown converts markdown from a note with the export's renderer.
+func (e *noteExport) renderMarkdown(noteID, markdown string, links *exportLinks) (string, error) {
+	if e.xhtml {
+		return e.notes.renderNote(e.notes.xhtml, noteID, markdown, links)
+	}
+	return e.notes.RenderNoteMarkdownWithLinks(noteID, markdown, links)
+}
+
+// exportLinks resolves the links of one note rendered into an exported page.
+// Embedded notes are rendered with their own exportLinks that keeps the page,
+// so their URLs stay relative to the page they are inlined into.
+type exportLinks struct {
+	export *noteExport
+	page   string   // Note ID of the page being written
+	note   string   // Note ID of the note being rendered
+	embeds []string // Notes being embedded, outermost first
+}
+
+// NoteURL returns the URL of an exported note, recording links to other notes as unresolved.
+func (l *exportLinks) NoteURL(target string) (string, bool) {
+	note, ok := l.export.set.resolve(target)
+	if !ok {
+		l.unresolved(target)
+		return "", false
+	}
+	return l.export.noteURL(l.page, note.ID), true
+}
+
+// AttachmentURL returns the URL of an attachment and marks it for copying.
+func (l *exportLinks) AttachmentURL(relPath string) string {
+	l.export.attachments[relPath] = true
+	return l.export.attachmentURL(l.page, relPath)
+}
+
+// EmbedNote renders an exported note, or one of its blocks, for inlining into the page.
+// Embeds of unexported notes, cycles and embeds nested too deeply are rendered as links.
+func (l *exportLinks) EmbedNote(target, fragment string) (string, bool) {
+	note, ok := l.export.set.resolve(target)
+	if !ok || note.ID == l.page || slices.Contains(l.embeds, note.ID) || len(l.embeds) >= maxEmbedDepth {
+		return "", false
+	}
+
+	content := note.Content
+	if blockID, isBlock := strings.CutPrefix(fragment, "^"); isBlock {
+		i := slices.IndexFunc(note.Blocks, func(b domain.Block) bool { return b.ID == blockID })
+		if i < 0 {
+			return "", false
+		}
+		content = note.Blocks[i].Content
+	}
+
+	embedded := &exportLinks{export: l.export, page: l.page, note: note.ID, embeds: append(slices.Clone(l.embeds), note.ID)}
+	html, err := l.export.renderMarkdown(note.ID, content, embedded)
+	if err != nil {
+		return "", false
+	}
+	return html, true
+}
+
+// unresolved records a link that does not reach an exported note. Links inside embedded notes
+// are recorded when the embedded note's own page is rendered, so they are not counted twice.
+func (l *exportLinks) unresolved(target string) {
+	if len(l.embeds) == 0 {
+		l.export.report.addUnresolved(l.note, target)
+	}
+}
+
+// newExportReport creates an empty report for an export written to output.
+func newExportReport(output string) *ExportReport {
+	return &ExportReport{
+		Output:          output,
+		Notes:           []string{},
+		Files:           []string{},
+		Attachments:     []string{},
+		UnresolvedLinks: []ExportLink{},
+	}
+}
+
+// addUnresolved records a link that does not reach an exported note, once per note and target.
+func (r *ExportReport) addUnresolved(noteID, target string) {
+	link := ExportLink{NoteID: noteID, Target: target}
+	if !slices.Contains(r.UnresolvedLinks, link) {
+		r.UnresolvedLinks = append(r.UnresolvedLinks, link)
+	}
+}
+
+// exportOutputPath resolves an export destination folder or file, rejecting paths inside the
+// workspace so exports are never indexed as notes.
+func (s *ExportService) exportOutputPath(output string) (string, error) {
+	workspace, err := s.fs.GetCurrentWorkspace()
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(output) == "" {
+		return "", &domain.ErrInvalidPath{Path: output, Reason: "output path is required"}
+	}
+	output, err = filepath.Abs(output)
+	if err != nil {
+		return "", &domain.ErrInvalidPath{Path: output, Reason: err.Error()}
+	}
+	if within(output, workspace.RootPath) || within(workspace.RootPath, output) {
+		return "", &domain.ErrInvalidPath{Path: output, Reason: "output path overlaps the open workspace"}
+	}
+	return output, nil
+}
+
+// writeExportFile writes a file under the output folder, creating its parent folders.
+func writeExportFile(report *ExportReport, rel string, content []byte) error {
+	full := filepath.Join(report.Output, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return fmt.Errorf("failed to create folder for %s: %w", rel, err)
+	}
+	if err := os.WriteFile(full, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rel, err)
+	}
+	report.Files = append(report.Files, rel)
+	return nil
+}
+
+// writeExportOutput writes an export that is a single file to the report's output path.
+func writeExportOutput(report *ExportReport, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(report.Output), 0755); err != nil {
+		return fmt.Errorf("failed to create folder for %s: %w", report.Output, err)
+	}
+	if err := os.WriteFile(report.Output, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", report.Output, err)
+	}
+	report.Files = append(report.Files, filepath.Base(report.Output))
+	return nil
+}
+
+// copyExportAttachments copies the attachments referenced by exported notes into the output
+// folder at their workspace paths. Missing attachments are skipped.
+func (s *ExportService) copyExportAttachments(report *ExportReport, attachments map[string]bool) error {
+	paths := make([]string, 0, len(attachments))
+	for rel := range attachments {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+
+	for _, rel := range paths {
+		src, err := s.fs.OpenFile(rel)
+		if err != nil {
+			continue
+		}
+
+		full := filepath.Join(report.Output, filepath.FromSlash(rel))
+		err = os.MkdirAll(filepath.Dir(full), 0755)
+		if err == nil {
+			err = copyToFile(src, full)
+		}
+		src.Close()
+		if err != nil {
+			return fmt.Errorf("failed to copy %s: %w", rel, err)
+		}
+
+		report.Attachments = append(report.Attachments, rel)
+		report.Files = append(report.Files, rel)
+	}
+	return nil
+}
+
+// copyToFile writes everything read from src to a new file at dest.
+func copyToFile(src io.Reader, dest string) error {
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// relativeURL returns the escaped URL of target relative to the page at from.
+// Both are slash-separated paths relative to the export root.
+func relativeURL(from, target string) string {
+	rel := strings.Repeat("../", strings.Count(from, "/")) + target
+	segments := strings.Split(rel, "/")
+	for i, segment := range segments {
+		if segment != ".." {
+			segments[i] = url.PathEscape(segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+
+	"notes/backend/domain"
+)
+
+// blockIDMarkerPattern matches a ^block-id marker at the end of a line, or on a line of its own.
+var blockIDMarkerPattern = regexp.MustCompile(`(?m)(^[ \t]*\^[A-Za-z0-9_-]+[ \t]*(?:\r?\n|$)|[ \t]+\^[A-Za-z0-9_-]+[ \t]*$)`)
+
+// markdownLabelEscaper escapes the characters that would end a Markdown link label early.
+var markdownLabelEscaper = strings.NewReplacer(`[`, `\[`, `]`, `\]`)
+
+// exportCommonMark writes the notes as portable Markdown files at their workspace paths.
+func (s *ExportService) exportCommonMark(report *ExportReport, set *exportSet) error {
+	attachments := map[string]bool{}
+	for _, note := range set.notes {
+		content := s.portableMarkdown(report, set, note, attachments)
+		if err := writeExportFile(report, note.ID, []byte(content)); err != nil {
+			return err
+		}
+		report.Notes = append(report.Notes, note.ID)
+	}
+	return s.copyExportAttachments(report, attachments)
+}
+
+// portableMarkdown converts a note to Markdown that renders the same in any CommonMark tool:
+// wikilinks become relative links, block ID markers are removed, and the title becomes a heading
+// when the note has none. Frontmatter is dropped. Referenced attachments are added to attachments.
+func (s *ExportService) portableMarkdown(report *ExportReport, set *exportSet, note *domain.Note, attachments map[string]bool) string {
+	content := s.replaceOutsideCode(note.Content, blockIDMarkerPattern, func([]string) string {
+		return ""
+	})
+
+	content = s.replaceOutsideCode(content, attachmentWikilinkPattern, func(m []string) string {
+		embed := m[1] == "!"
+		target := strings.TrimSpace(m[2])
+		fragment := strings.TrimPrefix(m[3], "#")
+		label := strings.TrimPrefix(m[4], "|")
+		if label == "" {
+			label = target + m[3]
+		}
+		label = markdownLabelEscaper.Replace(label)
+
+		if isAttachmentFile(target) {
+			resolved := cleanAttachmentPath(target)
+			if s.notes.attachments != nil {
+				resolved = s.notes.attachments.ResolveWikilink(target, note.ID)
+			}
+			attachments[resolved] = true
+
+			link := "[" + label + "](" + relativeURL(note.ID, resolved) + ")"
+			if embed && strings.HasPrefix(attachmentMediaType(resolved), "image/") {
+				return "!" + link
+			}
+			return link
+		}
+
+		linked, ok := set.resolve(target)
+		if !ok {
+			report.addUnresolved(note.ID, target)
+			return label
+		}
+
+		url := relativeURL(note.ID, linked.ID)
+		if fragment != "" && !strings.HasPrefix(fragment, "^") {
+			url += "#" + headingAnchor(fragment)
+		}
+		return "[" + label + "](" + url + ")"
+	})
+
+	for _, m := range attachmentMarkdownLinkPattern.FindAllStringSubmatch(content, -1) {
+		dest := strings.Trim(m[3], "<>")
+		if target, ok := resolveMarkdownDestination(dest, note.ID); ok && isAttachmentFile(target) {
+			attachments[target] = true
+		}
+	}
+
+	if !strings.HasPrefix(strings.TrimSpace(content), "# ") && note.Title != "" {
+		content = "# " + note.Title + "\n\n" + strings.TrimLeft(content, "\n")
+	}
+	return content
+}
+
+// replaceOutsideCode replaces the matches of pattern in content that are not inside code,
+// passing replace the match and its submatches.
+func (s *ExportService) replaceOutsideCode(content string, pattern *regexp.Regexp, replace func(m []string) string) string {
+	ranges := s.notes.codeRanges([]byte(content))
+	inCode := func(pos int) bool {
+		for _, r := range ranges {
+			if pos >= r.start && pos < r.end {
+				return true
+			}
+		}
+		return false
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range pattern.FindAllStringSubmatchIndex(content, -1) {
+		if inCode(loc[0]) {
+			continue
+		}
+
+		m := make([]string, len(loc)/2)
+		for i := range m {
+			if loc[2*i] >= 0 {
+				m[i] = content[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		b.WriteString(content[last:loc[0]])
+		b.WriteString(replace(m))
+		last = loc[1]
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
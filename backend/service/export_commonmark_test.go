@@ -0,0 +1,43 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExportService_ExportNotes_CommonMark(t *testing.T) {
+	exporter := newTestExportService(t, documentTestFiles)
+	out := t.TempDir()
+
+	report, err := exporter.ExportNotes(out, DocumentExportOptions{
+		Format:    ExportFormatCommonMark,
+		Selection: ExportSelection{NoteIDs: []string{"Intro.md", "chapters/two.md"}},
+	})
+	if err != nil {
+		t.Fatalf("ExportNotes() error = %v", err)
+	}
+
+	if want := []string{"Intro.md", "chapters/two.md", "attachments/diagram.png"}; !slices.Equal(report.Files, want) {
+		t.Errorf("Files = %v, want %v", report.Files, want)
+	}
+	if want := []ExportLink{{NoteID: "Intro.md", Target: "Draft"}}; !slices.Equal(report.UnresolvedLinks, want) {
+		t.Errorf("UnresolvedLinks = %v, want %v", report.UnresolvedLinks, want)
+	}
+
+	tests := map[string]string{
+		"Intro.md": "# Intro\n\nStart here\n\nNext: [chapter two](chapters/two.md), see [Two#Details](chapters/two.md#details) and Draft.\n\n" +
+			"![diagram.png](attachments/diagram.png)\n\n```\n[[Intro]] ^kept\n```\n",
+		"chapters/two.md": "# Chapter Two\n\nSecond chapter.\n\n## Details\n\n[Intro#^start](../Intro.md)\n",
+	}
+	for rel, want := range tests {
+		got, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", rel, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s =\n%s\nwant\n%s", rel, got, want)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"bytes"
+	"embed"
+	"encoding/base64"
+	"fmt"
+	htmltemplate "html/template"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"unicode"
+
+	"notes/backend/domain"
+)
+
+// Document export formats.
+const (
+	ExportFormatHTML       = "html"       // One standalone HTML file, ready to print to PDF
+	ExportFormatEPUB       = "epub"       // An EPUB 3 book with one chapter per note
+	ExportFormatCommonMark = "commonmark" // A folder of portable CommonMark files
+)
+
+//go:embed document/*
+var documentFS embed.FS
+
+var (
+	documentPageTemplate  = htmltemplate.Must(htmltemplate.ParseFS(documentFS, "document/page.html"))
+	documentStyleTemplate = texttemplate.Must(texttemplate.ParseFS(documentFS, "document/style.css"))
+)
+
+// DocumentExportOptions configures ExportNotes.
+type DocumentExportOptions struct {
+	Format    string          `json:"format"`    // ExportFormatHTML, ExportFormatEPUB or ExportFormatCommonMark
+	Selection ExportSelection `json:"selection"` // Notes to export; empty exports the whole workspace, or the notes ListNote links to
+	ListNote  string          `json:"listNote"`  // Note whose links give the order of notes; empty orders notes by folder
+	Title     string          `json:"title"`     // Document title; defaults to the only note's title, the list note's title or the workspace name
+	Author    string          `json:"author"`    // Book author for EPUB exports
+	Theme     string          `json:"theme"`     // Theme slug for HTML exports; empty uses the default theme
+}
+
+// documentSection is one note in a standalone HTML document.
+type documentSection struct {
+	Anchor    string
+	Title     string
+	ShowTitle bool // Set when the note does not start with its own heading
+	Content   htmltemplate.HTML
+}
+
+// ExportNotes exports the selected notes in a format meant for sharing outside the app.
+//
+// ExportFormatHTML writes outputPath as one HTML file with every note in order, embedded notes and
+// images inlined and links between the notes pointing within the file. ExportFormatEPUB writes
+// outputPath as an EPUB book with one chapter per note. Both order notes by ListNote's links when
+// it is set, and otherwise by folder and title.
+//
+// ExportFormatCommonMark writes the notes into the outputPath folder at their workspace paths,
+// with wikilinks converted to relative Markdown links, block IDs and frontmatter removed, and
+// referenced attachments copied alongside.
+//
+// Links to notes that are not exported become plain text and are reported as unresolved.
+// outputPath must be outside the workspace; existing files are overwritten.
+func (s *ExportService) ExportNotes(outputPath string, options DocumentExportOptions) (*ExportReport, error) {
+	switch options.Format {
+	case ExportFormatHTML, ExportFormatEPUB, ExportFormatCommonMark:
+	default:
+		return nil, fmt.Errorf("unknown export format %q", options.Format)
+	}
+
+	output, err := s.exportOutputPath(outputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	order, title, err := s.documentNotes(options)
+	if err != nil {
+		return nil, err
+	}
+	set := newExportSet(order, ExportSelection{})
+
+	report := newExportReport(output)
+	switch options.Format {
+	case ExportFormatHTML:
+		err = s.exportHTML(report, set, order, title, options.Theme)
+	case ExportFormatEPUB:
+		err = s.exportEPUB(report, set, order, title, options.Author)
+	case ExportFormatCommonMark:
+		err = s.exportCommonMark(report, set)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// documentNotes selects the notes of a document export and returns them in document order,
+// along with the document's title. The list note itself is not part of the document.
+func (s *ExportService) documentNotes(options DocumentExportOptions) ([]*domain.Note, string, error) {
+	notes, err := s.loadNotes()
+	if err != nil {
+		return nil, "", err
+	}
+
+	selection := options.Selection
+	title := strings.TrimSpace(options.Title)
+	listed := []string{}
+	if options.ListNote != "" {
+		list, err := s.notes.GetNote(options.ListNote)
+		if err != nil {
+			return nil, "", err
+		}
+		listed = s.listedNotes(newExportSet(notes, ExportSelection{}), list)
+		if selection.empty() {
+			selection.NoteIDs = listed
+		}
+		if title == "" {
+			title = list.Title
+		}
+	}
+
+	set := newExportSet(notes, selection)
+	order := make([]*domain.Note, 0, len(set.notes))
+	for _, id := range listed {
+		if note, ok := set.byID[strings.ToLower(id)]; ok {
+			order = append(order, note)
+		}
+	}
+
+	rest := []*domain.Note{}
+	for _, note := range set.notes {
+		if note.ID != options.ListNote && !slices.Contains(order, note) {
+			rest = append(rest, note)
+		}
+	}
+	sort.SliceStable(rest, func(i, j int) bool {
+		fi, fj := noteFolder(rest[i].ID), noteFolder(rest[j].ID)
+		if fi != fj {
+			return fi < fj
+		}
+		return strings.ToLower(rest[i].Title) < strings.ToLower(rest[j].Title)
+	})
+	order = append(order, rest...)
+
+	if title == "" && len(order) == 1 {
+		title = order[0].Title
+	}
+	if title == "" {
+		workspace, err := s.fs.GetCurrentWorkspace()
+		if err != nil {
+			return nil, "", err
+		}
+		title = workspace.Name
+	}
+	return order, title, nil
+}
+
+// listedNotes returns the IDs of the notes a list note links to, in link order, using the link index.
+func (s *ExportService) listedNotes(all *exportSet, list *domain.Note) []string {
+	ids := []string{}
+	for _, link := range s.graph.GetOutgoingLinks(list.ID) {
+		target := link.Target
+		if link.Type == domain.LinkTypeMarkdown {
+			resolved, ok := resolveMarkdownDestination(target, list.ID)
+			if !ok {
+				continue
+			}
+			target = resolved
+		}
+		if isAttachmentFile(target) {
+			continue
+		}
+
+		note, ok := all.resolve(target)
+		if ok && note.ID != list.ID && !slices.Contains(ids, note.ID) {
+			ids = append(ids, note.ID)
+		}
+	}
+	return ids
+}
+
+// exportHTML writes the notes into one standalone HTML file.
+func (s *ExportService) exportHTML(report *ExportReport, set *exportSet, order []*domain.Note, title, themeSlug string) error {
+	theme, err := s.siteTheme(themeSlug)
+	if err != nil {
+		return err
+	}
+
+	anchors := noteAnchors(order)
+	images := map[string]string{}
+	export := &noteExport{
+		notes:  s.notes,
+		set:    set,
+		report: report,
+		noteURL: func(page, noteID string) string {
+			if anchor, ok := anchors[noteID]; ok {
+				return "#" + anchor
+			}
+			return "#"
+		},
+		attachmentURL: func(page, relPath string) string {
+			return s.inlineImage(images, relPath)
+		},
+		attachments: map[string]bool{},
+	}
+
+	sections := make([]documentSection, 0, len(order))
+	for _, note := range order {
+		content, err := export.render(note)
+		if err != nil {
+			return fmt.Errorf("failed to render %s: %w", note.ID, err)
+		}
+		sections = append(sections, documentSection{
+			Anchor:    anchors[note.ID],
+			Title:     note.Title,
+			ShowTitle: !strings.HasPrefix(strings.TrimSpace(note.Content), "# "),
+			Content:   htmltemplate.HTML(content),
+		})
+		report.Notes = append(report.Notes, note.ID)
+	}
+
+	var style bytes.Buffer
+	if err := documentStyleTemplate.Execute(&style, struct {
+		Name   string
+		Colors []siteColor
+	}{theme.Name, paletteColors(theme.Palette)}); err != nil {
+		return fmt.Errorf("failed to render stylesheet: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := documentPageTemplate.Execute(&buf, struct {
+		Title    string
+		Style    htmltemplate.CSS
+		Sections []documentSection
+	}{title, htmltemplate.CSS(style.String()), sections}); err != nil {
+		return fmt.Errorf("failed to render document: %w", err)
+	}
+
+	if err := writeExportOutput(report, buf.Bytes()); err != nil {
+		return err
+	}
+
+	for relPath, uri := range images {
+		if strings.HasPrefix(uri, "data:") {
+			report.Attachments = append(report.Attachments, relPath)
+		}
+	}
+	sort.Strings(report.Attachments)
+	return nil
+}
+
+// inlineImage returns an image attachment as a data URI, caching it in images.
+// Other attachments, and images that cannot be read, keep their workspace-relative path.
+func (s *ExportService) inlineImage(images map[string]string, relPath string) string {
+	if uri, ok := images[relPath]; ok {
+		return uri
+	}
+
+	uri := relativeURL("", relPath)
+	if mediaType := attachmentMediaType(relPath); strings.HasPrefix(mediaType, "image/") {
+		if data, err := s.fs.ReadFile(relPath); err == nil {
+			uri = "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data)
+		}
+	}
+	images[relPath] = uri
+	return uri
+}
+
+// noteAnchors assigns each note a unique element ID derived from its path.
+func noteAnchors(notes []*domain.Note) map[string]string {
+	anchors := map[string]string{}
+	used := map[string]bool{}
+	for _, note := range notes {
+		base := "note-" + headingAnchor(strings.ReplaceAll(strings.TrimSuffix(note.ID, filepath.Ext(note.ID)), "/", "-"))
+		anchor := base
+		for i := 2; used[anchor]; i++ {
+			anchor = fmt.Sprintf("%s-%d", base, i)
+		}
+		used[anchor] = true
+		anchors[note.ID] = anchor
+	}
+	return anchors
+}
+
+// headingAnchor converts heading text to the anchor most Markdown renderers generate for it:
+// lowercase, spaces as dashes, punctuation removed.
+func headingAnchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var documentTestFiles = map[string]string{
+	"Book.md": "# Reading Order\n\n1. [[Intro]]\n2. [Chapter Two](chapters/two.md)\n3. [[diagram.png]]\n",
+	"Intro.md": "# Intro\n\nStart here ^start\n\nNext: [[Two|chapter two]], see [[Two#Details]] and [[Draft]].\n\n" +
+		"![[diagram.png]]\n\n```\n[[Intro]] ^kept\n```\n",
+	"chapters/two.md":         "---\ntitle: Chapter Two\n---\nSecond chapter.\n\n## Details\n\n![[Intro#^start]]\n",
+	"chapters/extra.md":       "# Extra\n\nAppendix.\n",
+	"Draft.md":                "# Draft\n",
+	"attachments/diagram.png": "png",
+}
+
+func TestExportService_ExportNotes_HTML(t *testing.T) {
+	exporter := newTestExportService(t, documentTestFiles)
+	output := filepath.Join(t.TempDir(), "book.html")
+
+	report, err := exporter.ExportNotes(output, DocumentExportOptions{Format: ExportFormatHTML, ListNote: "Book.md"})
+	if err != nil {
+		t.Fatalf("ExportNotes() error = %v", err)
+	}
+
+	if want := []string{"Intro.md", "chapters/two.md"}; !slices.Equal(report.Notes, want) {
+		t.Errorf("Notes = %v, want %v", report.Notes, want)
+	}
+	if want := []string{"attachments/diagram.png"}; !slices.Equal(report.Attachments, want) {
+		t.Errorf("Attachments = %v, want %v", report.Attachments, want)
+	}
+	if want := []ExportLink{{NoteID: "Intro.md", Target: "Draft"}}; !slices.Equal(report.UnresolvedLinks, want) {
+		t.Errorf("UnresolvedLinks = %v, want %v", report.UnresolvedLinks, want)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	html := string(data)
+	for _, want := range []string{
+		"<title>Reading Order</title>",
+		`<li><a href="#note-intro">Intro</a></li>`,
+		`<section class="note" id="note-intro">`,
+		`<a href="#note-chapters-two">chapter two</a>, see <a href="#note-chapters-two">Two#Details</a> and Draft.`,
+		`<img src="data:image/png;base64,cG5n">`,
+		`<section class="note" id="note-chapters-two">` + "\n      <h1>Chapter Two</h1>",
+		`<div class="note-embed"><p>Start here</p>`,
+		"--base00: #",
+		"@media print",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("document missing %q:\n%s", want, html)
+		}
+	}
+}
+
+func TestExportService_DocumentNotes(t *testing.T) {
+	exporter := newTestExportService(t, documentTestFiles)
+
+	tests := []struct {
+		name      string
+		options   DocumentExportOptions
+		wantNotes []string
+		wantTitle string
+	}{
+		{
+			name:      "folder order",
+			options:   DocumentExportOptions{Selection: ExportSelection{Folders: []string{"."}}},
+			wantNotes: []string{"Draft.md", "Intro.md", "Book.md", "chapters/two.md", "chapters/extra.md"},
+		},
+		{
+			name:      "list note",
+			options:   DocumentExportOptions{ListNote: "Book.md"},
+			wantNotes: []string{"Intro.md", "chapters/two.md"},
+			wantTitle: "Reading Order",
+		},
+		{
+			name:      "list note orders selection",
+			options:   DocumentExportOptions{ListNote: "Book.md", Selection: ExportSelection{Folders: []string{"chapters"}}, Title: "Chapters"},
+			wantNotes: []string{"chapters/two.md", "chapters/extra.md"},
+			wantTitle: "Chapters",
+		},
+		{
+			name:      "single note",
+			options:   DocumentExportOptions{Selection: ExportSelection{NoteIDs: []string{"chapters/two.md"}}},
+			wantNotes: []string{"chapters/two.md"},
+			wantTitle: "Chapter Two",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, title, err := exporter.documentNotes(tt.options)
+			if err != nil {
+				t.Fatalf("documentNotes() error = %v", err)
+			}
+
+			ids := []string{}
+			for _, note := range order {
+				ids = append(ids, note.ID)
+			}
+			if !slices.Equal(ids, tt.wantNotes) {
+				t.Errorf("documentNotes() notes = %v, want %v", ids, tt.wantNotes)
+			}
+			if tt.wantTitle != "" && title != tt.wantTitle {
+				t.Errorf("documentNotes() title = %q, want %q", title, tt.wantTitle)
+			}
+		})
+	}
+}
+
+func TestExportService_ExportNotes_UnknownFormat(t *testing.T) {
+	exporter := newTestExportService(t, documentTestFiles)
+
+	if _, err := exporter.ExportNotes(filepath.Join(t.TempDir(), "out.pdf"), DocumentExportOptions{Format: "pdf"}); err == nil {
+		t.Error("ExportNotes() with an unknown format succeeded")
+	}
+}
+
+func TestHeadingAnchor(t *testing.T) {
+	tests := map[string]string{
+		"Details":            "details",
+		"Next Steps (draft)": "next-steps-draft",
+		"  Über café ":       "über-café",
+		"v1.2_notes":         "v12_notes",
+	}
+
+	for heading, want := range tests {
+		if got := headingAnchor(heading); got != want {
+			t.Errorf("headingAnchor(%q) = %q, want %q", heading, got, want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"embed"
+	"encoding/xml"
+	"fmt"
+	htmltemplate "html/template"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"notes/backend/domain"
+
+	"github.com/google/uuid"
+)
+
+// epubLanguage is the language declared in exported books.
+const epubLanguage = "en"
+
+//go:embed epub/*
+var epubFS embed.FS
+
+var (
+	epubPackageTemplate = texttemplate.Must(texttemplate.New("content.opf").Funcs(texttemplate.FuncMap{"xml": xmlEscape}).ParseFS(epubFS, "epub/content.opf"))
+	epubNavTemplate     = htmltemplate.Must(htmltemplate.ParseFS(epubFS, "epub/nav.xhtml"))
+	epubChapterTemplate = htmltemplate.Must(htmltemplate.ParseFS(epubFS, "epub/chapter.xhtml"))
+)
+
+// epubItem is a file listed in the book's manifest.
+type epubItem struct {
+	ID        string
+	Href      string
+	MediaType string
+	Title     string // Chapter title, for chapters
+}
+
+// exportEPUB writes the notes as an EPUB 3 book with one chapter per note.
+// Links between notes point at their chapters, and referenced attachments are packaged with the book.
+func (s *ExportService) exportEPUB(report *ExportReport, set *exportSet, order []*domain.Note, title, author string) error {
+	chapters := make([]epubItem, len(order))
+	hrefs := map[string]string{}
+	for i, note := range order {
+		chapters[i] = epubItem{
+			ID:        fmt.Sprintf("chapter-%03d", i+1),
+			Href:      fmt.Sprintf("chapter-%03d.xhtml", i+1),
+			MediaType: "application/xhtml+xml",
+			Title:     note.Title,
+		}
+		hrefs[note.ID] = chapters[i].Href
+	}
+
+	export := &noteExport{
+		notes:  s.notes,
+		set:    set,
+		report: report,
+		noteURL: func(page, noteID string) string {
+			return hrefs[noteID]
+		},
+		attachmentURL: func(page, relPath string) string {
+			return relativeURL("", relPath)
+		},
+		attachments: map[string]bool{},
+		xhtml:       true,
+	}
+
+	var buf bytes.Buffer
+	book := zip.NewWriter(&buf)
+
+	// The mimetype file must come first and be stored uncompressed.
+	mimetype, err := book.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err == nil {
+		_, err = mimetype.Write([]byte("application/epub+zip"))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write epub: %w", err)
+	}
+
+	files := map[string][]byte{}
+	for i, note := range order {
+		content, err := export.render(note)
+		if err != nil {
+			return fmt.Errorf("failed to render %s: %w", note.ID, err)
+		}
+
+		var chapter bytes.Buffer
+		chapter.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+		if err := epubChapterTemplate.Execute(&chapter, struct {
+			Language  string
+			Title     string
+			ShowTitle bool
+			Content   htmltemplate.HTML
+		}{epubLanguage, note.Title, !strings.HasPrefix(strings.TrimSpace(note.Content), "# "), htmltemplate.HTML(content)}); err != nil {
+			return fmt.Errorf("failed to render chapter for %s: %w", note.ID, err)
+		}
+		files["OEBPS/"+chapters[i].Href] = chapter.Bytes()
+		report.Notes = append(report.Notes, note.ID)
+	}
+
+	items := append([]epubItem{}, chapters...)
+	attachments := make([]string, 0, len(export.attachments))
+	for relPath := range export.attachments {
+		attachments = append(attachments, relPath)
+	}
+	sort.Strings(attachments)
+	for i, relPath := range attachments {
+		data, err := s.fs.ReadFile(relPath)
+		if err != nil {
+			continue
+		}
+		files["OEBPS/"+relPath] = data
+		items = append(items, epubItem{ID: fmt.Sprintf("file-%03d", i+1), Href: relativeURL("", relPath), MediaType: attachmentMediaType(relPath)})
+		report.Attachments = append(report.Attachments, relPath)
+	}
+
+	var nav bytes.Buffer
+	nav.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	if err := epubNavTemplate.Execute(&nav, struct {
+		Language string
+		Title    string
+		Chapters []epubItem
+	}{epubLanguage, title, chapters}); err != nil {
+		return fmt.Errorf("failed to render table of contents: %w", err)
+	}
+	files["OEBPS/nav.xhtml"] = nav.Bytes()
+
+	var pkg bytes.Buffer
+	if err := epubPackageTemplate.Execute(&pkg, struct {
+		Identifier string
+		Title      string
+		Author     string
+		Language   string
+		Modified   string
+		Items      []epubItem
+		Chapters   []epubItem
+	}{"urn:uuid:" + uuid.New().String(), title, author, epubLanguage, time.Now().UTC().Format("2006-01-02T15:04:05Z"), items, chapters}); err != nil {
+		return fmt.Errorf("failed to render package document: %w", err)
+	}
+	files["OEBPS/content.opf"] = pkg.Bytes()
+
+	for name, asset := range map[string]string{"META-INF/container.xml": "epub/container.xml", "OEBPS/style.css": "epub/style.css"} {
+		data, err := epubFS.ReadFile(asset)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", asset, err)
+		}
+		files[name] = data
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		w, err := book.Create(name)
+		if err == nil {
+			_, err = w.Write(files[name])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write epub: %w", err)
+		}
+	}
+	if err := book.Close(); err != nil {
+		return fmt.Errorf("failed to write epub: %w", err)
+	}
+
+	return writeExportOutput(report, buf.Bytes())
+}
+
+// xmlEscape escapes text for use in XML content and attribute values.
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExportService_ExportNotes_EPUB(t *testing.T) {
+	exporter := newTestExportService(t, documentTestFiles)
+	output := filepath.Join(t.TempDir(), "book.epub")
+
+	report, err := exporter.ExportNotes(output, DocumentExportOptions{Format: ExportFormatEPUB, ListNote: "Book.md", Author: "Sam & Alex"})
+	if err != nil {
+		t.Fatalf("ExportNotes() error = %v", err)
+	}
+	if want := []string{"Intro.md", "chapters/two.md"}; !slices.Equal(report.Notes, want) {
+		t.Errorf("Notes = %v, want %v", report.Notes, want)
+	}
+
+	book, err := zip.OpenReader(output)
+	if err != nil {
+		t.Fatalf("OpenReader() error = %v", err)
+	}
+	defer book.Close()
+
+	if first := book.File[0]; first.Name != "mimetype" || first.Method != zip.Store {
+		t.Errorf("first entry = %s (method %d), want uncompressed mimetype", first.Name, first.Method)
+	}
+
+	files := map[string]string{}
+	for _, f := range book.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(data)
+	}
+
+	wantFiles := []string{
+		"META-INF/container.xml", "OEBPS/attachments/diagram.png", "OEBPS/chapter-001.xhtml", "OEBPS/chapter-002.xhtml",
+		"OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/style.css", "mimetype",
+	}
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, wantFiles) {
+		t.Errorf("entries = %v, want %v", names, wantFiles)
+	}
+
+	for name, content := range files {
+		if strings.HasSuffix(name, ".xhtml") || strings.HasSuffix(name, ".opf") || strings.HasSuffix(name, ".xml") {
+			decoder := xml.NewDecoder(bytes.NewReader([]byte(content)))
+			for {
+				if _, err := decoder.Token(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Errorf("%s is not well-formed XML: %v\n%s", name, err, content)
+					break
+				}
+			}
+		}
+	}
+
+	checks := map[string][]string{
+		"OEBPS/chapter-001.xhtml": {
+			`<a href="chapter-002.xhtml">chapter two</a>`,
+			`<img src="attachments/diagram.png" />`,
+		},
+		"OEBPS/chapter-002.xhtml": {
+			"<h1>Chapter Two</h1>",
+			`<div class="note-embed"><p>Start here</p>`,
+		},
+		"OEBPS/content.opf": {
+			"<dc:title>Reading Order</dc:title>",
+			"<dc:creator>Sam &amp; Alex</dc:creator>",
+			`<item id="file-001" href="attachments/diagram.png" media-type="image/png"/>`,
+			`<itemref idref="chapter-001"/>`,
+		},
+		"OEBPS/nav.xhtml": {
+			`<li><a href="chapter-002.xhtml">Chapter Two</a></li>`,
+		},
+	}
+	for name, wants := range checks {
+		for _, want := range wants {
+			if !strings.Contains(files[name], want) {
+				t.Errorf("%s missing %q:\n%s", name, want, files[name])
+			}
+		}
+	}
+}
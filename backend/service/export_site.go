@@ -0,0 +1,285 @@
+package service
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"notes/backend/domain"
+)
+
+//go:embed site/*
+var siteFS embed.FS
+
+var (
+	sitePageTemplate  = htmltemplate.Must(htmltemplate.ParseFS(siteFS, "site/page.html"))
+	siteIndexTemplate = htmltemplate.Must(htmltemplate.ParseFS(siteFS, "site/index.html"))
+	siteStyleTemplate = texttemplate.Must(texttemplate.ParseFS(siteFS, "site/style.css"))
+)
+
+// htmlTagPattern matches HTML tags, stripped from rendered notes for the search index.
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// SiteExportOptions configures a static site export.
+type SiteExportOptions struct {
+	Selection ExportSelection `json:"selection"` // Notes to export; empty exports the whole workspace
+	Theme     string          `json:"theme"`     // Theme slug for the stylesheet; empty uses the default theme
+	Title     string          `json:"title"`     // Site title; empty uses the workspace name
+}
+
+// siteLink is a link to an exported page.
+type siteLink struct {
+	URL    string
+	Title  string
+	Folder string
+}
+
+// sitePage is the data passed to the page template.
+type sitePage struct {
+	SiteTitle string
+	Title     string
+	ShowTitle bool // Set when the note does not start with its own heading
+	Root      string
+	Tags      []string
+	Content   htmltemplate.HTML
+	Backlinks []siteLink
+}
+
+// siteColor is one palette entry written to the stylesheet as a CSS custom property.
+type siteColor struct {
+	Name  string
+	Value string
+}
+
+// siteSearchEntry is one page in search-index.json.
+type siteSearchEntry struct {
+	URL   string   `json:"url"`
+	Title string   `json:"title"`
+	Tags  []string `json:"tags"`
+	Text  string   `json:"text"`
+}
+
+// ExportSite writes the selected notes to outputDir as a static HTML site.
+//
+// Each note becomes an HTML page at its workspace path with an .html extension. Wikilinks and
+// Markdown links between exported notes become relative URLs; links to other notes become plain
+// text and are reported as unresolved. Embedded notes are inlined, each page lists the exported
+// notes linking to it, and referenced attachments are copied alongside the pages.
+//
+// The site also gets an index.html listing every page (unless a root index.md is exported),
+// a style.css built from the theme's palette, and a search-index.json read by search.js.
+// outputDir must be outside the workspace; existing files in it are overwritten.
+func (s *ExportService) ExportSite(outputDir string, options SiteExportOptions) (*ExportReport, error) {
+	outputDir, err := s.exportOutputPath(outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	theme, err := s.siteTheme(options.Theme)
+	if err != nil {
+		return nil, err
+	}
+
+	title := strings.TrimSpace(options.Title)
+	if title == "" {
+		workspace, err := s.fs.GetCurrentWorkspace()
+		if err != nil {
+			return nil, err
+		}
+		title = workspace.Name
+	}
+
+	set, err := s.selectNotes(options.Selection)
+	if err != nil {
+		return nil, err
+	}
+
+	report := newExportReport(outputDir)
+	export := &noteExport{
+		notes:  s.notes,
+		set:    set,
+		report: report,
+		noteURL: func(page, noteID string) string {
+			return relativeURL(sitePagePath(page), sitePagePath(noteID))
+		},
+		attachmentURL: func(page, relPath string) string {
+			return relativeURL(sitePagePath(page), relPath)
+		},
+		attachments: map[string]bool{},
+	}
+
+	index := []siteSearchEntry{}
+	listing := []siteLink{}
+	for _, note := range set.notes {
+		content, err := export.render(note)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", note.ID, err)
+		}
+
+		pagePath := sitePagePath(note.ID)
+		tags := noteTagNames(note)
+		page := sitePage{
+			SiteTitle: title,
+			Title:     note.Title,
+			ShowTitle: !strings.HasPrefix(strings.TrimSpace(note.Content), "# "),
+			Root:      strings.Repeat("../", strings.Count(pagePath, "/")),
+			Tags:      tags,
+			Content:   htmltemplate.HTML(content),
+			Backlinks: s.siteBacklinks(set, note),
+		}
+
+		var buf bytes.Buffer
+		if err := sitePageTemplate.Execute(&buf, page); err != nil {
+			return nil, fmt.Errorf("failed to render page for %s: %w", note.ID, err)
+		}
+		if err := writeExportFile(report, pagePath, buf.Bytes()); err != nil {
+			return nil, err
+		}
+		report.Notes = append(report.Notes, note.ID)
+
+		index = append(index, siteSearchEntry{URL: relativeURL("", pagePath), Title: note.Title, Tags: tags, Text: searchText(content)})
+		listing = append(listing, siteLink{URL: relativeURL("", pagePath), Title: note.Title, Folder: noteFolder(note.ID)})
+	}
+
+	if !slices.Contains(report.Files, "index.html") {
+		if err := s.writeSiteIndex(report, title, listing); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.writeSiteAssets(report, theme, index); err != nil {
+		return nil, err
+	}
+	if err := s.copyExportAttachments(report, export.attachments); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// siteTheme loads the theme for a site export, falling back to the default theme.
+func (s *ExportService) siteTheme(slug string) (*domain.Base16Theme, error) {
+	if strings.TrimSpace(slug) == "" {
+		return s.themes.GetDefaultTheme()
+	}
+	return s.themes.LoadTheme(slug)
+}
+
+// siteBacklinks returns links to the exported notes that link to note, sorted by title.
+func (s *ExportService) siteBacklinks(set *exportSet, note *domain.Note) []siteLink {
+	pagePath := sitePagePath(note.ID)
+	seen := map[string]bool{note.ID: true}
+	links := []siteLink{}
+
+	for _, backlink := range s.graph.GetBacklinks(note.ID) {
+		source, ok := set.byID[strings.ToLower(backlink.Source)]
+		if !ok || seen[source.ID] {
+			continue
+		}
+		seen[source.ID] = true
+		links = append(links, siteLink{URL: relativeURL(pagePath, sitePagePath(source.ID)), Title: source.Title})
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].Title < links[j].Title
+	})
+	return links
+}
+
+// writeSiteIndex writes index.html listing every exported page.
+func (s *ExportService) writeSiteIndex(report *ExportReport, title string, listing []siteLink) error {
+	sort.SliceStable(listing, func(i, j int) bool {
+		return strings.ToLower(listing[i].Title) < strings.ToLower(listing[j].Title)
+	})
+
+	var content bytes.Buffer
+	if err := siteIndexTemplate.Execute(&content, struct {
+		SiteTitle string
+		Notes     []siteLink
+	}{title, listing}); err != nil {
+		return fmt.Errorf("failed to render index: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := sitePageTemplate.Execute(&buf, sitePage{SiteTitle: title, Content: htmltemplate.HTML(content.String())}); err != nil {
+		return fmt.Errorf("failed to render index: %w", err)
+	}
+	return writeExportFile(report, "index.html", buf.Bytes())
+}
+
+// writeSiteAssets writes the stylesheet, search script and search index.
+func (s *ExportService) writeSiteAssets(report *ExportReport, theme *domain.Base16Theme, index []siteSearchEntry) error {
+	var style bytes.Buffer
+	if err := siteStyleTemplate.Execute(&style, struct {
+		Name    string
+		Author  string
+		Variant string
+		Colors  []siteColor
+	}{theme.Name, theme.Author, theme.Variant, paletteColors(theme.Palette)}); err != nil {
+		return fmt.Errorf("failed to render stylesheet: %w", err)
+	}
+	if err := writeExportFile(report, "style.css", style.Bytes()); err != nil {
+		return err
+	}
+
+	script, err := siteFS.ReadFile("site/search.js")
+	if err != nil {
+		return fmt.Errorf("failed to read search script: %w", err)
+	}
+	if err := writeExportFile(report, "search.js", script); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to encode search index: %w", err)
+	}
+	return writeExportFile(report, "search-index.json", data)
+}
+
+// sitePagePath returns the path of a note's page in an exported site.
+func sitePagePath(noteID string) string {
+	return strings.TrimSuffix(noteID, path.Ext(noteID)) + ".html"
+}
+
+// noteTagNames returns the sorted, unique tag names of a note.
+func noteTagNames(note *domain.Note) []string {
+	tags := []string{}
+	for _, tag := range note.Tags {
+		if !slices.Contains(tags, tag.Name) {
+			tags = append(tags, tag.Name)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// paletteColors lists a palette as CSS custom properties, adding the "#" some themes omit.
+func paletteColors(p domain.Base16Palette) []siteColor {
+	values := []string{
+		p.Base00, p.Base01, p.Base02, p.Base03, p.Base04, p.Base05, p.Base06, p.Base07,
+		p.Base08, p.Base09, p.Base0A, p.Base0B, p.Base0C, p.Base0D, p.Base0E, p.Base0F,
+	}
+
+	colors := make([]siteColor, len(values))
+	for i, value := range values {
+		if value != "" && !strings.HasPrefix(value, "#") {
+			value = "#" + value
+		}
+		colors[i] = siteColor{Name: fmt.Sprintf("base%02X", i), Value: value}
+	}
+	return colors
+}
+
+// searchText reduces rendered HTML to its whitespace-normalized text.
+func searchText(rendered string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(htmlTagPattern.ReplaceAllString(rendered, " "))), " ")
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"notes/backend/domain"
+)
+
+var exportTestFiles = map[string]string{
+	"Home.md": "---\npublish: true\n---\n# Home\n\nSee [[Projects/Alpha]], [[beta|the beta]] and [[Secret]].\n\n" +
+		"![[Alpha#^intro]]\n\n![[diagram.png]]\n",
+	"Projects/Alpha.md":       "---\ntags: [project/alpha]\n---\n# Alpha\n\nIntro paragraph ^intro\n\nBack to [home](../Home.md).\n",
+	"Beta.md":                 "---\ntitle: Beta Plan\naliases: [beta]\n---\nBeta body #project\n\n![[Home]]\n",
+	"Secret.md":               "# Secret\n\n[[Home]]\n",
+	"attachments/diagram.png": "png",
+	"attachments/unused.png":  "unused",
+}
+
+// newTestExportService opens a workspace with files, indexes its notes and returns an export service for it.
+func newTestExportService(t *testing.T, files map[string]string) *ExportService {
+	t.Helper()
+
+	_, notes, graph := newTestAttachmentWorkspace(t, files)
+	indexTestNotes(t, notes, graph)
+	return NewExportService(notes.fs, notes, graph, NewThemeService())
+}
+
+func TestExportService_ExportSite(t *testing.T) {
+	exporter := newTestExportService(t, exportTestFiles)
+	out := t.TempDir()
+
+	report, err := exporter.ExportSite(out, SiteExportOptions{
+		Selection: ExportSelection{Tags: []string{"project"}, Published: true},
+		Title:     "Team Notes",
+	})
+	if err != nil {
+		t.Fatalf("ExportSite() error = %v", err)
+	}
+
+	if want := []string{"Beta.md", "Home.md", "Projects/Alpha.md"}; !slices.Equal(report.Notes, want) {
+		t.Errorf("Notes = %v, want %v", report.Notes, want)
+	}
+	if want := []string{"attachments/diagram.png"}; !slices.Equal(report.Attachments, want) {
+		t.Errorf("Attachments = %v, want %v", report.Attachments, want)
+	}
+	if want := []ExportLink{{NoteID: "Home.md", Target: "Secret"}}; !slices.Equal(report.UnresolvedLinks, want) {
+		t.Errorf("UnresolvedLinks = %v, want %v", report.UnresolvedLinks, want)
+	}
+
+	read := func(rel string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", rel, err)
+		}
+		return string(data)
+	}
+
+	home := read("Home.html")
+	for _, want := range []string{
+		`<a href="Projects/Alpha.html">Projects/Alpha</a>`,
+		`<a href="Beta.html">the beta</a>`,
+		` and Secret.`,
+		`<div class="note-embed"><p>Intro paragraph</p>`,
+		`<img src="attachments/diagram.png">`,
+		`<link rel="stylesheet" href="style.css">`,
+		`<li><a href="Beta.html">Beta Plan</a></li>`,
+	} {
+		if !strings.Contains(home, want) {
+			t.Errorf("Home.html missing %q:\n%s", want, home)
+		}
+	}
+	if strings.Contains(home, "Secret.html") {
+		t.Error("Home.html links to an unexported note")
+	}
+
+	alpha := read("Projects/Alpha.html")
+	for _, want := range []string{
+		`<a href="../Home.html">home</a>`,
+		`<link rel="stylesheet" href="../style.css">`,
+		`<li>#project/alpha</li>`,
+		`<li><a href="../Home.html">Home</a></li>`,
+	} {
+		if !strings.Contains(alpha, want) {
+			t.Errorf("Projects/Alpha.html missing %q:\n%s", want, alpha)
+		}
+	}
+
+	beta := read("Beta.html")
+	if !strings.Contains(beta, "<h1>Beta Plan</h1>") || !strings.Contains(beta, `<div class="note-embed"><h1>Home</h1>`) {
+		t.Errorf("Beta.html missing title or embedded note:\n%s", beta)
+	}
+
+	if index := read("index.html"); !strings.Contains(index, `<a href="Projects/Alpha.html">Alpha</a> <span class="folder">Projects</span>`) {
+		t.Errorf("index.html missing page listing:\n%s", index)
+	}
+	if style := read("style.css"); !strings.Contains(style, "--base00: #") || !strings.Contains(style, "--base0F: #") {
+		t.Errorf("style.css missing palette:\n%s", style)
+	}
+
+	var entries []siteSearchEntry
+	if err := json.Unmarshal([]byte(read("search-index.json")), &entries); err != nil {
+		t.Fatalf("search-index.json is invalid: %v", err)
+	}
+	if len(entries) != 3 || entries[0].URL != "Beta.html" || entries[0].Title != "Beta Plan" || !slices.Equal(entries[0].Tags, []string{"project"}) {
+		t.Errorf("search index = %+v, want entries for the exported notes", entries)
+	}
+	read("search.js")
+
+	if _, err := os.Stat(filepath.Join(out, "Secret.html")); err == nil {
+		t.Error("unselected note was exported")
+	}
+	if _, err := os.Stat(filepath.Join(out, "attachments", "unused.png")); err == nil {
+		t.Error("unreferenced attachment was copied")
+	}
+}
+
+func TestExportService_ExportSite_InvalidOptions(t *testing.T) {
+	exporter := newTestExportService(t, exportTestFiles)
+	workspace, _ := exporter.fs.GetCurrentWorkspace()
+
+	for _, dir := range []string{"", workspace.RootPath, filepath.Join(workspace.RootPath, "site")} {
+		_, err := exporter.ExportSite(dir, SiteExportOptions{})
+		var invalid *domain.ErrInvalidPath
+		if !errors.As(err, &invalid) {
+			t.Errorf("ExportSite(%q) error = %v, want ErrInvalidPath", dir, err)
+		}
+	}
+
+	if _, err := exporter.ExportSite(t.TempDir(), SiteExportOptions{Theme: "no-such-theme"}); err == nil {
+		t.Error("ExportSite() with an unknown theme succeeded")
+	}
+}
+
+func TestRelativeURL(t *testing.T) {
+	tests := []struct {
+		from, target string
+		want         string
+	}{
+		{"Home.html", "Beta.html", "Beta.html"},
+		{"a/b/Note.html", "Other Note.html", "../../Other%20Note.html"},
+		{"a/Note.html", "a/Other.html", "../a/Other.html"},
+		{"", "assets/chart #1.png", "assets/chart%20%231.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			if got := relativeURL(tt.from, tt.target); got != tt.want {
+				t.Errorf("relativeURL(%q, %q) = %q, want %q", tt.from, tt.target, got, tt.want)
+			}
+		})
+	}
+}
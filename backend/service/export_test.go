@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+
+	"notes/backend/domain"
+)
+
+func TestExportSelection_Matches(t *testing.T) {
+	note := &domain.Note{
+		ID:          "work/projects/Alpha.md",
+		Tags:        []domain.Tag{{Name: "project/alpha"}},
+		Frontmatter: map[string]any{"publish": true},
+	}
+
+	tests := []struct {
+		name      string
+		selection ExportSelection
+		want      bool
+	}{
+		{"note ID", ExportSelection{NoteIDs: []string{"work/projects/Alpha.md"}}, true},
+		{"parent folder", ExportSelection{Folders: []string{"work/"}}, true},
+		{"root folder", ExportSelection{Folders: []string{"."}}, true},
+		{"folder name prefix", ExportSelection{Folders: []string{"wor"}}, false},
+		{"parent tag", ExportSelection{Tags: []string{"#project"}}, true},
+		{"other tag", ExportSelection{Tags: []string{"proj"}}, false},
+		{"published", ExportSelection{Published: true}, true},
+		{"any criterion", ExportSelection{Tags: []string{"other"}, Published: true}, true},
+		{"no match", ExportSelection{NoteIDs: []string{"Alpha.md"}, Folders: []string{"home"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.selection.matches(note); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportSet_Resolve(t *testing.T) {
+	exporter := newTestExportService(t, exportTestFiles)
+	set, err := exporter.selectNotes(ExportSelection{Folders: []string{"Projects"}, NoteIDs: []string{"Beta.md"}})
+	if err != nil {
+		t.Fatalf("selectNotes() error = %v", err)
+	}
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"Projects/Alpha", "Projects/Alpha.md"},
+		{"projects/alpha.md", "Projects/Alpha.md"},
+		{"Alpha", "Projects/Alpha.md"},
+		{"beta plan", "Beta.md"},
+		{"BETA", "Beta.md"},
+		{"Home", ""},
+		{"Missing", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got := ""
+			if note, ok := set.resolve(tt.target); ok {
+				got = note.ID
+			}
+			if got != tt.want {
+				t.Errorf("resolve(%q) = %q, want %q", tt.target, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/md5"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -25,12 +26,24 @@ type FilesystemService struct {
 	eventChan        chan FileEvent
 	stopChan         chan struct{}
 	logger           runtimeLogger
+	config           domain.WorkspaceConfig
+	configPath       string
+	configHook       func(domain.WorkspaceConfig)
+	configReload     *time.Timer
+	ignore           *ignoreMatcher
+	dirsMu           sync.Mutex
+	watchedDirs      map[string]bool // Absolute paths of the watched directories, to recognize removed folders
 }
 
+// configReloadDelay is how long config.toml must stay unchanged before it is reloaded,
+// so an editor's truncate-then-write is read once, complete.
+const configReloadDelay = 100 * time.Millisecond
+
 // FileEvent represents a filesystem change event.
 type FileEvent struct {
 	Path      string
 	Operation FileOperation
+	IsDir     bool // The event is for a folder rather than a Markdown file
 	Timestamp time.Time
 }
 
@@ -63,7 +76,17 @@ func (s *FilesystemService) SetLogger(ctx context.Context) {
 	s.logger.attach(ctx)
 }
 
+// SetConfigHook sets the function told about every change to the workspace config,
+// whether saved with SaveWorkspaceConfig or made to config.toml outside the app.
+func (s *FilesystemService) SetConfigHook(hook func(domain.WorkspaceConfig)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.configHook = hook
+}
+
 // OpenWorkspace opens or creates a workspace at the specified path.
+// Settings are read from the workspace's config.toml; an invalid file is reported and the defaults used.
 // Returns workspace information and begins filesystem watching.
 func (s *FilesystemService) OpenWorkspace(path string) (*domain.WorkspaceInfo, error) {
 	s.mu.Lock()
@@ -90,17 +113,28 @@ func (s *FilesystemService) OpenWorkspace(path string) (*domain.WorkspaceInfo, e
 		return nil, &domain.ErrInvalidPath{Path: path, Reason: "not a directory"}
 	}
 
+	configPath, err := WorkspaceConfigPath(absPath)
+	if err != nil {
+		return nil, &domain.ErrInvalidPath{Path: path, Reason: err.Error()}
+	}
+	config, err := LoadWorkspaceConfig(configPath)
+	if err != nil {
+		s.logger.Warnf("using default workspace config: %v", err)
+		config = DefaultWorkspaceConfig()
+	}
+
 	workspaceID := generateWorkspaceID(absPath)
 	workspace := &domain.Workspace{
 		ID:             workspaceID,
 		Name:           filepath.Base(absPath),
 		RootPath:       absPath,
-		IgnorePatterns: defaultIgnorePatterns(),
+		IgnorePatterns: config.IgnorePatterns,
 		CreatedAt:      time.Now(),
 		LastOpenedAt:   time.Now(),
 	}
 
-	noteCount, err := s.countMarkdownFiles(absPath, workspace.IgnorePatterns)
+	ignore := newIgnoreMatcher(absPath, workspace.IgnorePatterns)
+	noteCount, err := s.countMarkdownFiles(absPath, ignore)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count notes: %w", err)
 	}
@@ -110,6 +144,9 @@ func (s *FilesystemService) OpenWorkspace(path string) (*domain.WorkspaceInfo, e
 	}
 
 	s.currentWorkspace = workspace
+	s.config = config
+	s.configPath = configPath
+	s.ignore = ignore
 
 	if err := s.startWatching(absPath); err != nil {
 		return nil, fmt.Errorf("failed to start filesystem watcher: %w", err)
@@ -117,12 +154,8 @@ func (s *FilesystemService) OpenWorkspace(path string) (*domain.WorkspaceInfo, e
 	s.logger.Infof("watching workspace %s for markdown changes", absPath)
 
 	return &domain.WorkspaceInfo{
-		Workspace: *workspace,
-		Config: domain.WorkspaceConfig{
-			DailyNoteFormat: "2006-01-02",
-			DailyNoteFolder: "",
-			DefaultTags:     []string{},
-		},
+		Workspace:   *workspace,
+		Config:      config,
 		NoteCount:   noteCount,
 		TotalBlocks: 0,
 	}, nil
@@ -140,6 +173,133 @@ func (s *FilesystemService) GetCurrentWorkspace() (*domain.Workspace, error) {
 	return s.currentWorkspace, nil
 }
 
+// WorkspaceConfig returns the current workspace's config as last loaded from config.toml.
+func (s *FilesystemService) WorkspaceConfig() (domain.WorkspaceConfig, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.currentWorkspace == nil {
+		return domain.WorkspaceConfig{}, &domain.ErrWorkspaceNotOpen{}
+	}
+	return s.config, nil
+}
+
+// HasWorkspaceConfig reports whether the current workspace has a config.toml.
+func (s *FilesystemService) HasWorkspaceConfig() bool {
+	s.mu.RLock()
+	configPath := s.configPath
+	s.mu.RUnlock()
+
+	if configPath == "" {
+		return false
+	}
+	_, err := os.Stat(configPath)
+	return err == nil
+}
+
+// SaveWorkspaceConfig validates config, writes it to the current workspace's config.toml
+// and applies it. Returns the config as saved.
+func (s *FilesystemService) SaveWorkspaceConfig(config domain.WorkspaceConfig) (domain.WorkspaceConfig, error) {
+	s.mu.RLock()
+	configPath := s.configPath
+	s.mu.RUnlock()
+
+	if configPath == "" {
+		return domain.WorkspaceConfig{}, &domain.ErrWorkspaceNotOpen{}
+	}
+
+	config, err := SaveWorkspaceConfig(configPath, config)
+	if err != nil {
+		return domain.WorkspaceConfig{}, err
+	}
+	s.applyConfig(config)
+	return config, nil
+}
+
+// scheduleConfigReload reloads config.toml once it has not changed for configReloadDelay.
+func (s *FilesystemService) scheduleConfigReload() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.configReload != nil {
+		s.configReload.Stop()
+	}
+	s.configReload = time.AfterFunc(configReloadDelay, s.reloadConfig)
+}
+
+// reloadConfig re-reads config.toml after it changed on disk. An invalid file is reported
+// and the previous config kept, so a half-finished edit does not reset the workspace.
+func (s *FilesystemService) reloadConfig() {
+	s.mu.RLock()
+	configPath, current := s.configPath, s.config
+	s.mu.RUnlock()
+
+	config, err := LoadWorkspaceConfig(configPath)
+	if err != nil {
+		s.logger.Warnf("keeping previous workspace config: %v", err)
+		return
+	}
+	if sameWorkspaceConfigFile(config, current) {
+		return
+	}
+
+	s.logger.Infof("reloaded workspace config from %s", configPath)
+	s.applyConfig(config)
+}
+
+// applyConfig makes config the current workspace config and tells the config hook.
+func (s *FilesystemService) applyConfig(config domain.WorkspaceConfig) {
+	s.mu.RLock()
+	current := s.currentWorkspace
+	s.mu.RUnlock()
+	if current == nil {
+		return
+	}
+	ignore := newIgnoreMatcher(current.RootPath, config.IgnorePatterns)
+
+	s.mu.Lock()
+	if s.currentWorkspace == nil {
+		s.mu.Unlock()
+		return
+	}
+	// Replace rather than modify the workspace, since callers hold on to the pointer.
+	workspace := *s.currentWorkspace
+	workspace.IgnorePatterns = config.IgnorePatterns
+	s.currentWorkspace = &workspace
+	s.config = config
+	s.ignore = ignore
+	hook := s.configHook
+	s.mu.Unlock()
+
+	if hook != nil {
+		hook(config)
+	}
+}
+
+// reloadIgnoreFiles re-reads the workspace's ignore files after one of them changed.
+func (s *FilesystemService) reloadIgnoreFiles() {
+	s.mu.RLock()
+	workspace := s.currentWorkspace
+	s.mu.RUnlock()
+	if workspace == nil {
+		return
+	}
+
+	ignore := newIgnoreMatcher(workspace.RootPath, workspace.IgnorePatterns)
+	s.mu.Lock()
+	s.ignore = ignore
+	s.mu.Unlock()
+	s.logger.Debugf("reloaded ignore files for %s", workspace.RootPath)
+}
+
+// ignoreMatcher returns the matcher for the current workspace's ignored paths.
+func (s *FilesystemService) ignoreMatcher() *ignoreMatcher {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.ignore
+}
+
 // LoadMarkdownFiles scans the workspace and returns all Markdown file paths.
 func (s *FilesystemService) LoadMarkdownFiles() ([]string, error) {
 	workspace, err := s.GetCurrentWorkspace()
@@ -147,13 +307,14 @@ func (s *FilesystemService) LoadMarkdownFiles() ([]string, error) {
 		return nil, err
 	}
 
+	ignore := s.ignoreMatcher()
 	var files []string
 	err = filepath.WalkDir(workspace.RootPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if s.shouldIgnore(path, workspace.IgnorePatterns) {
+		if ignore.Match(path, d.IsDir()) {
 			if d.IsDir() {
 				return filepath.SkipDir
 			}
@@ -178,6 +339,81 @@ func (s *FilesystemService) LoadMarkdownFiles() ([]string, error) {
 	return files, nil
 }
 
+// LoadFolders scans the workspace and returns the paths of all folders that are not ignored,
+// including empty ones. The workspace root is not included.
+func (s *FilesystemService) LoadFolders() ([]string, error) {
+	workspace, err := s.GetCurrentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+
+	ignore := s.ignoreMatcher()
+	folders := []string{}
+	err = filepath.WalkDir(workspace.RootPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || path == workspace.RootPath {
+			return nil
+		}
+		if ignore.Match(path, true) {
+			return filepath.SkipDir
+		}
+
+		relPath, err := filepath.Rel(workspace.RootPath, path)
+		if err != nil {
+			return err
+		}
+		folders = append(folders, relPath)
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load folders: %w", err)
+	}
+
+	return folders, nil
+}
+
+// LoadAttachmentFiles scans the workspace and returns all attachment file paths.
+func (s *FilesystemService) LoadAttachmentFiles() ([]string, error) {
+	workspace, err := s.GetCurrentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+
+	ignore := s.ignoreMatcher()
+	var files []string
+	err = filepath.WalkDir(workspace.RootPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if ignore.Match(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.IsDir() && isAttachmentFile(path) {
+			relPath, err := filepath.Rel(workspace.RootPath, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, relPath)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attachment files: %w", err)
+	}
+
+	return files, nil
+}
+
 // ReadFile reads a file from the workspace.
 func (s *FilesystemService) ReadFile(relativePath string) ([]byte, error) {
 	workspace, err := s.GetCurrentWorkspace()
@@ -220,13 +456,59 @@ func (s *FilesystemService) WriteFile(relativePath string, content []byte) error
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	if err := os.WriteFile(fullPath, content, 0644); err != nil {
+	if err := writeFileAtomic(fullPath, content); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
 	return nil
 }
 
+// writeFileAtomic replaces the file at path with content so that a crash leaves either the old
+// or the new content, never a truncated file: the content is written and synced to a temporary
+// file in the same directory, which is then renamed over the target. An existing file keeps its
+// permissions, and a symlink is followed so the link itself survives.
+func writeFileAtomic(path string, content []byte) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	// The .tmp suffix keeps the temporary file out of the watcher and note scans.
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(content)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Sync the directory so the rename itself survives a crash. Not every platform can open a directory.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
 // DeleteFile removes a file from the workspace.
 func (s *FilesystemService) DeleteFile(relativePath string) error {
 	workspace, err := s.GetCurrentWorkspace()
@@ -250,6 +532,157 @@ func (s *FilesystemService) DeleteFile(relativePath string) error {
 	return nil
 }
 
+// CreateFolder creates a folder in the workspace, with any missing parent folders.
+// Fails if a file or folder already exists at the path.
+func (s *FilesystemService) CreateFolder(relativePath string) error {
+	fullPath, err := s.workspacePath(relativePath)
+	if err != nil {
+		return err
+	}
+	workspace, err := s.GetCurrentWorkspace()
+	if err != nil {
+		return err
+	}
+	if fullPath == filepath.Clean(workspace.RootPath) {
+		return &domain.ErrInvalidPath{Path: relativePath, Reason: "folder path is empty"}
+	}
+
+	if _, err := os.Stat(fullPath); err == nil {
+		return &domain.ErrAlreadyExists{Resource: "folder", ID: relativePath}
+	}
+	if err := os.MkdirAll(fullPath, 0755); err != nil {
+		return fmt.Errorf("failed to create folder: %w", err)
+	}
+
+	return nil
+}
+
+// StatFile returns file information for a file in the workspace.
+func (s *FilesystemService) StatFile(relativePath string) (os.FileInfo, error) {
+	fullPath, err := s.workspacePath(relativePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &domain.ErrNotFound{Resource: "file", ID: relativePath}
+		}
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	return info, nil
+}
+
+// OpenFile opens a file in the workspace for reading. The caller must close it.
+func (s *FilesystemService) OpenFile(relativePath string) (*os.File, error) {
+	fullPath, err := s.workspacePath(relativePath)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &domain.ErrNotFound{Resource: "file", ID: relativePath}
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return file, nil
+}
+
+// CopyFile copies a file from outside the workspace to a path inside it, creating parent directories.
+func (s *FilesystemService) CopyFile(srcPath, relativePath string) error {
+	fullPath, err := s.workspacePath(relativePath)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(srcPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &domain.ErrNotFound{Resource: "file", ID: srcPath}
+		}
+		return fmt.Errorf("failed to open source file: %w", err)
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	dst, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return &domain.ErrAlreadyExists{Resource: "file", ID: relativePath}
+		}
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(fullPath)
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	return nil
+}
+
+// MoveFile renames a file or folder within the workspace, creating the destination's parent directories.
+// Fails if the destination already exists.
+func (s *FilesystemService) MoveFile(oldPath, newPath string) error {
+	oldFull, err := s.workspacePath(oldPath)
+	if err != nil {
+		return err
+	}
+	newFull, err := s.workspacePath(newPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(oldFull); os.IsNotExist(err) {
+		return &domain.ErrNotFound{Resource: "file", ID: oldPath}
+	}
+	if _, err := os.Stat(newFull); err == nil {
+		return &domain.ErrAlreadyExists{Resource: "file", ID: newPath}
+	}
+	if rel, err := filepath.Rel(oldFull, newFull); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return &domain.ErrInvalidPath{Path: newPath, Reason: "cannot move a folder into itself"}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newFull), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := os.Rename(oldFull, newFull); err != nil {
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+
+	return nil
+}
+
+// workspacePath resolves a workspace-relative path to an absolute path, rejecting paths outside the workspace.
+func (s *FilesystemService) workspacePath(relativePath string) (string, error) {
+	workspace, err := s.GetCurrentWorkspace()
+	if err != nil {
+		return "", err
+	}
+
+	fullPath := filepath.Join(workspace.RootPath, relativePath)
+	rel, err := filepath.Rel(workspace.RootPath, fullPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &domain.ErrInvalidPath{Path: relativePath, Reason: "path outside workspace"}
+	}
+
+	return fullPath, nil
+}
+
 // Events returns the channel for filesystem events.
 func (s *FilesystemService) Events() <-chan FileEvent {
 	return s.eventChan
@@ -261,6 +694,9 @@ func (s *FilesystemService) Close() error {
 	defer s.mu.Unlock()
 
 	s.stopWatching()
+	if s.configReload != nil {
+		s.configReload.Stop()
+	}
 
 	if s.watcher != nil {
 		return s.watcher.Close()
@@ -272,7 +708,7 @@ func (s *FilesystemService) Close() error {
 // startWatching begins filesystem watching for the workspace.
 func (s *FilesystemService) startWatching(rootPath string) error {
 	s.logger.Debugf("starting filesystem watcher for %s", rootPath)
-	if err := s.addWatchRecursive(rootPath); err != nil {
+	if err := s.addWatchRecursive(rootPath, s.ignore); err != nil {
 		return err
 	}
 
@@ -288,23 +724,33 @@ func (s *FilesystemService) stopWatching() {
 		close(s.stopChan)
 		s.stopChan = make(chan struct{})
 	}
+
+	s.dirsMu.Lock()
+	s.watchedDirs = nil
+	s.dirsMu.Unlock()
 }
 
-// addWatchRecursive adds watches for directory and all subdirectories.
-func (s *FilesystemService) addWatchRecursive(root string) error {
+// addWatchRecursive adds watches for directory and all subdirectories that ignore does not skip.
+func (s *FilesystemService) addWatchRecursive(root string, ignore *ignoreMatcher) error {
 	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
 		if d.IsDir() {
-			if s.shouldIgnore(path, s.currentWorkspace.IgnorePatterns) {
+			if ignore.Match(path, true) {
 				return filepath.SkipDir
 			}
 
 			if err := s.watcher.Add(path); err != nil {
 				return fmt.Errorf("failed to watch %s: %w", path, err)
 			}
+			s.dirsMu.Lock()
+			if s.watchedDirs == nil {
+				s.watchedDirs = make(map[string]bool)
+			}
+			s.watchedDirs[path] = true
+			s.dirsMu.Unlock()
 		}
 
 		return nil
@@ -323,12 +769,16 @@ func (s *FilesystemService) processEvents() {
 				return
 			}
 
+			s.mu.RLock()
+			workspace, configPath, ignore := s.currentWorkspace, s.configPath, s.ignore
+			s.mu.RUnlock()
+
 			var op FileOperation
 			switch {
 			case event.Op&fsnotify.Create == fsnotify.Create:
 				op = FileOpCreate
-				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
-					s.addWatchRecursive(event.Name)
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() && !ignore.Match(event.Name, true) {
+					s.addWatchRecursive(event.Name, ignore)
 				}
 			case event.Op&fsnotify.Write == fsnotify.Write:
 				op = FileOpModify
@@ -340,14 +790,29 @@ func (s *FilesystemService) processEvents() {
 				continue
 			}
 
-			if isMarkdownFile(event.Name) {
-				relPath, err := filepath.Rel(s.currentWorkspace.RootPath, event.Name)
+			if event.Name == configPath {
+				s.scheduleConfigReload()
+				continue
+			}
+			if isIgnoreFile(event.Name) {
+				s.reloadIgnoreFiles()
+				continue
+			}
+
+			isDir := s.isFolderEvent(event.Name, op)
+			if (isDir || isMarkdownFile(event.Name)) && !ignore.Match(event.Name, isDir) {
+				relPath, err := filepath.Rel(workspace.RootPath, event.Name)
 				if err == nil {
 					s.logger.Debugf("filesystem %s detected for %s", op, relPath)
-					s.eventChan <- FileEvent{
+					// Drop events nobody is reading rather than stall the watcher, which also reloads the config.
+					select {
+					case s.eventChan <- FileEvent{
 						Path:      relPath,
 						Operation: op,
+						IsDir:     isDir,
 						Timestamp: time.Now(),
+					}:
+					default:
 					}
 				}
 			}
@@ -362,30 +827,38 @@ func (s *FilesystemService) processEvents() {
 	}
 }
 
-// shouldIgnore checks if a path matches any ignore patterns.
-func (s *FilesystemService) shouldIgnore(path string, patterns []string) bool {
-	base := filepath.Base(path)
-	for _, pattern := range patterns {
-		if matched, _ := filepath.Match(pattern, base); matched {
-			return true
-		}
+// isFolderEvent reports whether an event is for a folder. A created folder is recognized on disk;
+// a removed or renamed one by having been watched, and it and its subfolders are forgotten.
+func (s *FilesystemService) isFolderEvent(path string, op FileOperation) bool {
+	if op == FileOpCreate || op == FileOpModify {
+		info, err := os.Stat(path)
+		return err == nil && info.IsDir()
+	}
 
-		if strings.HasPrefix(base, pattern) {
-			return true
+	s.dirsMu.Lock()
+	defer s.dirsMu.Unlock()
+
+	if !s.watchedDirs[path] {
+		return false
+	}
+	prefix := path + string(filepath.Separator)
+	for dir := range s.watchedDirs {
+		if dir == path || strings.HasPrefix(dir, prefix) {
+			delete(s.watchedDirs, dir)
 		}
 	}
-	return false
+	return true
 }
 
-// countMarkdownFiles counts Markdown files in a directory.
-func (s *FilesystemService) countMarkdownFiles(root string, ignorePatterns []string) (int, error) {
+// countMarkdownFiles counts the Markdown files in a directory that ignore does not skip.
+func (s *FilesystemService) countMarkdownFiles(root string, ignore *ignoreMatcher) (int, error) {
 	count := 0
 	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if s.shouldIgnore(path, ignorePatterns) {
+		if ignore.Match(path, d.IsDir()) {
 			if d.IsDir() {
 				return filepath.SkipDir
 			}
@@ -408,6 +881,48 @@ func isMarkdownFile(path string) bool {
 	return ext == ".md" || ext == ".markdown"
 }
 
+// attachmentMediaTypes maps the file extensions recognized as attachments to their MIME types.
+var attachmentMediaTypes = map[string]string{
+	".apng": "image/apng",
+	".avif": "image/avif",
+	".bmp":  "image/bmp",
+	".gif":  "image/gif",
+	".jpeg": "image/jpeg",
+	".jpg":  "image/jpeg",
+	".png":  "image/png",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+	".flac": "audio/flac",
+	".m4a":  "audio/mp4",
+	".mp3":  "audio/mpeg",
+	".ogg":  "audio/ogg",
+	".wav":  "audio/wav",
+	".mov":  "video/quicktime",
+	".mp4":  "video/mp4",
+	".ogv":  "video/ogg",
+	".webm": "video/webm",
+	".pdf":  "application/pdf",
+	".csv":  "text/csv",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".zip":  "application/zip",
+}
+
+// isAttachmentFile checks if a file has an extension recognized as an attachment.
+func isAttachmentFile(path string) bool {
+	_, ok := attachmentMediaTypes[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// attachmentMediaType returns the MIME type for an attachment path, or application/octet-stream.
+func attachmentMediaType(path string) string {
+	if mediaType, ok := attachmentMediaTypes[strings.ToLower(filepath.Ext(path))]; ok {
+		return mediaType
+	}
+	return "application/octet-stream"
+}
+
 // generateWorkspaceID creates a unique identifier for a workspace based on its path.
 func generateWorkspaceID(path string) string {
 	hash := md5.Sum([]byte(path))
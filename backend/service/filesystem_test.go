@@ -3,6 +3,8 @@ package service
 import (
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -122,6 +124,85 @@ func TestFilesystemService_LoadMarkdownFiles(t *testing.T) {
 	}
 }
 
+func TestFilesystemService_IgnoreFiles(t *testing.T) {
+	root := t.TempDir()
+	writeTestTree(t, root, map[string]string{
+		".gitignore":            "build/\narchive/2019/\n",
+		".knowledgelabignore":   "*.draft.md\n!keep.draft.md\n",
+		"builder-notes.md":      "",
+		"build/out.md":          "",
+		"archive/2019/old.md":   "",
+		"archive/2020/new.md":   "",
+		"journal/2019/today.md": "",
+		"idea.draft.md":         "",
+		"keep.draft.md":         "",
+	})
+
+	fs, err := NewFilesystemService()
+	if err != nil {
+		t.Fatalf("NewFilesystemService() error = %v", err)
+	}
+	defer fs.Close()
+
+	info, err := fs.OpenWorkspace(root)
+	if err != nil {
+		t.Fatalf("OpenWorkspace() error = %v", err)
+	}
+
+	files, err := fs.LoadMarkdownFiles()
+	if err != nil {
+		t.Fatalf("LoadMarkdownFiles() error = %v", err)
+	}
+	slices.Sort(files)
+	want := []string{"archive/2020/new.md", "builder-notes.md", "journal/2019/today.md", "keep.draft.md"}
+	for i := range want {
+		want[i] = filepath.FromSlash(want[i])
+	}
+	if !slices.Equal(files, want) {
+		t.Errorf("LoadMarkdownFiles() = %v, want %v", files, want)
+	}
+	if info.NoteCount != len(want) {
+		t.Errorf("NoteCount = %d, want %d", info.NoteCount, len(want))
+	}
+
+	// Changing an ignore file takes effect without reopening the workspace.
+	if err := os.WriteFile(filepath.Join(root, ".knowledgelabignore"), []byte("journal/\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		files, _ = fs.LoadMarkdownFiles()
+		if len(files) == 4 && !slices.Contains(files, filepath.FromSlash("journal/2019/today.md")) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("LoadMarkdownFiles() after editing .knowledgelabignore = %v", files)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// The watcher drops events for ignored notes.
+	if err := fs.WriteFile("build/late.md", []byte("late")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := fs.WriteFile("seen.md", []byte("seen")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	for {
+		select {
+		case event := <-fs.Events():
+			if strings.HasPrefix(event.Path, "build") {
+				t.Fatalf("received event for ignored note %s", event.Path)
+			}
+			if event.Path == "seen.md" {
+				return
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("did not receive event for seen.md")
+		}
+	}
+}
+
 func TestFilesystemService_ReadWriteDelete(t *testing.T) {
 	tmpDir := filepath.Join(os.TempDir(), "test-workspace-rwd")
 	os.RemoveAll(tmpDir)
@@ -169,6 +250,50 @@ func TestFilesystemService_ReadWriteDelete(t *testing.T) {
 	}
 }
 
+func TestFilesystemService_WriteFileAtomic(t *testing.T) {
+	root := t.TempDir()
+	writeTestTree(t, root, map[string]string{"private.md": "old", "real/target.md": "old"})
+	if err := os.Chmod(filepath.Join(root, "private.md"), 0600); err != nil {
+		t.Fatalf("Chmod() error = %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "real", "target.md"), filepath.Join(root, "link.md")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	fs, err := NewFilesystemService()
+	if err != nil {
+		t.Fatalf("NewFilesystemService() error = %v", err)
+	}
+	defer fs.Close()
+	if _, err := fs.OpenWorkspace(root); err != nil {
+		t.Fatalf("OpenWorkspace() error = %v", err)
+	}
+
+	for _, path := range []string{"private.md", "link.md", "new/fresh.md"} {
+		if err := fs.WriteFile(path, []byte("new")); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", path, err)
+		}
+		if content, _ := fs.ReadFile(path); string(content) != "new" {
+			t.Errorf("ReadFile(%s) = %q, want new", path, content)
+		}
+	}
+
+	if info, _ := os.Stat(filepath.Join(root, "private.md")); info.Mode().Perm() != 0600 {
+		t.Errorf("private.md mode = %v, want 0600 kept", info.Mode().Perm())
+	}
+	if info, _ := os.Lstat(filepath.Join(root, "link.md")); info.Mode()&os.ModeSymlink == 0 {
+		t.Error("writing through a symlink replaced the link")
+	}
+	if content, _ := os.ReadFile(filepath.Join(root, "real", "target.md")); string(content) != "new" {
+		t.Errorf("symlink target = %q, want new", content)
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(root, "*.tmp"))
+	if len(leftovers) > 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+}
+
 func TestFilesystemService_Events(t *testing.T) {
 	tmpDir := filepath.Join(os.TempDir(), "test-workspace-events")
 	os.RemoveAll(tmpDir)
@@ -208,6 +333,51 @@ func TestFilesystemService_Events(t *testing.T) {
 	}
 }
 
+func TestFilesystemService_FolderEvents(t *testing.T) {
+	fs, err := NewFilesystemService()
+	if err != nil {
+		t.Fatalf("NewFilesystemService() error = %v", err)
+	}
+	defer fs.Close()
+
+	if _, err := fs.OpenWorkspace(t.TempDir()); err != nil {
+		t.Fatalf("OpenWorkspace() error = %v", err)
+	}
+
+	waitFor := func(path string, op FileOperation) {
+		t.Helper()
+		timeout := time.After(2 * time.Second)
+		for {
+			select {
+			case event := <-fs.Events():
+				if event.Path == path && event.Operation == op {
+					if !event.IsDir {
+						t.Errorf("%s event for %s is not marked as a folder", op, path)
+					}
+					return
+				}
+			case <-timeout:
+				t.Fatalf("no %s event for folder %s", op, path)
+			}
+		}
+	}
+
+	if err := fs.CreateFolder("projects"); err != nil {
+		t.Fatalf("CreateFolder() error = %v", err)
+	}
+	waitFor("projects", FileOpCreate)
+
+	if err := fs.CreateFolder("projects"); err == nil {
+		t.Error("CreateFolder() of an existing folder succeeded")
+	}
+
+	time.Sleep(50 * time.Millisecond) // Let the watcher add the new folder
+	if err := fs.MoveFile("projects", "archive"); err != nil {
+		t.Fatalf("MoveFile() error = %v", err)
+	}
+	waitFor("projects", FileOpRename)
+}
+
 func TestFilesystemService_PathTraversal(t *testing.T) {
 	tmpDir := filepath.Join(os.TempDir(), "test-workspace-security")
 	os.RemoveAll(tmpDir)
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"bytes"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"notes/backend/domain"
+)
+
+// FolderMoveResult reports the notes affected by a folder rename or move.
+type FolderMoveResult struct {
+	OldPath string       `json:"oldPath"`
+	NewPath string       `json:"newPath"`
+	Moved   []NoteMove   `json:"moved"`   // Notes inside the folder, with their IDs before and after the move
+	Changes []NoteChange `json:"changes"` // Per-note diffs of the rewritten links, by the notes' new IDs
+	Applied bool         `json:"applied"` // False when the move ran as a dry run
+}
+
+// NoteMove records the IDs of a note before and after its folder moved.
+type NoteMove struct {
+	OldID string `json:"oldId"`
+	NewID string `json:"newId"`
+}
+
+// OldIDs returns the IDs the moved notes had before the move.
+func (r *FolderMoveResult) OldIDs() []string {
+	ids := make([]string, 0, len(r.Moved))
+	for _, move := range r.Moved {
+		ids = append(ids, move.OldID)
+	}
+	return ids
+}
+
+// NoteIDs returns the current IDs of all notes that moved or whose links were rewritten.
+func (r *FolderMoveResult) NoteIDs() []string {
+	ids := make([]string, 0, len(r.Moved)+len(r.Changes))
+	seen := make(map[string]bool)
+	for _, move := range r.Moved {
+		ids = append(ids, move.NewID)
+		seen[move.NewID] = true
+	}
+	for _, change := range r.Changes {
+		if !seen[change.NoteID] {
+			ids = append(ids, change.NoteID)
+		}
+	}
+	return ids
+}
+
+// ListFolderTree returns the workspace's folders as a tree rooted at the workspace itself,
+// with the number of notes in each. Empty folders are included; ignored folders and the trash are not.
+func (s *NoteService) ListFolderTree() (*domain.FolderNode, error) {
+	folders, err := s.fs.LoadFolders()
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.fs.LoadMarkdownFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	type folderCounts struct {
+		notes    int
+		children []string
+	}
+	counts := map[string]*folderCounts{"": {}}
+	var addFolder func(folder string)
+	addFolder = func(folder string) {
+		if _, ok := counts[folder]; ok {
+			return
+		}
+		counts[folder] = &folderCounts{}
+		parent := noteFolder(folder)
+		addFolder(parent)
+		counts[parent].children = append(counts[parent].children, folder)
+	}
+	for _, folder := range folders {
+		addFolder(filepath.ToSlash(folder))
+	}
+	for _, file := range files {
+		folder := noteFolder(file)
+		addFolder(folder)
+		counts[folder].notes++
+	}
+
+	var build func(folder string) domain.FolderNode
+	build = func(folder string) domain.FolderNode {
+		node := domain.FolderNode{
+			Path:      folder,
+			NoteCount: counts[folder].notes,
+			Children:  []domain.FolderNode{},
+		}
+		if folder != "" {
+			node.Name = path.Base(folder)
+		}
+		node.TotalNoteCount = node.NoteCount
+
+		children := counts[folder].children
+		sort.Strings(children)
+		for _, child := range children {
+			childNode := build(child)
+			node.TotalNoteCount += childNode.TotalNoteCount
+			node.Children = append(node.Children, childNode)
+		}
+		return node
+	}
+
+	root := build("")
+	return &root, nil
+}
+
+// CreateFolder creates a folder, with any missing parents, and returns its cleaned path.
+func (s *NoteService) CreateFolder(folder string) (string, error) {
+	folder, err := s.checkFolderPath(folder)
+	if err != nil {
+		return "", err
+	}
+	if err := s.fs.CreateFolder(filepath.FromSlash(folder)); err != nil {
+		return "", err
+	}
+	return folder, nil
+}
+
+// RenameFolder gives a folder a new name in the same parent folder. See MoveFolder.
+func (s *NoteService) RenameFolder(folder, name string, dryRun bool) (*FolderMoveResult, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return nil, &domain.ErrInvalidPath{Path: name, Reason: "folder name must not be empty or contain slashes"}
+	}
+	return s.MoveFolder(folder, path.Join(noteFolder(cleanAttachmentPath(folder)), name), dryRun)
+}
+
+// MoveFolder moves a folder with everything in it to a new path and rewrites the path-based links
+// affected: wikilinks written with a path into the folder, Markdown links into the folder from
+// anywhere, and relative Markdown links out of the folder from the notes it holds.
+// Wikilinks by bare name keep working and are left alone, as are links inside code.
+// The stored timestamps of the moved notes move with them. Re-indexing is left to the caller.
+// With dryRun set, nothing is moved or written and the result only previews the changes.
+func (s *NoteService) MoveFolder(oldPath, newPath string, dryRun bool) (*FolderMoveResult, error) {
+	oldPath, err := s.checkFolderPath(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	newPath, err = s.checkFolderPath(newPath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.fs.StatFile(filepath.FromSlash(oldPath))
+	if err != nil {
+		return nil, &domain.ErrNotFound{Resource: "folder", ID: oldPath}
+	}
+	if !info.IsDir() {
+		return nil, &domain.ErrInvalidPath{Path: oldPath, Reason: "not a folder"}
+	}
+
+	result := &FolderMoveResult{OldPath: oldPath, NewPath: newPath, Moved: []NoteMove{}, Changes: []NoteChange{}}
+	if oldPath == newPath {
+		result.Applied = !dryRun
+		return result, nil
+	}
+	if strings.HasPrefix(newPath, oldPath+"/") {
+		return nil, &domain.ErrInvalidPath{Path: newPath, Reason: "cannot move a folder into itself"}
+	}
+	if _, err := s.fs.StatFile(filepath.FromSlash(newPath)); err == nil {
+		return nil, &domain.ErrAlreadyExists{Resource: "folder", ID: newPath}
+	}
+
+	moved := func(p string) string {
+		if p == oldPath || strings.HasPrefix(p, oldPath+"/") {
+			return newPath + strings.TrimPrefix(p, oldPath)
+		}
+		return p
+	}
+
+	files, err := s.fs.LoadMarkdownFiles()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	type pendingWrite struct {
+		path    string
+		content []byte
+	}
+	writes := []pendingWrite{}
+
+	for _, file := range files {
+		oldID := filepath.ToSlash(file)
+		newID := moved(oldID)
+		if newID != oldID {
+			result.Moved = append(result.Moved, NoteMove{OldID: oldID, NewID: newID})
+		}
+
+		content, err := s.fs.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		updated, changed := s.rewriteMovedLinks(content, oldID, newID, moved)
+		if !changed {
+			continue
+		}
+
+		result.Changes = append(result.Changes, NoteChange{
+			NoteID: newID,
+			Diff:   ChangedLines(DiffLines(string(content), string(updated))),
+		})
+		writes = append(writes, pendingWrite{path: newID, content: updated})
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	if err := s.fs.MoveFile(filepath.FromSlash(oldPath), filepath.FromSlash(newPath)); err != nil {
+		return nil, err
+	}
+	for _, w := range writes {
+		if err := s.fs.WriteFile(filepath.FromSlash(w.path), w.content); err != nil {
+			return nil, err
+		}
+	}
+	for _, move := range result.Moved {
+		if err := s.moveMetadata(move.OldID, move.NewID); err != nil {
+			return nil, err
+		}
+	}
+	if s.attachments != nil {
+		if err := s.attachments.Refresh(); err != nil {
+			return nil, err
+		}
+	}
+	result.Applied = true
+
+	return result, nil
+}
+
+// checkFolderPath cleans a workspace-relative folder path, rejecting the workspace root and the trash.
+func (s *NoteService) checkFolderPath(folder string) (string, error) {
+	cleaned, err := normalizeConfigFolder(folder)
+	if err != nil {
+		return "", &domain.ErrInvalidPath{Path: folder, Reason: err.Error()}
+	}
+	if cleaned == "" {
+		return "", &domain.ErrInvalidPath{Path: folder, Reason: "folder path is empty"}
+	}
+	if cleaned == TrashDirName || strings.HasPrefix(cleaned, TrashDirName+"/") {
+		return "", &domain.ErrInvalidPath{Path: folder, Reason: "folder is in the trash"}
+	}
+	return cleaned, nil
+}
+
+// notesInFolder returns the IDs of the notes in a folder and its subfolders, with forward slashes.
+func (s *NoteService) notesInFolder(folder string) ([]string, error) {
+	files, err := s.fs.LoadMarkdownFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	notes := []string{}
+	for _, file := range files {
+		id := filepath.ToSlash(file)
+		if strings.HasPrefix(id, folder+"/") {
+			notes = append(notes, id)
+		}
+	}
+	sort.Strings(notes)
+	return notes, nil
+}
+
+// moveMetadata moves the stored timestamps of a note to its new ID.
+func (s *NoteService) moveMetadata(oldID, newID string) error {
+	meta := s.storedMetadata(filepath.FromSlash(oldID))
+	if meta == nil {
+		return nil
+	}
+	meta.NoteID = filepath.FromSlash(newID)
+	if err := s.metadata.SaveNoteMetadata(*meta); err != nil {
+		return err
+	}
+	return s.forgetMetadata(filepath.FromSlash(oldID))
+}
+
+// rewriteMovedLinks rewrites the path-based links in a note whose targets moved, or whose relative
+// destinations changed because the note itself moved from oldID to newID. moved maps a workspace
+// path to where it is after the move. Links inside code blocks and code spans are left alone.
+func (s *NoteService) rewriteMovedLinks(content []byte, oldID, newID string, moved func(string) string) ([]byte, bool) {
+	bodyStart := 0
+	if _, _, start, ok := splitFrontmatter(content); ok {
+		bodyStart = start
+	}
+	codeRanges := s.codeRanges(content[bodyStart:])
+	inCode := func(pos int) bool {
+		pos -= bodyStart
+		for _, r := range codeRanges {
+			if pos >= r.start && pos < r.end {
+				return true
+			}
+		}
+		return false
+	}
+
+	type replacement struct {
+		start, end int
+		text       string
+	}
+	replacements := []replacement{}
+
+	// Wikilinks with a path are resolved from the workspace root, so only their targets can move.
+	for _, m := range attachmentWikilinkPattern.FindAllSubmatchIndex(content, -1) {
+		if inCode(m[0]) {
+			continue
+		}
+		target := string(content[m[4]:m[5]])
+		if !strings.Contains(target, "/") {
+			continue
+		}
+		cleaned := cleanAttachmentPath(target)
+		if updated := moved(cleaned); updated != cleaned {
+			replacements = append(replacements, replacement{m[4], m[5], updated})
+		}
+	}
+
+	for _, m := range attachmentMarkdownLinkPattern.FindAllSubmatchIndex(content, -1) {
+		if inCode(m[0]) {
+			continue
+		}
+		dest := string(content[m[6]:m[7]])
+		bracketed := strings.HasPrefix(dest, "<") && strings.HasSuffix(dest, ">")
+		if bracketed {
+			dest = dest[1 : len(dest)-1]
+		}
+		target, ok := resolveMarkdownDestination(dest, oldID)
+		if !ok {
+			continue
+		}
+		newTarget := moved(target)
+		if newTarget == target && (newID == oldID || strings.HasPrefix(dest, "/")) {
+			continue
+		}
+
+		updated := movedMarkdownDestination(dest, newID, newTarget, bracketed)
+		if updated != string(content[m[6]:m[7]]) {
+			replacements = append(replacements, replacement{m[6], m[7], updated})
+		}
+	}
+
+	if len(replacements) == 0 {
+		return content, false
+	}
+
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].start < replacements[j].start
+	})
+
+	var buf bytes.Buffer
+	last := 0
+	for _, r := range replacements {
+		if r.start < last {
+			continue
+		}
+		buf.Write(content[last:r.start])
+		buf.WriteString(r.text)
+		last = r.end
+	}
+	buf.Write(content[last:])
+	return buf.Bytes(), true
+}
+
+// movedMarkdownDestination returns the Markdown link destination from noteID to target, keeping
+// the written destination's form and any "#fragment" or "?query" suffix.
+func movedMarkdownDestination(written, noteID, target string, bracketed bool) string {
+	suffix := ""
+	if i := strings.IndexAny(written, "?#"); i >= 0 {
+		written, suffix = written[:i], written[i:]
+	}
+
+	dest := renamedMarkdownDestination(written, noteID, target, bracketed)
+	if bracketed {
+		return strings.TrimSuffix(dest, ">") + suffix + ">"
+	}
+	return dest + suffix
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"notes/backend/domain"
+)
+
+func TestNoteService_ListFolderTree(t *testing.T) {
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{
+		"index.md":                   "# Index\n",
+		"projects/plan.md":           "# Plan\n",
+		"projects/alpha/spec.md":     "# Spec\n",
+		"projects/alpha/notes.md":    "# Notes\n",
+		"projects/alpha/image.png":   "png",
+		"node_modules/pkg/readme.md": "# Readme\n",
+	})
+	if _, err := notes.CreateFolder("empty/inner"); err != nil {
+		t.Fatalf("CreateFolder() error = %v", err)
+	}
+	if _, err := notes.TrashNote("index.md", nil); err != nil {
+		t.Fatalf("TrashNote() error = %v", err)
+	}
+
+	tree, err := notes.ListFolderTree()
+	if err != nil {
+		t.Fatalf("ListFolderTree() error = %v", err)
+	}
+	if tree.Path != "" || tree.NoteCount != 0 || tree.TotalNoteCount != 3 {
+		t.Errorf("root = %+v, want 3 notes below it", tree)
+	}
+
+	names := []string{}
+	for _, child := range tree.Children {
+		names = append(names, child.Path)
+	}
+	if !slices.Equal(names, []string{"empty", "projects"}) {
+		t.Fatalf("root folders = %v, want ignored folders and the trash left out", names)
+	}
+
+	projects := tree.Children[len(tree.Children)-1]
+	if projects.NoteCount != 1 || projects.TotalNoteCount != 3 || len(projects.Children) != 1 {
+		t.Errorf("projects = %+v", projects)
+	}
+	if alpha := projects.Children[0]; alpha.Name != "alpha" || alpha.Path != "projects/alpha" || alpha.NoteCount != 2 {
+		t.Errorf("projects/alpha = %+v", alpha)
+	}
+	empty := tree.Children[len(tree.Children)-2]
+	if empty.TotalNoteCount != 0 || len(empty.Children) != 1 || empty.Children[0].Path != "empty/inner" {
+		t.Errorf("empty = %+v, want empty folders listed", empty)
+	}
+}
+
+func TestNoteService_CreateFolder(t *testing.T) {
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{"index.md": "# Index\n"})
+
+	created, err := notes.CreateFolder(" projects//alpha/ ")
+	if err != nil {
+		t.Fatalf("CreateFolder() error = %v", err)
+	}
+	if created != "projects/alpha" {
+		t.Errorf("CreateFolder() = %q, want projects/alpha", created)
+	}
+
+	var exists *domain.ErrAlreadyExists
+	if _, err := notes.CreateFolder("projects/alpha"); !errors.As(err, &exists) {
+		t.Errorf("CreateFolder() of an existing folder error = %v, want ErrAlreadyExists", err)
+	}
+	var invalid *domain.ErrInvalidPath
+	for _, folder := range []string{"", "../outside", ".trash/hidden"} {
+		if _, err := notes.CreateFolder(folder); !errors.As(err, &invalid) {
+			t.Errorf("CreateFolder(%q) error = %v, want ErrInvalidPath", folder, err)
+		}
+	}
+}
+
+func TestNoteService_MoveFolder(t *testing.T) {
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{
+		"index.md": "# Index\n\n[Plan](projects/plan.md#goals) and [[projects/plan]] and [[plan]]\n\n" +
+			"`[[projects/plan]]`\n",
+		"projects/plan.md":       "# Plan\n\nBack to [index](../index.md), see [spec](alpha/spec.md) and ![d](diagram.png)\n",
+		"projects/alpha/spec.md": "# Spec\n\nPart of [the plan](/projects/plan.md)\n",
+		"projects/diagram.png":   "png",
+	})
+
+	preview, err := notes.MoveFolder("projects", "archive/2026/projects", true)
+	if err != nil {
+		t.Fatalf("MoveFolder() dry run error = %v", err)
+	}
+	if preview.Applied || len(preview.Moved) != 2 || len(preview.Changes) != 3 {
+		t.Errorf("MoveFolder() dry run = %+v", preview)
+	}
+	if _, err := notes.fs.StatFile("projects/plan.md"); err != nil {
+		t.Error("dry run moved the folder")
+	}
+
+	result, err := notes.MoveFolder("projects", "archive/2026/projects", false)
+	if err != nil {
+		t.Fatalf("MoveFolder() error = %v", err)
+	}
+	if !result.Applied || !slices.Equal(result.OldIDs(), []string{"projects/alpha/spec.md", "projects/plan.md"}) {
+		t.Errorf("MoveFolder() = %+v", result)
+	}
+	if ids := result.NoteIDs(); !slices.Equal(ids, []string{"archive/2026/projects/alpha/spec.md", "archive/2026/projects/plan.md", "index.md"}) {
+		t.Errorf("NoteIDs() = %v", ids)
+	}
+
+	want := map[string]string{
+		"index.md": "# Index\n\n[Plan](archive/2026/projects/plan.md#goals) and [[archive/2026/projects/plan]] and [[plan]]\n\n" +
+			"`[[projects/plan]]`\n",
+		"archive/2026/projects/plan.md":       "# Plan\n\nBack to [index](../../../index.md), see [spec](alpha/spec.md) and ![d](diagram.png)\n",
+		"archive/2026/projects/alpha/spec.md": "# Spec\n\nPart of [the plan](/archive/2026/projects/plan.md)\n",
+	}
+	for id, content := range want {
+		if got, err := notes.fs.ReadFile(id); err != nil || string(got) != content {
+			t.Errorf("%s = %q, %v, want %q", id, got, err, content)
+		}
+	}
+	if _, ok := notes.attachments.Get("archive/2026/projects/diagram.png"); !ok {
+		t.Error("attachment index was not refreshed after the move")
+	}
+
+	var exists *domain.ErrAlreadyExists
+	if _, err := notes.CreateFolder("other"); err != nil {
+		t.Fatalf("CreateFolder() error = %v", err)
+	}
+	if _, err := notes.MoveFolder("other", "archive", false); !errors.As(err, &exists) {
+		t.Errorf("MoveFolder() onto an existing folder error = %v, want ErrAlreadyExists", err)
+	}
+	var invalid *domain.ErrInvalidPath
+	if _, err := notes.MoveFolder("archive", "archive/inner", false); !errors.As(err, &invalid) {
+		t.Errorf("MoveFolder() into itself error = %v, want ErrInvalidPath", err)
+	}
+	var notFound *domain.ErrNotFound
+	if _, err := notes.MoveFolder("missing", "found", false); !errors.As(err, &notFound) {
+		t.Errorf("MoveFolder() of a missing folder error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestNoteService_RenameFolder(t *testing.T) {
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{
+		"work/projects/plan.md": "# Plan\n",
+		"index.md":              "[[work/projects/plan]]\n",
+	})
+
+	result, err := notes.RenameFolder("work/projects", "archive", false)
+	if err != nil {
+		t.Fatalf("RenameFolder() error = %v", err)
+	}
+	if result.NewPath != "work/archive" || len(result.Moved) != 1 || result.Moved[0].NewID != "work/archive/plan.md" {
+		t.Errorf("RenameFolder() = %+v", result)
+	}
+	if content, _ := notes.fs.ReadFile("index.md"); string(content) != "[[work/archive/plan]]\n" {
+		t.Errorf("index.md = %q", content)
+	}
+
+	var invalid *domain.ErrInvalidPath
+	if _, err := notes.RenameFolder("work/archive", "a/b", false); !errors.As(err, &invalid) {
+		t.Errorf("RenameFolder() with a slash error = %v, want ErrInvalidPath", err)
+	}
+}
+
+func TestNoteService_TrashFolder(t *testing.T) {
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{
+		"projects/plan.md":       "# Plan\n",
+		"projects/alpha/spec.md": "# Spec\n",
+		"index.md":               "[[projects/plan]]\n",
+	})
+
+	entry, err := notes.TrashFolder("projects", []string{"index.md"})
+	if err != nil {
+		t.Fatalf("TrashFolder() error = %v", err)
+	}
+	if !entry.Folder || entry.Title != "projects" || !slices.Equal(entry.Notes, []string{"projects/alpha/spec.md", "projects/plan.md"}) {
+		t.Errorf("TrashFolder() = %+v", entry)
+	}
+	if _, err := notes.fs.StatFile("projects"); err == nil {
+		t.Error("trashed folder is still in the workspace")
+	}
+
+	if _, err := notes.CreateFolder("projects"); err != nil {
+		t.Fatalf("CreateFolder() error = %v", err)
+	}
+	restored, err := notes.RestoreNote(entry.ID)
+	if err != nil {
+		t.Fatalf("RestoreNote() error = %v", err)
+	}
+	if restored.Path != "projects (restored)" ||
+		!slices.Equal(restored.NoteIDs, []string{"projects (restored)/alpha/spec.md", "projects (restored)/plan.md"}) {
+		t.Errorf("RestoreNote() = %+v", restored)
+	}
+	if content, _ := notes.fs.ReadFile("projects (restored)/alpha/spec.md"); string(content) != "# Spec\n" {
+		t.Errorf("restored spec.md = %q", content)
+	}
+}
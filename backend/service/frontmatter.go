@@ -0,0 +1,660 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"notes/backend/domain"
+
+	"gopkg.in/yaml.v3"
+)
+
+// frontmatterEdit sets or deletes a single top-level frontmatter key.
+type frontmatterEdit struct {
+	key    string
+	value  any
+	delete bool
+}
+
+// dateOnlyPattern matches YAML dates written without a time component.
+var dateOnlyPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// editFrontmatter applies edits to raw YAML frontmatter (the text between the --- delimiters).
+// Only the lines belonging to edited keys are rewritten; every other byte, including comments,
+// key order, quoting and indentation, is preserved. New keys are appended after the last key.
+func editFrontmatter(raw []byte, edits []frontmatterEdit) ([]byte, error) {
+	if len(edits) == 0 {
+		return raw, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse frontmatter: %w", err)
+	}
+
+	var mapping *yaml.Node
+	if len(doc.Content) > 0 {
+		mapping = doc.Content[0]
+		if mapping.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("frontmatter is not a mapping")
+		}
+	}
+
+	text := string(raw)
+	crlf := strings.Contains(text, "\r\n")
+	trailingNewline := strings.HasSuffix(text, "\n")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if text == "" {
+		lines = []string{}
+	}
+
+	// Locate the line span of each top-level key: from its key line up to the next key,
+	// excluding trailing blank lines and top-level comments (which belong to the next key).
+	type keySpan struct {
+		key, value *yaml.Node
+		start, end int
+	}
+	spans := map[string]keySpan{}
+	lastEnd := len(lines)
+	if mapping != nil {
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			key := mapping.Content[i]
+			start := key.Line - 1
+			end := len(lines)
+			if i+2 < len(mapping.Content) {
+				end = mapping.Content[i+2].Line - 1
+			}
+			for end > start+1 && isFrontmatterGap(lines[end-1]) {
+				end--
+			}
+			spans[key.Value] = keySpan{key: key, value: mapping.Content[i+1], start: start, end: end}
+			lastEnd = end
+		}
+	}
+
+	replacements := map[int][]string{} // start line -> replacement lines
+	removed := map[int]int{}           // start line -> end line of the replaced span
+	appended := []string{}
+
+	for _, edit := range edits {
+		span, exists := spans[edit.key]
+		if edit.delete {
+			if exists {
+				replacements[span.start] = nil
+				removed[span.start] = span.end
+			}
+			continue
+		}
+
+		var keyNode, oldValue *yaml.Node
+		if exists {
+			keyNode, oldValue = span.key, span.value
+		}
+		encoded, err := encodeFrontmatterPair(edit.key, keyNode, oldValue, edit.value)
+		if err != nil {
+			return nil, err
+		}
+		if crlf {
+			for i := range encoded {
+				encoded[i] += "\r"
+			}
+		}
+
+		if exists {
+			replacements[span.start] = encoded
+			removed[span.start] = span.end
+		} else {
+			appended = append(appended, encoded...)
+		}
+	}
+
+	out := make([]string, 0, len(lines)+len(appended))
+	for i := 0; i < len(lines); {
+		if end, ok := removed[i]; ok {
+			out = append(out, replacements[i]...)
+			if end == lastEnd {
+				out = append(out, appended...)
+				appended = nil
+			}
+			i = end
+			continue
+		}
+		if i == lastEnd && appended != nil {
+			out = append(out, appended...)
+			appended = nil
+		}
+		out = append(out, lines[i])
+		i++
+	}
+	if appended != nil {
+		out = append(out, appended...)
+	}
+
+	if len(out) == 0 {
+		return []byte{}, nil
+	}
+
+	result := strings.Join(out, "\n")
+	if trailingNewline || text == "" {
+		result += "\n"
+	}
+	return []byte(result), nil
+}
+
+// isFrontmatterGap reports whether a line is blank or a top-level comment.
+func isFrontmatterGap(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || (strings.HasPrefix(line, "#") && strings.HasPrefix(trimmed, "#"))
+}
+
+// encodeFrontmatterPair encodes a single "key: value" pair as YAML lines.
+// When replacing an existing value, the key's spelling and the old value's style
+// (quoting, flow vs block, sequence indentation, trailing comment, date layout) are kept.
+func encodeFrontmatterPair(name string, oldKey, oldValue *yaml.Node, value any) ([]string, error) {
+	if oldValue != nil {
+		value = coerceFrontmatterValue(oldValue, value)
+	}
+
+	var valueNode yaml.Node
+	if node, ok := value.(*yaml.Node); ok {
+		valueNode = *node
+	} else if err := valueNode.Encode(value); err != nil {
+		return nil, fmt.Errorf("failed to encode frontmatter field %s: %w", name, err)
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+	if oldKey != nil {
+		copied := *oldKey
+		copied.HeadComment, copied.FootComment = "", ""
+		keyNode = &copied
+	}
+
+	if oldValue != nil && oldValue.Kind == valueNode.Kind {
+		switch {
+		case valueNode.Kind == yaml.SequenceNode || valueNode.Kind == yaml.MappingNode:
+			valueNode.Style = oldValue.Style & yaml.FlowStyle
+		case valueNode.Tag == oldValue.ShortTag() && oldValue.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0:
+			valueNode.Style = oldValue.Style & (yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle)
+		}
+		valueNode.LineComment = oldValue.LineComment
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	pair := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{keyNode, &valueNode}}
+	if err := encoder.Encode(pair); err != nil {
+		return nil, fmt.Errorf("failed to encode frontmatter field %s: %w", name, err)
+	}
+	encoder.Close()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+	// Keep unindented block sequences ("tags:\n- a") in the style they were written.
+	if oldValue != nil && oldKey != nil && oldValue.Kind == yaml.SequenceNode && oldValue.Style&yaml.FlowStyle == 0 &&
+		len(oldValue.Content) > 0 && oldValue.Content[0].Column == oldKey.Column+2 {
+		for i := 1; i < len(lines); i++ {
+			lines[i] = strings.TrimPrefix(lines[i], "  ")
+		}
+	}
+
+	return lines, nil
+}
+
+// coerceFrontmatterValue converts value back to the YAML type of the value it replaces.
+// Values that round-trip through JSON arrive as strings and floats; timestamps and integers
+// are restored so the file keeps unquoted dates and whole numbers.
+func coerceFrontmatterValue(old *yaml.Node, value any) any {
+	if t, ok := value.(time.Time); ok && old.ShortTag() == "!!str" {
+		if dateOnlyPattern.MatchString(old.Value) {
+			return t.Format("2006-01-02")
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	switch old.ShortTag() {
+	case "!!timestamp":
+		t, ok := value.(time.Time)
+		if !ok {
+			s, isString := value.(string)
+			if !isString {
+				return value
+			}
+			parsed, err := parseTime(s)
+			if err != nil {
+				return value
+			}
+			t = parsed
+		}
+		if dateOnlyPattern.MatchString(old.Value) {
+			return dateOnlyValue(t)
+		}
+		return t
+	case "!!int":
+		if f, ok := value.(float64); ok && f == math.Trunc(f) {
+			return int64(f)
+		}
+	}
+	return value
+}
+
+// dateOnlyValue is a date that encodes as an unquoted YYYY-MM-DD YAML timestamp.
+type dateOnlyValue time.Time
+
+func (d dateOnlyValue) MarshalYAML() (any, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!timestamp", Value: time.Time(d).Format("2006-01-02")}, nil
+}
+
+// frontmatterValuesEqual compares decoded frontmatter values semantically.
+// Numbers compare by value regardless of Go type, and a time equals a string holding the same instant,
+// so values that round-tripped through JSON still compare equal to what is on disk.
+func frontmatterValuesEqual(a, b any) bool {
+	if af, ok := frontmatterNumber(a); ok {
+		bf, ok := frontmatterNumber(b)
+		return ok && af == bf
+	}
+
+	if at, ok := frontmatterTime(a); ok {
+		if bt, ok := frontmatterTime(b); ok {
+			return at.Equal(bt)
+		}
+	}
+
+	switch av := a.(type) {
+	case nil:
+		return b == nil
+	case string:
+		bv, ok := b.(string)
+		return ok && av == bv
+	case bool:
+		bv, ok := b.(bool)
+		return ok && av == bv
+	case []any, []string:
+		al, bl := frontmatterList(a), frontmatterList(b)
+		if bl == nil || len(al) != len(bl) {
+			return false
+		}
+		for i := range al {
+			if !frontmatterValuesEqual(al[i], bl[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, ok := bv[key]
+			if !ok || !frontmatterValuesEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func frontmatterNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func frontmatterTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		if queryDatePattern.FindString(v) != v {
+			return time.Time{}, false
+		}
+		t, err := parseTime(v)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+func frontmatterList(value any) []any {
+	switch v := value.(type) {
+	case []any:
+		return v
+	case []string:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return items
+	}
+	return nil
+}
+
+// standardFrontmatterKeys are the keys NoteService maps onto dedicated Note fields, in the order
+// they are written when added to a file.
+var standardFrontmatterKeys = []string{"title", "aliases", "type", "tags", "created", "modified"}
+
+// updateNoteContent applies the changes in note to the existing file content.
+// Frontmatter keys are edited in place only when the note's value differs from the one on disk.
+// When something changed, timestamps follow the frontmatter policy: always writes created/modified,
+// preserve-existing refreshes a `modified` key the file already has, and never leaves them alone.
+// Reports false when the note matches the file and nothing needs to be written.
+func (s *NoteService) updateNoteContent(note *domain.Note, existing []byte) ([]byte, bool, error) {
+	generic, body, fields, err := s.extractFrontmatter(existing)
+	if err != nil {
+		return nil, false, err
+	}
+
+	yamlStart, yamlEnd, bodyStart, hasFrontmatter := splitFrontmatter(existing)
+	raw := map[string]any{}
+	if hasFrontmatter {
+		if err := yaml.Unmarshal(existing[yamlStart:yamlEnd], &raw); err != nil {
+			return nil, false, fmt.Errorf("failed to parse frontmatter: %w", err)
+		}
+	}
+
+	newBody := note.Content
+	if note.Properties != nil {
+		newBody = applyPageProperties(newBody, note.Properties)
+	}
+
+	edits := s.frontmatterEdits(note, raw, generic, fields, body, []byte(newBody))
+	if newBody == string(body) && len(edits) == 0 {
+		return existing, false, nil
+	}
+
+	note.ModifiedAt = time.Now()
+	modified := note.ModifiedAt.UTC().Truncate(time.Second)
+	switch s.policy {
+	case domain.FrontmatterAlways:
+		if _, ok := raw["created"]; !ok {
+			created := note.CreatedAt
+			if created.IsZero() {
+				created = note.ModifiedAt
+			}
+			edits = append(edits, frontmatterEdit{key: "created", value: created.UTC().Truncate(time.Second)})
+		}
+		edits = append(edits, frontmatterEdit{key: "modified", value: modified})
+	case domain.FrontmatterPreserveExisting:
+		if _, ok := raw["modified"]; ok && !fields.Modified.IsZero() {
+			edits = append(edits, frontmatterEdit{key: "modified", value: modified})
+		}
+	}
+
+	var buf bytes.Buffer
+	switch {
+	case hasFrontmatter:
+		yamlText, err := editFrontmatter(existing[yamlStart:yamlEnd], edits)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(bytes.TrimSpace(yamlText)) == 0 && len(raw) > 0 {
+			// Every key was removed: drop the delimiters along with the blank line that followed them.
+			newBody = strings.TrimPrefix(strings.TrimPrefix(newBody, "\r\n"), "\n")
+			break
+		}
+		buf.Write(existing[:yamlStart])
+		buf.Write(yamlText)
+		buf.Write(existing[yamlEnd:bodyStart])
+	case len(edits) > 0:
+		yamlText, err := editFrontmatter(nil, edits)
+		if err != nil {
+			return nil, false, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(yamlText)
+		buf.WriteString("---\n")
+		if !strings.HasPrefix(newBody, "\n") && !strings.HasPrefix(newBody, "\r\n") {
+			buf.WriteString("\n")
+		}
+	}
+	buf.WriteString(newBody)
+
+	return buf.Bytes(), true, nil
+}
+
+// frontmatterEdits compares note against the frontmatter it was parsed from and returns
+// the key edits needed to bring the file in line. Nil slices and maps on note mean "unchanged".
+func (s *NoteService) frontmatterEdits(note *domain.Note, raw, generic map[string]any, fields *frontmatterFields, oldBody, newBody []byte) []frontmatterEdit {
+	edits := []frontmatterEdit{}
+	set := func(key string, value any) {
+		edits = append(edits, frontmatterEdit{key: key, value: value})
+	}
+	remove := func(key string) {
+		if _, ok := raw[key]; ok {
+			edits = append(edits, frontmatterEdit{key: key, delete: true})
+		}
+	}
+
+	newProperties := extractPageProperties(string(newBody))
+
+	if note.Title != "" && note.Title != fields.Title {
+		derived := newProperties["title"]
+		if derived == "" {
+			derived = s.extractTitleFromContent(newBody)
+		}
+		if derived == "" {
+			derived = strings.TrimSuffix(filepath.Base(note.Path), filepath.Ext(note.Path))
+		}
+		if fields.Title != "" || note.Title != derived {
+			set("title", note.Title)
+		}
+	}
+
+	if note.Aliases != nil {
+		fromOld := make(map[string]bool)
+		for _, alias := range fields.Aliases {
+			fromOld[alias] = true
+		}
+		fromProperties := make(map[string]bool)
+		for _, alias := range splitPropertyList(newProperties["alias"]) {
+			fromProperties[alias] = true
+		}
+
+		aliases := []string{}
+		for _, alias := range note.Aliases {
+			if fromOld[alias] || !fromProperties[alias] {
+				aliases = append(aliases, alias)
+			}
+		}
+		if !frontmatterValuesEqual(aliases, fields.Aliases) {
+			if len(aliases) == 0 {
+				remove("aliases")
+			} else {
+				set("aliases", aliases)
+			}
+		}
+	}
+
+	if note.Type != fields.Type {
+		if note.Type == "" {
+			remove("type")
+		} else {
+			set("type", note.Type)
+		}
+	}
+
+	if note.Tags != nil || note.FrontmatterTags != nil {
+		if tags, changed := s.frontmatterTags(note, fields, oldBody, newBody); changed {
+			if len(tags) == 0 {
+				remove("tags")
+			} else {
+				set("tags", tags)
+			}
+		}
+	}
+
+	if !fields.Created.IsZero() && !note.CreatedAt.IsZero() &&
+		!note.CreatedAt.Truncate(time.Second).Equal(fields.Created.Truncate(time.Second)) {
+		set("created", note.CreatedAt.Truncate(time.Second))
+	}
+
+	if note.Frontmatter != nil {
+		keys := make([]string, 0, len(note.Frontmatter))
+		for key := range note.Frontmatter {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			old, exists := generic[key]
+			if !exists && slices.Contains(standardFrontmatterKeys, key) {
+				continue
+			}
+			if !exists || !frontmatterValuesEqual(note.Frontmatter[key], old) {
+				set(key, note.Frontmatter[key])
+			}
+		}
+
+		removed := []string{}
+		for key := range generic {
+			if _, ok := note.Frontmatter[key]; !ok {
+				removed = append(removed, key)
+			}
+		}
+		sort.Strings(removed)
+		for _, key := range removed {
+			remove(key)
+		}
+	}
+
+	return edits
+}
+
+// frontmatterTags works out the `tags` frontmatter value for note.
+// The note's tag list merges frontmatter, inline and property tags, so only tags added or removed
+// relative to the parsed file are applied to the frontmatter list; tags the body declares are not duplicated.
+// An edited FrontmatterTags list takes precedence and is written as is.
+func (s *NoteService) frontmatterTags(note *domain.Note, fields *frontmatterFields, oldBody, newBody []byte) ([]string, bool) {
+	if note.FrontmatterTags != nil && !frontmatterValuesEqual(note.FrontmatterTags, fields.Tags) {
+		return note.FrontmatterTags, true
+	}
+	if note.Tags == nil {
+		return nil, false
+	}
+
+	bodyTags := func(body []byte) map[string]bool {
+		tags := make(map[string]bool)
+		for _, name := range s.bodyTags(body) {
+			tags[name] = true
+		}
+		return tags
+	}
+
+	parsed := bodyTags(oldBody)
+	for _, name := range fields.Tags {
+		parsed[name] = true
+	}
+
+	wanted := make(map[string]bool)
+	for _, tag := range note.Tags {
+		wanted[tag.Name] = true
+	}
+
+	inBody := bodyTags(newBody)
+	tags := []string{}
+	for _, name := range fields.Tags {
+		if wanted[name] {
+			tags = append(tags, name)
+		}
+	}
+
+	added := []string{}
+	for name := range wanted {
+		if !parsed[name] && !inBody[name] && !slices.Contains(tags, name) {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	tags = append(tags, added...)
+
+	return tags, !frontmatterValuesEqual(tags, fields.Tags)
+}
+
+// newFrontmatterTags returns the tags to list in the frontmatter of a newly written note.
+// Without explicit FrontmatterTags, tags the body already declares are left out.
+func (s *NoteService) newFrontmatterTags(note *domain.Note) []string {
+	if note.FrontmatterTags != nil {
+		return note.FrontmatterTags
+	}
+
+	inBody := make(map[string]bool)
+	for _, name := range s.bodyTags([]byte(note.Content)) {
+		inBody[name] = true
+	}
+
+	tags := []string{}
+	for _, tag := range note.Tags {
+		if !inBody[tag.Name] {
+			tags = append(tags, tag.Name)
+		}
+	}
+	return tags
+}
+
+// storedMetadata returns the timestamps recorded in the metadata store for a note, or nil.
+func (s *NoteService) storedMetadata(id string) *NoteMetadata {
+	if s.metadata == nil {
+		return nil
+	}
+	workspace, err := s.fs.GetCurrentWorkspace()
+	if err != nil {
+		return nil
+	}
+	meta, err := s.metadata.GetNoteMetadata(workspace.ID, id)
+	if err != nil {
+		return nil
+	}
+	return meta
+}
+
+// recordMetadata stores a note's timestamps so they survive even when the file doesn't carry them.
+func (s *NoteService) recordMetadata(note *domain.Note) error {
+	if s.metadata == nil {
+		return nil
+	}
+	workspace, err := s.fs.GetCurrentWorkspace()
+	if err != nil {
+		return err
+	}
+
+	createdAt := note.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = note.ModifiedAt
+	}
+
+	return s.metadata.SaveNoteMetadata(NoteMetadata{
+		WorkspaceID: workspace.ID,
+		NoteID:      note.ID,
+		CreatedAt:   createdAt,
+		ModifiedAt:  note.ModifiedAt,
+	})
+}
+
+// forgetMetadata removes the stored timestamps of a deleted note.
+func (s *NoteService) forgetMetadata(id string) error {
+	if s.metadata == nil {
+		return nil
+	}
+	workspace, err := s.fs.GetCurrentWorkspace()
+	if err != nil {
+		return err
+	}
+	return s.metadata.DeleteNoteMetadata(workspace.ID, id)
+}
@@ -1,11 +1,14 @@
 package service
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"notes/backend/domain"
 )
 
 func TestFrontmatter_Aliases(t *testing.T) {
@@ -336,3 +339,364 @@ Content`
 		t.Error("project field not preserved in saved file")
 	}
 }
+
+// openFrontmatterFixture copies a fixture from testdata/frontmatter into a fresh workspace.
+func openFrontmatterFixture(t *testing.T, name string) (*FilesystemService, *NoteService, []byte) {
+	t.Helper()
+
+	original, err := os.ReadFile(filepath.Join("testdata", "frontmatter", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	fs, err := NewFilesystemService()
+	if err != nil {
+		t.Fatalf("NewFilesystemService() error = %v", err)
+	}
+	t.Cleanup(func() { fs.Close() })
+
+	if _, err := fs.OpenWorkspace(t.TempDir()); err != nil {
+		t.Fatalf("OpenWorkspace() error = %v", err)
+	}
+	if err := fs.WriteFile(name, original); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	return fs, NewNoteService(fs), original
+}
+
+func TestFrontmatter_FixtureRoundTrip(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "frontmatter", "*.md"))
+	if err != nil {
+		t.Fatalf("Glob() error = %v", err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no frontmatter fixtures found")
+	}
+
+	for _, fixture := range fixtures {
+		name := filepath.Base(fixture)
+		t.Run(name, func(t *testing.T) {
+			fs, noteService, original := openFrontmatterFixture(t, name)
+
+			note, err := noteService.GetNote(name)
+			if err != nil {
+				t.Fatalf("GetNote() error = %v", err)
+			}
+			if err := noteService.SaveNote(note); err != nil {
+				t.Fatalf("SaveNote() error = %v", err)
+			}
+
+			saved, _ := fs.ReadFile(name)
+			if string(saved) != string(original) {
+				t.Errorf("SaveNote() changed unmodified note:\n%s", saved)
+			}
+
+			// Notes edited in the UI arrive through JSON, which turns dates into strings
+			// and integers into floats; that must not count as a change either.
+			encoded, err := json.Marshal(note)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			var decoded domain.Note
+			if err := json.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if err := noteService.SaveNote(&decoded); err != nil {
+				t.Fatalf("SaveNote() error = %v", err)
+			}
+
+			saved, _ = fs.ReadFile(name)
+			if string(saved) != string(original) {
+				t.Errorf("SaveNote() changed note after JSON round trip:\n%s", saved)
+			}
+
+			reloaded, err := noteService.GetNote(name)
+			if err != nil {
+				t.Fatalf("GetNote() after save error = %v", err)
+			}
+			if reloaded.Title != note.Title || reloaded.Content != note.Content {
+				t.Errorf("reloaded note = (%q, %q), want (%q, %q)", reloaded.Title, reloaded.Content, note.Title, note.Content)
+			}
+		})
+	}
+}
+
+func TestFrontmatter_EditsInPlace(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		edit    func(note *domain.Note)
+		want    []DiffLine
+	}{
+		{
+			name:    "generic field keeps comments and order",
+			fixture: "comments.md",
+			edit:    func(note *domain.Note) { note.Frontmatter["status"] = "final" },
+			want: []DiffLine{
+				{Op: DiffDelete, Text: "status: draft"},
+				{Op: DiffInsert, Text: "status: final"},
+			},
+		},
+		{
+			name:    "title keeps trailing comment",
+			fixture: "comments.md",
+			edit:    func(note *domain.Note) { note.Title = "Annual Planning" },
+			want: []DiffLine{
+				{Op: DiffDelete, Text: "title: Quarterly Planning   # shown in the sidebar"},
+				{Op: DiffInsert, Text: "title: Annual Planning # shown in the sidebar"},
+			},
+		},
+		{
+			name:    "flow sequence stays flow",
+			fixture: "flow-style.md",
+			edit: func(note *domain.Note) {
+				note.Tags = append(note.Tags, domain.Tag{Name: "favorites"})
+			},
+			want: []DiffLine{
+				{Op: DiffDelete, Text: "tags: [research, reading/books]"},
+				{Op: DiffInsert, Text: "tags: [research, reading/books, favorites]"},
+			},
+		},
+		{
+			name:    "quoted scalar stays quoted",
+			fixture: "quoted.md",
+			edit:    func(note *domain.Note) { note.Frontmatter["version"] = "2.0" },
+			want: []DiffLine{
+				{Op: DiffDelete, Text: "version: '1.10'"},
+				{Op: DiffInsert, Text: "version: '2.0'"},
+			},
+		},
+		{
+			name:    "unindented sequence and modified timestamp",
+			fixture: "key-order.md",
+			edit: func(note *domain.Note) {
+				note.Tags = []domain.Tag{{Name: "unindented"}}
+			},
+			want: []DiffLine{
+				{Op: DiffDelete, Text: "modified: 2025-01-20T15:30:00Z"},
+				{Op: DiffInsert, Text: "modified: <now>"},
+				{Op: DiffDelete, Text: "- sequence"},
+			},
+		},
+		{
+			name:    "date-only value keeps its layout",
+			fixture: "block-scalars.md",
+			edit: func(note *domain.Note) {
+				note.Frontmatter["published"] = "2024-04-02T00:00:00Z"
+			},
+			want: []DiffLine{
+				{Op: DiffDelete, Text: "published: 2024-03-01"},
+				{Op: DiffInsert, Text: "published: 2024-04-02"},
+			},
+		},
+		{
+			name:    "removed field is deleted",
+			fixture: "block-scalars.md",
+			edit:    func(note *domain.Note) { delete(note.Frontmatter, "folded") },
+			want: []DiffLine{
+				{Op: DiffDelete, Text: "folded: >-"},
+				{Op: DiffDelete, Text: "  Folded text that"},
+				{Op: DiffDelete, Text: "  continues here."},
+			},
+		},
+		{
+			name:    "new field is appended after the last key",
+			fixture: "comments.md",
+			edit:    func(note *domain.Note) { note.Frontmatter["priority"] = "high" },
+			want: []DiffLine{
+				{Op: DiffInsert, Text: "priority: high"},
+			},
+		},
+		{
+			name:    "new field creates frontmatter",
+			fixture: "no-frontmatter.md",
+			edit:    func(note *domain.Note) { note.Frontmatter["status"] = "draft" },
+			want: []DiffLine{
+				{Op: DiffInsert, Text: "---"},
+				{Op: DiffInsert, Text: "status: draft"},
+				{Op: DiffInsert, Text: "---"},
+				{Op: DiffInsert, Text: ""},
+			},
+		},
+		{
+			name:    "CRLF line endings are kept",
+			fixture: "crlf.md",
+			edit:    func(note *domain.Note) { note.Frontmatter["count"] = 4 },
+			want: []DiffLine{
+				{Op: DiffDelete, Text: "count: 3\r"},
+				{Op: DiffInsert, Text: "count: 4\r"},
+			},
+		},
+		{
+			name:    "body edit leaves plain files without frontmatter",
+			fixture: "no-frontmatter.md",
+			edit:    func(note *domain.Note) { note.Content += "- another item\n" },
+			want: []DiffLine{
+				{Op: DiffInsert, Text: "- another item"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs, noteService, original := openFrontmatterFixture(t, tt.fixture)
+
+			note, err := noteService.GetNote(tt.fixture)
+			if err != nil {
+				t.Fatalf("GetNote() error = %v", err)
+			}
+			tt.edit(note)
+			if err := noteService.SaveNote(note); err != nil {
+				t.Fatalf("SaveNote() error = %v", err)
+			}
+
+			saved, _ := fs.ReadFile(tt.fixture)
+			got := ChangedLines(DiffLines(string(original), string(saved)))
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("changed lines = %+v, want %+v\n%s", got, tt.want, saved)
+			}
+			for i := range got {
+				want := tt.want[i]
+				if strings.Contains(want.Text, "<now>") {
+					want.Text = strings.Replace(want.Text, "<now>", note.ModifiedAt.UTC().Truncate(time.Second).Format(time.RFC3339), 1)
+				}
+				if got[i].Op != want.Op || got[i].Text != want.Text {
+					t.Errorf("changed line %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestFrontmatter_NewNoteHasSingleBlankLine(t *testing.T) {
+	fs, err := NewFilesystemService()
+	if err != nil {
+		t.Fatalf("NewFilesystemService() error = %v", err)
+	}
+	defer fs.Close()
+
+	if _, err := fs.OpenWorkspace(t.TempDir()); err != nil {
+		t.Fatalf("OpenWorkspace() error = %v", err)
+	}
+	noteService := NewNoteService(fs)
+	noteService.SetFrontmatterPolicy(domain.FrontmatterAlways)
+
+	note, err := noteService.CreateNote("Fresh", "")
+	if err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		loaded, err := noteService.GetNote(note.Path)
+		if err != nil {
+			t.Fatalf("GetNote() error = %v", err)
+		}
+		loaded.Content += "more\n"
+		if err := noteService.SaveNote(loaded); err != nil {
+			t.Fatalf("SaveNote() error = %v", err)
+		}
+	}
+
+	raw, _ := fs.ReadFile(note.Path)
+	if !strings.Contains(string(raw), "---\n\n# Fresh\n") {
+		t.Errorf("saved note has wrong separator after frontmatter:\n%s", raw)
+	}
+}
+
+func TestFrontmatter_Policy(t *testing.T) {
+	tests := []struct {
+		policy          domain.FrontmatterPolicy
+		fixture         string
+		wantFrontmatter bool // plain file gains frontmatter
+		wantModified    bool // existing modified key is refreshed
+	}{
+		{policy: domain.FrontmatterNever, fixture: "no-frontmatter.md"},
+		{policy: domain.FrontmatterNever, fixture: "key-order.md"},
+		{policy: domain.FrontmatterPreserveExisting, fixture: "no-frontmatter.md"},
+		{policy: domain.FrontmatterPreserveExisting, fixture: "key-order.md", wantModified: true},
+		{policy: domain.FrontmatterAlways, fixture: "no-frontmatter.md", wantFrontmatter: true},
+		{policy: domain.FrontmatterAlways, fixture: "key-order.md", wantModified: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy)+"/"+tt.fixture, func(t *testing.T) {
+			fs, noteService, original := openFrontmatterFixture(t, tt.fixture)
+
+			stores, err := NewStores(filepath.Join(t.TempDir(), "testapp"), "testworkspace", nil)
+			if err != nil {
+				t.Fatalf("NewStores() error = %v", err)
+			}
+			defer stores.Close(nil)
+			noteService.SetMetadataStore(stores.Metadata)
+			noteService.SetFrontmatterPolicy(tt.policy)
+
+			note, err := noteService.GetNote(tt.fixture)
+			if err != nil {
+				t.Fatalf("GetNote() error = %v", err)
+			}
+			note.Content += "Edited.\n"
+			if err := noteService.SaveNote(note); err != nil {
+				t.Fatalf("SaveNote() error = %v", err)
+			}
+
+			raw, _ := fs.ReadFile(tt.fixture)
+			saved := string(raw)
+
+			if !strings.HasPrefix(string(original), "---") {
+				if got := strings.HasPrefix(saved, "---\n"); got != tt.wantFrontmatter {
+					t.Errorf("saved file has frontmatter = %v, want %v:\n%s", got, tt.wantFrontmatter, saved)
+				}
+				if strings.Contains(saved, "tags:") {
+					t.Errorf("inline tags were copied into frontmatter:\n%s", saved)
+				}
+			} else {
+				refreshed := !strings.Contains(saved, "modified: 2025-01-20T15:30:00Z")
+				if refreshed != tt.wantModified {
+					t.Errorf("modified refreshed = %v, want %v:\n%s", refreshed, tt.wantModified, saved)
+				}
+			}
+
+			reloaded, err := noteService.GetNote(tt.fixture)
+			if err != nil {
+				t.Fatalf("GetNote() after save error = %v", err)
+			}
+			if tt.wantModified || !strings.HasPrefix(string(original), "---") {
+				if diff := reloaded.ModifiedAt.Sub(note.ModifiedAt); diff > time.Second || diff < -time.Second {
+					t.Errorf("reloaded ModifiedAt = %v, want %v", reloaded.ModifiedAt, note.ModifiedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestNoteService_SeparatesInlineAndFrontmatterTags(t *testing.T) {
+	fs, noteService, _ := openFrontmatterFixture(t, "comments.md")
+	fs.WriteFile("tagged.md", []byte("---\ntags: [from-yaml]\n---\n\ntags:: from-property\n\nBody #from-body\n"))
+
+	note, err := noteService.GetNote("tagged.md")
+	if err != nil {
+		t.Fatalf("GetNote() error = %v", err)
+	}
+
+	if got := strings.Join(note.FrontmatterTags, ","); got != "from-yaml" {
+		t.Errorf("FrontmatterTags = %v, want [from-yaml]", note.FrontmatterTags)
+	}
+	if got := strings.Join(note.InlineTags, ","); got != "from-body,from-property" {
+		t.Errorf("InlineTags = %v, want [from-body from-property]", note.InlineTags)
+	}
+	if len(note.Tags) != 3 {
+		t.Errorf("Tags length = %d, want 3", len(note.Tags))
+	}
+
+	note.FrontmatterTags = []string{"from-yaml", "added"}
+	if err := noteService.SaveNote(note); err != nil {
+		t.Fatalf("SaveNote() error = %v", err)
+	}
+
+	raw, _ := fs.ReadFile("tagged.md")
+	if !strings.HasPrefix(string(raw), "---\ntags: [from-yaml, added]\n---\n") {
+		t.Errorf("frontmatter tags not saved as edited:\n%s", raw)
+	}
+}
@@ -1,9 +1,12 @@
 package service
 
 import (
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"notes/backend/domain"
 
@@ -22,8 +25,25 @@ type GraphService struct {
 	// backlinks maps target note ID to all notes linking to it
 	backlinks map[string][]domain.Link
 	// tags maps tag name to note IDs containing that tag
-	tags   map[string][]string
+	tags map[string][]string
+	// nodes maps note ID to the metadata captured when the note was indexed
+	nodes  map[string]GraphNode
 	parser goldmark.Markdown
+	// attachments resolves links to images and files; nil treats link targets as written
+	attachments *AttachmentService
+	// version is bumped by every change to the index
+	version int
+
+	// chunks holds the graph GetGraphChunk is paging through
+	chunksMu sync.Mutex
+	chunks   *graphSnapshot
+}
+
+// graphSnapshot is the graph as of one version, with each node's outgoing edges.
+type graphSnapshot struct {
+	version int
+	nodes   []GraphNode
+	edges   map[string][]GraphEdge // Keyed by source node ID
 }
 
 // NewGraphService creates a new graph service.
@@ -38,22 +58,68 @@ func NewGraphService() *GraphService {
 		links:     make(map[string][]domain.Link),
 		backlinks: make(map[string][]domain.Link),
 		tags:      make(map[string][]string),
+		nodes:     make(map[string]GraphNode),
 		parser:    md,
 	}
 }
 
+// Clear removes every indexed note, e.g. before indexing another workspace.
+func (s *GraphService) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+
+	s.links = make(map[string][]domain.Link)
+	s.backlinks = make(map[string][]domain.Link)
+	s.tags = make(map[string][]string)
+	s.nodes = make(map[string]GraphNode)
+}
+
+// SetAttachments makes the graph resolve attachment links against the workspace's files
+// and report attachments as graph nodes.
+func (s *GraphService) SetAttachments(attachments *AttachmentService) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+
+	s.attachments = attachments
+}
+
 // IndexNote parses a note and updates the graph index with its links and tags.
 func (s *GraphService) IndexNote(note *domain.Note) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.version++
+
+	s.indexNote(note, s.extractLinks(note))
+	return nil
+}
+
+// IndexParsedNotes adds notes just loaded by NoteService.GetNote or LoadNote to the graph index,
+// using the links found when they were parsed instead of parsing them again.
+// Notes edited since they were loaded must go through IndexNote.
+func (s *GraphService) IndexParsedNotes(notes []*domain.Note) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
 
+	for _, note := range notes {
+		links := note.Links
+		if links == nil {
+			links = s.extractLinks(note)
+		}
+		s.indexNote(note, links)
+	}
+}
+
+// indexNote replaces a note's links, tags and node in the index. Caller must hold the lock.
+func (s *GraphService) indexNote(note *domain.Note, links []domain.Link) {
 	noteID := note.ID
 
 	delete(s.links, noteID)
 	s.removeNoteFromBacklinks(noteID)
 	s.removeNoteFromTags(noteID)
 
-	links := s.extractLinks(note)
 	tags := s.extractTags(note)
 
 	s.links[noteID] = links
@@ -70,18 +136,20 @@ func (s *GraphService) IndexNote(note *domain.Note) error {
 	note.Links = links
 	note.Tags = tags
 
-	return nil
+	s.nodes[noteID] = newGraphNode(note)
 }
 
 // RemoveNote removes a note from the graph index.
 func (s *GraphService) RemoveNote(noteID string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.version++
 
 	s.removeNoteFromBacklinks(noteID) // Must call before deleting links
 	delete(s.links, noteID)
 	delete(s.backlinks, noteID) // Remove all backlinks TO this note
 	s.removeNoteFromTags(noteID)
+	delete(s.nodes, noteID)
 }
 
 // GetBacklinks returns all notes linking to the specified note.
@@ -109,40 +177,255 @@ func (s *GraphService) GetOutgoingLinks(noteID string) []domain.Link {
 }
 
 // GetGraph returns the complete graph structure.
+// Nodes carry note metadata and parallel links between two notes are collapsed into a single weighted edge.
 func (s *GraphService) GetGraph() *Graph {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	nodes := make([]string, 0, len(s.links))
-	edges := []GraphEdge{}
+	return &Graph{
+		Nodes: s.buildNodes(),
+		Edges: s.buildEdges(),
+	}
+}
+
+// GetGraphChunk returns a slice of the graph starting at cursor with at most size nodes.
+// Each edge is delivered exactly once, in the chunk containing its source node, so a client can
+// stream a large graph by requesting chunks until Done is set. A size <= 0 returns the remainder.
+// The graph is built once when cursor is 0 and sliced by the following calls until it changes;
+// a chunk whose Version differs from the first one's means the client should start over.
+func (s *GraphService) GetGraphChunk(cursor, size int) *GraphChunk {
+	snapshot := s.graphSnapshot(cursor == 0)
+	nodes := snapshot.nodes
+	total := len(nodes)
+
+	cursor = max(cursor, 0)
+	cursor = min(cursor, total)
+
+	end := total
+	if size > 0 {
+		end = min(cursor+size, total)
+	}
+
+	chunkNodes := nodes[cursor:end]
+	chunkEdges := []GraphEdge{}
+	for _, node := range chunkNodes {
+		chunkEdges = append(chunkEdges, snapshot.edges[node.ID]...)
+	}
+
+	return &GraphChunk{
+		Nodes:      chunkNodes,
+		Edges:      chunkEdges,
+		Cursor:     cursor,
+		NextCursor: end,
+		Total:      total,
+		Done:       end >= total,
+		Version:    snapshot.version,
+	}
+}
+
+// graphSnapshot returns the graph GetGraphChunk pages through, building it again when fresh is
+// set or the index changed since it was built.
+func (s *GraphService) graphSnapshot(fresh bool) *graphSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.chunksMu.Lock()
+	defer s.chunksMu.Unlock()
+
+	if !fresh && s.chunks != nil && s.chunks.version == s.version {
+		return s.chunks
+	}
+
+	snapshot := &graphSnapshot{version: s.version, nodes: s.buildNodes(), edges: make(map[string][]GraphEdge)}
+	for _, edge := range s.buildEdges() {
+		snapshot.edges[edge.Source] = append(snapshot.edges[edge.Source], edge)
+	}
+	s.chunks = snapshot
+	return snapshot
+}
 
+// buildNodes collects every indexed note and every link target into a list sorted by ID.
+// Link targets that were never indexed are reported with Exists set to false.
+// Caller must hold the read lock.
+func (s *GraphService) buildNodes() []GraphNode {
 	nodeSet := make(map[string]bool)
+	for id := range s.nodes {
+		nodeSet[id] = true
+	}
 	for source := range s.links {
 		nodeSet[source] = true
 	}
 	for target := range s.backlinks {
 		nodeSet[target] = true
 	}
-	for node := range nodeSet {
-		nodes = append(nodes, node)
+
+	attachments := make(map[string]domain.Attachment)
+	if s.attachments != nil {
+		for _, attachment := range s.attachments.List() {
+			attachments[attachment.Path] = attachment
+			nodeSet[attachment.Path] = true
+		}
 	}
 
+	nodes := make([]GraphNode, 0, len(nodeSet))
+	for id := range nodeSet {
+		if node, ok := s.nodes[id]; ok {
+			nodes = append(nodes, node)
+			continue
+		}
+		if attachment, ok := attachments[id]; ok {
+			nodes = append(nodes, newAttachmentNode(attachment))
+			continue
+		}
+
+		kind := GraphNodeNote
+		title := strings.TrimSuffix(filepath.Base(id), filepath.Ext(id))
+		if isAttachmentFile(id) {
+			kind = GraphNodeAttachment
+			title = filepath.Base(id)
+		}
+		nodes = append(nodes, GraphNode{
+			ID:     id,
+			Title:  title,
+			Path:   id,
+			Folder: noteFolder(id),
+			Tags:   []string{},
+			Kind:   kind,
+			Exists: false,
+		})
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID < nodes[j].ID
+	})
+
+	return nodes
+}
+
+// buildEdges collapses all links between the same source and target into one weighted edge.
+// Caller must hold the read lock.
+func (s *GraphService) buildEdges() []GraphEdge {
+	type edgeKey struct{ source, target string }
+
+	byKey := make(map[edgeKey]*GraphEdge)
 	for source, links := range s.links {
 		for _, link := range links {
-			edges = append(edges, GraphEdge{
-				Source: source,
-				Target: link.Target,
-				Type:   string(link.Type),
-			})
+			key := edgeKey{source: source, target: link.Target}
+			edge, ok := byKey[key]
+			if !ok {
+				edge = &GraphEdge{
+					Source: source,
+					Target: link.Target,
+					Counts: make(map[string]int),
+				}
+				byKey[key] = edge
+			}
+			edge.Weight++
+			edge.Counts[string(link.Type)]++
 		}
 	}
 
-	return &Graph{
-		Nodes: nodes,
-		Edges: edges,
+	edges := make([]GraphEdge, 0, len(byKey))
+	for _, edge := range byKey {
+		edge.Type = dominantLinkType(edge.Counts)
+		edges = append(edges, *edge)
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Source != edges[j].Source {
+			return edges[i].Source < edges[j].Source
+		}
+		return edges[i].Target < edges[j].Target
+	})
+
+	return edges
+}
+
+// dominantLinkType returns the most frequent link type, breaking ties alphabetically.
+func dominantLinkType(counts map[string]int) string {
+	best := ""
+	bestCount := 0
+	for linkType, count := range counts {
+		if count > bestCount || (count == bestCount && linkType < best) {
+			best = linkType
+			bestCount = count
+		}
+	}
+	return best
+}
+
+// newGraphNode captures the metadata of an indexed note for graph payloads.
+func newGraphNode(note *domain.Note) GraphNode {
+	path := note.Path
+	if path == "" {
+		path = note.ID
+	}
+
+	title := note.Title
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	tags := make([]string, 0, len(note.Tags))
+	for _, tag := range note.Tags {
+		tags = append(tags, tag.Name)
+	}
+
+	return GraphNode{
+		ID:         note.ID,
+		Title:      title,
+		Path:       path,
+		Folder:     noteFolder(path),
+		Tags:       tags,
+		Type:       note.Type,
+		CreatedAt:  note.CreatedAt,
+		ModifiedAt: note.ModifiedAt,
+		WordCount:  len(strings.Fields(note.Content)),
+		Kind:       GraphNodeNote,
+		Exists:     true,
 	}
 }
 
+// newAttachmentNode describes an attachment file for graph payloads.
+func newAttachmentNode(attachment domain.Attachment) GraphNode {
+	return GraphNode{
+		ID:         attachment.Path,
+		Title:      attachment.Name,
+		Path:       attachment.Path,
+		Folder:     noteFolder(attachment.Path),
+		Tags:       []string{},
+		Kind:       GraphNodeAttachment,
+		ModifiedAt: attachment.ModifiedAt,
+		Exists:     true,
+	}
+}
+
+// OrphanedAttachments returns the attachments no indexed note links to or embeds.
+func (s *GraphService) OrphanedAttachments() []domain.Attachment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	orphans := []domain.Attachment{}
+	if s.attachments == nil {
+		return orphans
+	}
+
+	for _, attachment := range s.attachments.List() {
+		if len(s.backlinks[attachment.Path]) == 0 {
+			orphans = append(orphans, attachment)
+		}
+	}
+	return orphans
+}
+
+// noteFolder returns the slash-separated folder of a relative note path, or "" for the workspace root.
+func noteFolder(path string) string {
+	dir := filepath.ToSlash(filepath.Dir(path))
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
 // GetNeighbors returns all notes directly connected to the specified note.
 // Includes both incoming (backlinks) and outgoing links.
 func (s *GraphService) GetNeighbors(noteID string) []string {
@@ -179,6 +462,110 @@ func (s *GraphService) GetNotesWithTag(tagName string) []string {
 	return result
 }
 
+// GetNotesWithTagHierarchy returns note IDs tagged with tagName and, when includeDescendants is set,
+// with any tag nested beneath it (e.g., "project" also matches "project/alpha").
+// Results are deduplicated and sorted.
+func (s *GraphService) GetNotesWithTagHierarchy(tagName string, includeDescendants bool) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tagName = normalizeTagName(tagName)
+	noteSet := make(map[string]bool)
+	for tag, noteIDs := range s.tags {
+		if tag != tagName && (!includeDescendants || !isTagOrDescendant(tag, tagName)) {
+			continue
+		}
+		for _, noteID := range noteIDs {
+			noteSet[noteID] = true
+		}
+	}
+
+	result := make([]string, 0, len(noteSet))
+	for noteID := range noteSet {
+		result = append(result, noteID)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// GetTagTree returns the tag hierarchy as a forest of top-level tags.
+// Each node reports notes tagged with exactly that tag and a rolled-up count of distinct notes
+// across its whole subtree. Siblings are sorted by name.
+func (s *GraphService) GetTagTree() []domain.TagNode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type treeNode struct {
+		node     domain.TagNode
+		children []string
+		notes    map[string]bool
+	}
+
+	nodes := make(map[string]*treeNode)
+	var ensure func(fullName string) *treeNode
+	ensure = func(fullName string) *treeNode {
+		if n, ok := nodes[fullName]; ok {
+			return n
+		}
+		n := &treeNode{
+			node: domain.TagNode{
+				Name:     fullName[strings.LastIndex(fullName, "/")+1:],
+				FullName: fullName,
+				NoteIDs:  []string{},
+				Children: []domain.TagNode{},
+			},
+			notes: make(map[string]bool),
+		}
+		nodes[fullName] = n
+		if parent := tagParent(fullName); parent != "" {
+			p := ensure(parent)
+			p.children = append(p.children, fullName)
+		}
+		return n
+	}
+
+	for tagName, noteIDs := range s.tags {
+		n := ensure(tagName)
+		n.node.Count = len(noteIDs)
+		n.node.NoteIDs = append(n.node.NoteIDs, noteIDs...)
+		sort.Strings(n.node.NoteIDs)
+
+		for _, name := range append(tagAncestors(tagName), tagName) {
+			for _, noteID := range noteIDs {
+				nodes[name].notes[noteID] = true
+			}
+		}
+	}
+
+	var build func(fullName string) domain.TagNode
+	build = func(fullName string) domain.TagNode {
+		n := nodes[fullName]
+		sort.Strings(n.children)
+		result := n.node
+		result.TotalCount = len(n.notes)
+		for _, child := range n.children {
+			result.Children = append(result.Children, build(child))
+		}
+		return result
+	}
+
+	roots := []string{}
+	for fullName := range nodes {
+		if tagParent(fullName) == "" {
+			roots = append(roots, fullName)
+		}
+	}
+	sort.Strings(roots)
+
+	tree := make([]domain.TagNode, 0, len(roots))
+	for _, root := range roots {
+		tree = append(tree, build(root))
+	}
+
+	return tree
+}
+
 // GetAllTags returns all unique tags in the workspace.
 func (s *GraphService) GetAllTags() []string {
 	s.mu.RLock()
@@ -192,24 +579,31 @@ func (s *GraphService) GetAllTags() []string {
 	return tags
 }
 
-// GetAllTagsWithCounts returns all tags with their occurrence counts and note IDs.
-// Results are sorted by tag name for consistency.
+// GetAllTagsWithCounts returns the tags on notes with their occurrence counts and note IDs, as a
+// flat list sorted by tag name. It is read off GetTagTree, leaving out tags that only exist as the
+// parents of nested tags.
+//
+// Deprecated: Use GetTagTree, which also reports the tag hierarchy and rolled-up counts.
 func (s *GraphService) GetAllTagsWithCounts() []domain.TagInfo {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	tagInfos := make([]domain.TagInfo, 0, len(s.tags))
-	for tagName, noteIDs := range s.tags {
-		noteIDsCopy := make([]string, len(noteIDs))
-		copy(noteIDsCopy, noteIDs)
-
-		tagInfos = append(tagInfos, domain.TagInfo{
-			Name:    tagName,
-			Count:   len(noteIDs),
-			NoteIDs: noteIDsCopy,
-		})
+	tagInfos := []domain.TagInfo{}
+	var flatten func(nodes []domain.TagNode)
+	flatten = func(nodes []domain.TagNode) {
+		for _, node := range nodes {
+			if node.Count > 0 {
+				tagInfos = append(tagInfos, domain.TagInfo{
+					Name:    node.FullName,
+					Count:   node.Count,
+					NoteIDs: node.NoteIDs,
+				})
+			}
+			flatten(node.Children)
+		}
 	}
+	flatten(s.GetTagTree())
 
+	sort.Slice(tagInfos, func(i, j int) bool {
+		return tagInfos[i].Name < tagInfos[j].Name
+	})
 	return tagInfos
 }
 
@@ -237,9 +631,13 @@ func (s *GraphService) GetTagInfo(tagName string) *domain.TagInfo {
 // extractLinks parses note content to find all links (wikilinks and markdown links).
 func (s *GraphService) extractLinks(note *domain.Note) []domain.Link {
 	content := []byte(note.Content)
-	links := []domain.Link{}
+	return extractLinks(note.ID, s.parser.Parser().Parse(text.NewReader(content)), content, s.attachments)
+}
 
-	doc := s.parser.Parser().Parse(text.NewReader(content))
+// extractLinks finds the wikilinks, Markdown links and attachment embeds of a parsed note body.
+// Wikilinks to attachments are resolved against attachments, or taken as written when it is nil.
+func extractLinks(noteID string, doc ast.Node, content []byte, attachments *AttachmentService) []domain.Link {
+	links := []domain.Link{}
 
 	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
@@ -253,7 +651,11 @@ func (s *GraphService) extractLinks(note *domain.Note) []domain.Link {
 
 			blockRef := ""
 			linkType := domain.LinkTypeWiki
-			if strings.Contains(target, "#") {
+			if len(node.Fragment) > 0 {
+				blockRef = string(node.Fragment)
+				displayText = target + "#" + blockRef
+				linkType = domain.LinkTypeBlock
+			} else if strings.Contains(target, "#") {
 				parts := strings.SplitN(target, "#", 2)
 				target = parts[0]
 				blockRef = parts[1]
@@ -264,25 +666,45 @@ func (s *GraphService) extractLinks(note *domain.Note) []domain.Link {
 				linkType = domain.LinkTypeEmbed
 			}
 
-			if !strings.HasSuffix(target, ".md") && target != "" {
+			if isAttachmentFile(target) {
+				if attachments == nil {
+					target = cleanAttachmentPath(target)
+				} else {
+					target = attachments.ResolveWikilink(target, noteID)
+				}
+			} else if !strings.HasSuffix(target, ".md") && target != "" {
 				target = target + ".md"
 			}
 
 			links = append(links, domain.Link{
-				Source:      note.ID,
+				Source:      noteID,
 				Target:      target,
 				DisplayText: displayText,
 				Type:        linkType,
 				BlockRef:    blockRef,
 			})
 
+		case *ast.Image:
+			target, ok := resolveMarkdownDestination(string(node.Destination), noteID)
+			if ok && isAttachmentFile(target) {
+				links = append(links, domain.Link{
+					Source:      noteID,
+					Target:      target,
+					DisplayText: nodeText(node, content),
+					Type:        domain.LinkTypeEmbed,
+				})
+			}
+
 		case *ast.Link:
 			dest := string(node.Destination)
+			if target, ok := resolveMarkdownDestination(dest, noteID); ok && isAttachmentFile(target) {
+				dest = target
+			}
 			if !strings.HasPrefix(dest, "http://") && !strings.HasPrefix(dest, "https://") {
 				displayText := nodeText(node, content)
 
 				links = append(links, domain.Link{
-					Source:      note.ID,
+					Source:      noteID,
 					Target:      dest,
 					DisplayText: displayText,
 					Type:        domain.LinkTypeMarkdown,
@@ -296,10 +718,30 @@ func (s *GraphService) extractLinks(note *domain.Note) []domain.Link {
 	return links
 }
 
-// extractTags parses note content and frontmatter to find all tags.
+// extractTags collects a note's frontmatter and inline tags.
+// Parsed notes carry them in FrontmatterTags and InlineTags; notes built without them
+// fall back to a tags entry in the generic frontmatter map and a scan of the content.
 func (s *GraphService) extractTags(note *domain.Note) []domain.Tag {
 	tags := []domain.Tag{}
 	tagSet := make(map[string]bool)
+	addTag := func(name string) {
+		tagName := strings.TrimPrefix(name, "#")
+		if tagName != "" && !tagSet[tagName] {
+			tags = append(tags, domain.Tag{Name: tagName, NoteID: note.ID})
+			tagSet[tagName] = true
+		}
+	}
+
+	for _, tag := range note.FrontmatterTags {
+		addTag(tag)
+	}
+
+	if note.InlineTags != nil {
+		for _, tag := range note.InlineTags {
+			addTag(tag)
+		}
+		return tags
+	}
 
 	if fmTags, ok := note.Frontmatter["tags"]; ok {
 		switch t := fmTags.(type) {
@@ -369,9 +811,14 @@ func (s *GraphService) removeNoteFromBacklinks(noteID string) {
 	}
 }
 
-// removeNoteFromTags removes a note from all tag indexes.
+// removeNoteFromTags removes a note from the indexes of the tags it was indexed with.
 func (s *GraphService) removeNoteFromTags(noteID string) {
-	for tagName, notes := range s.tags {
+	node, ok := s.nodes[noteID]
+	if !ok {
+		return
+	}
+	for _, tagName := range node.Tags {
+		notes := s.tags[tagName]
 		filtered := make([]string, 0, len(notes))
 		for _, nid := range notes {
 			if nid != noteID {
@@ -388,13 +835,47 @@ func (s *GraphService) removeNoteFromTags(noteID string) {
 
 // Graph represents the complete note graph structure.
 type Graph struct {
-	Nodes []string    `json:"nodes"`
+	Nodes []GraphNode `json:"nodes"`
 	Edges []GraphEdge `json:"edges"`
 }
 
-// GraphEdge represents a connection between two notes.
+// GraphNode represents a note (or a dangling link target) in the graph with display metadata.
+type GraphNode struct {
+	ID         string    `json:"id"`                          // Note ID (relative path)
+	Title      string    `json:"title"`                       // Note title, or filename for missing notes
+	Path       string    `json:"path"`                        // Relative path within workspace
+	Folder     string    `json:"folder"`                      // Containing folder ("" for workspace root)
+	Tags       []string  `json:"tags"`                        // Tag names on the note
+	Type       string    `json:"type"`                        // Note type from frontmatter
+	CreatedAt  time.Time `json:"createdAt" ts_type:"string"`  // Note creation time
+	ModifiedAt time.Time `json:"modifiedAt" ts_type:"string"` // Last modification time
+	WordCount  int       `json:"wordCount"`                   // Number of words in the note body
+	Kind       string    `json:"kind"`                        // GraphNodeNote or GraphNodeAttachment
+	Exists     bool      `json:"exists"`                      // False when the node is only a link target
+}
+
+// Graph node kinds.
+const (
+	GraphNodeNote       = "note"
+	GraphNodeAttachment = "attachment"
+)
+
+// GraphEdge represents all links from one note to another, collapsed into a weighted edge.
 type GraphEdge struct {
-	Source string `json:"source"`
-	Target string `json:"target"`
-	Type   string `json:"type"`
+	Source string         `json:"source"`
+	Target string         `json:"target"`
+	Type   string         `json:"type"`   // Most frequent link type between source and target
+	Weight int            `json:"weight"` // Total number of links from source to target
+	Counts map[string]int `json:"counts"` // Number of links per link type
+}
+
+// GraphChunk is a slice of the graph used to stream large graphs to the frontend.
+type GraphChunk struct {
+	Nodes      []GraphNode `json:"nodes"`
+	Edges      []GraphEdge `json:"edges"`
+	Cursor     int         `json:"cursor"`     // Index of the first node in this chunk
+	NextCursor int         `json:"nextCursor"` // Cursor to request the following chunk
+	Total      int         `json:"total"`      // Total number of nodes in the graph
+	Done       bool        `json:"done"`       // True when this is the last chunk
+	Version    int         `json:"version"`    // Version of the graph the chunk was sliced from
 }
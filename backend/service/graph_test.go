@@ -545,6 +545,70 @@ func TestGraphService_TagIndexDeduplication(t *testing.T) {
 	}
 }
 
+func TestGraphService_TagIndexParsedNote(t *testing.T) {
+	graph := NewGraphService()
+
+	// Parsed notes carry frontmatter tags separately; the generic frontmatter map has no tags entry.
+	note := &domain.Note{
+		ID:              "note.md",
+		Content:         "Body with #inline and `#not-a-tag`",
+		Frontmatter:     map[string]any{},
+		FrontmatterTags: []string{"from-yaml"},
+		InlineTags:      []string{"inline"},
+		ModifiedAt:      time.Now(),
+	}
+
+	graph.IndexNote(note)
+
+	for _, tag := range []string{"from-yaml", "inline"} {
+		if info := graph.GetTagInfo(tag); info == nil || info.Count != 1 {
+			t.Errorf("GetTagInfo(%q) = %+v, want count 1", tag, info)
+		}
+	}
+	if info := graph.GetTagInfo("not-a-tag"); info != nil && info.Count > 0 {
+		t.Errorf("GetTagInfo(%q) = %+v, want no notes", "not-a-tag", info)
+	}
+	if len(note.Tags) != 2 {
+		t.Errorf("note.Tags = %v, want 2 tags", note.Tags)
+	}
+}
+
+func TestGraphService_IndexParsedNotes(t *testing.T) {
+	_, notes, graph := newTestAttachmentWorkspace(t, map[string]string{
+		"a.md": "# A\n\nSee [[b]] #alpha\n",
+		"b.md": "# B\n\nBack to [A](a.md)\n",
+	})
+
+	a, err := notes.GetNote("a.md")
+	if err != nil {
+		t.Fatalf("GetNote() error = %v", err)
+	}
+	b, err := notes.GetNote("b.md")
+	if err != nil {
+		t.Fatalf("GetNote() error = %v", err)
+	}
+	// Links found when the note was parsed are used as they are.
+	b.Links = append(b.Links, domain.Link{Source: "b.md", Target: "c.md", Type: domain.LinkTypeWiki})
+
+	graph.IndexParsedNotes([]*domain.Note{a, b})
+
+	if backlinks := graph.GetBacklinks("b.md"); len(backlinks) != 1 || backlinks[0].Source != "a.md" {
+		t.Errorf("GetBacklinks(b.md) = %+v", backlinks)
+	}
+	if backlinks := graph.GetBacklinks("c.md"); len(backlinks) != 1 {
+		t.Errorf("GetBacklinks(c.md) = %+v, want the parsed link indexed", backlinks)
+	}
+	if info := graph.GetTagInfo("alpha"); info == nil || info.Count != 1 {
+		t.Errorf("GetTagInfo(alpha) = %+v", info)
+	}
+
+	// Notes without parsed links are parsed by the graph.
+	graph.IndexParsedNotes([]*domain.Note{{ID: "c.md", Content: "Up to [[a]]"}})
+	if backlinks := graph.GetBacklinks("a.md"); len(backlinks) != 2 {
+		t.Errorf("GetBacklinks(a.md) = %+v, want b.md and c.md", backlinks)
+	}
+}
+
 func TestGraphService_TagIndexNestedTags(t *testing.T) {
 	graph := NewGraphService()
 
@@ -731,3 +795,240 @@ func TestGraphService_MixedNestedAndFlatTags(t *testing.T) {
 		t.Errorf("GetAllTagsWithCounts() returned %d tags, want 3", len(tagInfos))
 	}
 }
+
+func TestGraphService_GraphNodeMetadata(t *testing.T) {
+	graph := NewGraphService()
+
+	created := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	modified := time.Date(2025, 1, 20, 15, 30, 0, 0, time.UTC)
+
+	note := &domain.Note{
+		ID:         "projects/alpha.md",
+		Title:      "Alpha",
+		Path:       "projects/alpha.md",
+		Type:       "project",
+		Content:    "Alpha links to [[missing]] #active",
+		CreatedAt:  created,
+		ModifiedAt: modified,
+	}
+	graph.IndexNote(note)
+
+	g := graph.GetGraph()
+	if len(g.Nodes) != 2 {
+		t.Fatalf("GetGraph() nodes = %d, want 2", len(g.Nodes))
+	}
+
+	nodes := make(map[string]GraphNode)
+	for _, node := range g.Nodes {
+		nodes[node.ID] = node
+	}
+
+	alpha, ok := nodes["projects/alpha.md"]
+	if !ok {
+		t.Fatal("GetGraph() missing node for projects/alpha.md")
+	}
+	if alpha.Title != "Alpha" {
+		t.Errorf("Title = %q, want %q", alpha.Title, "Alpha")
+	}
+	if alpha.Folder != "projects" {
+		t.Errorf("Folder = %q, want %q", alpha.Folder, "projects")
+	}
+	if alpha.Type != "project" {
+		t.Errorf("Type = %q, want %q", alpha.Type, "project")
+	}
+	if !alpha.CreatedAt.Equal(created) || !alpha.ModifiedAt.Equal(modified) {
+		t.Errorf("timestamps = %v/%v, want %v/%v", alpha.CreatedAt, alpha.ModifiedAt, created, modified)
+	}
+	if alpha.WordCount != 5 {
+		t.Errorf("WordCount = %d, want 5", alpha.WordCount)
+	}
+	if !slices.Contains(alpha.Tags, "active") {
+		t.Errorf("Tags = %v, want to contain %q", alpha.Tags, "active")
+	}
+	if !alpha.Exists {
+		t.Error("indexed note should exist")
+	}
+
+	missing, ok := nodes["missing.md"]
+	if !ok {
+		t.Fatal("GetGraph() missing node for dangling link target")
+	}
+	if missing.Exists {
+		t.Error("dangling link target should not exist")
+	}
+	if missing.Title != "missing" {
+		t.Errorf("dangling node Title = %q, want %q", missing.Title, "missing")
+	}
+}
+
+func TestGraphService_WeightedEdges(t *testing.T) {
+	graph := NewGraphService()
+
+	graph.IndexNote(&domain.Note{
+		ID:      "a.md",
+		Content: "See [[b]], again [[b]], a block [[b#^x]] and an embed ![[b]]",
+	})
+	graph.IndexNote(&domain.Note{ID: "b.md", Content: "Back to [[a]]"})
+
+	g := graph.GetGraph()
+	if len(g.Edges) != 2 {
+		t.Fatalf("GetGraph() edges = %d, want 2: %+v", len(g.Edges), g.Edges)
+	}
+
+	var ab *GraphEdge
+	for i := range g.Edges {
+		if g.Edges[i].Source == "a.md" && g.Edges[i].Target == "b.md" {
+			ab = &g.Edges[i]
+		}
+	}
+	if ab == nil {
+		t.Fatal("missing collapsed edge a.md -> b.md")
+	}
+
+	if ab.Weight != 4 {
+		t.Errorf("Weight = %d, want 4", ab.Weight)
+	}
+	if ab.Counts["wiki"] != 2 || ab.Counts["block"] != 1 || ab.Counts["embed"] != 1 {
+		t.Errorf("Counts = %v, want wiki:2 block:1 embed:1", ab.Counts)
+	}
+	if ab.Type != "wiki" {
+		t.Errorf("Type = %q, want dominant type %q", ab.Type, "wiki")
+	}
+}
+
+func TestGraphService_GetGraphChunk(t *testing.T) {
+	graph := NewGraphService()
+
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		graph.IndexNote(&domain.Note{
+			ID:      id + ".md",
+			Content: "Links to [[a]] and [[e]]",
+		})
+	}
+
+	full := graph.GetGraph()
+
+	var nodes []GraphNode
+	var edges []GraphEdge
+	cursor := 0
+	chunks := 0
+	for {
+		chunk := graph.GetGraphChunk(cursor, 2)
+		chunks++
+		if chunk.Total != len(full.Nodes) {
+			t.Fatalf("Total = %d, want %d", chunk.Total, len(full.Nodes))
+		}
+		nodes = append(nodes, chunk.Nodes...)
+		edges = append(edges, chunk.Edges...)
+		if chunk.Done {
+			break
+		}
+		if chunk.NextCursor <= cursor {
+			t.Fatalf("NextCursor = %d did not advance from %d", chunk.NextCursor, cursor)
+		}
+		cursor = chunk.NextCursor
+	}
+
+	if chunks != 3 {
+		t.Errorf("streamed %d chunks, want 3", chunks)
+	}
+	if len(nodes) != len(full.Nodes) {
+		t.Errorf("streamed %d nodes, want %d", len(nodes), len(full.Nodes))
+	}
+	if len(edges) != len(full.Edges) {
+		t.Errorf("streamed %d edges, want %d", len(edges), len(full.Edges))
+	}
+
+	rest := graph.GetGraphChunk(0, 0)
+	if !rest.Done || len(rest.Nodes) != len(full.Nodes) {
+		t.Errorf("GetGraphChunk(0, 0) = %d nodes done=%v, want all nodes", len(rest.Nodes), rest.Done)
+	}
+
+	past := graph.GetGraphChunk(100, 2)
+	if !past.Done || len(past.Nodes) != 0 {
+		t.Errorf("GetGraphChunk past end = %d nodes done=%v, want empty done chunk", len(past.Nodes), past.Done)
+	}
+
+	// Chunks of an unchanged graph share a version; a change between two chunks shows in the next one.
+	first := graph.GetGraphChunk(0, 2)
+	if second := graph.GetGraphChunk(first.NextCursor, 2); second.Version != first.Version {
+		t.Errorf("chunk versions = %d, %d, want the same for an unchanged graph", first.Version, second.Version)
+	}
+	graph.RemoveNote("b.md")
+	if third := graph.GetGraphChunk(first.NextCursor+2, 2); third.Version == first.Version {
+		t.Errorf("chunk version after a change = %d, want it to differ from %d", third.Version, first.Version)
+	}
+}
+
+func TestGraphService_TagTree(t *testing.T) {
+	graph := NewGraphService()
+
+	graph.IndexNote(&domain.Note{ID: "a.md", Content: "#project/alpha #project/beta"})
+	graph.IndexNote(&domain.Note{ID: "b.md", Content: "#project/alpha"})
+	graph.IndexNote(&domain.Note{ID: "c.md", Content: "#project #area/home"})
+
+	tree := graph.GetTagTree()
+	if len(tree) != 2 {
+		t.Fatalf("GetTagTree() roots = %d, want 2", len(tree))
+	}
+
+	if tree[0].FullName != "area" || tree[1].FullName != "project" {
+		t.Fatalf("roots = %q, %q, want area, project", tree[0].FullName, tree[1].FullName)
+	}
+
+	area := tree[0]
+	if area.Count != 0 || area.TotalCount != 1 {
+		t.Errorf("area count/total = %d/%d, want 0/1", area.Count, area.TotalCount)
+	}
+
+	project := tree[1]
+	if project.Count != 1 {
+		t.Errorf("project count = %d, want 1", project.Count)
+	}
+	if project.TotalCount != 3 {
+		t.Errorf("project total = %d, want 3", project.TotalCount)
+	}
+	if len(project.Children) != 2 {
+		t.Fatalf("project children = %d, want 2", len(project.Children))
+	}
+
+	alpha := project.Children[0]
+	if alpha.Name != "alpha" || alpha.FullName != "project/alpha" {
+		t.Errorf("child = %q (%q), want alpha (project/alpha)", alpha.Name, alpha.FullName)
+	}
+	if alpha.Count != 2 || alpha.TotalCount != 2 {
+		t.Errorf("alpha count/total = %d/%d, want 2/2", alpha.Count, alpha.TotalCount)
+	}
+}
+
+func TestGraphService_GetNotesWithTagHierarchy(t *testing.T) {
+	graph := NewGraphService()
+
+	graph.IndexNote(&domain.Note{ID: "a.md", Content: "#project/alpha"})
+	graph.IndexNote(&domain.Note{ID: "b.md", Content: "#project"})
+	graph.IndexNote(&domain.Note{ID: "c.md", Content: "#projects"})
+
+	exact := graph.GetNotesWithTagHierarchy("project", false)
+	if !slices.Equal(exact, []string{"b.md"}) {
+		t.Errorf("exact = %v, want [b.md]", exact)
+	}
+
+	withDescendants := graph.GetNotesWithTagHierarchy("#project", true)
+	if !slices.Equal(withDescendants, []string{"a.md", "b.md"}) {
+		t.Errorf("with descendants = %v, want [a.md b.md]", withDescendants)
+	}
+}
+
+func TestGraphService_Clear(t *testing.T) {
+	graph := NewGraphService()
+	graph.IndexNote(&domain.Note{ID: "a.md", Content: "Links to [[b]] #tag", Frontmatter: map[string]any{}, ModifiedAt: time.Now()})
+
+	graph.Clear()
+
+	if g := graph.GetGraph(); len(g.Nodes) != 0 || len(g.Edges) != 0 {
+		t.Errorf("GetGraph() after Clear() = %d nodes, %d edges", len(g.Nodes), len(g.Edges))
+	}
+	if tags := graph.GetAllTags(); len(tags) != 0 {
+		t.Errorf("GetAllTags() after Clear() = %v", tags)
+	}
+}
@@ -97,6 +97,32 @@ func Migrate(db *sql.DB, logger *runtimeLogger) error {
 		migrationsApplied++
 	}
 
+	if version < 3 {
+		if logger != nil {
+			logger.Debugf("Applying migration 3 (note metadata and workspace settings)")
+		}
+		if err := applyMigration3(db); err != nil {
+			if timer != nil {
+				timer.CompleteWithError(err, "")
+			}
+			return fmt.Errorf("failed to apply migration 3: %w", err)
+		}
+		migrationsApplied++
+	}
+
+	if version < 4 {
+		if logger != nil {
+			logger.Debugf("Applying migration 4 (note snapshots)")
+		}
+		if err := applyMigration4(db); err != nil {
+			if timer != nil {
+				timer.CompleteWithError(err, "")
+			}
+			return fmt.Errorf("failed to apply migration 4: %w", err)
+		}
+		migrationsApplied++
+	}
+
 	// Get final version after migrations
 	finalVersion, err := getCurrentVersion(db)
 	if err != nil {
@@ -258,6 +284,89 @@ func applyMigration2(db *sql.DB) error {
 	return tx.Commit()
 }
 
+// applyMigration3 creates the note_metadata and workspace_settings tables.
+// note_metadata holds timestamps for notes whose frontmatter policy keeps them out of the file.
+func applyMigration3(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		CREATE TABLE note_metadata (
+			workspace_id TEXT NOT NULL,
+			note_id TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			modified_at DATETIME NOT NULL,
+			PRIMARY KEY (workspace_id, note_id)
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create note_metadata table: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		CREATE TABLE workspace_settings (
+			workspace_id TEXT NOT NULL,
+			key TEXT NOT NULL,
+			value TEXT NOT NULL,
+			PRIMARY KEY (workspace_id, key)
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create workspace_settings table: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO schema_meta (version, applied_at) VALUES (?, ?)",
+		3,
+		time.Now(),
+	); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// applyMigration4 creates the note_versions table indexing note snapshots.
+// Snapshot content lives in compressed files named by hash, so identical versions share one file.
+func applyMigration4(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		CREATE TABLE note_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			workspace_id TEXT NOT NULL,
+			note_id TEXT NOT NULL,
+			hash TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			created_at DATETIME NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create note_versions table: %w", err)
+	}
+
+	if _, err := tx.Exec("CREATE INDEX idx_note_versions_note ON note_versions(workspace_id, note_id, created_at)"); err != nil {
+		return fmt.Errorf("failed to create note_versions index: %w", err)
+	}
+	if _, err := tx.Exec("CREATE INDEX idx_note_versions_hash ON note_versions(hash)"); err != nil {
+		return fmt.Errorf("failed to create note_versions index: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO schema_meta (version, applied_at) VALUES (?, ?)",
+		4,
+		time.Now(),
+	); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+
+	return tx.Commit()
+}
+
 // Page represents a note/page in the graph database.
 type Page struct {
 	ID         string
@@ -377,9 +486,16 @@ func DeletePage(db *sql.DB, pageID string) error {
 	return nil
 }
 
+// queryer is the part of *sql.DB and *sql.Tx the task functions use, so they can run inside a transaction.
+type queryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 // SaveTask inserts or updates a task in the database.
 // Uses INSERT OR REPLACE to handle both create and update operations.
-func SaveTask(db *sql.DB, task *domain.Task) error {
+func SaveTask(db queryer, task *domain.Task) error {
 	query := `
 		INSERT OR REPLACE INTO tasks (
 			id, note_id, note_path, content, is_completed, created_at, completed_at, line_number
@@ -403,7 +519,7 @@ func SaveTask(db *sql.DB, task *domain.Task) error {
 }
 
 // GetTaskByID retrieves a task by its ID.
-func GetTaskByID(db *sql.DB, id string) (*domain.Task, error) {
+func GetTaskByID(db queryer, id string) (*domain.Task, error) {
 	query := `
 		SELECT id, note_id, note_path, content, is_completed, created_at, completed_at, line_number
 		FROM tasks WHERE id = ?
@@ -438,7 +554,7 @@ func GetTaskByID(db *sql.DB, id string) (*domain.Task, error) {
 }
 
 // GetTasksForNote retrieves all tasks for a specific note.
-func GetTasksForNote(db *sql.DB, noteID string) ([]domain.Task, error) {
+func GetTasksForNote(db queryer, noteID string) ([]domain.Task, error) {
 	query := `
 		SELECT id, note_id, note_path, content, is_completed, created_at, completed_at, line_number
 		FROM tasks WHERE note_id = ? ORDER BY line_number
@@ -447,6 +563,24 @@ func GetTasksForNote(db *sql.DB, noteID string) ([]domain.Task, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tasks for note: %w", err)
 	}
+	return scanTasks(rows)
+}
+
+// GetAllTasks retrieves every stored task.
+func GetAllTasks(db queryer) ([]domain.Task, error) {
+	query := `
+		SELECT id, note_id, note_path, content, is_completed, created_at, completed_at, line_number
+		FROM tasks
+	`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+	return scanTasks(rows)
+}
+
+// scanTasks reads the tasks selected by a query and closes its rows.
+func scanTasks(rows *sql.Rows) ([]domain.Task, error) {
 	defer rows.Close()
 
 	var tasks []domain.Task
@@ -479,7 +613,7 @@ func GetTasksForNote(db *sql.DB, noteID string) ([]domain.Task, error) {
 }
 
 // DeleteTasksForNote removes all tasks associated with a note.
-func DeleteTasksForNote(db *sql.DB, noteID string) error {
+func DeleteTasksForNote(db queryer, noteID string) error {
 	query := `DELETE FROM tasks WHERE note_id = ?`
 	_, err := db.Exec(query, noteID)
 	if err != nil {
@@ -487,3 +621,149 @@ func DeleteTasksForNote(db *sql.DB, noteID string) error {
 	}
 	return nil
 }
+
+// DeleteAllTasks removes every stored task.
+func DeleteAllTasks(db queryer) error {
+	if _, err := db.Exec(`DELETE FROM tasks`); err != nil {
+		return fmt.Errorf("failed to delete tasks: %w", err)
+	}
+	return nil
+}
+
+// NoteMetadata holds note timestamps kept in the database rather than in frontmatter.
+type NoteMetadata struct {
+	WorkspaceID string
+	NoteID      string
+	CreatedAt   time.Time
+	ModifiedAt  time.Time
+}
+
+// SaveNoteMetadata inserts or updates the stored timestamps for a note.
+func SaveNoteMetadata(db *sql.DB, meta NoteMetadata) error {
+	query := `
+		INSERT OR REPLACE INTO note_metadata (workspace_id, note_id, created_at, modified_at)
+		VALUES (?, ?, ?, ?)
+	`
+	_, err := db.Exec(query, meta.WorkspaceID, meta.NoteID, meta.CreatedAt, meta.ModifiedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save note metadata: %w", err)
+	}
+	return nil
+}
+
+// GetNoteMetadata retrieves the stored timestamps for a note, or nil when none are stored.
+func GetNoteMetadata(db *sql.DB, workspaceID, noteID string) (*NoteMetadata, error) {
+	query := `
+		SELECT workspace_id, note_id, created_at, modified_at
+		FROM note_metadata WHERE workspace_id = ? AND note_id = ?
+	`
+	var meta NoteMetadata
+	err := db.QueryRow(query, workspaceID, noteID).Scan(&meta.WorkspaceID, &meta.NoteID, &meta.CreatedAt, &meta.ModifiedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note metadata: %w", err)
+	}
+	return &meta, nil
+}
+
+// DeleteNoteMetadata removes the stored timestamps for a note.
+func DeleteNoteMetadata(db *sql.DB, workspaceID, noteID string) error {
+	query := `DELETE FROM note_metadata WHERE workspace_id = ? AND note_id = ?`
+	_, err := db.Exec(query, workspaceID, noteID)
+	if err != nil {
+		return fmt.Errorf("failed to delete note metadata: %w", err)
+	}
+	return nil
+}
+
+// GetWorkspaceSetting retrieves a workspace setting. Returns false when the setting is not stored.
+func GetWorkspaceSetting(db *sql.DB, workspaceID, key string) (string, bool, error) {
+	query := `SELECT value FROM workspace_settings WHERE workspace_id = ? AND key = ?`
+	var value string
+	err := db.QueryRow(query, workspaceID, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get workspace setting: %w", err)
+	}
+	return value, true, nil
+}
+
+// SetWorkspaceSetting inserts or updates a workspace setting.
+func SetWorkspaceSetting(db *sql.DB, workspaceID, key, value string) error {
+	query := `INSERT OR REPLACE INTO workspace_settings (workspace_id, key, value) VALUES (?, ?, ?)`
+	_, err := db.Exec(query, workspaceID, key, value)
+	if err != nil {
+		return fmt.Errorf("failed to set workspace setting: %w", err)
+	}
+	return nil
+}
+
+// NoteVersionRecord is a stored snapshot of a note's content.
+type NoteVersionRecord struct {
+	ID          int64
+	WorkspaceID string
+	NoteID      string
+	Hash        string // SHA-256 of the content, naming its snapshot file
+	Size        int64  // Uncompressed content size in bytes
+	CreatedAt   time.Time
+}
+
+// SaveNoteVersion inserts a snapshot record and returns its ID.
+func SaveNoteVersion(db *sql.DB, version NoteVersionRecord) (int64, error) {
+	query := `
+		INSERT INTO note_versions (workspace_id, note_id, hash, size, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := db.Exec(query, version.WorkspaceID, version.NoteID, version.Hash, version.Size, version.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save note version: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetNoteVersions retrieves the snapshot records of a note, newest first.
+func GetNoteVersions(db *sql.DB, workspaceID, noteID string) ([]NoteVersionRecord, error) {
+	query := `
+		SELECT id, workspace_id, note_id, hash, size, created_at
+		FROM note_versions WHERE workspace_id = ? AND note_id = ?
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := db.Query(query, workspaceID, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []NoteVersionRecord{}
+	for rows.Next() {
+		var v NoteVersionRecord
+		if err := rows.Scan(&v.ID, &v.WorkspaceID, &v.NoteID, &v.Hash, &v.Size, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan note version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// DeleteNoteVersion removes a snapshot record.
+func DeleteNoteVersion(db *sql.DB, id int64) error {
+	_, err := db.Exec(`DELETE FROM note_versions WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete note version: %w", err)
+	}
+	return nil
+}
+
+// CountNoteVersionsWithHash counts the snapshot records, in any workspace, that share a content hash.
+func CountNoteVersionsWithHash(db *sql.DB, hash string) (int, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM note_versions WHERE hash = ?`, hash).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count note versions: %w", err)
+	}
+	return count, nil
+}
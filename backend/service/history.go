@@ -0,0 +1,565 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"notes/backend/domain"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Author used for automatic commits when the repository has no user configured.
+const (
+	defaultCommitAuthor = "Notes"
+	defaultCommitEmail  = "notes@localhost"
+)
+
+// ErrUnrelatedStagedChanges is returned by automatic commits that were skipped because the
+// repository's index holds staged changes to files other than the pending notes.
+var ErrUnrelatedStagedChanges = errors.New("the git index holds staged changes to other files")
+
+// HistoryService reads and writes the git history of notes in workspaces that are git repositories.
+// The workspace may be the repository's worktree or any folder inside it.
+type HistoryService struct {
+	fs *FilesystemService
+
+	mu       sync.Mutex
+	repo     *git.Repository
+	root     string // Workspace root the repository was opened for
+	prefix   string // Workspace folder relative to the worktree, with a trailing slash ("" at the worktree root)
+	delay    time.Duration
+	pending  map[string]bool // Note IDs changed since the last automatic commit
+	timer    *time.Timer
+	onCommit func(hash string, err error)
+}
+
+// NewHistoryService creates a history service for the workspace open in fs.
+// Automatic commits are off until SetAutoCommit is called.
+func NewHistoryService(fs *FilesystemService) *HistoryService {
+	return &HistoryService{
+		fs:      fs,
+		pending: map[string]bool{},
+	}
+}
+
+// SetCommitHook sets a function called after each automatic commit with the new commit's hash,
+// or the error that prevented it. The hash is empty when there was nothing to commit.
+func (s *HistoryService) SetCommitHook(hook func(hash string, err error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onCommit = hook
+}
+
+// IsRepository reports whether the current workspace is inside a git repository.
+func (s *HistoryService) IsRepository() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.openRepository()
+	return err == nil
+}
+
+// NoteHistory returns the commits that changed a note, newest first, like `git log --follow`.
+// Renames are followed back through the note's earlier paths; merges are walked along their
+// first parent. A limit of 0 returns the whole history. A note that was never committed has
+// an empty history.
+func (s *HistoryService) NoteHistory(noteID string, limit int) ([]domain.NoteVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, err := s.openRepository()
+	if err != nil {
+		return nil, err
+	}
+	return s.noteHistory(repo, noteID, limit)
+}
+
+// NoteVersionContent returns a note's content as of a commit from its history.
+func (s *HistoryService) NoteVersionContent(noteID, hash string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, err := s.openRepository()
+	if err != nil {
+		return "", err
+	}
+	return s.versionContent(repo, noteID, hash)
+}
+
+// DiffNoteVersions returns the line diff of a note between two commits from its history.
+// An empty toHash compares against the note as it is on disk now.
+func (s *HistoryService) DiffNoteVersions(noteID, fromHash, toHash string) ([]DiffLine, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, err := s.openRepository()
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := s.versionContent(repo, noteID, fromHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var after string
+	if toHash == "" {
+		content, err := s.fs.ReadFile(noteID)
+		var notFound *domain.ErrNotFound
+		if err != nil && !errors.As(err, &notFound) {
+			return nil, err
+		}
+		after = string(content)
+	} else {
+		after, err = s.versionContent(repo, noteID, toHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return DiffLines(before, after), nil
+}
+
+// RestoreNoteVersion overwrites a note with its content as of a commit from its history.
+// The restored file is not committed; with automatic commits on, the caller reports it
+// through NoteChanged like any other save.
+func (s *HistoryService) RestoreNoteVersion(noteID, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, err := s.openRepository()
+	if err != nil {
+		return err
+	}
+
+	content, err := s.versionContent(repo, noteID, hash)
+	if err != nil {
+		return err
+	}
+	return s.fs.WriteFile(noteID, []byte(content))
+}
+
+// SetAutoCommit turns automatic commits on with the given debounce delay, or off with 0.
+// Turning them off keeps pending changes until CommitPending is called.
+func (s *HistoryService) SetAutoCommit(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.delay = delay
+	if delay <= 0 && s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+// NoteChanged records a saved, restored or deleted note for the next automatic commit.
+// Each call restarts the debounce delay, so a burst of saves becomes one commit.
+// It does nothing when automatic commits are off or the workspace is not in a repository.
+func (s *HistoryService) NoteChanged(noteID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.delay <= 0 {
+		return
+	}
+	if _, err := s.openRepository(); err != nil {
+		return
+	}
+
+	s.pending[noteID] = true
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = time.AfterFunc(s.delay, s.autoCommit)
+}
+
+// CommitPending commits the notes recorded by NoteChanged with a generated message and returns
+// the commit hash. It returns an empty hash when no recorded note differs from HEAD.
+// Call it before switching workspaces or shutting down so pending changes are not lost.
+func (s *HistoryService) CommitPending() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commitPending()
+}
+
+// autoCommit runs when the debounce delay expires.
+func (s *HistoryService) autoCommit() {
+	s.mu.Lock()
+	s.timer = nil
+	hash, err := s.commitPending()
+	hook := s.onCommit
+	s.mu.Unlock()
+
+	if hook != nil {
+		hook(hash, err)
+	}
+}
+
+// commitPending stages and commits the pending notes. Callers must hold s.mu.
+// Since a commit records the whole index, nothing is committed while other files are
+// staged; the notes stay pending and ErrUnrelatedStagedChanges is returned.
+func (s *HistoryService) commitPending() (string, error) {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if len(s.pending) == 0 {
+		return "", nil
+	}
+
+	ids := make([]string, 0, len(s.pending))
+	for id := range s.pending {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	s.pending = map[string]bool{}
+
+	repo, err := s.openRepository()
+	if err != nil {
+		return "", err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("failed to open worktree: %w", err)
+	}
+
+	var head *object.Tree
+	if commit, err := s.headCommit(repo); err != nil {
+		return "", err
+	} else if commit != nil {
+		if head, err = commit.Tree(); err != nil {
+			return "", fmt.Errorf("failed to read commit tree: %w", err)
+		}
+	}
+
+	if staged, err := s.stagedOutside(worktree, ids); err != nil {
+		return "", err
+	} else if len(staged) > 0 {
+		// Keep the notes pending; they are committed once the index is clear.
+		for _, id := range ids {
+			s.pending[id] = true
+		}
+		return "", fmt.Errorf("skipped automatic commit of %d notes: %w (%s)",
+			len(ids), ErrUnrelatedStagedChanges, strings.Join(staged, ", "))
+	}
+
+	changes := []string{}
+	for _, id := range ids {
+		action, err := s.pendingChange(head, id)
+		if err != nil {
+			return "", err
+		}
+		if action == "" {
+			continue
+		}
+		if _, err := worktree.Add(s.repoPath(id)); err != nil {
+			return "", fmt.Errorf("failed to stage %s: %w", id, err)
+		}
+		changes = append(changes, action+" "+id)
+	}
+	if len(changes) == 0 {
+		return "", nil
+	}
+
+	message := changes[0]
+	if len(changes) > 1 {
+		message = fmt.Sprintf("Update %d notes\n\n- %s", len(changes), strings.Join(changes, "\n- "))
+	}
+
+	hash, err := worktree.Commit(message, &git.CommitOptions{Author: commitSignature(repo)})
+	if errors.Is(err, git.ErrEmptyCommit) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to commit notes: %w", err)
+	}
+	return hash.String(), nil
+}
+
+// stagedOutside returns the repository paths, sorted, that are staged in the index but
+// are not among the given notes.
+func (s *HistoryService) stagedOutside(worktree *git.Worktree, noteIDs []string) ([]string, error) {
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read worktree status: %w", err)
+	}
+
+	notes := make(map[string]bool, len(noteIDs))
+	for _, id := range noteIDs {
+		notes[s.repoPath(id)] = true
+	}
+
+	staged := []string{}
+	for file, fileStatus := range status {
+		if fileStatus.Staging == git.Unmodified || fileStatus.Staging == git.Untracked || notes[file] {
+			continue
+		}
+		staged = append(staged, file)
+	}
+	sort.Strings(staged)
+	return staged, nil
+}
+
+// pendingChange returns how a note differs from the HEAD tree: "Add", "Update" or "Delete",
+// or "" when it is unchanged.
+func (s *HistoryService) pendingChange(head *object.Tree, noteID string) (string, error) {
+	var committed *object.TreeEntry
+	if head != nil {
+		committed, _ = head.FindEntry(s.repoPath(noteID))
+	}
+
+	content, err := s.fs.ReadFile(noteID)
+	var notFound *domain.ErrNotFound
+	switch {
+	case errors.As(err, &notFound):
+		if committed == nil {
+			return "", nil
+		}
+		return "Delete", nil
+	case err != nil:
+		return "", err
+	case committed == nil:
+		return "Add", nil
+	case committed.Hash == plumbing.ComputeHash(plumbing.BlobObject, content):
+		return "", nil
+	default:
+		return "Update", nil
+	}
+}
+
+// noteHistory walks the first-parent chain from HEAD, following the note's path through renames.
+func (s *HistoryService) noteHistory(repo *git.Repository, noteID string, limit int) ([]domain.NoteVersion, error) {
+	versions := []domain.NoteVersion{}
+
+	commit, err := s.headCommit(repo)
+	if err != nil || commit == nil {
+		return versions, err
+	}
+
+	current := s.repoPath(noteID)
+	found := false
+	for commit != nil && (limit <= 0 || len(versions) < limit) {
+		tree, err := commit.Tree()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commit tree: %w", err)
+		}
+
+		var parent *object.Commit
+		var parentTree *object.Tree
+		if commit.NumParents() > 0 {
+			if parent, err = commit.Parent(0); err != nil {
+				return nil, fmt.Errorf("failed to read parent commit: %w", err)
+			}
+			if parentTree, err = parent.Tree(); err != nil {
+				return nil, fmt.Errorf("failed to read commit tree: %w", err)
+			}
+		}
+
+		entry, _ := tree.FindEntry(current)
+		if entry == nil {
+			if found {
+				break
+			}
+			// Not committed at this path yet, e.g. deleted since; keep looking further back.
+			commit = parent
+			continue
+		}
+		found = true
+
+		var previous *object.TreeEntry
+		if parentTree != nil {
+			previous, _ = parentTree.FindEntry(current)
+		}
+		if previous != nil && previous.Hash == entry.Hash {
+			commit = parent
+			continue
+		}
+
+		version := noteVersion(commit, strings.TrimPrefix(current, s.prefix), domain.NoteModified)
+		if previous == nil {
+			version.Change = domain.NoteAdded
+			if parentTree != nil {
+				from, err := renamedFrom(parentTree, tree, current)
+				if err != nil {
+					return nil, err
+				}
+				if from != "" {
+					version.Change = domain.NoteRenamed
+					current = from
+				}
+			}
+		}
+		versions = append(versions, version)
+
+		if version.Change == domain.NoteAdded {
+			break
+		}
+		commit = parent
+	}
+
+	return versions, nil
+}
+
+// versionContent reads a note as of a commit from its history.
+func (s *HistoryService) versionContent(repo *git.Repository, noteID, hash string) (string, error) {
+	versions, err := s.noteHistory(repo, noteID, 0)
+	if err != nil {
+		return "", err
+	}
+
+	for _, version := range versions {
+		if version.Hash != hash && !(len(hash) >= 7 && strings.HasPrefix(version.Hash, hash)) {
+			continue
+		}
+
+		commit, err := repo.CommitObject(plumbing.NewHash(version.Hash))
+		if err != nil {
+			return "", fmt.Errorf("failed to read commit %s: %w", hash, err)
+		}
+		file, err := commit.File(s.prefix + version.Path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s at %s: %w", version.Path, hash, err)
+		}
+		content, err := file.Contents()
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s at %s: %w", version.Path, hash, err)
+		}
+		return content, nil
+	}
+
+	return "", &domain.ErrNotFound{Resource: "version", ID: noteID + "@" + hash}
+}
+
+// headCommit returns the commit HEAD points at, or nil in a repository without commits.
+func (s *HistoryService) headCommit(repo *git.Repository) (*object.Commit, error) {
+	ref, err := repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+	return commit, nil
+}
+
+// openRepository returns the repository containing the current workspace, opening it on first
+// use and again whenever the workspace changes. Callers must hold s.mu.
+func (s *HistoryService) openRepository() (*git.Repository, error) {
+	workspace, err := s.fs.GetCurrentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	if s.repo != nil && s.root == workspace.RootPath {
+		return s.repo, nil
+	}
+
+	s.repo, s.root, s.prefix = nil, workspace.RootPath, ""
+	repo, err := git.PlainOpenWithOptions(workspace.RootPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, &domain.ErrNoRepository{Path: workspace.RootPath}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, &domain.ErrNoRepository{Path: workspace.RootPath}
+	}
+	prefix, err := worktreePrefix(worktree.Filesystem.Root(), workspace.RootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	s.repo, s.prefix = repo, prefix
+	return repo, nil
+}
+
+// repoPath converts a note ID to its path in the repository.
+func (s *HistoryService) repoPath(noteID string) string {
+	return s.prefix + path.Clean(filepath.ToSlash(noteID))
+}
+
+// worktreePrefix returns the workspace folder relative to the repository worktree, as a
+// slash-separated prefix ending in "/", or "" when the workspace is the worktree itself.
+func worktreePrefix(worktree, workspace string) (string, error) {
+	if resolved, err := filepath.EvalSymlinks(worktree); err == nil {
+		worktree = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(workspace); err == nil {
+		workspace = resolved
+	}
+
+	rel, err := filepath.Rel(worktree, workspace)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &domain.ErrNoRepository{Path: workspace}
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(rel) + "/", nil
+}
+
+// renamedFrom returns the path a file was renamed from between two trees, or "" when it was added.
+func renamedFrom(before, after *object.Tree, name string) (string, error) {
+	changes, err := object.DiffTreeWithOptions(context.Background(), before, after, &object.DiffTreeOptions{DetectRenames: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to diff commits: %w", err)
+	}
+
+	for _, change := range changes {
+		if change.To.Name == name && change.From.Name != "" && change.From.Name != name {
+			return change.From.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// noteVersion describes a commit that changed a note.
+func noteVersion(commit *object.Commit, notePath, change string) domain.NoteVersion {
+	message, _, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+	return domain.NoteVersion{
+		Hash:    commit.Hash.String(),
+		Message: message,
+		Author:  commit.Author.Name,
+		Email:   commit.Author.Email,
+		Time:    commit.Author.When,
+		Path:    notePath,
+		Change:  change,
+	}
+}
+
+// commitSignature returns the author for automatic commits, taken from the repository's
+// git configuration (including global and system config) when a user is set there.
+func commitSignature(repo *git.Repository) *object.Signature {
+	signature := &object.Signature{Name: defaultCommitAuthor, Email: defaultCommitEmail, When: time.Now()}
+
+	cfg, err := repo.ConfigScoped(config.SystemScope)
+	if err != nil {
+		return signature
+	}
+	if name := cmp.Or(cfg.Author.Name, cfg.User.Name); name != "" {
+		signature.Name = name
+	}
+	if email := cmp.Or(cfg.Author.Email, cfg.User.Email); email != "" {
+		signature.Email = email
+	}
+	return signature
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"notes/backend/domain"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// newTestHistoryRepo initializes a git repository in a temporary directory and returns it with its path.
+func newTestHistoryRepo(t *testing.T) (*git.Repository, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	repo, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatalf("PlainInit() error = %v", err)
+	}
+	return repo, root
+}
+
+// commitTestFiles writes files (or deletes those mapped to "") under root, stages them and commits.
+func commitTestFiles(t *testing.T, repo *git.Repository, root, message string, files map[string]string) string {
+	t.Helper()
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Worktree() error = %v", err)
+	}
+	for rel, content := range files {
+		if content == "" {
+			if err := os.Remove(filepath.Join(root, filepath.FromSlash(rel))); err != nil {
+				t.Fatalf("Remove(%s) error = %v", rel, err)
+			}
+		} else {
+			writeTestTree(t, root, map[string]string{rel: content})
+		}
+		if _, err := worktree.Add(rel); err != nil {
+			t.Fatalf("Add(%s) error = %v", rel, err)
+		}
+	}
+
+	hash, err := worktree.Commit(message, &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("Commit(%q) error = %v", message, err)
+	}
+	return hash.String()
+}
+
+// newTestHistoryService opens workspace as the current workspace and returns a history service for it.
+func newTestHistoryService(t *testing.T, workspace string) (*HistoryService, *FilesystemService) {
+	t.Helper()
+
+	fs, err := NewFilesystemService()
+	if err != nil {
+		t.Fatalf("NewFilesystemService() error = %v", err)
+	}
+	t.Cleanup(func() { fs.Close() })
+
+	if _, err := fs.OpenWorkspace(workspace); err != nil {
+		t.Fatalf("OpenWorkspace() error = %v", err)
+	}
+	return NewHistoryService(fs), fs
+}
+
+func TestHistoryService_NoteHistory(t *testing.T) {
+	repo, root := newTestHistoryRepo(t)
+	added := commitTestFiles(t, repo, root, "Add draft", map[string]string{"draft.md": "# Draft\n\nFirst line\n"})
+	edited := commitTestFiles(t, repo, root, "Edit draft\n\nLonger description", map[string]string{"draft.md": "# Draft\n\nFirst line\nSecond line\n"})
+	commitTestFiles(t, repo, root, "Unrelated", map[string]string{"other.md": "# Other\n"})
+	renamed := commitTestFiles(t, repo, root, "Move draft", map[string]string{
+		"draft.md":        "",
+		"essays/final.md": "# Draft\n\nFirst line\nSecond line\n",
+	})
+	latest := commitTestFiles(t, repo, root, "Polish", map[string]string{"essays/final.md": "# Final\n\nFirst line\nSecond line\n"})
+
+	history, _ := newTestHistoryService(t, root)
+	if !history.IsRepository() {
+		t.Fatal("IsRepository() = false, want true")
+	}
+
+	versions, err := history.NoteHistory("essays/final.md", 0)
+	if err != nil {
+		t.Fatalf("NoteHistory() error = %v", err)
+	}
+
+	want := []domain.NoteVersion{
+		{Hash: latest, Message: "Polish", Path: "essays/final.md", Change: domain.NoteModified},
+		{Hash: renamed, Message: "Move draft", Path: "essays/final.md", Change: domain.NoteRenamed},
+		{Hash: edited, Message: "Edit draft", Path: "draft.md", Change: domain.NoteModified},
+		{Hash: added, Message: "Add draft", Path: "draft.md", Change: domain.NoteAdded},
+	}
+	if len(versions) != len(want) {
+		t.Fatalf("NoteHistory() returned %d versions, want %d: %+v", len(versions), len(want), versions)
+	}
+	for i, w := range want {
+		got := versions[i]
+		if got.Hash != w.Hash || got.Message != w.Message || got.Path != w.Path || got.Change != w.Change {
+			t.Errorf("version %d = %+v, want %+v", i, got, w)
+		}
+		if got.Author != "Test" || got.Email != "test@example.com" || got.Time.IsZero() {
+			t.Errorf("version %d author = %q <%s> at %v", i, got.Author, got.Email, got.Time)
+		}
+	}
+
+	limited, err := history.NoteHistory("essays/final.md", 2)
+	if err != nil || len(limited) != 2 {
+		t.Errorf("NoteHistory(limit 2) = %d versions, %v; want 2", len(limited), err)
+	}
+
+	uncommitted, err := history.NoteHistory("new.md", 0)
+	if err != nil || len(uncommitted) != 0 {
+		t.Errorf("NoteHistory(uncommitted) = %+v, %v; want empty", uncommitted, err)
+	}
+}
+
+func TestHistoryService_ContentDiffAndRestore(t *testing.T) {
+	repo, root := newTestHistoryRepo(t)
+	first := commitTestFiles(t, repo, root, "First", map[string]string{"note.md": "one\ntwo\n"})
+	second := commitTestFiles(t, repo, root, "Second", map[string]string{"note.md": "one\n2\nthree\n"})
+
+	history, fs := newTestHistoryService(t, root)
+	if err := fs.WriteFile("note.md", []byte("one\n2\nthree\nfour\n")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	content, err := history.NoteVersionContent("note.md", first)
+	if err != nil || content != "one\ntwo\n" {
+		t.Errorf("NoteVersionContent(first) = %q, %v", content, err)
+	}
+	if content, err := history.NoteVersionContent("note.md", second[:7]); err != nil || content != "one\n2\nthree\n" {
+		t.Errorf("NoteVersionContent(short hash) = %q, %v", content, err)
+	}
+
+	var notFound *domain.ErrNotFound
+	if _, err := history.NoteVersionContent("note.md", strings.Repeat("0", 40)); !errors.As(err, &notFound) {
+		t.Errorf("NoteVersionContent(unknown) error = %v, want ErrNotFound", err)
+	}
+
+	diff, err := history.DiffNoteVersions("note.md", first, second)
+	if err != nil {
+		t.Fatalf("DiffNoteVersions() error = %v", err)
+	}
+	changed := ChangedLines(diff)
+	if len(changed) != 3 || changed[0].Op != DiffDelete || changed[0].Text != "two" || changed[1].Text != "2" || changed[2].Text != "three" {
+		t.Errorf("DiffNoteVersions(first, second) changes = %+v", changed)
+	}
+
+	diff, err = history.DiffNoteVersions("note.md", second, "")
+	if err != nil {
+		t.Fatalf("DiffNoteVersions(working copy) error = %v", err)
+	}
+	if changed := ChangedLines(diff); len(changed) != 1 || changed[0].Op != DiffInsert || changed[0].Text != "four" {
+		t.Errorf("DiffNoteVersions(second, working copy) changes = %+v", changed)
+	}
+
+	if err := history.RestoreNoteVersion("note.md", first); err != nil {
+		t.Fatalf("RestoreNoteVersion() error = %v", err)
+	}
+	if data, _ := fs.ReadFile("note.md"); string(data) != "one\ntwo\n" {
+		t.Errorf("restored content = %q, want first version", data)
+	}
+}
+
+func TestHistoryService_AutoCommit(t *testing.T) {
+	repo, root := newTestHistoryRepo(t)
+	commitTestFiles(t, repo, root, "Initial", map[string]string{"a.md": "a\n", "gone.md": "gone\n"})
+
+	history, fs := newTestHistoryService(t, root)
+
+	// Changes are ignored while auto-commit is off.
+	history.NoteChanged("a.md")
+	if hash, err := history.CommitPending(); hash != "" || err != nil {
+		t.Errorf("CommitPending() with auto-commit off = %q, %v; want nothing", hash, err)
+	}
+
+	commits := make(chan string, 1)
+	history.SetCommitHook(func(hash string, err error) {
+		if err != nil {
+			t.Errorf("auto-commit error = %v", err)
+		}
+		commits <- hash
+	})
+	history.SetAutoCommit(50 * time.Millisecond)
+
+	fs.WriteFile("a.md", []byte("a1\n"))
+	history.NoteChanged("a.md")
+	fs.WriteFile("a.md", []byte("a2\n"))
+	history.NoteChanged("a.md")
+
+	var hash string
+	select {
+	case hash = <-commits:
+	case <-time.After(5 * time.Second):
+		t.Fatal("auto-commit did not run")
+	}
+
+	versions, err := history.NoteHistory("a.md", 0)
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("NoteHistory() = %+v, %v; want 2 versions", versions, err)
+	}
+	if versions[0].Hash != hash || versions[0].Message != "Update a.md" {
+		t.Errorf("auto-commit = %+v, want hash %s and message %q", versions[0], hash, "Update a.md")
+	}
+	if content, _ := history.NoteVersionContent("a.md", hash); content != "a2\n" {
+		t.Errorf("committed content = %q, want the last save", content)
+	}
+
+	history.SetAutoCommit(time.Hour)
+	fs.WriteFile("b.md", []byte("b\n"))
+	history.NoteChanged("b.md")
+	fs.DeleteFile("gone.md")
+	history.NoteChanged("gone.md")
+	history.NoteChanged("a.md") // Unchanged since the last commit
+
+	hash, err = history.CommitPending()
+	if err != nil || hash == "" {
+		t.Fatalf("CommitPending() = %q, %v", hash, err)
+	}
+	head, _ := repo.Head()
+	commit, _ := repo.CommitObject(head.Hash())
+	if want := "Update 2 notes\n\n- Add b.md\n- Delete gone.md"; strings.TrimSpace(commit.Message) != want {
+		t.Errorf("commit message = %q, want %q", commit.Message, want)
+	}
+	if _, err := commit.File("gone.md"); err == nil {
+		t.Error("deleted note still in the commit")
+	}
+
+	if hash, err := history.CommitPending(); hash != "" || err != nil {
+		t.Errorf("CommitPending() with nothing pending = %q, %v", hash, err)
+	}
+}
+
+func TestHistoryService_AutoCommitSkipsStagedChanges(t *testing.T) {
+	repo, root := newTestHistoryRepo(t)
+	commitTestFiles(t, repo, root, "Initial", map[string]string{"a.md": "a\n"})
+
+	history, fs := newTestHistoryService(t, root)
+	history.SetAutoCommit(time.Hour)
+
+	// The user has staged a file of their own.
+	writeTestTree(t, root, map[string]string{"staged.txt": "mine\n"})
+	worktree, _ := repo.Worktree()
+	if _, err := worktree.Add("staged.txt"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	fs.WriteFile("a.md", []byte("a1\n"))
+	history.NoteChanged("a.md")
+	hash, err := history.CommitPending()
+	if hash != "" || !errors.Is(err, ErrUnrelatedStagedChanges) || !strings.Contains(err.Error(), "staged.txt") {
+		t.Fatalf("CommitPending() with staged changes = %q, %v; want ErrUnrelatedStagedChanges", hash, err)
+	}
+	if versions, _ := history.NoteHistory("a.md", 0); len(versions) != 1 {
+		t.Errorf("NoteHistory() = %d versions after a skipped commit, want 1", len(versions))
+	}
+
+	// Once the user commits their change, the note is still pending and gets its own commit.
+	commitTestFiles(t, repo, root, "Mine", map[string]string{"staged.txt": "mine\n"})
+	if hash, err := history.CommitPending(); hash == "" || err != nil {
+		t.Fatalf("CommitPending() after the index cleared = %q, %v", hash, err)
+	}
+	head, _ := repo.Head()
+	commit, _ := repo.CommitObject(head.Hash())
+	if strings.TrimSpace(commit.Message) != "Update a.md" {
+		t.Errorf("commit message = %q, want %q", commit.Message, "Update a.md")
+	}
+}
+
+func TestHistoryService_WorkspaceInSubfolder(t *testing.T) {
+	repo, root := newTestHistoryRepo(t)
+	first := commitTestFiles(t, repo, root, "Add", map[string]string{"README.md": "readme\n", "vault/idea.md": "idea\n"})
+
+	history, fs := newTestHistoryService(t, filepath.Join(root, "vault"))
+	versions, err := history.NoteHistory("idea.md", 0)
+	if err != nil || len(versions) != 1 || versions[0].Hash != first || versions[0].Path != "idea.md" {
+		t.Fatalf("NoteHistory() = %+v, %v", versions, err)
+	}
+
+	history.SetAutoCommit(time.Hour)
+	fs.WriteFile("idea.md", []byte("better idea\n"))
+	history.NoteChanged("idea.md")
+	hash, err := history.CommitPending()
+	if err != nil || hash == "" {
+		t.Fatalf("CommitPending() = %q, %v", hash, err)
+	}
+
+	ref, _ := repo.Head()
+	commit, _ := repo.CommitObject(ref.Hash())
+	file, err := commit.File("vault/idea.md")
+	if err != nil {
+		t.Fatalf("File(vault/idea.md) error = %v", err)
+	}
+	if content, _ := file.Contents(); content != "better idea\n" || strings.TrimSpace(commit.Message) != "Update idea.md" {
+		t.Errorf("commit = %q with content %q", commit.Message, content)
+	}
+}
+
+func TestHistoryService_NotARepository(t *testing.T) {
+	history, _ := newTestHistoryService(t, t.TempDir())
+
+	if history.IsRepository() {
+		t.Error("IsRepository() = true outside a repository")
+	}
+
+	var noRepo *domain.ErrNoRepository
+	if _, err := history.NoteHistory("note.md", 0); !errors.As(err, &noRepo) {
+		t.Errorf("NoteHistory() error = %v, want ErrNoRepository", err)
+	}
+
+	history.SetAutoCommit(time.Millisecond)
+	history.NoteChanged("note.md")
+	if hash, err := history.CommitPending(); hash != "" || err != nil {
+		t.Errorf("CommitPending() = %q, %v; want nothing", hash, err)
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// ignoreFileNames are the files whose patterns apply to the directory holding them and everything
+// below it. Where both exist in a directory, .knowledgelabignore takes precedence over .gitignore.
+var ignoreFileNames = []string{".gitignore", ".knowledgelabignore"}
+
+// isIgnoreFile reports whether path is one of the files ignore patterns are read from.
+func isIgnoreFile(path string) bool {
+	return slices.Contains(ignoreFileNames, filepath.Base(path))
+}
+
+// ignoreMatcher decides which workspace paths are skipped, with .gitignore semantics: "**" wildcards,
+// patterns anchored by a slash, "!" negation and directory-only patterns ending in "/".
+// The workspace's ignore patterns apply first; ignore files override them, deeper files overriding
+// shallower ones. As in git, a path inside an ignored directory cannot be re-included.
+type ignoreMatcher struct {
+	root    string
+	matcher gitignore.Matcher
+}
+
+// newIgnoreMatcher returns a matcher for the workspace at root from its configured patterns and
+// the ignore files found in directories that are not themselves ignored.
+func newIgnoreMatcher(root string, patterns []string) *ignoreMatcher {
+	m := &ignoreMatcher{root: root}
+
+	parsed := []gitignore.Pattern{}
+	for _, pattern := range patterns {
+		parsed = append(parsed, parseIgnorePatterns(pattern, nil)...)
+	}
+	m.matcher = gitignore.NewMatcher(parsed)
+
+	// Walk the directories only, reading their ignore files as they are reached, so the
+	// ignore files inside an ignored directory are never read.
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if m.Match(path, true) {
+			return filepath.SkipDir
+		}
+
+		domain := m.split(path)
+		added := false
+		for _, name := range ignoreFileNames {
+			data, err := os.ReadFile(filepath.Join(path, name))
+			if err != nil {
+				continue
+			}
+			parsed = append(parsed, parseIgnorePatterns(string(data), domain)...)
+			added = true
+		}
+		if added {
+			m.matcher = gitignore.NewMatcher(parsed)
+		}
+		return nil
+	})
+
+	return m
+}
+
+// Match reports whether an absolute path inside the workspace is ignored. The root and paths
+// outside the workspace never are; the trash folder always is.
+func (m *ignoreMatcher) Match(path string, isDir bool) bool {
+	parts := m.split(path)
+	if len(parts) == 0 {
+		return false
+	}
+	if parts[0] == TrashDirName {
+		return true
+	}
+	return m.matcher.Match(parts, isDir)
+}
+
+// split returns the components of path relative to the workspace root, or nil for the root itself
+// and paths outside the workspace.
+func (m *ignoreMatcher) split(path string) []string {
+	rel, err := filepath.Rel(m.root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	return strings.Split(filepath.ToSlash(rel), "/")
+}
+
+// parseIgnorePatterns parses the lines of an ignore file, skipping blank lines and comments.
+// domain is the directory holding the file, relative to the workspace root.
+func parseIgnorePatterns(content string, domain []string) []gitignore.Pattern {
+	patterns := []gitignore.Pattern{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, domain))
+	}
+	return patterns
+}
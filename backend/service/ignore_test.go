@@ -0,0 +1,56 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	root := t.TempDir()
+	writeTestTree(t, root, map[string]string{
+		".gitignore":                     "# Build output\nbuild\n/drafts/\narchive/2019/\n**/scratch/*.md\n*.log\n!keep.log\n",
+		"notes/.knowledgelabignore":      "private.md\n!build\n",
+		"node_modules/pkg/.gitignore":    "!*.md\n",
+		"notes/deep/.knowledgelabignore": "!private.md\n",
+		"index.md":                       "",
+	})
+	m := newIgnoreMatcher(root, []string{".git", "node_modules"})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"build", true, true},
+		{"builder-notes.md", false, false},
+		{"notes/build", true, false}, // Negated by notes/.knowledgelabignore
+		{"other/build", true, true},
+		{"drafts", true, true},
+		{"drafts", false, false}, // Directory-only pattern
+		{"notes/drafts", true, false},
+		{"archive/2019", true, true},
+		{"archive/2020", true, false},
+		{"notes/archive/2019", true, false}, // Anchored by the inner slash
+		{"notes/2019", true, false},
+		{"scratch/idea.md", false, true},
+		{"a/b/scratch/idea.md", false, true},
+		{"a/b/scratch/idea.txt", false, false},
+		{"debug.log", false, true},
+		{"keep.log", false, false},
+		{"notes/private.md", false, true},
+		{"private.md", false, false}, // Outside the directory of the ignore file
+		{"notes/deep/private.md", false, false},
+		{"node_modules", true, true},
+		{".git", true, true},
+		{"index.md", false, false},
+		{".trash", true, true}, // Always ignored
+		{".trash/note.md", false, true},
+		{"notes/.trash", true, false},
+		{".", true, false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(filepath.Join(root, tt.path), tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, dir=%t) = %t, want %t", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,514 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"notes/backend/domain"
+)
+
+// ImportProgressEvent is the event name the app emits ImportProgress values under.
+const ImportProgressEvent = "import:progress"
+
+// Import phases reported in ImportProgress.
+const (
+	ImportPhaseScan        = "scan"
+	ImportPhaseNotes       = "notes"
+	ImportPhaseAttachments = "attachments"
+)
+
+// ImportProgress reports how far an import has got.
+type ImportProgress struct {
+	Phase   string `json:"phase"`   // One of the ImportPhase constants
+	Current int    `json:"current"` // Files processed so far in this phase
+	Total   int    `json:"total"`   // Files to process in this phase
+	Path    string `json:"path"`    // Source-relative path of the file just processed
+}
+
+// ImportProgressFunc receives progress updates during an import. It may be nil.
+type ImportProgressFunc func(ImportProgress)
+
+// ImportLink is a link in an imported note that could not be resolved to a file in the source.
+type ImportLink struct {
+	NoteID string `json:"noteId"` // Workspace path of the imported note
+	Target string `json:"target"` // Link target as written
+}
+
+// ImportIssue describes a file or construct the importer skipped or could not convert.
+type ImportIssue struct {
+	Path   string `json:"path"`   // Source-relative path
+	Reason string `json:"reason"` // Human-readable explanation
+}
+
+// ImportReport summarizes an import. In a dry run it describes what would be written.
+type ImportReport struct {
+	Source           string         `json:"source"`           // Absolute path of the imported vault or graph
+	TargetFolder     string         `json:"targetFolder"`     // Workspace folder the files were imported into
+	Notes            []string       `json:"notes"`            // Workspace paths of imported notes
+	Attachments      []string       `json:"attachments"`      // Workspace paths of copied attachments
+	Conversions      map[string]int `json:"conversions"`      // Count of converted constructs by kind (e.g. "callouts")
+	UnresolvedLinks  []ImportLink   `json:"unresolvedLinks"`  // Links whose target was not found in the source
+	Unsupported      []ImportIssue  `json:"unsupported"`      // Content kept as-is or skipped because it has no equivalent
+	Skipped          []ImportIssue  `json:"skipped"`          // Files not written, e.g. because the destination exists
+	AttachmentFolder string         `json:"attachmentFolder"` // Attachment folder configured in the source, as a workspace path
+	DryRun           bool           `json:"dryRun"`
+}
+
+// newImportReport creates an empty report for an import of source into targetFolder.
+func newImportReport(source, targetFolder string, dryRun bool) *ImportReport {
+	return &ImportReport{
+		Source:          source,
+		TargetFolder:    targetFolder,
+		Notes:           []string{},
+		Attachments:     []string{},
+		Conversions:     make(map[string]int),
+		UnresolvedLinks: []ImportLink{},
+		Unsupported:     []ImportIssue{},
+		Skipped:         []ImportIssue{},
+		DryRun:          dryRun,
+	}
+}
+
+// ImportService imports notes and attachments from other note-taking tools into the open workspace.
+type ImportService struct {
+	fs              *FilesystemService
+	notes           *NoteService
+	dailyNoteFormat string
+	dailyNoteFolder string
+}
+
+// NewImportService creates an import service writing through fs.
+// The note service supplies the Markdown parser used to leave code untouched while converting.
+// Imported journals are named "2006-01-02.md" in the target folder until SetDailyNotes is called.
+func NewImportService(fs *FilesystemService, notes *NoteService) *ImportService {
+	return &ImportService{fs: fs, notes: notes, dailyNoteFormat: "2006-01-02"}
+}
+
+// SetDailyNotes sets the Go time layout and folder that imported journals are renamed to,
+// matching the workspace's daily note convention.
+func (s *ImportService) SetDailyNotes(format, folder string) {
+	if format != "" {
+		s.dailyNoteFormat = format
+	}
+	s.dailyNoteFolder = strings.Trim(filepath.ToSlash(folder), "/")
+}
+
+// importSource is the scanned content of a vault or graph, keyed by slash-separated source-relative paths.
+type importSource struct {
+	root        string
+	notes       []string
+	attachments []string
+	// files maps lower-cased paths of notes and attachments to their spelling on disk
+	files map[string]string
+}
+
+// scanImportSource walks root and sorts files into notes, attachments and unsupported files.
+// Hidden files and directories (.obsidian, .trash, .git...) are skipped, as are the directories in skipDirs.
+// unsupported is called for every other file and may return false to skip reporting it.
+func scanImportSource(root string, skipDirs []string, unsupported func(rel string) (string, bool)) (*importSource, []ImportIssue, error) {
+	info, err := os.Stat(root)
+	if err != nil || !info.IsDir() {
+		return nil, nil, &domain.ErrInvalidPath{Path: root, Reason: "not a directory"}
+	}
+
+	src := &importSource{root: root, files: make(map[string]string)}
+	issues := []ImportIssue{}
+
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			for _, dir := range skipDirs {
+				if rel == dir {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+
+		switch {
+		case isMarkdownFile(rel):
+			src.notes = append(src.notes, rel)
+		case isAttachmentFile(rel):
+			src.attachments = append(src.attachments, rel)
+		default:
+			if reason, ok := unsupported(rel); ok {
+				issues = append(issues, ImportIssue{Path: rel, Reason: reason})
+			}
+			return nil
+		}
+		src.files[strings.ToLower(rel)] = rel
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to scan import source: %w", err)
+	}
+
+	sort.Strings(src.notes)
+	sort.Strings(src.attachments)
+	return src, issues, nil
+}
+
+// lookup returns the spelling on disk of a source-relative path, ignoring case.
+func (src *importSource) lookup(rel string) (string, bool) {
+	found, ok := src.files[strings.ToLower(rel)]
+	return found, ok
+}
+
+// resolveWikilink finds the file a wikilink target refers to, the way Obsidian and Logseq do:
+// a path relative to the linking note or to the source root, then the closest file whose path
+// ends in the target. Targets without an extension also match notes with ".md" appended.
+// relativeFirst prefers the note-relative reading when both exist.
+func (src *importSource) resolveWikilink(target, fromNote string, relativeFirst bool) (string, bool) {
+	target = strings.TrimPrefix(strings.TrimSpace(target), "/")
+	if target == "" {
+		return "", false
+	}
+
+	candidates := []string{target}
+	if !isMarkdownFile(target) && !isAttachmentFile(target) {
+		candidates = append(candidates, target+".md")
+	}
+
+	for _, candidate := range candidates {
+		relative := path.Join(noteFolder(fromNote), candidate)
+		absolute := path.Clean(candidate)
+		order := []string{absolute, relative}
+		if relativeFirst {
+			order = []string{relative, absolute}
+		}
+		for _, p := range order {
+			if found, ok := src.lookup(p); ok {
+				return found, true
+			}
+		}
+	}
+
+	if strings.Contains(target, "..") {
+		return "", false
+	}
+
+	for _, candidate := range candidates {
+		suffix := "/" + strings.ToLower(candidate)
+		var matches []string
+		for lower, found := range src.files {
+			if strings.HasSuffix("/"+lower, suffix) {
+				matches = append(matches, found)
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		folder := noteFolder(fromNote)
+		sort.Slice(matches, func(i, j int) bool {
+			iSame, jSame := noteFolder(matches[i]) == folder, noteFolder(matches[j]) == folder
+			if iSame != jSame {
+				return iSame
+			}
+			if len(matches[i]) != len(matches[j]) {
+				return len(matches[i]) < len(matches[j])
+			}
+			return matches[i] < matches[j]
+		})
+		return matches[0], true
+	}
+
+	return "", false
+}
+
+// resolveMarkdownLink finds the file a Markdown link destination refers to, relative to the
+// linking note first and then to the source root. URLs and anchors are not resolved.
+func (src *importSource) resolveMarkdownLink(dest, fromNote string) (string, bool) {
+	if strings.Contains(dest, ":") || strings.HasPrefix(dest, "#") {
+		return "", false
+	}
+	if i := strings.IndexAny(dest, "#?"); i >= 0 {
+		dest = dest[:i]
+	}
+	if unescaped, err := url.PathUnescape(dest); err == nil {
+		dest = unescaped
+	}
+	if dest == "" {
+		return "", false
+	}
+
+	if !strings.HasPrefix(dest, "/") {
+		if found, ok := src.lookup(path.Join(noteFolder(fromNote), dest)); ok {
+			return found, true
+		}
+	}
+	return src.lookup(path.Clean(strings.TrimPrefix(dest, "/")))
+}
+
+// normalizeImportFolder validates the workspace folder an import writes into.
+// "" imports into the workspace root.
+func normalizeImportFolder(folder string) (string, error) {
+	folder = strings.TrimSpace(filepath.ToSlash(folder))
+	if folder == "" {
+		return "", nil
+	}
+	if path.IsAbs(folder) || filepath.IsAbs(folder) {
+		return "", &domain.ErrInvalidPath{Path: folder, Reason: "import folder must be relative to the workspace"}
+	}
+
+	folder = path.Clean(folder)
+	if folder == "." {
+		return "", nil
+	}
+	if folder == ".." || strings.HasPrefix(folder, "../") {
+		return "", &domain.ErrInvalidPath{Path: folder, Reason: "import folder must be inside the workspace"}
+	}
+	return folder, nil
+}
+
+// importLinkRewriter rewrites the wikilinks and Markdown links of a source note so they resolve
+// in the workspace, recording the ones that do not resolve in the source.
+type importLinkRewriter struct {
+	src    *importSource
+	report *ImportReport
+	// resolve finds the source file a wikilink target refers to from a source note
+	resolve func(target, fromRel string) (string, bool)
+	// destination maps a source-relative path to its workspace path
+	destination func(rel string) string
+}
+
+// rewrite returns content with its links pointed at the imported files.
+// noteRel is the source path of the note; noteID its workspace path.
+// Links inside code blocks and code spans, and #[[tag]] tags, are left alone.
+func (r *importLinkRewriter) rewrite(content []byte, noteRel, noteID string, inCode func(pos int) bool) []byte {
+	type replacement struct {
+		start, end int
+		text       string
+	}
+	replacements := []replacement{}
+
+	for _, m := range attachmentWikilinkPattern.FindAllSubmatchIndex(content, -1) {
+		if inCode(m[0]) || (m[0] > 0 && content[m[0]-1] == '#') {
+			continue
+		}
+		written := string(content[m[4]:m[5]])
+		found, ok := r.resolve(written, noteRel)
+		if !ok {
+			r.report.UnresolvedLinks = append(r.report.UnresolvedLinks, ImportLink{NoteID: noteID, Target: written})
+			continue
+		}
+
+		target, keep := r.wikilinkTarget(written, found)
+		if keep {
+			continue
+		}
+
+		embed := m[3] > m[2]
+		var b strings.Builder
+		if embed {
+			b.WriteString("!")
+		}
+		b.WriteString("[[")
+		b.WriteString(target)
+		if m[6] >= 0 {
+			b.Write(content[m[6]:m[7]])
+		}
+		switch {
+		case m[8] >= 0:
+			b.Write(content[m[8]:m[9]])
+		case !embed && isMarkdownFile(found):
+			b.WriteString("|")
+			b.WriteString(strings.TrimSpace(written))
+		}
+		b.WriteString("]]")
+		replacements = append(replacements, replacement{m[0], m[1], b.String()})
+	}
+
+	for _, m := range attachmentMarkdownLinkPattern.FindAllSubmatchIndex(content, -1) {
+		if inCode(m[0]) {
+			continue
+		}
+		dest := string(content[m[6]:m[7]])
+		bracketed := strings.HasPrefix(dest, "<") && strings.HasSuffix(dest, ">")
+		if bracketed {
+			dest = dest[1 : len(dest)-1]
+		}
+
+		found, ok := r.src.resolveMarkdownLink(dest, noteRel)
+		if !ok {
+			if !strings.Contains(dest, ":") && (isMarkdownFile(dest) || isAttachmentFile(dest)) {
+				r.report.UnresolvedLinks = append(r.report.UnresolvedLinks, ImportLink{NoteID: noteID, Target: dest})
+			}
+			continue
+		}
+
+		newPath := r.destination(found)
+		if current, ok := resolveMarkdownDestination(dest, noteID); ok && current == newPath {
+			continue
+		}
+		replacements = append(replacements, replacement{m[6], m[7], renamedMarkdownDestination(dest, noteID, newPath, bracketed)})
+	}
+
+	if len(replacements) == 0 {
+		return content
+	}
+
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].start < replacements[j].start
+	})
+
+	var buf bytes.Buffer
+	last := 0
+	for _, rep := range replacements {
+		if rep.start < last {
+			continue
+		}
+		buf.Write(content[last:rep.start])
+		buf.WriteString(rep.text)
+		last = rep.end
+	}
+	buf.Write(content[last:])
+	return buf.Bytes()
+}
+
+// wikilinkTarget returns the workspace wikilink target for a link resolved to found, and whether
+// the written target already resolves there. Note links use the workspace path without ".md",
+// which is how the graph resolves them; attachment links keep a bare file name when it is unique.
+func (r *importLinkRewriter) wikilinkTarget(written, found string) (string, bool) {
+	newPath := r.destination(found)
+	written = strings.TrimSpace(written)
+
+	if isMarkdownFile(found) {
+		target := strings.TrimSuffix(newPath, ".md")
+		return target, written == target || written == newPath
+	}
+
+	if written == newPath {
+		return newPath, true
+	}
+	if !strings.Contains(written, "/") {
+		name := strings.ToLower(path.Base(found))
+		for lower := range r.src.files {
+			if path.Base(lower) == name && lower != strings.ToLower(found) {
+				return newPath, false
+			}
+		}
+		return written, true
+	}
+	return newPath, false
+}
+
+// codeChecker returns a function reporting whether a byte offset of content lies in code.
+// Offsets in the frontmatter are never code.
+func (s *ImportService) codeChecker(content []byte) func(pos int) bool {
+	bodyStart := 0
+	if _, _, start, ok := splitFrontmatter(content); ok {
+		bodyStart = start
+	}
+	ranges := s.notes.codeRanges(content[bodyStart:])
+	return func(pos int) bool {
+		pos -= bodyStart
+		for _, r := range ranges {
+			if pos >= r.start && pos < r.end {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// writeNote writes an imported note unless the destination already exists.
+func (s *ImportService) writeNote(report *ImportReport, rel, dest string, content []byte) error {
+	if _, err := s.fs.StatFile(dest); err == nil {
+		report.Skipped = append(report.Skipped, ImportIssue{Path: rel, Reason: fmt.Sprintf("%s already exists", dest)})
+		return nil
+	}
+
+	if !report.DryRun {
+		if err := s.fs.WriteFile(filepath.FromSlash(dest), content); err != nil {
+			return fmt.Errorf("failed to write %s: %w", dest, err)
+		}
+	}
+	report.Notes = append(report.Notes, dest)
+	return nil
+}
+
+// copyAttachment copies an attachment from the source unless the destination already exists.
+func (s *ImportService) copyAttachment(report *ImportReport, rel, dest string) error {
+	if _, err := s.fs.StatFile(dest); err == nil {
+		report.Skipped = append(report.Skipped, ImportIssue{Path: rel, Reason: fmt.Sprintf("%s already exists", dest)})
+		return nil
+	}
+
+	if !report.DryRun {
+		err := s.fs.CopyFile(filepath.Join(report.Source, filepath.FromSlash(rel)), filepath.FromSlash(dest))
+		var exists *domain.ErrAlreadyExists
+		if errors.As(err, &exists) {
+			report.Skipped = append(report.Skipped, ImportIssue{Path: rel, Reason: fmt.Sprintf("%s already exists", dest)})
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to copy %s: %w", rel, err)
+		}
+	}
+	report.Attachments = append(report.Attachments, dest)
+	return nil
+}
+
+// importTarget prepares an import: it checks a workspace is open, validates the target folder
+// and rejects sources that contain the workspace or are inside it.
+func (s *ImportService) importTarget(source, targetFolder string) (string, string, error) {
+	workspace, err := s.fs.GetCurrentWorkspace()
+	if err != nil {
+		return "", "", err
+	}
+
+	folder, err := normalizeImportFolder(targetFolder)
+	if err != nil {
+		return "", "", err
+	}
+
+	source, err = filepath.Abs(source)
+	if err != nil {
+		return "", "", &domain.ErrInvalidPath{Path: source, Reason: err.Error()}
+	}
+	if within(source, workspace.RootPath) || within(workspace.RootPath, source) {
+		return "", "", &domain.ErrInvalidPath{Path: source, Reason: "source overlaps the open workspace"}
+	}
+
+	return source, folder, nil
+}
+
+// within reports whether p is dir or a path inside it.
+func within(p, dir string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// reportProgress calls progress when it is set.
+func reportProgress(progress ImportProgressFunc, phase string, current, total int, rel string) {
+	if progress != nil {
+		progress(ImportProgress{Phase: phase, Current: current, Total: total, Path: rel})
+	}
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"notes/backend/domain"
+)
+
+// IndexProgressEvent is the event name the app emits IndexStatus values under while a workspace is indexed.
+const IndexProgressEvent = "index:progress"
+
+// indexProgressInterval is the least time between two progress reports of a running index build.
+const indexProgressInterval = 100 * time.Millisecond
+
+// indexBatchSize is how many loaded notes are added to the indexes at once; the tasks of a batch
+// are persisted in one transaction.
+const indexBatchSize = 256
+
+// IndexState is the state of a workspace's index build.
+type IndexState string
+
+const (
+	IndexIdle      IndexState = "idle"      // No index build has started
+	IndexRunning   IndexState = "running"   // Notes are being indexed
+	IndexDone      IndexState = "done"      // Every note was indexed
+	IndexCancelled IndexState = "cancelled" // The build was stopped before it finished
+	IndexFailed    IndexState = "failed"    // The build stopped on an error
+)
+
+// IndexStatus reports how far a workspace's index build has got.
+type IndexStatus struct {
+	WorkspaceID string     `json:"workspaceId"`
+	State       IndexState `json:"state"`
+	Done        int        `json:"done"`    // Notes indexed so far, including those that failed to load
+	Total       int        `json:"total"`   // Notes to index
+	Current     string     `json:"current"` // ID of the note indexed last
+	Failed      int        `json:"failed"`  // Notes that could not be loaded
+	StartedAt   time.Time  `json:"startedAt"`
+	FinishedAt  time.Time  `json:"finishedAt"` // Zero while running
+	Error       string     `json:"error"`      // Why the build failed
+	Partial     bool       `json:"partial"`    // The indexes hold only some notes: the build cleared them and has not finished
+}
+
+// Indexer builds a workspace's attachment, graph, search, metadata, schema, and task indexes from
+// the notes on disk, as a background job that can be cancelled and restarted. Notes are read and
+// parsed, once each, by a pool of workers; one goroutine adds them to the indexes in batches.
+type Indexer struct {
+	session    *WorkspaceSession
+	logger     runtimeLogger
+	startMu    sync.Mutex // Held while a build is cancelled and its successor started
+	mu         sync.Mutex
+	workers    int
+	status     IndexStatus
+	progress   func(IndexStatus)
+	lastReport time.Time
+	cancel     context.CancelFunc
+	done       chan struct{}       // Closed when the running build has stopped
+	changed    map[string]struct{} // Notes the app indexed itself while the build ran, nil once the build stops taking them
+}
+
+// NewIndexer creates an idle indexer for a session, with a worker per CPU.
+func NewIndexer(session *WorkspaceSession) *Indexer {
+	return &Indexer{
+		session: session,
+		workers: runtime.NumCPU(),
+		status:  IndexStatus{WorkspaceID: session.ID(), State: IndexIdle},
+	}
+}
+
+// SetLogger attaches the runtime logger context.
+func (ix *Indexer) SetLogger(ctx context.Context) {
+	ix.logger.attach(ctx)
+}
+
+// SetProgressHook sets the function told about the progress of index builds: when one starts,
+// at most every 100ms while it runs, and when it stops.
+func (ix *Indexer) SetProgressHook(hook func(IndexStatus)) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.progress = hook
+}
+
+// SetWorkers sets how many notes are loaded and parsed at once by builds started afterwards.
+func (ix *Indexer) SetWorkers(workers int) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.workers = max(workers, 1)
+}
+
+// Status returns the progress of the running or last index build.
+func (ix *Indexer) Status() IndexStatus {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	return ix.status
+}
+
+// Start begins rebuilding the indexes in the background. A build that is still running is
+// cancelled first, and has stopped touching the indexes by the time the new one starts.
+// Concurrent calls start their builds one after the other.
+func (ix *Indexer) Start() {
+	ix.startMu.Lock()
+	defer ix.startMu.Unlock()
+
+	ix.Cancel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	ix.mu.Lock()
+	ix.cancel, ix.done = cancel, done
+	ix.status = IndexStatus{WorkspaceID: ix.session.ID(), State: IndexRunning, StartedAt: time.Now()}
+	ix.changed = make(map[string]struct{})
+	workers := ix.workers
+	ix.mu.Unlock()
+	ix.report(true)
+
+	go func() {
+		defer close(done)
+		defer cancel()
+		ix.run(ctx, workers)
+	}()
+}
+
+// Cancel stops the running index build, if any, and waits until it has stopped.
+func (ix *Indexer) Cancel() {
+	ix.mu.Lock()
+	cancel, done := ix.cancel, ix.done
+	ix.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// Wait blocks until the running index build, if any, has stopped.
+func (ix *Indexer) Wait() {
+	ix.mu.Lock()
+	done := ix.done
+	ix.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
+
+// NoteChanged tells the indexer that the app is about to index, or unindex, a note it wrote or
+// removed. A running build may have loaded the note before the change; it reloads the note once
+// it has added the rest, so the indexes don't keep the content it loaded.
+func (ix *Indexer) NoteChanged(id string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if ix.changed != nil {
+		ix.changed[id] = struct{}{}
+	}
+}
+
+// indexedNote is a note loaded by a worker, or the error that kept it from loading.
+type indexedNote struct {
+	index  int
+	id     string
+	parsed *ParsedNote
+	err    error
+}
+
+// run builds the indexes, stopping early when ctx is cancelled.
+func (ix *Indexer) run(ctx context.Context, workers int) {
+	w := ix.session
+	start := time.Now()
+	ix.logger.Infof("Starting index build for workspace %s", w.Info.Workspace.Name)
+
+	if err := w.Attachments.Refresh(); err != nil {
+		ix.logger.Warnf("failed to index attachments: %v", err)
+	}
+
+	files, err := w.FS.LoadMarkdownFiles()
+	if err != nil {
+		ix.finish(IndexFailed, err)
+		ix.logger.Errorf("failed to list notes during indexing: %v", err)
+		return
+	}
+
+	ix.mu.Lock()
+	ix.status.Total = len(files)
+	ix.mu.Unlock()
+	ix.report(true)
+
+	jobs := make(chan int)
+	results := make(chan indexedNote)
+	var wg sync.WaitGroup
+	for range min(workers, max(len(files), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				parsed, err := w.Notes.LoadNote(files[i])
+				result := indexedNote{index: i, id: files[i], parsed: parsed, err: err}
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range files {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// The indexes are cleared when the first batch is ready, so a build cancelled before then leaves
+	// the previous ones whole.
+	loaded := 0
+	batch := make([]*ParsedNote, 0, indexBatchSize)
+	for result := range results {
+		if ctx.Err() != nil || err != nil {
+			continue // Drain the workers without touching the indexes
+		}
+		if result.err != nil {
+			ix.logger.Warnf("failed to load note %s: %v", result.id, result.err)
+		} else {
+			loaded++
+			if batch = append(batch, result.parsed); len(batch) == indexBatchSize {
+				err = ix.indexBatch(batch)
+				batch = batch[:0]
+			}
+		}
+
+		ix.mu.Lock()
+		ix.status.Done++
+		ix.status.Current = result.id
+		if result.err != nil {
+			ix.status.Failed++
+		}
+		ix.mu.Unlock()
+		ix.report(false)
+	}
+
+	if ctx.Err() != nil {
+		ix.finish(IndexCancelled, nil)
+		ix.logger.Infof("Index build for workspace %s cancelled", w.Info.Workspace.Name)
+		return
+	}
+	if err == nil {
+		ix.clearIndexes() // Empty the indexes of a workspace whose notes were all removed
+		err = ix.indexBatch(batch)
+	}
+	if err == nil {
+		err = ix.reindexChanged()
+	}
+	if err != nil {
+		ix.finish(IndexFailed, err)
+		ix.logger.Errorf("failed to build search index: %v", err)
+		return
+	}
+
+	ix.finish(IndexDone, nil)
+	ix.logger.Infof("Index build complete for workspace %s: indexed %d notes (%dms total)",
+		w.Info.Workspace.Name, loaded, time.Since(start).Milliseconds())
+}
+
+// clearIndexes empties the graph, search, metadata, schema, and task indexes before the first batch of
+// a build is added to them. From then until the build is done, they hold only some notes.
+func (ix *Indexer) clearIndexes() {
+	ix.mu.Lock()
+	cleared := ix.status.Partial
+	ix.status.Partial = true
+	ix.mu.Unlock()
+	if cleared {
+		return
+	}
+
+	w := ix.session
+	w.Graph.Clear()
+	w.Search.Clear()
+	w.Query.Clear()
+	w.Schemas.Clear()
+	if err := w.Tasks.Clear(); err != nil {
+		ix.logger.Warnf("failed to clear tasks: %v", err)
+	}
+}
+
+// indexBatch adds loaded notes to the graph, search, metadata, schema, and task indexes,
+// persisting their tasks in one transaction.
+func (ix *Indexer) indexBatch(batch []*ParsedNote) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	ix.clearIndexes()
+	w := ix.session
+
+	notes := make([]*domain.Note, 0, len(batch))
+	tasks := make([]NoteTasks, 0, len(batch))
+	for _, parsed := range batch {
+		note := parsed.Note
+		notes = append(notes, note)
+		tasks = append(tasks, NoteTasks{NoteID: note.ID, NotePath: note.Path, Tasks: parsed.Tasks, ModifiedAt: note.ModifiedAt})
+
+		if err := w.Query.IndexNote(note); err != nil {
+			ix.logger.Warnf("failed to index metadata for note %s: %v", note.ID, err)
+		}
+		w.Schemas.IndexNote(note)
+	}
+
+	w.Graph.IndexParsedNotes(notes)
+	if err := w.Tasks.IndexNotes(tasks); err != nil {
+		ix.logger.Warnf("failed to index tasks for %d notes: %v", len(tasks), err)
+	}
+	return w.Search.IndexNotes(notes)
+}
+
+// reindexChanged reloads the notes the app changed while the build ran, which the build may have
+// added as they were before, and removes those that no longer load. Notes changed from now on are
+// indexed by the app after every batch of the build, so they are not tracked.
+func (ix *Indexer) reindexChanged() error {
+	ix.mu.Lock()
+	changed := ix.changed
+	ix.changed = nil
+	ix.mu.Unlock()
+
+	w := ix.session
+	batch := make([]*ParsedNote, 0, len(changed))
+	for id := range changed {
+		parsed, err := w.Notes.LoadNote(id)
+		if err == nil {
+			batch = append(batch, parsed)
+			continue
+		}
+		w.Graph.RemoveNote(id)
+		w.Search.RemoveNote(id)
+		w.Query.RemoveNote(id)
+		w.Schemas.RemoveNote(id)
+		if err := w.Tasks.RemoveNote(id); err != nil {
+			ix.logger.Warnf("failed to remove tasks for note %s: %v", id, err)
+		}
+	}
+	if len(changed) > 0 {
+		ix.logger.Debugf("reindexed %d notes changed during the build", len(changed))
+	}
+	return ix.indexBatch(batch)
+}
+
+// finish records how the running build ended and reports it.
+func (ix *Indexer) finish(state IndexState, err error) {
+	ix.mu.Lock()
+	ix.status.State = state
+	ix.status.FinishedAt = time.Now()
+	ix.changed = nil
+	if state == IndexDone {
+		ix.status.Partial = false
+	}
+	if err != nil {
+		ix.status.Error = err.Error()
+	}
+	ix.mu.Unlock()
+	ix.report(true)
+}
+
+// report tells the progress hook the current status, unless force is unset and the last
+// report was less than indexProgressInterval ago.
+func (ix *Indexer) report(force bool) {
+	ix.mu.Lock()
+	if ix.progress == nil || (!force && time.Since(ix.lastReport) < indexProgressInterval) {
+		ix.mu.Unlock()
+		return
+	}
+	ix.lastReport = time.Now()
+	hook, status := ix.progress, ix.status
+	ix.mu.Unlock()
+
+	hook(status)
+}
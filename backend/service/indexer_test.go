@@ -0,0 +1,265 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"notes/backend/domain"
+)
+
+// newTestIndexedSession opens a workspace holding the given notes through a test manager.
+func newTestIndexedSession(t testing.TB, files map[string]string) *WorkspaceSession {
+	t.Helper()
+
+	root := t.TempDir()
+	writeTestTree(t, root, files)
+	session, _, err := newTestWorkspaceManager(t).Open(root)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return session
+}
+
+func TestIndexer_Start(t *testing.T) {
+	files := map[string]string{"index.md": "# Index\n\n- [ ] Review the plan\n"}
+	for i := range 40 {
+		files[fmt.Sprintf("notes/note-%02d.md", i)] = fmt.Sprintf("# Note %d\n\nBack to [[index]] #indexed\n", i)
+	}
+	session := newTestIndexedSession(t, files)
+
+	var mu sync.Mutex
+	reports := []IndexStatus{}
+	session.Indexer.SetProgressHook(func(status IndexStatus) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, status)
+	})
+	session.Indexer.SetWorkers(4)
+
+	session.Indexer.Start()
+	session.Indexer.Wait()
+
+	status := session.Indexer.Status()
+	if status.State != IndexDone || status.Done != 41 || status.Total != 41 || status.Failed != 0 {
+		t.Errorf("Status() = %+v, want 41 of 41 notes indexed", status)
+	}
+	if status.WorkspaceID != session.ID() || status.FinishedAt.Before(status.StartedAt) {
+		t.Errorf("Status() = %+v", status)
+	}
+
+	mu.Lock()
+	if len(reports) < 2 || reports[0].State != IndexRunning || reports[len(reports)-1].State != IndexDone {
+		t.Errorf("progress reports = %+v, want running first and done last", reports)
+	}
+	mu.Unlock()
+
+	if backlinks := session.Graph.GetBacklinks("index.md"); len(backlinks) != 40 {
+		t.Errorf("GetBacklinks(index.md) = %d links, want 40", len(backlinks))
+	}
+	results, err := session.Search.Search(SearchQuery{Tags: []string{"indexed"}})
+	if err != nil || len(results) != 40 {
+		t.Errorf("Search() = %d results, %v, want 40", len(results), err)
+	}
+	tasks, err := session.Tasks.GetTasksForNote("index.md")
+	if err != nil || len(tasks) != 1 {
+		t.Errorf("GetTasksForNote() = %+v, %v, want 1 task", tasks, err)
+	}
+
+	// Concurrent builds run one after the other, each replacing the indexes rather than adding to them.
+	var starts sync.WaitGroup
+	for range 4 {
+		starts.Add(1)
+		go func() {
+			defer starts.Done()
+			session.Indexer.Start()
+		}()
+	}
+	starts.Wait()
+	session.Indexer.Wait()
+	if status := session.Indexer.Status(); status.State != IndexDone || status.Done != 41 {
+		t.Errorf("Status() after restart = %+v", status)
+	}
+	if backlinks := session.Graph.GetBacklinks("index.md"); len(backlinks) != 40 {
+		t.Errorf("GetBacklinks(index.md) after restart = %d links, want 40", len(backlinks))
+	}
+	if results, _ := session.Search.Search(SearchQuery{Tags: []string{"indexed"}}); len(results) != 40 {
+		t.Errorf("Search() after restart = %d results, want 40", len(results))
+	}
+}
+
+func TestIndexer_Cancel(t *testing.T) {
+	files := map[string]string{}
+	for i := range 20 {
+		files[fmt.Sprintf("note-%02d.md", i)] = fmt.Sprintf("# Note %d\n", i)
+	}
+	session := newTestIndexedSession(t, files)
+	indexer := session.Indexer
+	indexer.Start()
+	indexer.Wait()
+
+	listed := make(chan struct{})
+	release := make(chan struct{})
+	indexer.SetProgressHook(func(status IndexStatus) {
+		if status.State == IndexRunning && status.Total > 0 && status.Done == 0 {
+			close(listed)
+			<-release
+		}
+	})
+
+	indexer.Start()
+	<-listed
+	indexer.mu.Lock()
+	cancel := indexer.cancel
+	indexer.mu.Unlock()
+	cancel()
+	close(release)
+	indexer.Wait()
+
+	status := indexer.Status()
+	if status.State != IndexCancelled || status.Done != 0 || status.Total != 20 || status.Partial {
+		t.Errorf("Status() after cancel = %+v, want cancelled before any note", status)
+	}
+	if nodes := session.Graph.GetGraph().Nodes; len(nodes) != 20 {
+		t.Errorf("graph has %d nodes after a cancelled build, want the previous build's 20", len(nodes))
+	}
+
+	indexer.SetProgressHook(nil)
+	indexer.Cancel() // Nothing is running; must not block
+	indexer.Start()
+	indexer.Wait()
+	if status := indexer.Status(); status.State != IndexDone || status.Done != 20 {
+		t.Errorf("Status() after restart = %+v", status)
+	}
+}
+
+func TestIndexer_RemovedNote(t *testing.T) {
+	session := newTestIndexedSession(t, map[string]string{
+		"keep.md": "# Keep\n\n- [ ] Keep this\n",
+		"gone.md": "# Gone\n\n- [ ] Drop this\n- [x] And this\n",
+	})
+	session.Indexer.Start()
+	session.Indexer.Wait()
+	if info, _ := session.Tasks.GetAllTasks(domain.TaskFilter{}); info.TotalCount != 3 {
+		t.Fatalf("GetAllTasks() total = %d, want 3", info.TotalCount)
+	}
+
+	if err := os.Remove(filepath.Join(session.Info.Workspace.RootPath, "gone.md")); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	session.Indexer.Start()
+	session.Indexer.Wait()
+
+	info, err := session.Tasks.GetAllTasks(domain.TaskFilter{})
+	if err != nil {
+		t.Fatalf("GetAllTasks() error = %v", err)
+	}
+	if info.TotalCount != 1 || info.Tasks[0].NoteID != "keep.md" {
+		t.Errorf("GetAllTasks() after removing gone.md = %+v, want only the task of keep.md", info.Tasks)
+	}
+	if stored, _ := session.Stores.Task.GetTasksForNote("gone.md"); len(stored) != 0 {
+		t.Errorf("stored tasks of gone.md = %+v, want none", stored)
+	}
+}
+
+func TestIndexer_NoteChanged(t *testing.T) {
+	files := map[string]string{}
+	for i := range 5 {
+		files[fmt.Sprintf("note-%d.md", i)] = fmt.Sprintf("# Note %d\n\n- [ ] Task %d\n", i, i)
+	}
+	session := newTestIndexedSession(t, files)
+	indexer := session.Indexer
+	indexer.SetWorkers(1)
+
+	// Once the first note is loaded, but before it is indexed, rewrite it the way the app saves a note.
+	var changed string
+	indexer.SetProgressHook(func(status IndexStatus) {
+		switch {
+		case status.State != IndexRunning || changed != "":
+		case status.Done == 0:
+			indexer.mu.Lock()
+			indexer.lastReport = time.Time{} // Report the first loaded note
+			indexer.mu.Unlock()
+		default:
+			changed = status.Current
+			indexer.NoteChanged(changed)
+			path := filepath.Join(session.Info.Workspace.RootPath, changed)
+			if err := os.WriteFile(path, []byte("# Changed\n\n- [ ] Saved meanwhile\n"), 0644); err != nil {
+				t.Errorf("WriteFile() error = %v", err)
+			}
+		}
+	})
+	indexer.Start()
+	indexer.Wait()
+
+	if changed == "" {
+		t.Fatal("no progress was reported for a loaded note")
+	}
+	tasks, err := session.Tasks.GetTasksForNote(changed)
+	if err != nil || len(tasks) != 1 || tasks[0].Content != "Saved meanwhile" {
+		t.Errorf("GetTasksForNote(%s) = %+v, %v, want the task saved during the build", changed, tasks, err)
+	}
+	results, err := session.Search.Search(SearchQuery{Query: "meanwhile"})
+	if err != nil || len(results) != 1 || results[0].NoteID != changed {
+		t.Errorf("Search(meanwhile) = %+v, %v, want %s", results, err, changed)
+	}
+}
+
+func TestIndexer_Status(t *testing.T) {
+	session := newTestIndexedSession(t, map[string]string{"index.md": "# Index\n"})
+
+	status := session.Indexer.Status()
+	if status.State != IndexIdle || status.WorkspaceID != session.ID() {
+		t.Errorf("Status() before a build = %+v, want idle", status)
+	}
+}
+
+// writeBenchmarkVault writes a synthetic vault of n notes in folders of 100, each with
+// frontmatter tags, wikilinks, a Markdown link, tasks and a code block.
+func writeBenchmarkVault(b *testing.B, root string, n int) {
+	b.Helper()
+
+	for i := range n {
+		dir := filepath.Join(root, fmt.Sprintf("area-%03d", i/100))
+		if i%100 == 0 {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				b.Fatalf("MkdirAll() error = %v", err)
+			}
+		}
+		content := fmt.Sprintf("---\ntags: [area-%d, synthetic]\n---\n# Note %d\n\n"+
+			"Related to [[note-%05d]] and [[note-%05d|the next one]], see [the index](../index.md). #topic/%d\n\n"+
+			"- [ ] Follow up on note %d ^task-%d\n- [x] Review note %d\n  - [ ] Nested step\n\n"+
+			"```go\nfmt.Println(%d)\n```\n\nA closing paragraph with some words to search for in note %d.\n",
+			i/100, i, (i+7)%n, (i+1)%n, i%20, i, i, i, i, i)
+		path := filepath.Join(dir, fmt.Sprintf("note-%05d.md", i))
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			b.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+}
+
+func benchmarkIndexer(b *testing.B, n int) {
+	root := b.TempDir()
+	writeBenchmarkVault(b, root, n)
+	session, _, err := newTestWorkspaceManager(b).Open(root)
+	if err != nil {
+		b.Fatalf("Open() error = %v", err)
+	}
+
+	for b.Loop() {
+		session.Indexer.Start()
+		session.Indexer.Wait()
+	}
+
+	if status := session.Indexer.Status(); status.State != IndexDone || status.Done != n {
+		b.Fatalf("Status() = %+v, want %d notes indexed", status, n)
+	}
+	b.ReportMetric(float64(n*b.N)/b.Elapsed().Seconds(), "notes/s")
+}
+
+func BenchmarkIndexer_10k(b *testing.B) { benchmarkIndexer(b, 10_000) }
+
+func BenchmarkIndexer_50k(b *testing.B) { benchmarkIndexer(b, 50_000) }
@@ -0,0 +1,511 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// logseqSettings holds the parts of a graph's logseq/config.edn that affect importing.
+type logseqSettings struct {
+	PagesDirectory     string // Folder holding pages, "pages" by default
+	JournalsDirectory  string // Folder holding journals, "journals" by default
+	JournalFileFormat  string // Journal file name format, e.g. "yyyy_MM_dd"
+	JournalTitleFormat string // Journal page title format used in links, e.g. "MMM do, yyyy"
+	TripleLowbar       bool   // Namespaces are written "a___b.md" rather than "a%2Fb.md"
+}
+
+// loadLogseqSettings reads logseq/config.edn from a graph. Missing keys keep Logseq's defaults.
+// The EDN is only scanned for the handful of keys the importer needs; commented lines are ignored.
+func loadLogseqSettings(graphPath string) (logseqSettings, error) {
+	settings := logseqSettings{
+		PagesDirectory:     "pages",
+		JournalsDirectory:  "journals",
+		JournalFileFormat:  "yyyy_MM_dd",
+		JournalTitleFormat: "MMM do, yyyy",
+	}
+
+	data, err := os.ReadFile(filepath.Join(graphPath, "logseq", "config.edn"))
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("failed to read logseq settings: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, ";") {
+			continue
+		}
+		if m := logseqConfigPattern.FindStringSubmatch(line); m != nil {
+			key, value := m[1], m[2]
+			if strings.HasPrefix(value, `"`) {
+				value = strings.Trim(value, `"`)
+			}
+			switch key {
+			case "pages-directory":
+				settings.PagesDirectory = strings.Trim(value, "/")
+			case "journals-directory":
+				settings.JournalsDirectory = strings.Trim(value, "/")
+			case "journal/file-name-format":
+				settings.JournalFileFormat = value
+			case "journal/page-title-format":
+				settings.JournalTitleFormat = value
+			case "file/name-format":
+				settings.TripleLowbar = value == ":triple-lowbar"
+			}
+		}
+	}
+	return settings, nil
+}
+
+// logseqConfigPattern matches a config.edn entry with a string or keyword value.
+var logseqConfigPattern = regexp.MustCompile(`:((?:journal/|file/)?[\w-]+)\s+("[^"]*"|:[\w-]+)`)
+
+// Logseq syntax converted on import.
+var (
+	// logseqTaskPattern matches a block starting with a task marker, e.g. "- TODO Call Sam".
+	logseqTaskPattern = regexp.MustCompile(`^(\s*-\s+)(TODO|DOING|NOW|LATER|WAIT|WAITING|DONE|CANCELED|CANCELLED)\s+(.*)$`)
+	// logseqBlockRefPattern matches a ((block-uuid)) reference.
+	logseqBlockRefPattern = regexp.MustCompile(`\(\(([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})\)\)`)
+	// logseqEmbedPattern matches {{embed ((uuid))}} and {{embed [[page]]}} macros.
+	logseqEmbedPattern = regexp.MustCompile(`\{\{embed\s+(\(\([^)\n]+\)\)|\[\[[^\]\n]+\]\])\s*\}\}`)
+	// logseqMacroPattern matches the macros that have no equivalent, such as queries and renderers.
+	logseqMacroPattern = regexp.MustCompile(`\{\{(query|renderer|video|youtube|tweet|cloze|function)\b|#\+BEGIN_QUERY`)
+	// logseqOrdinalPattern matches the ordinal suffix of a day, e.g. "15th".
+	logseqOrdinalPattern = regexp.MustCompile(`(\d)(st|nd|rd|th)\b`)
+)
+
+// Conversion kinds counted in ImportReport.Conversions for Logseq graphs.
+const (
+	logseqConvertedJournals   = "journals"
+	logseqConvertedNamespaces = "namespaces"
+	logseqConvertedTasks      = "tasks"
+	logseqConvertedBlockRefs  = "blockReferences"
+	logseqConvertedEmbeds     = "embeds"
+)
+
+// logseqPage is a page or journal of the graph being imported.
+type logseqPage struct {
+	rel   string // Source path
+	title string // Page title as used in links
+	dest  string // Workspace path
+}
+
+// ImportLogseqGraph imports the pages, journals and assets of a Logseq graph into targetFolder
+// ("" for the workspace root).
+//
+// Journals are renamed to the workspace's daily note format and folder, and namespaced pages
+// ("project/tasks") become nested folders. Page links are rewritten to the imported paths,
+// TODO/DOING/DONE markers become task checkboxes, and ((block-uuid)) references become
+// [[note#^uuid]] links with a ^uuid anchor added to the referenced block in place of its id::
+// property. Other key:: value properties are kept; collapsed:: is dropped.
+// Existing workspace files are never overwritten. Org-mode pages, whiteboards and query
+// macros are reported as unsupported.
+//
+// With dryRun set, nothing is written and the report describes what would be imported.
+func (s *ImportService) ImportLogseqGraph(graphPath, targetFolder string, dryRun bool, progress ImportProgressFunc) (*ImportReport, error) {
+	graphPath, folder, err := s.importTarget(graphPath, targetFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	report := newImportReport(graphPath, folder, dryRun)
+	reportProgress(progress, ImportPhaseScan, 0, 0, "")
+
+	settings, err := loadLogseqSettings(graphPath)
+	if err != nil {
+		report.Unsupported = append(report.Unsupported, ImportIssue{Path: "logseq/config.edn", Reason: err.Error()})
+	}
+
+	src, unsupported, err := scanImportSource(graphPath, []string{"logseq"}, func(rel string) (string, bool) {
+		switch strings.ToLower(path.Ext(rel)) {
+		case ".org":
+			return "org-mode pages are not supported; convert them to Markdown first", true
+		case ".edn":
+			if strings.HasPrefix(rel, "whiteboards/") {
+				return "whiteboards are not supported", true
+			}
+		case ".excalidraw":
+			return "drawings are not supported", true
+		}
+		return "unsupported file type", true
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Unsupported = append(report.Unsupported, unsupported...)
+	report.AttachmentFolder = path.Join(folder, "assets")
+
+	contents := make(map[string][]byte, len(src.notes))
+	for _, rel := range src.notes {
+		content, err := os.ReadFile(filepath.Join(graphPath, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		contents[rel] = content
+	}
+
+	pages, titles := s.logseqPages(report, src, settings, folder, contents)
+	blocks := logseqBlockIDs(src.notes, contents)
+
+	destination := func(rel string) string {
+		if page, ok := pages[rel]; ok {
+			return page.dest
+		}
+		return path.Join(folder, rel)
+	}
+	links := &importLinkRewriter{
+		src:    src,
+		report: report,
+		resolve: func(target, fromRel string) (string, bool) {
+			if rel, ok := titles[strings.ToLower(strings.TrimSpace(target))]; ok {
+				return rel, true
+			}
+			if isAttachmentFile(target) {
+				return src.resolveWikilink(target, fromRel, true)
+			}
+			return "", false
+		},
+		destination: destination,
+	}
+
+	for i, rel := range src.notes {
+		if page, ok := pages[rel]; ok {
+			noteID := page.dest
+
+			converted := s.convertLogseqBlocks(report, rel, contents[rel])
+			converted = s.replaceLogseqEmbeds(report, converted, noteID, blocks, destination)
+			converted = links.rewrite(converted, rel, noteID, s.codeChecker(converted))
+			converted = s.replaceLogseqBlockRefs(report, converted, noteID, blocks, destination)
+
+			if err := s.writeNote(report, rel, noteID, converted); err != nil {
+				return nil, err
+			}
+		}
+		reportProgress(progress, ImportPhaseNotes, i+1, len(src.notes), rel)
+	}
+
+	for i, rel := range src.attachments {
+		if err := s.copyAttachment(report, rel, path.Join(folder, rel)); err != nil {
+			return nil, err
+		}
+		reportProgress(progress, ImportPhaseAttachments, i+1, len(src.attachments), rel)
+	}
+
+	return report, nil
+}
+
+// logseqPages assigns each note its title and workspace path, and indexes pages by lower-cased
+// title and alias. Journals are named with the workspace's daily note format; notes whose
+// destination is taken by an earlier note are skipped.
+func (s *ImportService) logseqPages(report *ImportReport, src *importSource, settings logseqSettings, folder string, contents map[string][]byte) (map[string]logseqPage, map[string]string) {
+	pages := make(map[string]logseqPage, len(src.notes))
+	titles := make(map[string]string)
+	taken := make(map[string]string)
+
+	fileLayout, fileOrdinal := logseqDateLayout(settings.JournalFileFormat)
+	fileLayout = strings.ReplaceAll(fileLayout, "\x00", "")
+
+	for _, rel := range src.notes {
+		name := strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+		body := contents[rel]
+		if _, _, start, ok := splitFrontmatter(body); ok {
+			body = body[start:]
+		}
+		properties := extractPageProperties(string(body))
+
+		var page logseqPage
+		switch dir := noteFolder(rel); {
+		case dir == settings.JournalsDirectory:
+			value := name
+			if fileOrdinal {
+				value = logseqOrdinalPattern.ReplaceAllString(value, "$1")
+			}
+			date, err := time.Parse(fileLayout, value)
+			if err != nil {
+				report.Unsupported = append(report.Unsupported, ImportIssue{Path: rel, Reason: "journal file name does not match the journal format; imported as a page"})
+				page = logseqPage{rel: rel, title: name, dest: path.Join(folder, rel)}
+				break
+			}
+			page = logseqPage{
+				rel:   rel,
+				title: formatLogseqDate(date, settings.JournalTitleFormat),
+				dest:  path.Join(folder, s.dailyNoteFolder, date.Format(s.dailyNoteFormat)+".md"),
+			}
+			report.Conversions[logseqConvertedJournals]++
+
+		case dir == settings.PagesDirectory:
+			title := properties["title"]
+			if title == "" {
+				title = logseqPageTitle(name, settings.TripleLowbar)
+			}
+			segments := strings.Split(title, "/")
+			for i, segment := range segments {
+				segments[i] = sanitizeFilename(segment)
+			}
+			if len(segments) > 1 {
+				report.Conversions[logseqConvertedNamespaces]++
+			}
+			page = logseqPage{rel: rel, title: title, dest: path.Join(folder, path.Join(segments...)+".md")}
+
+		default:
+			page = logseqPage{rel: rel, title: name, dest: path.Join(folder, rel)}
+		}
+
+		key := strings.ToLower(page.dest)
+		if other, ok := taken[key]; ok {
+			report.Skipped = append(report.Skipped, ImportIssue{Path: rel, Reason: fmt.Sprintf("%s is also imported from %s", page.dest, other)})
+			continue
+		}
+		taken[key] = rel
+		pages[rel] = page
+
+		titles[strings.ToLower(page.title)] = rel
+		for _, alias := range splitPropertyList(properties["alias"]) {
+			if _, ok := titles[strings.ToLower(alias)]; !ok {
+				titles[strings.ToLower(alias)] = rel
+			}
+		}
+	}
+
+	return pages, titles
+}
+
+// logseqPageTitle decodes a page title from its file name. Reserved characters are
+// percent-encoded, and namespaces are separated by "___" (or an encoded "/" in older graphs).
+func logseqPageTitle(name string, tripleLowbar bool) string {
+	if tripleLowbar {
+		name = strings.ReplaceAll(name, "___", "/")
+	}
+	if decoded, err := url.PathUnescape(name); err == nil {
+		name = decoded
+	}
+	return name
+}
+
+// logseqBlockIDs maps the id:: property of every block to the note declaring it.
+func logseqBlockIDs(notes []string, contents map[string][]byte) map[string]string {
+	ids := make(map[string]string)
+	for _, rel := range notes {
+		for _, line := range strings.Split(string(contents[rel]), "\n") {
+			if prop, ok := parsePropertyLine(line); ok && propertyKey(prop.key) == "id" && prop.indent != "" {
+				if _, exists := ids[strings.ToLower(prop.value)]; !exists {
+					ids[strings.ToLower(prop.value)] = rel
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// convertLogseqBlocks converts task markers to checkboxes and block id:: properties to ^anchors
+// on the block's first line, and drops collapsed:: properties. Fenced code is left alone.
+func (s *ImportService) convertLogseqBlocks(report *ImportReport, rel string, content []byte) []byte {
+	lines := strings.Split(string(content), "\n")
+	kept := make([]string, 0, len(lines))
+	inFence := false
+	blockLine := -1
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			kept = append(kept, line)
+			continue
+		}
+		if inFence {
+			kept = append(kept, line)
+			continue
+		}
+
+		if logseqMacroPattern.MatchString(line) {
+			macro := logseqMacroPattern.FindString(line)
+			report.Unsupported = append(report.Unsupported, ImportIssue{Path: rel, Reason: fmt.Sprintf("%s kept as-is", strings.TrimPrefix(macro, "{{"))})
+		}
+
+		if prop, ok := parsePropertyLine(line); ok && prop.indent != "" && blockLine >= 0 {
+			switch propertyKey(prop.key) {
+			case "id":
+				if isValidBlockID(prop.value) {
+					kept[blockLine] = strings.TrimRight(kept[blockLine], " \t\r") + " ^" + prop.value
+					continue
+				}
+			case "collapsed":
+				continue
+			}
+			kept = append(kept, line)
+			continue
+		}
+
+		if m := logseqTaskPattern.FindStringSubmatch(line); m != nil {
+			line = m[1] + logseqCheckbox(m[2], m[3])
+			report.Conversions[logseqConvertedTasks]++
+		}
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			blockLine = len(kept)
+		}
+		kept = append(kept, line)
+	}
+
+	return []byte(strings.Join(kept, "\n"))
+}
+
+// logseqCheckbox renders a Logseq task as a checkbox. Cancelled tasks are checked and struck through.
+func logseqCheckbox(marker, text string) string {
+	switch marker {
+	case "DONE":
+		return "[x] " + text
+	case "CANCELED", "CANCELLED":
+		return "[x] ~~" + text + "~~"
+	default:
+		return "[ ] " + text
+	}
+}
+
+// replaceLogseqEmbeds converts {{embed ((uuid))}} and {{embed [[page]]}} macros to ![[...]] embeds.
+// Page embeds keep their written title so the link rewriter can resolve them.
+func (s *ImportService) replaceLogseqEmbeds(report *ImportReport, content []byte, noteID string, blocks map[string]string, destination func(string) string) []byte {
+	inCode := s.codeChecker(content)
+
+	var buf bytes.Buffer
+	last := 0
+	for _, m := range logseqEmbedPattern.FindAllSubmatchIndex(content, -1) {
+		if inCode(m[0]) {
+			continue
+		}
+
+		arg := string(content[m[2]:m[3]])
+		var embed string
+		if strings.HasPrefix(arg, "((") {
+			id := strings.TrimSuffix(strings.TrimPrefix(arg, "(("), "))")
+			rel, ok := blocks[strings.ToLower(id)]
+			if !ok {
+				report.UnresolvedLinks = append(report.UnresolvedLinks, ImportLink{NoteID: noteID, Target: arg})
+				continue
+			}
+			embed = "![[" + strings.TrimSuffix(destination(rel), ".md") + "#^" + id + "]]"
+		} else {
+			embed = "!" + arg
+		}
+
+		buf.Write(content[last:m[0]])
+		buf.WriteString(embed)
+		last = m[1]
+		report.Conversions[logseqConvertedEmbeds]++
+	}
+
+	if last == 0 {
+		return content
+	}
+	buf.Write(content[last:])
+	return buf.Bytes()
+}
+
+// replaceLogseqBlockRefs converts ((uuid)) references to [[note#^uuid]] links.
+func (s *ImportService) replaceLogseqBlockRefs(report *ImportReport, content []byte, noteID string, blocks map[string]string, destination func(string) string) []byte {
+	inCode := s.codeChecker(content)
+
+	var buf bytes.Buffer
+	last := 0
+	for _, m := range logseqBlockRefPattern.FindAllSubmatchIndex(content, -1) {
+		if inCode(m[0]) {
+			continue
+		}
+
+		id := string(content[m[2]:m[3]])
+		rel, ok := blocks[strings.ToLower(id)]
+		if !ok {
+			report.UnresolvedLinks = append(report.UnresolvedLinks, ImportLink{NoteID: noteID, Target: string(content[m[0]:m[1]])})
+			continue
+		}
+
+		buf.Write(content[last:m[0]])
+		buf.WriteString("[[" + strings.TrimSuffix(destination(rel), ".md") + "#^" + id + "]]")
+		last = m[1]
+		report.Conversions[logseqConvertedBlockRefs]++
+	}
+
+	if last == 0 {
+		return content
+	}
+	buf.Write(content[last:])
+	return buf.Bytes()
+}
+
+// logseqDateLayout converts a Logseq date format ("yyyy_MM_dd", "MMM do, yyyy") to a Go time layout.
+// An ordinal day ("do") is written as the day followed by a NUL placeholder for its suffix;
+// ordinal reports whether the format has one.
+func logseqDateLayout(format string) (layout string, ordinal bool) {
+	var b strings.Builder
+	runes := []rune(format)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		j := i
+		for j < len(runes) && runes[j] == r {
+			j++
+		}
+		n := j - i
+
+		switch r {
+		case 'y':
+			if n == 2 {
+				b.WriteString("06")
+			} else {
+				b.WriteString("2006")
+			}
+		case 'M':
+			b.WriteString([]string{"1", "01", "Jan", "January"}[min(n, 4)-1])
+		case 'd':
+			if n == 1 && j < len(runes) && runes[j] == 'o' {
+				b.WriteString("2\x00")
+				ordinal = true
+				j++
+			} else if n == 1 {
+				b.WriteString("2")
+			} else {
+				b.WriteString("02")
+			}
+		case 'E':
+			if n >= 4 {
+				b.WriteString("Monday")
+			} else {
+				b.WriteString("Mon")
+			}
+		default:
+			b.WriteString(string(runes[i:j]))
+		}
+		i = j
+	}
+	return b.String(), ordinal
+}
+
+// formatLogseqDate formats t with a Logseq date format, e.g. "Jan 15th, 2024" for "MMM do, yyyy".
+func formatLogseqDate(t time.Time, format string) string {
+	layout, ordinal := logseqDateLayout(format)
+	if !ordinal {
+		return t.Format(layout)
+	}
+
+	suffix := "th"
+	if day := t.Day(); day < 11 || day > 13 {
+		switch day % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strings.ReplaceAll(t.Format(layout), "\x00", suffix)
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+var logseqTestGraph = map[string]string{
+	"logseq/config.edn":       "{:meta/version 1\n ;; :journal/page-title-format \"yyyy-MM-dd\"\n :file/name-format :triple-lowbar\n :journal/page-title-format \"MMM do, yyyy\"}\n",
+	"logseq/bak/pages/old.md": "- backup\n",
+	"journals/2024_01_15.md": "- TODO Call Sam\n- DONE Write report\n  collapsed:: true\n- Met with [[Project___Tasks]]? No: [[Project/Tasks]] and [[Reading List]]\n" +
+		"- See ((6540a1b2-0000-4000-8000-000000000001)) and [[Nowhere]]\n- {{embed ((6540a1b2-0000-4000-8000-000000000001))}}\n",
+	"pages/Project___Tasks.md": "title:: Project/Tasks\n\n- Ship the importer\n  id:: 6540a1b2-0000-4000-8000-000000000001\n- CANCELED Old plan\n" +
+		"- ![diagram](../assets/diagram.png)\n- Back to [[Jan 15th, 2024]]\n- ```\n  ((6540a1b2-0000-4000-8000-000000000001))\n  ```\n",
+	"pages/Reading List.md": "alias:: books\n\n- {{query (todo now)}}\n- {{embed [[books]]}}\n",
+	"assets/diagram.png":    "png",
+	"pages/notes.org":       "* Org page\n",
+	"whiteboards/board.edn": "{}",
+}
+
+func TestImportService_ImportLogseqGraph(t *testing.T) {
+	importer, fs := newTestImportService(t)
+	importer.SetDailyNotes("2006-01-02", "daily")
+
+	graph := t.TempDir()
+	writeTestTree(t, graph, logseqTestGraph)
+
+	report, err := importer.ImportLogseqGraph(graph, "", false, nil)
+	if err != nil {
+		t.Fatalf("ImportLogseqGraph() error = %v", err)
+	}
+
+	if want := []string{"daily/2024-01-15.md", "Project/Tasks.md", "Reading List.md"}; !slices.Equal(report.Notes, want) {
+		t.Errorf("Notes = %v, want %v", report.Notes, want)
+	}
+	if want := []string{"assets/diagram.png"}; !slices.Equal(report.Attachments, want) {
+		t.Errorf("Attachments = %v, want %v", report.Attachments, want)
+	}
+
+	journal, err := fs.ReadFile("daily/2024-01-15.md")
+	if err != nil {
+		t.Fatalf("ReadFile(journal) error = %v", err)
+	}
+	wantJournal := "- [ ] Call Sam\n- [x] Write report\n- Met with [[Project___Tasks]]? No: [[Project/Tasks]] and [[Reading List]]\n" +
+		"- See [[Project/Tasks#^6540a1b2-0000-4000-8000-000000000001]] and [[Nowhere]]\n- ![[Project/Tasks#^6540a1b2-0000-4000-8000-000000000001]]\n"
+	if string(journal) != wantJournal {
+		t.Errorf("journal =\n%s\nwant\n%s", journal, wantJournal)
+	}
+
+	tasks, _ := fs.ReadFile("Project/Tasks.md")
+	wantTasks := "title:: Project/Tasks\n\n- Ship the importer ^6540a1b2-0000-4000-8000-000000000001\n- [x] ~~Old plan~~\n" +
+		"- ![diagram](../assets/diagram.png)\n- Back to [[daily/2024-01-15|Jan 15th, 2024]]\n- ```\n  ((6540a1b2-0000-4000-8000-000000000001))\n  ```\n"
+	if string(tasks) != wantTasks {
+		t.Errorf("Project/Tasks.md =\n%s\nwant\n%s", tasks, wantTasks)
+	}
+
+	reading, _ := fs.ReadFile("Reading List.md")
+	if want := "alias:: books\n\n- {{query (todo now)}}\n- ![[Reading List]]\n"; string(reading) != want {
+		t.Errorf("Reading List.md = %q, want %q", reading, want)
+	}
+
+	wantUnresolved := []ImportLink{
+		{NoteID: "daily/2024-01-15.md", Target: "Project___Tasks"},
+		{NoteID: "daily/2024-01-15.md", Target: "Nowhere"},
+	}
+	if !slices.Equal(report.UnresolvedLinks, wantUnresolved) {
+		t.Errorf("UnresolvedLinks = %v, want %v", report.UnresolvedLinks, wantUnresolved)
+	}
+
+	wantUnsupported := []ImportIssue{
+		{Path: "pages/notes.org", Reason: "org-mode pages are not supported; convert them to Markdown first"},
+		{Path: "whiteboards/board.edn", Reason: "whiteboards are not supported"},
+		{Path: "pages/Reading List.md", Reason: "query kept as-is"},
+	}
+	if !slices.Equal(report.Unsupported, wantUnsupported) {
+		t.Errorf("Unsupported = %v, want %v", report.Unsupported, wantUnsupported)
+	}
+
+	wantConversions := map[string]int{"journals": 1, "namespaces": 1, "tasks": 3, "blockReferences": 1, "embeds": 2}
+	for kind, want := range wantConversions {
+		if report.Conversions[kind] != want {
+			t.Errorf("Conversions[%s] = %d, want %d", kind, report.Conversions[kind], want)
+		}
+	}
+
+	if _, err := fs.StatFile("logseq/bak/pages/old.md"); err == nil {
+		t.Error("logseq/ folder was imported")
+	}
+}
+
+func TestLogseqPageTitle(t *testing.T) {
+	tests := []struct {
+		name         string
+		tripleLowbar bool
+		want         string
+	}{
+		{"Project___Tasks", true, "Project/Tasks"},
+		{"Project___Tasks", false, "Project___Tasks"},
+		{"Project%2FTasks", false, "Project/Tasks"},
+		{"What%3F", true, "What?"},
+		{"100% done", true, "100% done"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := logseqPageTitle(tt.name, tt.tripleLowbar); got != tt.want {
+				t.Errorf("logseqPageTitle(%q, %v) = %q, want %q", tt.name, tt.tripleLowbar, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatLogseqDate(t *testing.T) {
+	tests := []struct {
+		date   time.Time
+		format string
+		want   string
+	}{
+		{time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), "MMM do, yyyy", "Jan 15th, 2024"},
+		{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), "MMM do, yyyy", "Mar 1st, 2024"},
+		{time.Date(2024, 3, 22, 0, 0, 0, 0, time.UTC), "MMMM do, yyyy", "March 22nd, 2024"},
+		{time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC), "do MMM yy", "13th Mar 24"},
+		{time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), "EEE, MM/dd/yyyy", "Tue, 03/05/2024"},
+		{time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), "yyyy_MM_dd", "2024_03_05"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := formatLogseqDate(tt.date, tt.format); got != tt.want {
+				t.Errorf("formatLogseqDate(%s, %q) = %q, want %q", tt.date.Format("2006-01-02"), tt.format, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,382 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"notes/backend/domain"
+)
+
+// noteBaseLimit is how many loaded or saved note versions are kept as merge bases.
+const noteBaseLimit = 128
+
+// noteBases keeps the most recent note versions the app loaded or wrote, by content hash,
+// so a save based on one of them can be merged with changes made on disk since.
+type noteBases struct {
+	mu      sync.Mutex
+	content map[string][]byte
+	order   []string // Hashes, oldest first
+}
+
+// add keeps content as a merge base, forgetting the oldest base beyond noteBaseLimit.
+func (b *noteBases) add(hash string, content []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.content == nil {
+		b.content = make(map[string][]byte)
+	}
+	if _, ok := b.content[hash]; ok {
+		return
+	}
+	b.content[hash] = content
+	b.order = append(b.order, hash)
+	if len(b.order) > noteBaseLimit {
+		delete(b.content, b.order[0])
+		b.order = b.order[1:]
+	}
+}
+
+// get returns the base with the given hash.
+func (b *noteBases) get(hash string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	content, ok := b.content[hash]
+	return content, ok
+}
+
+// mergeChange is a contiguous change one side made to the base: the base lines [start, end)
+// were replaced by lines. An insertion has start == end.
+type mergeChange struct {
+	start, end int
+	lines      []string
+}
+
+// MergeLines merges the changes local and disk each made to base, line by line.
+// Changes to separate regions are combined, including edits to neighbouring lines; identical changes
+// on both sides are taken once. Changes that overlap, or insertions next to a change of the other
+// side, whose order is ambiguous, are conflicts unless both sides made the same change: the merged
+// text keeps the disk lines there, and each conflict is returned as a hunk.
+// Frontmatter, fenced code blocks and tables of base are merged as single units: changes both sides
+// made to different lines of one of them are a conflict covering the whole block.
+// The merge is clean when no hunks are returned.
+func MergeLines(base, local, disk string) (string, []domain.MergeHunk) {
+	baseLines := splitLines(base)
+	blocks := atomicBlocks(baseLines)
+	localChanges := lineChanges(baseLines, splitLines(local))
+	diskChanges := lineChanges(baseLines, splitLines(disk))
+
+	merged := []string{}
+	hunks := []domain.MergeHunk{}
+	pos := 0
+	for len(localChanges) > 0 || len(diskChanges) > 0 {
+		// Start a group at the earliest change, then pull in every change from either side that
+		// overlaps the group, until it stops growing.
+		start, end := nextChangeRange(localChanges, diskChanges)
+		var groupLocal, groupDisk []mergeChange
+		for grew := true; grew; {
+			grew = false
+			if blockStart, blockEnd := expandToBlocks(start, end, blocks); blockStart != start || blockEnd != end {
+				start, end = blockStart, blockEnd
+				grew = true
+			}
+			for len(localChanges) > 0 && localChanges[0].overlaps(start, end) {
+				end = max(end, localChanges[0].end)
+				groupLocal = append(groupLocal, localChanges[0])
+				localChanges = localChanges[1:]
+				grew = true
+			}
+			for len(diskChanges) > 0 && diskChanges[0].overlaps(start, end) {
+				end = max(end, diskChanges[0].end)
+				groupDisk = append(groupDisk, diskChanges[0])
+				diskChanges = diskChanges[1:]
+				grew = true
+			}
+		}
+
+		merged = append(merged, baseLines[pos:start]...)
+		localLines := applyChanges(baseLines, start, end, groupLocal)
+		diskLines := applyChanges(baseLines, start, end, groupDisk)
+		switch {
+		case len(groupDisk) == 0:
+			merged = append(merged, localLines...)
+		case len(groupLocal) == 0 || slices.Equal(localLines, diskLines):
+			merged = append(merged, diskLines...)
+		default:
+			hunks = append(hunks, domain.MergeHunk{
+				Line:  len(merged) + 1,
+				Base:  slices.Clone(baseLines[start:end]),
+				Local: localLines,
+				Disk:  diskLines,
+			})
+			merged = append(merged, diskLines...)
+		}
+		pos = end
+	}
+	merged = append(merged, baseLines[pos:]...)
+
+	text := strings.Join(merged, "\n")
+	if len(merged) > 0 && (strings.HasSuffix(disk, "\n") || (disk == "" && strings.HasSuffix(local, "\n"))) {
+		text += "\n"
+	}
+	return text, hunks
+}
+
+// overlaps reports whether c, starting at or after start, cannot be merged independently of the
+// changes covering the base range [start, end): it replaces lines in the range, or it or the range
+// is an insertion at the range's edge.
+func (c mergeChange) overlaps(start, end int) bool {
+	if c.start < end {
+		return true
+	}
+	return c.start == end && (c.start == c.end || start == end)
+}
+
+// lineRange is the range of lines [start, end).
+type lineRange struct {
+	start, end int
+}
+
+// tableDelimiterPattern matches the delimiter row below a GFM table header, e.g. "| --- | :-: |".
+var tableDelimiterPattern = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+
+// atomicBlocks returns the line ranges of the frontmatter, fenced code blocks and tables in lines,
+// in order. An unclosed frontmatter or fence is not a block; an unclosed fence runs to the end.
+func atomicBlocks(lines []string) []lineRange {
+	blocks := []lineRange{}
+	i := 0
+	if len(lines) > 0 && strings.TrimRight(lines[0], " \t") == "---" {
+		for j := 1; j < len(lines); j++ {
+			if closing := strings.TrimRight(lines[j], " \t"); closing == "---" || closing == "..." {
+				blocks = append(blocks, lineRange{0, j + 1})
+				i = j + 1
+				break
+			}
+		}
+	}
+
+	for i < len(lines) {
+		if fence := codeFence(lines[i]); fence != "" {
+			end := len(lines)
+			for j := i + 1; j < len(lines); j++ {
+				if closing := strings.TrimSpace(lines[j]); strings.HasPrefix(closing, fence) &&
+					strings.Trim(closing, fence[:1]) == "" {
+					end = j + 1
+					break
+				}
+			}
+			blocks = append(blocks, lineRange{i, end})
+			i = end
+			continue
+		}
+		if i+1 < len(lines) && strings.Contains(lines[i], "|") && strings.Contains(lines[i+1], "|") &&
+			tableDelimiterPattern.MatchString(lines[i+1]) {
+			end := i + 2
+			for end < len(lines) && strings.TrimSpace(lines[end]) != "" && strings.Contains(lines[end], "|") {
+				end++
+			}
+			blocks = append(blocks, lineRange{i, end})
+			i = end
+			continue
+		}
+		i++
+	}
+	return blocks
+}
+
+// codeFence returns the fence that opens a fenced code block on line ("```" or "~~~", or longer),
+// or "" when the line does not open one.
+func codeFence(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < 3 || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return ""
+	}
+	n := len(trimmed) - len(strings.TrimLeft(trimmed, trimmed[:1]))
+	if n < 3 || (trimmed[0] == '`' && strings.Contains(trimmed[n:], "`")) {
+		return ""
+	}
+	return trimmed[:n]
+}
+
+// expandToBlocks widens the base range [start, end) to cover every atomic block it touches: one it
+// shares lines with or, for an insertion, one it is inserted into.
+func expandToBlocks(start, end int, blocks []lineRange) (int, int) {
+	for _, block := range blocks {
+		touches := start < block.end && end > block.start
+		if start == end {
+			touches = block.start < start && start < block.end
+		}
+		if touches {
+			start, end = min(start, block.start), max(end, block.end)
+		}
+	}
+	return start, end
+}
+
+// lineChanges returns the changes that turn base into other, in base order.
+func lineChanges(base, other []string) []mergeChange {
+	changes := []mergeChange{}
+	var current *mergeChange
+	pos := 0 // Index in base of the next line the diff reaches
+	for _, line := range diffLineSlices(base, other) {
+		if line.Op == DiffEqual {
+			if current != nil {
+				changes = append(changes, *current)
+				current = nil
+			}
+			pos++
+			continue
+		}
+		if current == nil {
+			current = &mergeChange{start: pos, end: pos, lines: []string{}}
+		}
+		if line.Op == DiffDelete {
+			pos++
+			current.end = pos
+		} else {
+			current.lines = append(current.lines, line.Text)
+		}
+	}
+	if current != nil {
+		changes = append(changes, *current)
+	}
+	return changes
+}
+
+// nextChangeRange returns the base range of whichever side's next change starts first.
+func nextChangeRange(local, disk []mergeChange) (int, int) {
+	switch {
+	case len(disk) == 0:
+		return local[0].start, local[0].end
+	case len(local) == 0 || disk[0].start < local[0].start:
+		return disk[0].start, disk[0].end
+	default:
+		return local[0].start, local[0].end
+	}
+}
+
+// applyChanges returns the base lines [start, end) with one side's changes in that range applied.
+func applyChanges(base []string, start, end int, changes []mergeChange) []string {
+	lines := []string{}
+	pos := start
+	for _, change := range changes {
+		lines = append(lines, base[pos:change.start]...)
+		lines = append(lines, change.lines...)
+		pos = change.end
+	}
+	return append(lines, base[pos:end]...)
+}
+
+// mergeExternalChange saves note, which was loaded as the version with note.Hash, over disk, the
+// different content now on disk. See SaveNoteMerging.
+func (s *NoteService) mergeExternalChange(note *domain.Note, disk []byte) (*ParsedNote, error) {
+	base, haveBase := s.noteBase(note.ID, note.Hash)
+
+	// The local content is the note's changes applied to the version it was loaded from.
+	pending := *note
+	against := disk
+	if haveBase {
+		against = base
+	}
+	local, _, err := s.updateNoteContent(&pending, against)
+	if err != nil {
+		return nil, err
+	}
+
+	conflict := domain.NoteConflict{
+		NoteID:   note.ID,
+		BaseHash: note.Hash,
+		DiskHash: contentHash(disk),
+		Local:    string(local),
+		Disk:     string(disk),
+		Merged:   string(disk),
+		Hunks:    []domain.MergeHunk{},
+	}
+	if haveBase {
+		merged, hunks := MergeLines(string(base), string(local), string(disk))
+		if len(hunks) == 0 {
+			return s.writeMerged(note, disk, []byte(merged))
+		}
+		conflict.Merged = merged
+		conflict.Hunks = hunks
+	}
+
+	copyPath, err := s.writeConflictCopy(note.Path, local)
+	if err != nil {
+		return nil, err
+	}
+	conflict.CopyPath = copyPath
+	return nil, &domain.ErrConflict{NoteConflict: conflict}
+}
+
+// writeMerged writes the clean merge of a note over the disk version and reloads note from it.
+func (s *NoteService) writeMerged(note *domain.Note, disk, merged []byte) (*ParsedNote, error) {
+	if err := s.snapshot(note.ID, disk); err != nil {
+		return nil, err
+	}
+	if err := s.fs.WriteFile(note.Path, merged); err != nil {
+		return nil, err
+	}
+
+	parsed, err := s.reloadNote(note, merged)
+	if err != nil {
+		return nil, err
+	}
+	return parsed, s.recordMetadata(note)
+}
+
+// noteBase returns the version of a note with the given hash: one the app loaded or wrote
+// recently, or a snapshot kept by the snapshot store.
+func (s *NoteService) noteBase(noteID, hash string) ([]byte, bool) {
+	if content, ok := s.bases.get(hash); ok {
+		return content, true
+	}
+	if s.snapshots == nil {
+		return nil, false
+	}
+	workspace, err := s.fs.GetCurrentWorkspace()
+	if err != nil {
+		return nil, false
+	}
+	content, err := s.snapshots.Content(workspace.ID, noteID, hash)
+	if err != nil {
+		return nil, false
+	}
+	return content, true
+}
+
+// writeConflictCopy writes content beside a note as "name (conflict 2026-10-16).md", numbering
+// further copies of the same day. A copy of the same day with the same content is reused, so
+// repeated saves of the same edits do not pile up copies. Returns the copy's path.
+func (s *NoteService) writeConflictCopy(notePath string, content []byte) (string, error) {
+	dir, file := path.Split(strings.ReplaceAll(notePath, "\\", "/"))
+	ext := path.Ext(file)
+	stem := strings.TrimSuffix(file, ext)
+	day := time.Now().Format("2006-01-02")
+
+	for n := 1; ; n++ {
+		suffix := day
+		if n > 1 {
+			suffix = fmt.Sprintf("%s %d", day, n)
+		}
+		copyPath := dir + stem + " (conflict " + suffix + ")" + ext
+
+		existing, err := s.fs.ReadFile(copyPath)
+		if err == nil {
+			if string(existing) == string(content) {
+				return copyPath, nil
+			}
+			continue
+		}
+		if err := s.fs.WriteFile(copyPath, content); err != nil {
+			return "", fmt.Errorf("failed to write conflict copy: %w", err)
+		}
+		return copyPath, nil
+	}
+}
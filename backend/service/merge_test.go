@@ -0,0 +1,167 @@
+package service
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMergeLines(t *testing.T) {
+	base := "# Plan\n\nalpha\nbeta\ngamma\ndelta\n"
+
+	tests := []struct {
+		name       string
+		local      string
+		disk       string
+		want       string
+		wantHunks  int
+		wantLocal  []string
+		wantDisk   []string
+		wantAtLine int
+	}{
+		{
+			name:  "only local changed",
+			local: "# Plan\n\nalpha\nBETA\ngamma\ndelta\n",
+			disk:  base,
+			want:  "# Plan\n\nalpha\nBETA\ngamma\ndelta\n",
+		},
+		{
+			name:  "separate regions",
+			local: "# Plan\n\nALPHA\nbeta\ngamma\ndelta\n",
+			disk:  "# Plan\n\nalpha\nbeta\ngamma\ndelta\nepsilon\n",
+			want:  "# Plan\n\nALPHA\nbeta\ngamma\ndelta\nepsilon\n",
+		},
+		{
+			name:  "neighbouring lines",
+			local: "# Plan\n\nalpha\nBETA\ngamma\ndelta\n",
+			disk:  "# Plan\n\nalpha\nbeta\nGAMMA\ndelta\n",
+			want:  "# Plan\n\nalpha\nBETA\nGAMMA\ndelta\n",
+		},
+		{
+			name:  "same change on both sides",
+			local: "# Plan\n\nalpha\nBETA\ngamma\n",
+			disk:  "# Plan\n\nalpha\nBETA\ngamma\n",
+			want:  "# Plan\n\nalpha\nBETA\ngamma\n",
+		},
+		{
+			name:  "local deletion and disk insertion elsewhere",
+			local: "# Plan\n\nalpha\ngamma\ndelta\n",
+			disk:  "# Plan\n\nzero\nalpha\nbeta\ngamma\ndelta\n",
+			want:  "# Plan\n\nzero\nalpha\ngamma\ndelta\n",
+		},
+		{
+			name:       "same line changed differently",
+			local:      "# Plan\n\nalpha\nbeta (mine)\ngamma\ndelta\n",
+			disk:       "# Plan\n\nalpha\nbeta (theirs)\ngamma\nDELTA\n",
+			want:       "# Plan\n\nalpha\nbeta (theirs)\ngamma\nDELTA\n",
+			wantHunks:  1,
+			wantLocal:  []string{"beta (mine)"},
+			wantDisk:   []string{"beta (theirs)"},
+			wantAtLine: 4,
+		},
+		{
+			name:       "insertions at the same place",
+			local:      "# Plan\n\nalpha\nbeta\ngamma\ndelta\nmine\n",
+			disk:       "# Plan\n\nalpha\nbeta\ngamma\ndelta\ntheirs\n",
+			want:       "# Plan\n\nalpha\nbeta\ngamma\ndelta\ntheirs\n",
+			wantHunks:  1,
+			wantLocal:  []string{"mine"},
+			wantDisk:   []string{"theirs"},
+			wantAtLine: 7,
+		},
+		{
+			name:       "local edit inside a disk deletion",
+			local:      "# Plan\n\nalpha\nbeta\nGAMMA\ndelta\n",
+			disk:       "# Plan\n\nalpha\ndelta\n",
+			want:       "# Plan\n\nalpha\ndelta\n",
+			wantHunks:  1,
+			wantLocal:  []string{"beta", "GAMMA"},
+			wantDisk:   []string{},
+			wantAtLine: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, hunks := MergeLines(base, tt.local, tt.disk)
+			if merged != tt.want {
+				t.Errorf("merged = %q, want %q", merged, tt.want)
+			}
+			if len(hunks) != tt.wantHunks {
+				t.Fatalf("hunks = %+v, want %d", hunks, tt.wantHunks)
+			}
+			if tt.wantHunks == 0 {
+				return
+			}
+			hunk := hunks[0]
+			if !slices.Equal(hunk.Local, tt.wantLocal) || !slices.Equal(hunk.Disk, tt.wantDisk) || hunk.Line != tt.wantAtLine {
+				t.Errorf("hunk = %+v, want local %q, disk %q at line %d", hunk, tt.wantLocal, tt.wantDisk, tt.wantAtLine)
+			}
+		})
+	}
+}
+
+func TestMergeLines_Blocks(t *testing.T) {
+	base := "---\ntitle: Plan\ntags: [a]\n---\n# Plan\n\n```go\nx := 1\ny := 2\n```\n\n| Name | Qty |\n| --- | --- |\n| nuts | 1 |\n| bolts | 2 |\n\nclosing\n"
+
+	tests := []struct {
+		name      string
+		local     string
+		disk      string
+		want      string
+		wantHunks int
+		wantLocal []string
+		wantDisk  []string
+	}{
+		{
+			name:  "edits to separate blocks",
+			local: "---\ntitle: Plan\ntags: [a]\n---\n# Plan\n\n```go\nx := 10\ny := 2\n```\n\n| Name | Qty |\n| --- | --- |\n| nuts | 1 |\n| bolts | 2 |\n\nclosing\n",
+			disk:  "---\ntitle: Plan\ntags: [a]\n---\n# Plan\n\n```go\nx := 1\ny := 2\n```\n\n| Name | Qty |\n| --- | --- |\n| nuts | 1 |\n| bolts | 20 |\n\nclosing\n",
+			want:  "---\ntitle: Plan\ntags: [a]\n---\n# Plan\n\n```go\nx := 10\ny := 2\n```\n\n| Name | Qty |\n| --- | --- |\n| nuts | 1 |\n| bolts | 20 |\n\nclosing\n",
+		},
+		{
+			name:      "different lines of one code block",
+			local:     "---\ntitle: Plan\ntags: [a]\n---\n# Plan\n\n```go\nx := 10\ny := 2\n```\n\n| Name | Qty |\n| --- | --- |\n| nuts | 1 |\n| bolts | 2 |\n\nclosing\n",
+			disk:      "---\ntitle: Plan\ntags: [a]\n---\n# Plan\n\n```go\nx := 1\ny := 20\n```\n\n| Name | Qty |\n| --- | --- |\n| nuts | 1 |\n| bolts | 2 |\n\nclosing\n",
+			want:      "---\ntitle: Plan\ntags: [a]\n---\n# Plan\n\n```go\nx := 1\ny := 20\n```\n\n| Name | Qty |\n| --- | --- |\n| nuts | 1 |\n| bolts | 2 |\n\nclosing\n",
+			wantHunks: 1,
+			wantLocal: []string{"```go", "x := 10", "y := 2", "```"},
+			wantDisk:  []string{"```go", "x := 1", "y := 20", "```"},
+		},
+		{
+			name:      "different rows of one table",
+			local:     "---\ntitle: Plan\ntags: [a]\n---\n# Plan\n\n```go\nx := 1\ny := 2\n```\n\n| Name | Qty |\n| --- | --- |\n| nuts | 5 |\n| bolts | 2 |\n\nclosing\n",
+			disk:      "---\ntitle: Plan\ntags: [a]\n---\n# Plan\n\n```go\nx := 1\ny := 2\n```\n\n| Name | Qty |\n| --- | --- |\n| nuts | 1 |\n| bolts | 2 |\n| washers | 9 |\n\nclosing\n",
+			want:      "---\ntitle: Plan\ntags: [a]\n---\n# Plan\n\n```go\nx := 1\ny := 2\n```\n\n| Name | Qty |\n| --- | --- |\n| nuts | 1 |\n| bolts | 2 |\n| washers | 9 |\n\nclosing\n",
+			wantHunks: 1,
+			wantLocal: []string{"| Name | Qty |", "| --- | --- |", "| nuts | 5 |", "| bolts | 2 |"},
+			wantDisk:  []string{"| Name | Qty |", "| --- | --- |", "| nuts | 1 |", "| bolts | 2 |", "| washers | 9 |"},
+		},
+		{
+			name:      "different frontmatter keys",
+			local:     "---\ntitle: Better plan\ntags: [a]\n---\n# Plan\n\n```go\nx := 1\ny := 2\n```\n\n| Name | Qty |\n| --- | --- |\n| nuts | 1 |\n| bolts | 2 |\n\nclosing\n",
+			disk:      "---\ntitle: Plan\ntags: [a, b]\n---\n# Plan\n\n```go\nx := 1\ny := 2\n```\n\n| Name | Qty |\n| --- | --- |\n| nuts | 1 |\n| bolts | 2 |\n\nclosing\n",
+			want:      "---\ntitle: Plan\ntags: [a, b]\n---\n# Plan\n\n```go\nx := 1\ny := 2\n```\n\n| Name | Qty |\n| --- | --- |\n| nuts | 1 |\n| bolts | 2 |\n\nclosing\n",
+			wantHunks: 1,
+			wantLocal: []string{"---", "title: Better plan", "tags: [a]", "---"},
+			wantDisk:  []string{"---", "title: Plan", "tags: [a, b]", "---"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, hunks := MergeLines(base, tt.local, tt.disk)
+			if merged != tt.want {
+				t.Errorf("merged = %q, want %q", merged, tt.want)
+			}
+			if len(hunks) != tt.wantHunks {
+				t.Fatalf("hunks = %+v, want %d", hunks, tt.wantHunks)
+			}
+			if tt.wantHunks == 0 {
+				return
+			}
+			if !slices.Equal(hunks[0].Local, tt.wantLocal) || !slices.Equal(hunks[0].Disk, tt.wantDisk) {
+				t.Errorf("hunk = %+v, want local %q and disk %q", hunks[0], tt.wantLocal, tt.wantDisk)
+			}
+		})
+	}
+}
@@ -2,10 +2,13 @@ package service
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -15,7 +18,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
 	"github.com/yuin/goldmark/text"
+	"go.abhg.dev/goldmark/wikilink"
 	"gopkg.in/yaml.v3"
 
 	_ "embed"
@@ -26,16 +32,81 @@ var WelcomeTutorialContent string
 
 // NoteService handles note operations including CRUD and parsing.
 type NoteService struct {
-	fs     *FilesystemService
-	parser goldmark.Markdown
+	fs          *FilesystemService
+	parser      goldmark.Markdown
+	renderer    goldmark.Markdown
+	xhtml       goldmark.Markdown // Renderer for XHTML output, used by EPUB export
+	queryRunner QueryRunner
+	metadata    *MetadataStore
+	snapshots   *SnapshotStore
+	attachments *AttachmentService
+	policy      domain.FrontmatterPolicy
+	defaultTags []string
+	bases       noteBases
+	// trashRetention is how many days deleted notes stay in the trash; 0 keeps them until emptied
+	trashRetention int
 }
 
 // NewNoteService creates a new note service.
+// Notes are saved with the preserve-existing frontmatter policy until SetFrontmatterPolicy is called.
 func NewNoteService(fs *FilesystemService) *NoteService {
-	return &NoteService{
-		fs:     fs,
-		parser: goldmark.New(),
+	s := &NoteService{
+		fs:             fs,
+		parser:         goldmark.New(goldmark.WithExtensions(&wikilink.Extender{})),
+		policy:         domain.FrontmatterPreserveExisting,
+		trashRetention: DefaultTrashRetention,
 	}
+	s.renderer = goldmark.New(goldmark.WithExtensions(
+		&queryBlockExtension{notes: s},
+		&attachmentExtension{notes: s},
+	))
+	s.xhtml = goldmark.New(
+		goldmark.WithExtensions(&queryBlockExtension{notes: s}, &attachmentExtension{notes: s}),
+		goldmark.WithRendererOptions(gmhtml.WithXHTML()),
+	)
+	return s
+}
+
+// SetQueryRunner attaches the runner used to evaluate ```query blocks in RenderMarkdown.
+// Without a runner, query blocks render as plain code blocks.
+func (s *NoteService) SetQueryRunner(runner QueryRunner) {
+	s.queryRunner = runner
+}
+
+// SetMetadataStore attaches the database used for note timestamps that are not written to frontmatter.
+// Without a store, such timestamps fall back to file modification times.
+func (s *NoteService) SetMetadataStore(store *MetadataStore) {
+	s.metadata = store
+}
+
+// SetSnapshotStore attaches the store that keeps prior versions of notes overwritten by SaveNote.
+// Without a store (the default, and the choice for git workspaces), no snapshots are taken.
+func (s *NoteService) SetSnapshotStore(store *SnapshotStore) {
+	s.snapshots = store
+}
+
+// SetAttachmentService attaches the attachment index used to resolve embeds in RenderMarkdown
+// and to rewrite links when an attachment is renamed.
+func (s *NoteService) SetAttachmentService(attachments *AttachmentService) {
+	s.attachments = attachments
+}
+
+// SetFrontmatterPolicy sets when saves may write created/modified (and new-note titles) into frontmatter.
+func (s *NoteService) SetFrontmatterPolicy(policy domain.FrontmatterPolicy) {
+	if !policy.Valid() {
+		policy = domain.FrontmatterPreserveExisting
+	}
+	s.policy = policy
+}
+
+// FrontmatterPolicy returns the policy applied when saving notes.
+func (s *NoteService) FrontmatterPolicy() domain.FrontmatterPolicy {
+	return s.policy
+}
+
+// SetDefaultTags sets the tags written into the frontmatter of newly created notes.
+func (s *NoteService) SetDefaultTags(tags []string) {
+	s.defaultTags = slices.Clone(tags)
 }
 
 // ScaffoldWorkspace creates a new workspace directory with a welcome tutorial note.
@@ -60,13 +131,35 @@ func (s *NoteService) ScaffoldWorkspace(path string) error {
 	return nil
 }
 
+// ParsedNote is a note loaded from disk together with the tasks in it,
+// both read from a single parse of its Markdown.
+type ParsedNote struct {
+	Note  *domain.Note
+	Tasks []domain.Task
+}
+
 // GetNote retrieves a note by its ID (relative path).
 func (s *NoteService) GetNote(id string) (*domain.Note, error) {
+	parsed, err := s.LoadNote(id)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Note, nil
+}
+
+// LoadNote retrieves a note like GetNote, along with its tasks. The note's blocks, tags, links
+// and tasks all come from one parse of its body, which is what indexing a workspace needs.
+func (s *NoteService) LoadNote(id string) (*ParsedNote, error) {
 	content, err := s.fs.ReadFile(id)
 	if err != nil {
 		return nil, err
 	}
+	return s.parseFile(id, content)
+}
 
+// parseFile parses content, read from or just written to the note file id, remembering it as a
+// version of the note merges can start from.
+func (s *NoteService) parseFile(id string, content []byte) (*ParsedNote, error) {
 	workspace, err := s.fs.GetCurrentWorkspace()
 	if err != nil {
 		return nil, err
@@ -78,12 +171,13 @@ func (s *NoteService) GetNote(id string) (*domain.Note, error) {
 		return nil, fmt.Errorf("failed to stat file: %w", err)
 	}
 
-	note, err := s.parseNote(id, content, info)
+	parsed, err := s.parseNote(id, content, info)
 	if err != nil {
 		return nil, err
 	}
+	s.bases.add(parsed.Note.Hash, content)
 
-	return note, nil
+	return parsed, nil
 }
 
 // ListNotes returns summaries of all notes in the workspace.
@@ -129,18 +223,105 @@ func (s *NoteService) ListNotes() ([]domain.NoteSummary, error) {
 	return summaries, nil
 }
 
+// SaveNote writes a note to disk.
+// When the file already exists only the frontmatter keys whose values changed are rewritten,
+// leaving comments, key order and quoting intact; a note that matches the file is not written at all.
+// If the existing file cannot be edited that way, e.g. because its frontmatter does not parse, the
+// error is returned rather than regenerating the whole file.
+// Timestamps the frontmatter policy keeps out of the file are recorded in the metadata store,
+// and the content being overwritten is kept as a snapshot when a snapshot store is attached.
+// A note with a Hash is saved over the file version it was loaded from; if the file changed on disk
+// since, both changes are merged as described for SaveNoteMerging.
+// On success note is updated to the file as saved, parsed like LoadNote does, including its Hash.
 func (s *NoteService) SaveNote(note *domain.Note) error {
+	_, _, err := s.SaveNoteMerging(note)
+	return err
+}
+
+// SaveNoteMerging saves a note like SaveNote and reports whether it had to be merged with changes
+// made on disk since it was loaded, as identified by its Hash. A clean merge is written and note
+// is reloaded from the merged file. Otherwise the file on disk is left alone, the note's content is
+// written to a conflict copy beside it, and an ErrConflict with both versions and the conflicting
+// hunks is returned.
+// The saved file is parsed once, for note and for the returned ParsedNote, whose Note is note; the
+// indexes can take both as they are.
+func (s *NoteService) SaveNoteMerging(note *domain.Note) (*ParsedNote, bool, error) {
+	existing, readErr := s.fs.ReadFile(note.Path)
+	if readErr == nil {
+		if note.Hash != "" && note.Hash != contentHash(existing) {
+			parsed, err := s.mergeExternalChange(note, existing)
+			return parsed, true, err
+		}
+		content, changed, err := s.updateNoteContent(note, existing)
+		if err != nil {
+			return nil, false, err
+		}
+		if changed {
+			if err := s.writeNote(note, existing, content); err != nil {
+				return nil, false, err
+			}
+		}
+		parsed, err := s.reloadNote(note, content)
+		return parsed, false, err
+	}
+
 	note.ModifiedAt = time.Now()
+	if note.CreatedAt.IsZero() {
+		note.CreatedAt = note.ModifiedAt
+	}
 	content := s.serializeNote(note)
-	return s.fs.WriteFile(note.Path, content)
+	if err := s.writeNote(note, nil, content); err != nil {
+		return nil, false, err
+	}
+	parsed, err := s.reloadNote(note, content)
+	return parsed, false, err
 }
 
-// DeleteNote removes a note from the workspace.
+// reloadNote parses content, just written to or found in note's file, into note.
+func (s *NoteService) reloadNote(note *domain.Note, content []byte) (*ParsedNote, error) {
+	parsed, err := s.parseFile(note.ID, content)
+	if err != nil {
+		return nil, err
+	}
+	*note = *parsed.Note
+	parsed.Note = note
+	return parsed, nil
+}
+
+// writeNote snapshots the previous content of a note, if any, then writes the new content.
+func (s *NoteService) writeNote(note *domain.Note, previous, content []byte) error {
+	if previous != nil && !bytes.Equal(previous, content) {
+		if err := s.snapshot(note.ID, previous); err != nil {
+			return err
+		}
+	}
+	if err := s.fs.WriteFile(note.Path, content); err != nil {
+		return err
+	}
+	note.Hash = contentHash(content)
+	s.bases.add(note.Hash, content)
+	return s.recordMetadata(note)
+}
+
+// DeleteNote moves a note into the workspace trash, from where RestoreNote can bring it back.
+// Use TrashNote to also record the notes linking to it.
 func (s *NoteService) DeleteNote(id string) error {
-	return s.fs.DeleteFile(id)
+	_, err := s.TrashNote(id, nil)
+	return err
 }
 
 func (s *NoteService) CreateNote(title, folder string) (*domain.Note, error) {
+	parsed, err := s.CreateTypedNote(title, folder, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Note, nil
+}
+
+// CreateTypedNote creates a new note with the given type and initial frontmatter fields,
+// typically the defaults declared by the type's schema. The note is returned as parsed from the
+// file written, along with its tasks.
+func (s *NoteService) CreateTypedNote(title, folder, noteType string, fields map[string]any) (*ParsedNote, error) {
 	filename := sanitizeFilename(title) + ".md"
 	relPath := filename
 	if folder != "" {
@@ -153,15 +334,23 @@ func (s *NoteService) CreateNote(title, folder string) (*domain.Note, error) {
 
 	content := "# " + title + "\n\n"
 
+	frontmatter := make(map[string]any, len(fields))
+	for key, value := range fields {
+		frontmatter[key] = value
+	}
+	if _, ok := frontmatter["tags"]; !ok && len(s.defaultTags) > 0 {
+		frontmatter["tags"] = slices.Clone(s.defaultTags)
+	}
+
 	now := time.Now()
 	note := &domain.Note{
 		ID:          relPath,
 		Title:       title,
 		Path:        relPath,
 		Content:     content,
-		Frontmatter: make(map[string]any),
+		Frontmatter: frontmatter,
 		Aliases:     []string{},
-		Type:        "",
+		Type:        noteType,
 		Blocks:      []domain.Block{},
 		Links:       []domain.Link{},
 		Tags:        []domain.Tag{},
@@ -169,17 +358,42 @@ func (s *NoteService) CreateNote(title, folder string) (*domain.Note, error) {
 		ModifiedAt:  now,
 	}
 
-	if err := s.SaveNote(note); err != nil {
+	parsed, _, err := s.SaveNoteMerging(note)
+	if err != nil {
 		return nil, err
 	}
 
-	return note, nil
+	return parsed, nil
 }
 
 // RenderMarkdown converts markdown content to HTML using goldmark.
+// Fenced ```query blocks are replaced with their results when a query runner is attached.
+// Local images and attachment links point at the asset handler; relative paths resolve from the workspace root.
 func (s *NoteService) RenderMarkdown(markdown string) (string, error) {
+	return s.RenderNoteMarkdown("", markdown)
+}
+
+// RenderNoteMarkdown converts a note's markdown to HTML, resolving relative attachment paths from the note's folder.
+func (s *NoteService) RenderNoteMarkdown(noteID, markdown string) (string, error) {
+	return s.RenderNoteMarkdownWithLinks(noteID, markdown, nil)
+}
+
+// RenderNoteMarkdownWithLinks converts a note's markdown to HTML, mapping its links to URLs with links.
+// Exporters use it to point links at exported pages and files instead of the app's asset handler.
+func (s *NoteService) RenderNoteMarkdownWithLinks(noteID, markdown string, links LinkResolver) (string, error) {
+	return s.renderNote(s.renderer, noteID, markdown, links)
+}
+
+// renderNote converts a note's markdown with the given renderer.
+func (s *NoteService) renderNote(md goldmark.Markdown, noteID, markdown string, links LinkResolver) (string, error) {
+	pc := parser.NewContext()
+	pc.Set(renderNoteKey, noteID)
+	if links != nil {
+		pc.Set(renderLinksKey, links)
+	}
+
 	var buf bytes.Buffer
-	if err := s.parser.Convert([]byte(markdown), &buf); err != nil {
+	if err := md.Convert([]byte(markdown), &buf, parser.WithContext(pc)); err != nil {
 		return "", fmt.Errorf("failed to render markdown: %w", err)
 	}
 	return buf.String(), nil
@@ -187,21 +401,27 @@ func (s *NoteService) RenderMarkdown(markdown string) (string, error) {
 
 // parseNote converts raw content into a structured Note.
 // It extracts frontmatter, parses Markdown structure, and identifies blocks.
-func (s *NoteService) parseNote(id string, content []byte, info os.FileInfo) (*domain.Note, error) {
+func (s *NoteService) parseNote(id string, content []byte, info os.FileInfo) (*ParsedNote, error) {
 	frontmatter, body, fields, err := s.extractFrontmatter(content)
 	if err != nil {
 		return nil, &domain.ErrInvalidFrontmatter{Path: id, Reason: err.Error()}
 	}
 
+	doc := s.parser.Parser().Parse(text.NewReader(body))
+	properties := extractPageProperties(string(body))
+
 	title := fields.Title
 	if title == "" {
-		title = s.extractTitleFromContent(body)
+		title = properties["title"]
+	}
+	if title == "" {
+		title = headingTitle(doc, body)
 	}
 	if title == "" {
 		title = strings.TrimSuffix(filepath.Base(id), filepath.Ext(id))
 	}
 
-	inlineTags := s.extractInlineTags(body)
+	inlineTags := documentTags(doc, body)
 
 	tagSet := make(map[string]bool)
 	for _, tagName := range fields.Tags {
@@ -219,33 +439,55 @@ func (s *NoteService) parseNote(id string, content []byte, info os.FileInfo) (*d
 		return tags[i].Name < tags[j].Name
 	})
 
+	frontmatterTags := fields.Tags
+	if frontmatterTags == nil {
+		frontmatterTags = []string{}
+	}
+
+	stored := s.storedMetadata(id)
+
 	createdAt := fields.Created
+	if createdAt.IsZero() && stored != nil {
+		createdAt = stored.CreatedAt
+	}
 	if createdAt.IsZero() {
 		createdAt = info.ModTime()
 	}
 
 	modifiedAt := fields.Modified
+	if modifiedAt.IsZero() && stored != nil {
+		modifiedAt = stored.ModifiedAt
+	}
 	if modifiedAt.IsZero() {
 		modifiedAt = info.ModTime()
 	}
 
-	blocks := s.extractBlocks(id, body)
 	note := &domain.Note{
-		ID:          id,
-		Title:       title,
-		Path:        id,
-		Content:     string(body),
-		Frontmatter: frontmatter,
-		Aliases:     fields.Aliases,
-		Type:        fields.Type,
-		Blocks:      blocks,
-		Links:       []domain.Link{},
-		Tags:        tags,
-		CreatedAt:   createdAt,
-		ModifiedAt:  modifiedAt,
+		ID:              id,
+		Title:           title,
+		Path:            id,
+		Content:         string(body),
+		Frontmatter:     frontmatter,
+		Properties:      properties,
+		Aliases:         mergeAliases(fields.Aliases, splitPropertyList(properties["alias"])),
+		Type:            fields.Type,
+		Blocks:          extractBlocks(id, doc, body),
+		Links:           extractLinks(id, doc, body, s.attachments),
+		Tags:            tags,
+		InlineTags:      inlineTags,
+		FrontmatterTags: frontmatterTags,
+		CreatedAt:       createdAt,
+		ModifiedAt:      modifiedAt,
+		Hash:            contentHash(content),
 	}
 
-	return note, nil
+	return &ParsedNote{Note: note, Tasks: extractTasks(id, id, doc, body, 0)}, nil
+}
+
+// contentHash returns the hex SHA-256 of a note file's content, used to detect external changes.
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
 }
 
 // extractFrontmatter parses YAML frontmatter from content and extracts standard fields.
@@ -377,7 +619,11 @@ func parseTime(value any) (time.Time, error) {
 
 // extractTitleFromContent gets the title from first heading.
 func (s *NoteService) extractTitleFromContent(content []byte) string {
-	doc := s.parser.Parser().Parse(text.NewReader(content))
+	return headingTitle(s.parser.Parser().Parse(text.NewReader(content)), content)
+}
+
+// headingTitle returns the text of the first level-1 heading of a parsed body.
+func headingTitle(doc ast.Node, content []byte) string {
 	var title string
 	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if entering && n.Kind() == ast.KindHeading {
@@ -401,18 +647,22 @@ func (s *NoteService) extractTitleAndTags(content []byte) (string, []domain.Tag)
 		return "", nil
 	}
 
+	doc := s.parser.Parser().Parse(text.NewReader(body))
+	properties := extractPageProperties(string(body))
+
 	title := fields.Title
 	if title == "" {
-		title = s.extractTitleFromContent(body)
+		title = properties["title"]
+	}
+	if title == "" {
+		title = headingTitle(doc, body)
 	}
-
-	inlineTags := s.extractInlineTags(body)
 
 	tagSet := make(map[string]bool)
 	for _, tagName := range fields.Tags {
 		tagSet[tagName] = true
 	}
-	for _, tagName := range inlineTags {
+	for _, tagName := range documentTags(doc, body) {
 		tagSet[tagName] = true
 	}
 
@@ -433,12 +683,38 @@ func (s *NoteService) extractTitleAndTags(content []byte) (string, []domain.Tag)
 // - Tags are not matched if preceded by alphanumeric (avoids matching in URLs, code)
 var inlineTagPattern = regexp.MustCompile(`(?:^|[^a-zA-Z0-9])#([a-zA-Z_][a-zA-Z0-9_-]*(?:/[a-zA-Z0-9_-]+)*)`)
 
+// bodyTags returns the sorted tags declared in a note body: inline #tags and the tags:: page property.
+func (s *NoteService) bodyTags(body []byte) []string {
+	return documentTags(s.parser.Parser().Parse(text.NewReader(body)), body)
+}
+
+// documentTags returns the sorted tags declared in a parsed note body, as bodyTags does.
+func documentTags(doc ast.Node, body []byte) []string {
+	tagSet := make(map[string]bool)
+	for _, tagName := range inlineTags(doc, body) {
+		tagSet[tagName] = true
+	}
+	for _, tagName := range splitPropertyList(extractPageProperties(string(body))["tags"]) {
+		tagSet[normalizeTagName(tagName)] = true
+	}
+
+	tags := make([]string, 0, len(tagSet))
+	for tagName := range tagSet {
+		tags = append(tags, tagName)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
 // extractInlineTags extracts hashtag-style tags from note content.
 // Excludes tags found in code blocks and inline code.
 func (s *NoteService) extractInlineTags(content []byte) []string {
-	doc := s.parser.Parser().Parse(text.NewReader(content))
+	return inlineTags(s.parser.Parser().Parse(text.NewReader(content)), content)
+}
 
-	var codeRanges []struct{ start, end int }
+// inlineTags extracts hashtag-style tags from a parsed body, outside its code blocks and links.
+func inlineTags(doc ast.Node, content []byte) []string {
+	skip := linkRanges(content)
 	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
 			return ast.WalkContinue, nil
@@ -448,33 +724,17 @@ func (s *NoteService) extractInlineTags(content []byte) []string {
 		case ast.KindFencedCodeBlock, ast.KindCodeBlock:
 			lines := n.Lines()
 			if lines.Len() > 0 {
-				start := lines.At(0).Start
-				end := lines.At(lines.Len() - 1).Stop
-				codeRanges = append(codeRanges, struct{ start, end int }{start, end})
+				skip = append(skip, byteRange{lines.At(0).Start, lines.At(lines.Len() - 1).Stop})
 			}
 		}
 
 		return ast.WalkContinue, nil
 	})
 
-	var filteredContent bytes.Buffer
-	lastPos := 0
-	for _, codeRange := range codeRanges {
-		if codeRange.start > lastPos {
-			filteredContent.Write(content[lastPos:codeRange.start])
-		}
-		lastPos = codeRange.end
-	}
-	if lastPos < len(content) {
-		filteredContent.Write(content[lastPos:])
-	}
-
 	tagSet := make(map[string]bool)
-	matches := inlineTagPattern.FindAllSubmatch(filteredContent.Bytes(), -1)
-	for _, match := range matches {
-		if len(match) > 1 {
-			tag := string(match[1])
-			tagSet[tag] = true
+	for _, match := range inlineTagPattern.FindAllSubmatchIndex(content, -1) {
+		if !inByteRanges(skip, match[2]-1) {
+			tagSet[string(content[match[2]:match[3]])] = true
 		}
 	}
 
@@ -486,12 +746,10 @@ func (s *NoteService) extractInlineTags(content []byte) []string {
 	return tags
 }
 
-// extractBlocks parses Markdown content into outline blocks.
+// extractBlocks splits parsed Markdown content into outline blocks.
 // Each paragraph, heading, list item, etc. becomes a separate block.
 // Supports Logseq-style block IDs (^block-id at end of line).
-func (s *NoteService) extractBlocks(noteID string, content []byte) []domain.Block {
-	doc := s.parser.Parser().Parse(text.NewReader(content))
-
+func extractBlocks(noteID string, doc ast.Node, content []byte) []domain.Block {
 	blocks := []domain.Block{}
 	blockIdx := 0
 	listDepth := 0
@@ -549,18 +807,22 @@ func (s *NoteService) extractBlocks(noteID string, content []byte) []domain.Bloc
 		}
 
 		if shouldCreate {
-			blockContent := nodeText(n, content)
+			blockContent, properties := splitBlockProperties(nodeText(n, content))
 			blockID, cleanContent := parseBlockID(blockContent)
+			if id := properties["id"]; id != "" && !strings.Contains(blockContent, "^") {
+				blockID = id
+			}
 
 			blocks = append(blocks, domain.Block{
-				ID:       blockID,
-				NoteID:   noteID,
-				Content:  cleanContent,
-				Level:    level,
-				Parent:   "",
-				Children: []string{},
-				Position: blockIdx,
-				Type:     blockType,
+				ID:         blockID,
+				NoteID:     noteID,
+				Content:    cleanContent,
+				Level:      level,
+				Parent:     "",
+				Children:   []string{},
+				Position:   blockIdx,
+				Type:       blockType,
+				Properties: properties,
 			})
 
 			blockIdx++
@@ -579,27 +841,63 @@ func (s *NoteService) extractBlocks(noteID string, content []byte) []domain.Bloc
 var taskCheckboxPattern = regexp.MustCompile(`^-\s+\[\s*([ xX])\s*\]\s+(.*)$`)
 
 // ExtractTasks parses note content and extracts all task items with metadata.
+// Line numbers count from the start of content, including any frontmatter.
 func (s *NoteService) ExtractTasks(noteID string, notePath string, content []byte) []domain.Task {
-	lines := strings.Split(string(content), "\n")
-	tasks := []domain.Task{}
-	now := time.Now()
+	lines := bytes.Split(content, []byte("\n"))
 
 	startLine := 0
-	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "---" {
+	if len(lines) > 0 && string(bytes.TrimSpace(lines[0])) == "---" {
 		for i := 1; i < len(lines); i++ {
-			if strings.TrimSpace(lines[i]) == "---" {
+			if string(bytes.TrimSpace(lines[i])) == "---" {
 				startLine = i + 1
 				break
 			}
 		}
 	}
 
-	for lineNum := startLine; lineNum < len(lines); lineNum++ {
-		line := strings.TrimSpace(lines[lineNum])
+	body := bytes.Join(lines[startLine:], []byte("\n"))
+	doc := s.parser.Parser().Parse(text.NewReader(body))
+	return extractTasks(noteID, notePath, doc, body, startLine)
+}
+
+// extractTasks finds the task items of a parsed body: "- [ ]" and "- [x]" list items, wherever
+// they are nested, but not lines that only look like tasks inside code blocks. Line numbers are
+// counted from the start of the body plus firstLine.
+func extractTasks(noteID, notePath string, doc ast.Node, body []byte, firstLine int) []domain.Task {
+	tasks := []domain.Task{}
+	now := time.Now()
+
+	var lineStarts []int
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || n.Kind() != ast.KindListItem {
+			return ast.WalkContinue, nil
+		}
+		if list, ok := n.Parent().(*ast.List); !ok || list.Marker != '-' {
+			return ast.WalkContinue, nil
+		}
+		first := n.FirstChild()
+		if first == nil || first.Lines().Len() == 0 {
+			return ast.WalkContinue, nil
+		}
+
+		if lineStarts == nil {
+			lineStarts = []int{0}
+			for i, c := range body {
+				if c == '\n' {
+					lineStarts = append(lineStarts, i+1)
+				}
+			}
+		}
+		lineNum := sort.SearchInts(lineStarts, first.Lines().At(0).Start+1) - 1
+		end := len(body)
+		if lineNum+1 < len(lineStarts) {
+			end = lineStarts[lineNum+1] - 1
+		}
+		line := strings.TrimSpace(string(body[lineStarts[lineNum]:end]))
 
 		matches := taskCheckboxPattern.FindStringSubmatch(line)
 		if matches == nil {
-			continue
+			return ast.WalkContinue, nil
 		}
 
 		checkboxState := matches[1]
@@ -618,7 +916,7 @@ func (s *NoteService) ExtractTasks(noteID string, notePath string, content []byt
 			IsCompleted: isCompleted,
 			CreatedAt:   now,
 			CompletedAt: nil,
-			LineNumber:  lineNum,
+			LineNumber:  firstLine + lineNum,
 		}
 
 		if isCompleted {
@@ -626,12 +924,16 @@ func (s *NoteService) ExtractTasks(noteID string, notePath string, content []byt
 		}
 
 		tasks = append(tasks, task)
-	}
+		return ast.WalkContinue, nil
+	})
 
 	return tasks
 }
 
-// serializeNote converts a Note back to Markdown with frontmatter.
+// serializeNote converts a Note to Markdown with freshly generated frontmatter.
+// Used for new files; existing files are updated in place by updateNoteContent.
+// Title and timestamps are only written under the always policy, and tags found in the body
+// are not repeated in frontmatter. A note with nothing to put in frontmatter gets none.
 func (s *NoteService) serializeNote(note *domain.Note) []byte {
 	var buf bytes.Buffer
 
@@ -641,7 +943,7 @@ func (s *NoteService) serializeNote(note *domain.Note) []byte {
 		completeFrontmatter[k] = v
 	}
 
-	if note.Title != "" {
+	if s.policy == domain.FrontmatterAlways && note.Title != "" {
 		completeFrontmatter["title"] = note.Title
 	}
 
@@ -653,19 +955,15 @@ func (s *NoteService) serializeNote(note *domain.Note) []byte {
 		completeFrontmatter["type"] = note.Type
 	}
 
-	if len(note.Tags) > 0 {
-		tagNames := make([]string, 0, len(note.Tags))
-		for _, tag := range note.Tags {
-			tagNames = append(tagNames, tag.Name)
-		}
+	if tagNames := s.newFrontmatterTags(note); len(tagNames) > 0 {
 		completeFrontmatter["tags"] = tagNames
 	}
 
-	if !note.CreatedAt.IsZero() {
+	if s.policy == domain.FrontmatterAlways && !note.CreatedAt.IsZero() {
 		completeFrontmatter["created"] = note.CreatedAt.Format(time.RFC3339)
 	}
 
-	if !note.ModifiedAt.IsZero() {
+	if s.policy == domain.FrontmatterAlways && !note.ModifiedAt.IsZero() {
 		completeFrontmatter["modified"] = note.ModifiedAt.Format(time.RFC3339)
 	}
 
@@ -676,14 +974,38 @@ func (s *NoteService) serializeNote(note *domain.Note) []byte {
 		if err == nil {
 			buf.Write(fmBytes)
 		}
-		buf.WriteString("---\n\n")
+		buf.WriteString("---\n")
 	}
 
-	buf.WriteString(note.Content)
+	content := note.Content
+	if note.Properties != nil {
+		content = applyPageProperties(content, note.Properties)
+	}
+	if len(completeFrontmatter) > 0 && !strings.HasPrefix(content, "\n") {
+		buf.WriteString("\n")
+	}
+	buf.WriteString(content)
 
 	return buf.Bytes()
 }
 
+// mergeAliases combines frontmatter aliases with aliases declared as page properties, dropping duplicates.
+func mergeAliases(frontmatter, properties []string) []string {
+	if len(properties) == 0 {
+		return frontmatter
+	}
+
+	seen := make(map[string]bool)
+	merged := []string{}
+	for _, alias := range append(append([]string{}, frontmatter...), properties...) {
+		if !seen[alias] {
+			seen[alias] = true
+			merged = append(merged, alias)
+		}
+	}
+	return merged
+}
+
 // sanitizeFilename converts a title to a valid filename.
 func sanitizeFilename(title string) string {
 	invalid := []string{"/", "\\", ":", "*", "?", "\"", "<", ">", "|"}
@@ -757,6 +1079,7 @@ func generateBlockID() string {
 }
 
 // nodeText extracts text content from an AST node by walking its children.
+// Wikilinks below the node are written back as [[wikilinks]] rather than as their labels.
 func nodeText(n ast.Node, source []byte) string {
 	var buf bytes.Buffer
 	ast.Walk(n, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
@@ -769,6 +1092,16 @@ func nodeText(n ast.Node, source []byte) string {
 				}
 			case *ast.String:
 				buf.Write(v.Value)
+			case *wikilink.Node:
+				if node == n {
+					break // The text of a wikilink itself is its label
+				}
+				label := ""
+				if text, ok := v.FirstChild().(*ast.Text); ok {
+					label = string(text.Segment.Value(source))
+				}
+				buf.WriteString(wikilinkSource(v, label))
+				return ast.WalkSkipChildren, nil
 			}
 		}
 		return ast.WalkContinue, nil
@@ -1,10 +1,14 @@
 package service
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+
+	"notes/backend/domain"
 )
 
 func TestNoteService_CreateNote(t *testing.T) {
@@ -130,6 +134,49 @@ func TestNoteService_GetNote(t *testing.T) {
 	}
 }
 
+func TestNoteService_LoadNote(t *testing.T) {
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{
+		"plan.md": "---\ntags: [work]\n---\n# Plan with [[Alice|alice]]\n\n" +
+			"See [[index#goals]] and [spec](specs/spec.md), #planning\n\n" +
+			"- [ ] Draft the plan ^draft\n  - [x] Nested step\n\n" +
+			"```\n- [ ] not a task [[not-a-link]] #not-a-tag\n```\n",
+	})
+
+	parsed, err := notes.LoadNote("plan.md")
+	if err != nil {
+		t.Fatalf("LoadNote() error = %v", err)
+	}
+	note := parsed.Note
+
+	if note.Title != "Plan with [[Alice|alice]]" {
+		t.Errorf("Title = %q, want the wikilink kept as written", note.Title)
+	}
+	if !slices.Equal(note.InlineTags, []string{"planning"}) {
+		t.Errorf("InlineTags = %v, want [planning]", note.InlineTags)
+	}
+
+	targets := []string{}
+	for _, link := range note.Links {
+		targets = append(targets, link.Target)
+	}
+	if !slices.Equal(targets, []string{"Alice.md", "index.md", "specs/spec.md"}) {
+		t.Errorf("link targets = %v", targets)
+	}
+
+	if len(parsed.Tasks) != 2 {
+		t.Fatalf("Tasks = %+v, want 2 outside the code block", parsed.Tasks)
+	}
+	if task := parsed.Tasks[0]; task.ID != "draft" || task.Content != "Draft the plan" || task.LineNumber != 4 {
+		t.Errorf("Tasks[0] = %+v", task)
+	}
+	if task := parsed.Tasks[1]; !task.IsCompleted || task.LineNumber != 5 {
+		t.Errorf("Tasks[1] = %+v", task)
+	}
+	if tasks := notes.ExtractTasks(note.ID, note.Path, []byte(note.Content)); len(tasks) != 2 || tasks[0].LineNumber != 4 {
+		t.Errorf("ExtractTasks() = %+v, want the same tasks", tasks)
+	}
+}
+
 func TestNoteService_SaveNote(t *testing.T) {
 	tmpDir := filepath.Join(os.TempDir(), "test-workspace-note-save")
 	os.RemoveAll(tmpDir)
@@ -175,6 +222,132 @@ func TestNoteService_SaveNote(t *testing.T) {
 	}
 }
 
+func TestNoteService_SaveNoteConflict(t *testing.T) {
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{"note.md": "# Note\n\nFirst line\n"})
+
+	note, err := notes.GetNote("note.md")
+	if err != nil {
+		t.Fatalf("GetNote() error = %v", err)
+	}
+	if note.Hash == "" {
+		t.Fatal("GetNote() returned a note without a hash")
+	}
+
+	// Saving from the loaded version succeeds and moves the hash to the saved content.
+	loadedHash := note.Hash
+	note.Content = "# Note\n\nFirst line\nMine\n"
+	if err := notes.SaveNote(note); err != nil {
+		t.Fatalf("SaveNote() error = %v", err)
+	}
+	if note.Hash == loadedHash {
+		t.Error("SaveNote() did not update the note's hash")
+	}
+
+	// A second save of the same note object is based on what it wrote, so it succeeds too.
+	note.Content += "More\n"
+	if err := notes.SaveNote(note); err != nil {
+		t.Fatalf("SaveNote() again error = %v", err)
+	}
+
+	external := "# Note\n\nEdited elsewhere\n"
+	if err := notes.fs.WriteFile("note.md", []byte(external)); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	note.Content += "Stale\n"
+	err = notes.SaveNote(note)
+	var conflict *domain.ErrConflict
+	if !errors.As(err, &conflict) {
+		t.Fatalf("SaveNote() after external edit error = %v, want ErrConflict", err)
+	}
+	if conflict.Disk != external || !strings.Contains(conflict.Local, "Stale") || conflict.BaseHash != note.Hash || conflict.DiskHash == note.Hash {
+		t.Errorf("conflict = %+v", conflict.NoteConflict)
+	}
+	if len(conflict.Hunks) != 1 || conflict.Merged != external {
+		t.Errorf("conflict hunks = %+v, merged = %q", conflict.Hunks, conflict.Merged)
+	}
+	if content, _ := notes.fs.ReadFile("note.md"); string(content) != external {
+		t.Errorf("file after refused save = %q, want the external edit kept", content)
+	}
+	if !strings.HasPrefix(conflict.CopyPath, "note (conflict ") {
+		t.Fatalf("CopyPath = %q, want a conflict copy beside the note", conflict.CopyPath)
+	}
+	if content, _ := notes.fs.ReadFile(conflict.CopyPath); string(content) != conflict.Local {
+		t.Errorf("conflict copy = %q, want the local version", content)
+	}
+
+	// Retrying the same save reuses the conflict copy.
+	err = notes.SaveNote(note)
+	if !errors.As(err, &conflict) {
+		t.Fatalf("SaveNote() retry error = %v, want ErrConflict", err)
+	}
+	if !strings.HasSuffix(conflict.CopyPath, ").md") || strings.Count(conflict.CopyPath, " ") != 2 {
+		t.Errorf("retry CopyPath = %q, want the first copy reused", conflict.CopyPath)
+	}
+
+	// A note without a hash is saved unconditionally.
+	note.Hash = ""
+	if err := notes.SaveNote(note); err != nil {
+		t.Errorf("SaveNote() without hash error = %v", err)
+	}
+}
+
+func TestNoteService_SaveNoteMerging(t *testing.T) {
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{"note.md": "# Note\n\nOne\nTwo\nThree\n"})
+
+	note, err := notes.GetNote("note.md")
+	if err != nil {
+		t.Fatalf("GetNote() error = %v", err)
+	}
+
+	if err := notes.fs.WriteFile("note.md", []byte("# Note\n\nOne\nTwo\nThree edited elsewhere\n")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	note.Content = "# Note\n\nOne edited here\nTwo\nThree\n"
+
+	_, merged, err := notes.SaveNoteMerging(note)
+	if err != nil {
+		t.Fatalf("SaveNoteMerging() error = %v", err)
+	}
+	if !merged {
+		t.Error("SaveNoteMerging() did not report the merge")
+	}
+
+	want := "# Note\n\nOne edited here\nTwo\nThree edited elsewhere\n"
+	if content, _ := notes.fs.ReadFile("note.md"); string(content) != want {
+		t.Errorf("file after merge = %q, want %q", content, want)
+	}
+	if note.Content != want || note.Hash != contentHash([]byte(want)) {
+		t.Errorf("note after merge = %q (hash %s), want it reloaded from the merged file", note.Content, note.Hash)
+	}
+
+	// The merged note saves normally from here, parsed from what was written.
+	note.Content += "- [ ] Call [[Other]]\n"
+	parsed, merged, err := notes.SaveNoteMerging(note)
+	if err != nil || merged {
+		t.Fatalf("SaveNoteMerging() after merge = %v, %v", merged, err)
+	}
+	if parsed.Note != note || len(parsed.Tasks) != 1 || parsed.Tasks[0].Content != "Call [[Other]]" {
+		t.Errorf("SaveNoteMerging() parsed = %+v, want note with its one task", parsed)
+	}
+	if len(note.Links) != 1 || note.Links[0].Target != "Other.md" {
+		t.Errorf("note links after save = %+v, want the link to Other.md", note.Links)
+	}
+}
+
+func TestNoteService_SaveNoteInvalidFrontmatter(t *testing.T) {
+	broken := "---\ntitle: [unclosed\n---\n# Broken\n"
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{"broken.md": broken})
+
+	// The file is never regenerated from the note, which would drop its formatting.
+	note := &domain.Note{ID: "broken.md", Path: "broken.md", Title: "Broken", Content: "# Broken\n\nNew text\n"}
+	if _, _, err := notes.SaveNoteMerging(note); err == nil {
+		t.Error("SaveNoteMerging() over invalid frontmatter succeeded, want an error")
+	}
+	if content, _ := notes.fs.ReadFile("broken.md"); string(content) != broken {
+		t.Errorf("broken.md = %q, want it untouched", content)
+	}
+}
+
 func TestNoteService_DeleteNote(t *testing.T) {
 	tmpDir := filepath.Join(os.TempDir(), "test-workspace-note-delete")
 	os.RemoveAll(tmpDir)
@@ -1073,6 +1246,11 @@ func TestExtractInlineTags(t *testing.T) {
 			content:  "#tag at start",
 			wantTags: []string{"tag"},
 		},
+		{
+			name:     "links and URLs skipped",
+			content:  "[[#heading]] https://example.com/page#section [doc](plan.md#part) #tag",
+			wantTags: []string{"tag"},
+		},
 		{
 			name:     "empty string",
 			content:  "",
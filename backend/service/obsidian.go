@@ -0,0 +1,283 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// obsidianSettings holds the parts of a vault's .obsidian/app.json that affect importing.
+type obsidianSettings struct {
+	AttachmentFolderPath string `json:"attachmentFolderPath"` // "/" (vault root), "./" (next to the note), or a folder
+	NewLinkFormat        string `json:"newLinkFormat"`        // "shortest", "relative" or "absolute"
+}
+
+// loadObsidianSettings reads .obsidian/app.json from a vault. A missing file yields Obsidian's defaults.
+func loadObsidianSettings(vaultPath string) (obsidianSettings, error) {
+	settings := obsidianSettings{AttachmentFolderPath: "/", NewLinkFormat: "shortest"}
+
+	data, err := os.ReadFile(filepath.Join(vaultPath, ".obsidian", "app.json"))
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("failed to read obsidian settings: %w", err)
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return settings, fmt.Errorf("failed to parse obsidian settings: %w", err)
+	}
+	return settings, nil
+}
+
+// workspaceAttachmentFolder maps Obsidian's attachment folder setting onto the workspace's
+// attachment folder format for a vault imported into folder.
+func (o obsidianSettings) workspaceAttachmentFolder(folder string) string {
+	setting := strings.TrimSpace(o.AttachmentFolderPath)
+	switch {
+	case setting == "" || setting == "/":
+		return folder
+	case setting == "." || strings.HasPrefix(setting, "./"):
+		return setting
+	default:
+		return path.Join(folder, strings.Trim(setting, "/"))
+	}
+}
+
+// Obsidian-only syntax converted on import.
+var (
+	// obsidianCommentPattern matches %%comments%%, which may span lines.
+	obsidianCommentPattern = regexp.MustCompile(`(?s)%%(.*?)%%`)
+	// obsidianHighlightPattern matches ==highlighted text==.
+	obsidianHighlightPattern = regexp.MustCompile(`==([^=\s](?:[^=\n]*[^=\s])?)==`)
+	// obsidianCalloutPattern matches the first line of a callout, e.g. "> [!warning]- Title".
+	obsidianCalloutPattern = regexp.MustCompile(`(?m)^((?:[ \t]*>)+[ \t]*)\[!([A-Za-z][\w-]*)\][+-]?[ \t]*(.*?)[ \t]*$`)
+	// obsidianPluginBlockPattern matches the opening fence of a plugin code block.
+	obsidianPluginBlockPattern = regexp.MustCompile("(?m)^[ \t]*(?:```|~~~)[ \t]*(dataview|dataviewjs|query|tasks)[ \t]*\r?$")
+)
+
+// Conversion kinds counted in ImportReport.Conversions for Obsidian vaults.
+const (
+	obsidianConvertedComments   = "comments"
+	obsidianConvertedHighlights = "highlights"
+	obsidianConvertedCallouts   = "callouts"
+	obsidianConvertedAliases    = "aliases"
+	obsidianConvertedTags       = "tags"
+)
+
+// ImportObsidianVault imports the notes and attachments of an Obsidian vault into targetFolder
+// ("" for the workspace root), keeping the vault's folder structure.
+//
+// Links are rewritten to resolve in the workspace using the vault's link format setting,
+// %%comments%% become HTML comments, ==highlights== become bold text, callouts become
+// blockquotes with a bold title, and alias and tag frontmatter keys are normalized to lists.
+// Existing workspace files are never overwritten. Canvas files and plugin blocks are
+// reported as unsupported.
+//
+// With dryRun set, nothing is written and the report describes what would be imported.
+func (s *ImportService) ImportObsidianVault(vaultPath, targetFolder string, dryRun bool, progress ImportProgressFunc) (*ImportReport, error) {
+	vaultPath, folder, err := s.importTarget(vaultPath, targetFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	report := newImportReport(vaultPath, folder, dryRun)
+	reportProgress(progress, ImportPhaseScan, 0, 0, "")
+
+	src, unsupported, err := scanImportSource(vaultPath, nil, func(rel string) (string, bool) {
+		if strings.EqualFold(path.Ext(rel), ".canvas") {
+			return "canvas files are not supported", true
+		}
+		return "unsupported file type", true
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Unsupported = append(report.Unsupported, unsupported...)
+
+	settings, err := loadObsidianSettings(vaultPath)
+	if err != nil {
+		report.Unsupported = append(report.Unsupported, ImportIssue{Path: ".obsidian/app.json", Reason: err.Error()})
+	}
+	report.AttachmentFolder = settings.workspaceAttachmentFolder(folder)
+
+	destination := func(rel string) string {
+		return path.Join(folder, rel)
+	}
+	relativeFirst := settings.NewLinkFormat == "relative"
+	links := &importLinkRewriter{
+		src:    src,
+		report: report,
+		resolve: func(target, fromRel string) (string, bool) {
+			return src.resolveWikilink(target, fromRel, relativeFirst)
+		},
+		destination: destination,
+	}
+
+	for i, rel := range src.notes {
+		content, err := os.ReadFile(filepath.Join(vaultPath, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+
+		converted := s.convertObsidianNote(report, rel, content)
+		converted = links.rewrite(converted, rel, destination(rel), s.codeChecker(converted))
+
+		if err := s.writeNote(report, rel, destination(rel), converted); err != nil {
+			return nil, err
+		}
+		reportProgress(progress, ImportPhaseNotes, i+1, len(src.notes), rel)
+	}
+
+	for i, rel := range src.attachments {
+		if err := s.copyAttachment(report, rel, destination(rel)); err != nil {
+			return nil, err
+		}
+		reportProgress(progress, ImportPhaseAttachments, i+1, len(src.attachments), rel)
+	}
+
+	return report, nil
+}
+
+// convertObsidianNote rewrites Obsidian-only syntax in a note and normalizes its frontmatter.
+// Code blocks and code spans are left untouched.
+func (s *ImportService) convertObsidianNote(report *ImportReport, rel string, content []byte) []byte {
+	bodyStart := 0
+	if yamlStart, yamlEnd, start, ok := splitFrontmatter(content); ok {
+		raw := content[yamlStart:yamlEnd]
+		edited, err := obsidianFrontmatter(report, raw)
+		if err != nil {
+			report.Unsupported = append(report.Unsupported, ImportIssue{Path: rel, Reason: err.Error()})
+		} else if !bytes.Equal(edited, raw) {
+			updated := make([]byte, 0, len(content)+len(edited)-len(raw))
+			updated = append(updated, content[:yamlStart]...)
+			updated = append(updated, edited...)
+			updated = append(updated, content[yamlEnd:]...)
+			start += len(edited) - len(raw)
+			content = updated
+		}
+		bodyStart = start
+	}
+
+	body := content[bodyStart:]
+	for _, m := range obsidianPluginBlockPattern.FindAllSubmatch(body, -1) {
+		report.Unsupported = append(report.Unsupported, ImportIssue{
+			Path:   rel,
+			Reason: fmt.Sprintf("%s block kept as-is", m[1]),
+		})
+	}
+
+	inCode := s.codeChecker(content)
+
+	type replacement struct {
+		start, end int
+		text       string
+		kind       string
+	}
+	replacements := []replacement{}
+
+	for _, m := range obsidianCommentPattern.FindAllSubmatchIndex(body, -1) {
+		if !inCode(bodyStart + m[0]) {
+			replacements = append(replacements, replacement{m[0], m[1], "<!--" + string(body[m[2]:m[3]]) + "-->", obsidianConvertedComments})
+		}
+	}
+	for _, m := range obsidianHighlightPattern.FindAllSubmatchIndex(body, -1) {
+		if !inCode(bodyStart + m[0]) {
+			replacements = append(replacements, replacement{m[0], m[1], "**" + string(body[m[2]:m[3]]) + "**", obsidianConvertedHighlights})
+		}
+	}
+	for _, m := range obsidianCalloutPattern.FindAllSubmatchIndex(body, -1) {
+		if inCode(bodyStart + m[0]) {
+			continue
+		}
+		kind := string(body[m[4]:m[5]])
+		heading := "**" + strings.ToUpper(kind[:1]) + strings.ToLower(kind[1:])
+		if title := string(body[m[6]:m[7]]); title != "" {
+			heading += ":** " + title
+		} else {
+			heading += "**"
+		}
+		replacements = append(replacements, replacement{m[0], m[1], string(body[m[2]:m[3]]) + heading, obsidianConvertedCallouts})
+	}
+
+	if len(replacements) == 0 {
+		return content
+	}
+
+	sort.SliceStable(replacements, func(i, j int) bool {
+		return replacements[i].start < replacements[j].start
+	})
+
+	var buf bytes.Buffer
+	buf.Write(content[:bodyStart])
+	last := 0
+	for _, r := range replacements {
+		if r.start < last {
+			continue
+		}
+		buf.Write(body[last:r.start])
+		buf.WriteString(r.text)
+		last = r.end
+		report.Conversions[r.kind]++
+	}
+	buf.Write(body[last:])
+	return buf.Bytes()
+}
+
+// obsidianFrontmatter normalizes the frontmatter keys Obsidian accepts in legacy forms:
+// alias and tag become aliases and tags, and comma-separated strings become lists.
+func obsidianFrontmatter(report *ImportReport, raw []byte) ([]byte, error) {
+	var fields map[string]any
+	if err := yaml.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("invalid frontmatter kept as-is: %v", err)
+	}
+
+	edits := []frontmatterEdit{}
+	normalize := func(legacy, key, kind string, split func(string) []string) {
+		value, hasKey := fields[key]
+		legacyValue, hasLegacy := fields[legacy]
+		if !hasKey && hasLegacy {
+			value = legacyValue
+			edits = append(edits, frontmatterEdit{key: legacy, delete: true})
+		} else if _, ok := value.(string); !ok {
+			return
+		}
+
+		var list []string
+		switch v := value.(type) {
+		case string:
+			list = split(v)
+		default:
+			list = parseStringArray(v)
+		}
+		edits = append(edits, frontmatterEdit{key: key, value: list})
+		report.Conversions[kind]++
+	}
+
+	normalize("alias", "aliases", obsidianConvertedAliases, func(v string) []string {
+		return splitFrontmatterList(v, func(r rune) bool { return r == ',' })
+	})
+	normalize("tag", "tags", obsidianConvertedTags, func(v string) []string {
+		return splitFrontmatterList(v, func(r rune) bool { return r == ',' || r == ' ' })
+	})
+
+	return editFrontmatter(raw, edits)
+}
+
+// splitFrontmatterList splits a string-valued list field, trimming spaces and "#" prefixes.
+func splitFrontmatterList(value string, sep func(rune) bool) []string {
+	items := []string{}
+	for _, item := range strings.FieldsFunc(value, sep) {
+		if item = strings.TrimPrefix(strings.TrimSpace(item), "#"); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"notes/backend/domain"
+)
+
+// writeTestTree writes files (slash-separated relative paths) under root.
+func writeTestTree(t testing.TB, root string, files map[string]string) {
+	t.Helper()
+
+	for rel, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", rel, err)
+		}
+	}
+}
+
+// newTestImportService opens an empty workspace and returns an import service writing into it.
+func newTestImportService(t *testing.T) (*ImportService, *FilesystemService) {
+	t.Helper()
+
+	fs, err := NewFilesystemService()
+	if err != nil {
+		t.Fatalf("NewFilesystemService() error = %v", err)
+	}
+	t.Cleanup(func() { fs.Close() })
+
+	if _, err := fs.OpenWorkspace(t.TempDir()); err != nil {
+		t.Fatalf("OpenWorkspace() error = %v", err)
+	}
+
+	return NewImportService(fs, NewNoteService(fs)), fs
+}
+
+var obsidianTestVault = map[string]string{
+	".obsidian/app.json": `{"attachmentFolderPath": "assets", "newLinkFormat": "shortest"}`,
+	"Home.md": "---\nalias: Start, Index\ntag: home\n---\n" +
+		"# Home\n\nSee [[Project Plan]] and [[Project Plan#Goals|goals]] and [[Missing Note]].\n\n" +
+		"![[diagram.png]] ==important== %%private note%%\n\n" +
+		"> [!warning] Careful\n> Body\n\n> [!tip]\n> Hint\n\n" +
+		"`==code==` and [[Project Plan]] in prose\n\n```\n%%kept%% [[Project Plan]]\n```\n",
+	"work/Project Plan.md": "# Plan\n\n![chart](../assets/chart.pdf)\n\n```dataview\nLIST\n```\n",
+	"assets/diagram.png":   "png",
+	"assets/chart.pdf":     "pdf",
+	"board.canvas":         "{}",
+	".trash/old.md":        "# Old\n",
+}
+
+func TestImportService_ImportObsidianVault(t *testing.T) {
+	importer, fs := newTestImportService(t)
+	vault := t.TempDir()
+	writeTestTree(t, vault, obsidianTestVault)
+
+	var progress []ImportProgress
+	report, err := importer.ImportObsidianVault(vault, "Imported", false, func(p ImportProgress) {
+		progress = append(progress, p)
+	})
+	if err != nil {
+		t.Fatalf("ImportObsidianVault() error = %v", err)
+	}
+
+	if want := []string{"Imported/Home.md", "Imported/work/Project Plan.md"}; !slices.Equal(report.Notes, want) {
+		t.Errorf("Notes = %v, want %v", report.Notes, want)
+	}
+	if want := []string{"Imported/assets/chart.pdf", "Imported/assets/diagram.png"}; !slices.Equal(report.Attachments, want) {
+		t.Errorf("Attachments = %v, want %v", report.Attachments, want)
+	}
+	if report.AttachmentFolder != "Imported/assets" {
+		t.Errorf("AttachmentFolder = %q, want Imported/assets", report.AttachmentFolder)
+	}
+
+	home, err := fs.ReadFile("Imported/Home.md")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	wantHome := "---\naliases:\n  - Start\n  - Index\ntags:\n  - home\n---\n" +
+		"# Home\n\nSee [[Imported/work/Project Plan|Project Plan]] and [[Imported/work/Project Plan#Goals|goals]] and [[Missing Note]].\n\n" +
+		"![[diagram.png]] **important** <!--private note-->\n\n" +
+		"> **Warning:** Careful\n> Body\n\n> **Tip**\n> Hint\n\n" +
+		"`==code==` and [[Imported/work/Project Plan|Project Plan]] in prose\n\n```\n%%kept%% [[Project Plan]]\n```\n"
+	if string(home) != wantHome {
+		t.Errorf("Home.md =\n%s\nwant\n%s", home, wantHome)
+	}
+
+	plan, _ := fs.ReadFile("Imported/work/Project Plan.md")
+	if want := "# Plan\n\n![chart](../assets/chart.pdf)\n\n```dataview\nLIST\n```\n"; string(plan) != want {
+		t.Errorf("Project Plan.md = %q, want %q", plan, want)
+	}
+
+	if want := []ImportLink{{NoteID: "Imported/Home.md", Target: "Missing Note"}}; !slices.Equal(report.UnresolvedLinks, want) {
+		t.Errorf("UnresolvedLinks = %v, want %v", report.UnresolvedLinks, want)
+	}
+
+	wantUnsupported := []ImportIssue{
+		{Path: "board.canvas", Reason: "canvas files are not supported"},
+		{Path: "work/Project Plan.md", Reason: "dataview block kept as-is"},
+	}
+	if !slices.Equal(report.Unsupported, wantUnsupported) {
+		t.Errorf("Unsupported = %v, want %v", report.Unsupported, wantUnsupported)
+	}
+
+	wantConversions := map[string]int{"comments": 1, "highlights": 1, "callouts": 2, "aliases": 1, "tags": 1}
+	for kind, want := range wantConversions {
+		if report.Conversions[kind] != want {
+			t.Errorf("Conversions[%s] = %d, want %d", kind, report.Conversions[kind], want)
+		}
+	}
+
+	if len(progress) == 0 || progress[len(progress)-1] != (ImportProgress{Phase: ImportPhaseAttachments, Current: 2, Total: 2, Path: "assets/diagram.png"}) {
+		t.Errorf("last progress = %v, want attachments 2/2", progress)
+	}
+
+	if _, err := fs.StatFile("Imported/.trash/old.md"); err == nil {
+		t.Error("hidden .trash folder was imported")
+	}
+}
+
+func TestImportService_ImportObsidianVault_DryRunAndConflicts(t *testing.T) {
+	importer, fs := newTestImportService(t)
+	vault := t.TempDir()
+	writeTestTree(t, vault, obsidianTestVault)
+
+	if err := fs.WriteFile("Home.md", []byte("# Existing\n")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	report, err := importer.ImportObsidianVault(vault, "", true, nil)
+	if err != nil {
+		t.Fatalf("ImportObsidianVault(dry run) error = %v", err)
+	}
+	if !report.DryRun || !slices.Equal(report.Notes, []string{"work/Project Plan.md"}) {
+		t.Errorf("dry run Notes = %v, want only work/Project Plan.md", report.Notes)
+	}
+	if want := []ImportIssue{{Path: "Home.md", Reason: "Home.md already exists"}}; !slices.Equal(report.Skipped, want) {
+		t.Errorf("Skipped = %v, want %v", report.Skipped, want)
+	}
+	if _, err := fs.StatFile("work/Project Plan.md"); err == nil {
+		t.Error("dry run wrote a note")
+	}
+	if _, err := fs.StatFile("assets/diagram.png"); err == nil {
+		t.Error("dry run copied an attachment")
+	}
+
+	existing, _ := fs.ReadFile("Home.md")
+	if string(existing) != "# Existing\n" {
+		t.Errorf("existing note was modified: %q", existing)
+	}
+}
+
+func TestImportService_ImportObsidianVault_InvalidTarget(t *testing.T) {
+	importer, fs := newTestImportService(t)
+	workspace, _ := fs.GetCurrentWorkspace()
+
+	tests := []struct {
+		name   string
+		vault  string
+		folder string
+	}{
+		{"folder outside workspace", t.TempDir(), "../elsewhere"},
+		{"absolute folder", t.TempDir(), "/abs"},
+		{"workspace as vault", workspace.RootPath, ""},
+		{"vault is not a directory", filepath.Join(t.TempDir(), "missing"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := importer.ImportObsidianVault(tt.vault, tt.folder, true, nil)
+			var invalid *domain.ErrInvalidPath
+			if !errors.As(err, &invalid) {
+				t.Errorf("ImportObsidianVault() error = %v, want ErrInvalidPath", err)
+			}
+		})
+	}
+}
+
+func TestImportSource_ResolveWikilink(t *testing.T) {
+	src := &importSource{files: map[string]string{}}
+	for _, rel := range []string{"a/Note.md", "b/Note.md", "b/c/Other.md", "Other.md", "img/pic.png"} {
+		src.files[strings.ToLower(rel)] = rel
+	}
+
+	tests := []struct {
+		target        string
+		fromNote      string
+		relativeFirst bool
+		want          string
+		wantOK        bool
+	}{
+		{"Note", "b/c/x.md", false, "a/Note.md", true},
+		{"Note", "b/x.md", false, "b/Note.md", true},
+		{"note", "a/x.md", false, "a/Note.md", true},
+		{"Other", "b/c/x.md", false, "Other.md", true},
+		{"Other", "b/c/x.md", true, "b/c/Other.md", true},
+		{"../Note", "b/c/x.md", false, "b/Note.md", true},
+		{"pic.png", "a/x.md", false, "img/pic.png", true},
+		{"Missing", "a/x.md", false, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got, ok := src.resolveWikilink(tt.target, tt.fromNote, tt.relativeFirst)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("resolveWikilink(%q, %q, %v) = %q, %v, want %q, %v", tt.target, tt.fromNote, tt.relativeFirst, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"notes/backend/domain"
+)
+
+// propertyLinePattern matches a Logseq-style property line ("key:: value").
+// Keys may contain letters, digits, hyphens and underscores; the value may be empty.
+var propertyLinePattern = regexp.MustCompile(`^(\s*)([A-Za-z][\w-]*)::(?:\s+(.*?))?\s*$`)
+
+// propertyLine is a single parsed property line.
+type propertyLine struct {
+	key    string // Key as written
+	value  string // Trimmed value
+	indent string // Leading whitespace, preserved when rewriting
+}
+
+// parsePropertyLine parses a "key:: value" line.
+func parsePropertyLine(line string) (propertyLine, bool) {
+	m := propertyLinePattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+	if m == nil {
+		return propertyLine{}, false
+	}
+	return propertyLine{indent: m[1], key: m[2], value: m[3]}, true
+}
+
+// propertyKey normalizes a property key for lookups. Logseq treats keys case-insensitively.
+func propertyKey(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
+
+// pagePropertyBlock locates the page property block: the run of property lines at the start
+// of the body, optionally preceded by blank lines.
+// Returns the index of the first and one past the last property line; start == end when there is none.
+func pagePropertyBlock(lines []string) (start, end int) {
+	start = 0
+	for start < len(lines) && strings.TrimSpace(lines[start]) == "" {
+		start++
+	}
+	end = start
+	for end < len(lines) {
+		if _, ok := parsePropertyLine(lines[end]); !ok {
+			break
+		}
+		end++
+	}
+	if end == start {
+		return 0, 0
+	}
+	return start, end
+}
+
+// extractPageProperties returns the page-level properties declared at the top of the body.
+func extractPageProperties(body string) map[string]string {
+	lines := strings.Split(body, "\n")
+	start, end := pagePropertyBlock(lines)
+
+	properties := make(map[string]string)
+	for _, line := range lines[start:end] {
+		prop, _ := parsePropertyLine(line)
+		if _, ok := properties[propertyKey(prop.key)]; !ok {
+			properties[propertyKey(prop.key)] = prop.value
+		}
+	}
+	return properties
+}
+
+// splitBlockProperties separates property lines from a block's content.
+// The first line is always content (a block whose text is "key:: value" is not a property block);
+// any following lines that are properties are returned in the map and removed from the content.
+func splitBlockProperties(content string) (string, map[string]string) {
+	properties := make(map[string]string)
+
+	lines := strings.Split(content, "\n")
+	if len(lines) < 2 {
+		return content, properties
+	}
+
+	kept := []string{lines[0]}
+	for _, line := range lines[1:] {
+		if prop, ok := parsePropertyLine(line); ok {
+			properties[propertyKey(prop.key)] = prop.value
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	return strings.Join(kept, "\n"), properties
+}
+
+// splitPropertyList splits a Logseq list value ("[[a]], b, #c") into plain names.
+func splitPropertyList(value string) []string {
+	items := []string{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		part = strings.TrimPrefix(part, "#")
+		part = strings.TrimSuffix(strings.TrimPrefix(part, "[["), "]]")
+		part = strings.TrimSpace(part)
+		if part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
+
+// applyPageProperties rewrites the page property block in body to match properties.
+// Existing lines keep their position, key spelling and indentation; only changed values are rewritten.
+// When a key repeats, the first occurrence is the one read and updated.
+// Keys missing from properties are removed, and new keys are appended in sorted order.
+func applyPageProperties(body string, updates map[string]string) string {
+	properties := make(map[string]string, len(updates))
+	for key, value := range updates {
+		properties[propertyKey(key)] = value
+	}
+
+	lines := strings.Split(body, "\n")
+	start, end := pagePropertyBlock(lines)
+
+	seen := make(map[string]bool)
+	block := []string{}
+	for _, line := range lines[start:end] {
+		prop, _ := parsePropertyLine(line)
+		key := propertyKey(prop.key)
+		value, ok := properties[key]
+		if !ok {
+			continue
+		}
+		if seen[key] {
+			// Repeated keys only honour the first occurrence; keep later lines as written.
+			block = append(block, line)
+			continue
+		}
+		seen[key] = true
+		if value != prop.value {
+			line = formatPropertyLine(prop.indent, prop.key, value)
+		}
+		block = append(block, line)
+	}
+
+	added := []string{}
+	for key := range properties {
+		if !seen[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	for _, key := range added {
+		block = append(block, formatPropertyLine("", key, properties[key]))
+	}
+
+	rest := lines[end:]
+	if end == start && len(block) > 0 {
+		// New property block: insert at the very top, separated from existing content.
+		rest = lines
+		if len(rest) > 0 && strings.TrimSpace(rest[0]) != "" {
+			rest = append([]string{""}, rest...)
+		}
+		return strings.Join(append(block, rest...), "\n")
+	}
+
+	out := append([]string{}, lines[:start]...)
+	out = append(out, block...)
+	out = append(out, rest...)
+	return strings.Join(out, "\n")
+}
+
+func formatPropertyLine(indent, key, value string) string {
+	if value == "" {
+		return indent + key + "::"
+	}
+	return indent + key + ":: " + value
+}
+
+// collectNoteProperties merges a note's page properties with the properties of its blocks.
+// Page properties take precedence over block properties with the same key.
+func collectNoteProperties(note *domain.Note) map[string]string {
+	properties := make(map[string]string)
+	for _, block := range note.Blocks {
+		for key, value := range block.Properties {
+			if _, ok := properties[key]; !ok {
+				properties[key] = value
+			}
+		}
+	}
+	for key, value := range note.Properties {
+		properties[propertyKey(key)] = value
+	}
+	return properties
+}
+
+// matchesProperties reports whether properties satisfy every filter.
+// Values compare case-insensitively, and a filter matches any item of a comma-separated list value.
+func matchesProperties(properties, filters map[string]string) bool {
+	for key, want := range filters {
+		value, ok := properties[propertyKey(key)]
+		if !ok {
+			return false
+		}
+		if want == "" || strings.EqualFold(value, want) {
+			continue
+		}
+
+		matched := false
+		for _, item := range splitPropertyList(value) {
+			if strings.EqualFold(item, strings.TrimSpace(want)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"notes/backend/domain"
+)
+
+func TestExtractPageProperties(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want map[string]string
+	}{
+		{
+			name: "properties at top of page",
+			body: "title:: My Page\nStatus:: active\nempty::\n\n- first block\n",
+			want: map[string]string{"title": "My Page", "status": "active", "empty": ""},
+		},
+		{
+			name: "leading blank lines",
+			body: "\n\ntype:: book\n# Heading\n",
+			want: map[string]string{"type": "book"},
+		},
+		{
+			name: "properties after content are not page properties",
+			body: "# Heading\n\nstatus:: active\n",
+			want: map[string]string{},
+		},
+		{
+			name: "first occurrence wins",
+			body: "rating:: 4\nrating:: 5\n",
+			want: map[string]string{"rating": "4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractPageProperties(tt.body)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("extractPageProperties() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitBlockProperties(t *testing.T) {
+	content, properties := splitBlockProperties("Read the paper\nid:: 64f1c2a0-1111-2222-3333-444455556666\ncollapsed:: true\nmore text")
+
+	if content != "Read the paper\nmore text" {
+		t.Errorf("splitBlockProperties() content = %q", content)
+	}
+	want := map[string]string{"id": "64f1c2a0-1111-2222-3333-444455556666", "collapsed": "true"}
+	if !maps.Equal(properties, want) {
+		t.Errorf("splitBlockProperties() properties = %v, want %v", properties, want)
+	}
+
+	content, properties = splitBlockProperties("status:: single line")
+	if content != "status:: single line" || len(properties) != 0 {
+		t.Errorf("single-line block should not be treated as properties, got %q %v", content, properties)
+	}
+}
+
+func TestApplyPageProperties(t *testing.T) {
+	body := "Title:: Old\n  status:: draft\ntags:: a, b\n\n- block\n"
+
+	tests := []struct {
+		name    string
+		updates map[string]string
+		want    string
+	}{
+		{
+			name:    "unchanged properties round-trip exactly",
+			updates: map[string]string{"title": "Old", "status": "draft", "tags": "a, b"},
+			want:    body,
+		},
+		{
+			name:    "changed value keeps position, key spelling and indent",
+			updates: map[string]string{"title": "New", "status": "done", "tags": "a, b"},
+			want:    "Title:: New\n  status:: done\ntags:: a, b\n\n- block\n",
+		},
+		{
+			name:    "removed and added keys",
+			updates: map[string]string{"title": "Old", "tags": "a, b", "rating": "5", "author": "Ada"},
+			want:    "Title:: Old\ntags:: a, b\nauthor:: Ada\nrating:: 5\n\n- block\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyPageProperties(body, tt.updates); got != tt.want {
+				t.Errorf("applyPageProperties() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := applyPageProperties("# Heading\n", map[string]string{"type": "book"}); got != "type:: book\n\n# Heading\n" {
+		t.Errorf("applyPageProperties() new block = %q", got)
+	}
+}
+
+func TestNoteService_Properties(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	fs, err := NewFilesystemService()
+	if err != nil {
+		t.Fatalf("NewFilesystemService() error = %v", err)
+	}
+	defer fs.Close()
+
+	if _, err := fs.OpenWorkspace(tmpDir); err != nil {
+		t.Fatalf("OpenWorkspace() error = %v", err)
+	}
+
+	noteService := NewNoteService(fs)
+
+	content := "title:: Reading List\nalias:: books, [[Library]]\ntags:: [[reading]], #media\n\n- Dune\n  author:: Frank Herbert\n  id:: 6540a1b2-0000-4000-8000-000000000001\n- Neuromancer\n"
+	if err := fs.WriteFile("reading.md", []byte(content)); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	note, err := noteService.GetNote("reading.md")
+	if err != nil {
+		t.Fatalf("GetNote() error = %v", err)
+	}
+
+	if note.Title != "Reading List" {
+		t.Errorf("Title = %q, want %q", note.Title, "Reading List")
+	}
+	if want := []string{"books", "Library"}; !slices.Equal(note.Aliases, want) {
+		t.Errorf("Aliases = %v, want %v", note.Aliases, want)
+	}
+
+	tagNames := []string{}
+	for _, tag := range note.Tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+	if want := []string{"media", "reading"}; !slices.Equal(tagNames, want) {
+		t.Errorf("Tags = %v, want %v", tagNames, want)
+	}
+
+	var dune *domain.Block
+	for i := range note.Blocks {
+		if note.Blocks[i].Content == "Dune" {
+			dune = &note.Blocks[i]
+		}
+	}
+	if dune == nil {
+		t.Fatalf("Blocks = %+v, want a block with content %q", note.Blocks, "Dune")
+	}
+	if dune.Properties["author"] != "Frank Herbert" {
+		t.Errorf("block properties = %v", dune.Properties)
+	}
+	if dune.ID != "6540a1b2-0000-4000-8000-000000000001" {
+		t.Errorf("block ID = %q, want id:: property", dune.ID)
+	}
+
+	note.Properties["status"] = "reading"
+	if err := noteService.SaveNote(note); err != nil {
+		t.Fatalf("SaveNote() error = %v", err)
+	}
+
+	saved, err := noteService.GetNote("reading.md")
+	if err != nil {
+		t.Fatalf("GetNote() after save error = %v", err)
+	}
+	if saved.Properties["status"] != "reading" || saved.Properties["alias"] != "books, [[Library]]" {
+		t.Errorf("Properties after save = %v", saved.Properties)
+	}
+	wantBody := "title:: Reading List\nalias:: books, [[Library]]\ntags:: [[reading]], #media\nstatus:: reading\n\n- Dune\n"
+	if !strings.HasPrefix(strings.TrimLeft(saved.Content, "\n"), wantBody) {
+		t.Errorf("Content after save = %q", saved.Content)
+	}
+}
+
+func TestSearchService_PropertyFilter(t *testing.T) {
+	search := NewSearchService()
+
+	notes := []domain.Note{
+		{
+			ID:         "dune.md",
+			Title:      "Dune",
+			Path:       "dune.md",
+			Content:    "type:: book\nstatus:: done",
+			Properties: map[string]string{"type": "book", "status": "done"},
+			ModifiedAt: time.Now(),
+		},
+		{
+			ID:         "meeting.md",
+			Title:      "Meeting",
+			Path:       "meeting.md",
+			Content:    "- Agenda\n  owner:: ada",
+			Properties: map[string]string{"type": "meeting"},
+			Blocks:     []domain.Block{{Content: "Agenda", Properties: map[string]string{"owner": "ada"}}},
+			ModifiedAt: time.Now(),
+		},
+		{
+			ID:         "list.md",
+			Title:      "List",
+			Path:       "list.md",
+			Content:    "genres:: scifi, fantasy",
+			Properties: map[string]string{"genres": "[[scifi]], fantasy"},
+			ModifiedAt: time.Now(),
+		},
+	}
+	if err := search.IndexAll(notes); err != nil {
+		t.Fatalf("IndexAll() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		properties map[string]string
+		want       []string
+	}{
+		{"page property value", map[string]string{"Type": "BOOK"}, []string{"dune.md"}},
+		{"block property", map[string]string{"owner": "ada"}, []string{"meeting.md"}},
+		{"key exists", map[string]string{"type": ""}, []string{"dune.md", "meeting.md"}},
+		{"list item", map[string]string{"genres": "scifi"}, []string{"list.md"}},
+		{"all filters must match", map[string]string{"type": "book", "status": "open"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := search.Search(SearchQuery{Properties: tt.properties})
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			got := []string{}
+			for _, result := range results {
+				got = append(got, result.NoteID)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Search() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
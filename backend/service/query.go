@@ -0,0 +1,902 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"notes/backend/domain"
+)
+
+// QueryValueType identifies the type of a value produced by a metadata query.
+type QueryValueType string
+
+const (
+	QueryValueNull    QueryValueType = "null"
+	QueryValueString  QueryValueType = "string"
+	QueryValueNumber  QueryValueType = "number"
+	QueryValueBoolean QueryValueType = "boolean"
+	QueryValueDate    QueryValueType = "date"
+	QueryValueLink    QueryValueType = "link"
+	QueryValueList    QueryValueType = "list"
+)
+
+// QueryValue is a typed value from the metadata index or an evaluated query expression.
+// Serialized to JSON as {"type": ..., "value": ...}.
+type QueryValue struct {
+	Type   QueryValueType
+	String string // String contents, or the link target for links
+	Number float64
+	Bool   bool
+	Date   time.Time
+	List   []QueryValue
+}
+
+// NullValue returns the null query value.
+func NullValue() QueryValue { return QueryValue{Type: QueryValueNull} }
+
+// StringValue wraps a string as a query value.
+func StringValue(s string) QueryValue { return QueryValue{Type: QueryValueString, String: s} }
+
+// NumberValue wraps a number as a query value.
+func NumberValue(n float64) QueryValue { return QueryValue{Type: QueryValueNumber, Number: n} }
+
+// BoolValue wraps a boolean as a query value.
+func BoolValue(b bool) QueryValue { return QueryValue{Type: QueryValueBoolean, Bool: b} }
+
+// DateValue wraps a time as a query value.
+func DateValue(t time.Time) QueryValue { return QueryValue{Type: QueryValueDate, Date: t} }
+
+// LinkValue wraps a wikilink target as a query value.
+func LinkValue(target string) QueryValue { return QueryValue{Type: QueryValueLink, String: target} }
+
+// ListValue wraps a list of values as a query value.
+func ListValue(items []QueryValue) QueryValue { return QueryValue{Type: QueryValueList, List: items} }
+
+// MarshalJSON encodes the value as {"type": ..., "value": ...} for the frontend.
+func (v QueryValue) MarshalJSON() ([]byte, error) {
+	var value any
+	switch v.Type {
+	case QueryValueString, QueryValueLink:
+		value = v.String
+	case QueryValueNumber:
+		value = v.Number
+	case QueryValueBoolean:
+		value = v.Bool
+	case QueryValueDate:
+		value = v.Date.Format(time.RFC3339)
+	case QueryValueList:
+		value = v.List
+	}
+	return json.Marshal(struct {
+		Type  QueryValueType `json:"type"`
+		Value any            `json:"value"`
+	}{v.Type, value})
+}
+
+// Display formats the value as plain text.
+func (v QueryValue) Display() string {
+	switch v.Type {
+	case QueryValueString:
+		return v.String
+	case QueryValueLink:
+		return "[[" + v.String + "]]"
+	case QueryValueNumber:
+		return strconv.FormatFloat(v.Number, 'f', -1, 64)
+	case QueryValueBoolean:
+		return strconv.FormatBool(v.Bool)
+	case QueryValueDate:
+		if v.Date.Hour() == 0 && v.Date.Minute() == 0 && v.Date.Second() == 0 {
+			return v.Date.Format("2006-01-02")
+		}
+		return v.Date.Format("2006-01-02 15:04")
+	case QueryValueList:
+		parts := make([]string, len(v.List))
+		for i, item := range v.List {
+			parts[i] = item.Display()
+		}
+		return strings.Join(parts, ", ")
+	}
+	return ""
+}
+
+// truthy reports whether the value counts as true in a WHERE clause.
+func (v QueryValue) truthy() bool {
+	switch v.Type {
+	case QueryValueBoolean:
+		return v.Bool
+	case QueryValueNumber:
+		return v.Number != 0
+	case QueryValueString:
+		return v.String != ""
+	case QueryValueList:
+		return len(v.List) > 0
+	case QueryValueDate, QueryValueLink:
+		return true
+	}
+	return false
+}
+
+// QueryResult is the output of a metadata query.
+type QueryResult struct {
+	Type      QueryType  `json:"type"`      // "table" or "list"
+	Columns   []string   `json:"columns"`   // Column headers (TABLE includes "File" unless WITHOUT ID)
+	Rows      []QueryRow `json:"rows"`      // Matching notes in result order
+	WithoutID bool       `json:"withoutId"` // True when the TABLE omits the leading file column
+}
+
+// QueryRow is a single matching note and its evaluated column values.
+type QueryRow struct {
+	NoteID string       `json:"noteId"`
+	Title  string       `json:"title"`
+	Values []QueryValue `json:"values"`
+}
+
+// QueryRunner executes metadata queries. Implemented by QueryService.
+type QueryRunner interface {
+	RunQuery(source string) (*QueryResult, error)
+}
+
+// queryPage holds the indexed fields of a single note.
+type queryPage struct {
+	id     string
+	title  string
+	tags   []string
+	links  []string
+	fields map[string]QueryValue
+}
+
+// QueryService indexes note metadata (frontmatter and inline fields) and evaluates queries against it.
+type QueryService struct {
+	mu      sync.RWMutex
+	pages   map[string]*queryPage
+	schemas *SchemaService
+}
+
+// inlineFieldPattern matches a full-line inline field such as "status:: done" or "- due:: 2025-01-20".
+var inlineFieldPattern = regexp.MustCompile(`^\s*(?:[-*+]\s+)?([A-Za-z][\w -]*?)::\s*(.*?)\s*$`)
+
+// bracketedFieldPattern matches inline fields embedded in text such as "[rating:: 4]".
+var bracketedFieldPattern = regexp.MustCompile(`[\[(]([A-Za-z][\w -]*?)::\s*([^\])]*?)\s*[\])]`)
+
+// queryDatePattern matches ISO dates with an optional time component.
+var queryDatePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(?:[T ]\d{2}:\d{2}(?::\d{2})?(?:Z|[+-]\d{2}:\d{2})?)?`)
+
+// queryLinkPattern matches a value consisting solely of a wikilink.
+var queryLinkPattern = regexp.MustCompile(`^\[\[([^\]|#]+)(?:#[^\]|]*)?(?:\|[^\]]*)?\]\]$`)
+
+// NewQueryService creates a new query service.
+func NewQueryService() *QueryService {
+	return &QueryService{
+		pages: make(map[string]*queryPage),
+	}
+}
+
+// SetSchemas makes the index coerce field values to the types declared by note type schemas.
+func (s *QueryService) SetSchemas(schemas *SchemaService) {
+	s.schemas = schemas
+}
+
+// IndexNote adds or updates a note's metadata in the query index.
+func (s *QueryService) IndexNote(note *domain.Note) error {
+	page := newQueryPage(note)
+	if s.schemas != nil {
+		s.schemas.CoerceFields(note.Type, page.fields)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pages[note.ID] = page
+	return nil
+}
+
+// RemoveNote removes a note from the query index.
+func (s *QueryService) RemoveNote(noteID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.pages, noteID)
+}
+
+// Clear removes all notes from the query index.
+func (s *QueryService) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pages = make(map[string]*queryPage)
+}
+
+// RunQuery parses and evaluates a query such as
+// `TABLE status, due FROM #project WHERE status != "done" SORT due`.
+// Syntax errors are returned as *domain.ErrQuerySyntax with the offending line and column, and
+// errors evaluating it, such as a division by zero or a SORT by a field no note has, as
+// *domain.ErrQueryEval.
+func (s *QueryService) RunQuery(source string) (*QueryResult, error) {
+	q, err := parseQuery(source)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.pages))
+	for id := range s.pages {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, key := range q.sort {
+		if field, ok := key.expr.(fieldExpr); ok && len(s.pages) > 0 && !s.hasField(field.path) {
+			return nil, queryEvalError(field.at, "no note has a field %q to sort by", field.path)
+		}
+	}
+
+	matched := []*queryPage{}
+	for _, id := range ids {
+		page := s.pages[id]
+		if q.from != nil && !q.from.matches(page) {
+			continue
+		}
+		if q.where != nil {
+			value, err := q.where.eval(page)
+			if err != nil {
+				return nil, err
+			}
+			if !value.truthy() {
+				continue
+			}
+		}
+		matched = append(matched, page)
+	}
+
+	if len(q.sort) > 0 {
+		keys := make(map[*queryPage][]QueryValue, len(matched))
+		for _, page := range matched {
+			values := make([]QueryValue, len(q.sort))
+			for i, key := range q.sort {
+				value, err := key.expr.eval(page)
+				if err != nil {
+					return nil, err
+				}
+				values[i] = value
+			}
+			keys[page] = values
+		}
+
+		sort.SliceStable(matched, func(i, j int) bool {
+			for k, key := range q.sort {
+				cmp := compareQueryValues(keys[matched[i]][k], keys[matched[j]][k])
+				if cmp == 0 {
+					continue
+				}
+				if key.descending {
+					return cmp > 0
+				}
+				return cmp < 0
+			}
+			return false
+		})
+	}
+
+	if q.limit > 0 && len(matched) > q.limit {
+		matched = matched[:q.limit]
+	}
+
+	result := &QueryResult{Type: q.kind, Columns: []string{}, Rows: []QueryRow{}, WithoutID: q.withoutID}
+	if q.kind == QueryTypeTable && !q.withoutID {
+		result.Columns = append(result.Columns, "File")
+	}
+	for _, column := range q.columns {
+		result.Columns = append(result.Columns, column.label)
+	}
+
+	for _, page := range matched {
+		row := QueryRow{NoteID: page.id, Title: page.title, Values: make([]QueryValue, len(q.columns))}
+		for i, column := range q.columns {
+			value, err := column.expr.eval(page)
+			if err != nil {
+				return nil, err
+			}
+			row.Values[i] = value
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	return result, nil
+}
+
+// hasField reports whether any indexed note has a field. Caller must hold the read lock.
+func (s *QueryService) hasField(path string) bool {
+	for _, page := range s.pages {
+		if _, ok := page.fields[path]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// newQueryPage builds the field index for a note.
+// Frontmatter keys and inline fields are normalized with normalizeQueryField; nested frontmatter
+// maps are flattened to dotted keys. Implicit file.* fields describe the note itself.
+func newQueryPage(note *domain.Note) *queryPage {
+	page := &queryPage{
+		id:     note.ID,
+		title:  note.Title,
+		fields: make(map[string]QueryValue),
+	}
+
+	for _, tag := range note.Tags {
+		page.tags = append(page.tags, tag.Name)
+	}
+	for _, link := range note.Links {
+		page.links = append(page.links, link.Target)
+	}
+
+	for key, value := range note.Frontmatter {
+		addFrontmatterField(page.fields, normalizeQueryField(key), value)
+	}
+
+	for key, values := range extractInlineFields(note.Content) {
+		items := make([]QueryValue, len(values))
+		for i, raw := range values {
+			items[i] = parseInlineFieldValue(raw)
+		}
+		if existing, ok := page.fields[key]; ok {
+			items = append([]QueryValue{existing}, items...)
+		}
+		if len(items) == 1 {
+			page.fields[key] = items[0]
+		} else {
+			page.fields[key] = ListValue(items)
+		}
+	}
+
+	// Standard frontmatter fields are lifted out of Frontmatter during parsing, so restore them here.
+	setDefaultField(page.fields, "title", StringValue(note.Title))
+	if note.Type != "" {
+		setDefaultField(page.fields, "type", StringValue(note.Type))
+	}
+	if len(note.Aliases) > 0 {
+		setDefaultField(page.fields, "aliases", stringListValue(note.Aliases))
+	}
+	if !note.CreatedAt.IsZero() {
+		setDefaultField(page.fields, "created", DateValue(note.CreatedAt))
+	}
+	if !note.ModifiedAt.IsZero() {
+		setDefaultField(page.fields, "modified", DateValue(note.ModifiedAt))
+	}
+	setDefaultField(page.fields, "tags", stringListValue(page.tags))
+
+	folder := filepath.ToSlash(filepath.Dir(note.Path))
+	if folder == "." {
+		folder = ""
+	}
+	page.fields["file.name"] = StringValue(strings.TrimSuffix(filepath.Base(note.Path), filepath.Ext(note.Path)))
+	page.fields["file.path"] = StringValue(note.Path)
+	page.fields["file.folder"] = StringValue(folder)
+	page.fields["file.link"] = LinkValue(strings.TrimSuffix(note.Path, filepath.Ext(note.Path)))
+	page.fields["file.title"] = StringValue(note.Title)
+	page.fields["file.tags"] = stringListValue(page.tags)
+	page.fields["file.aliases"] = stringListValue(note.Aliases)
+	page.fields["file.ctime"] = DateValue(note.CreatedAt)
+	page.fields["file.mtime"] = DateValue(note.ModifiedAt)
+
+	outlinks := make([]QueryValue, len(page.links))
+	for i, target := range page.links {
+		outlinks[i] = LinkValue(target)
+	}
+	page.fields["file.outlinks"] = ListValue(outlinks)
+
+	return page
+}
+
+func setDefaultField(fields map[string]QueryValue, key string, value QueryValue) {
+	if _, ok := fields[key]; !ok {
+		fields[key] = value
+	}
+}
+
+func stringListValue(items []string) QueryValue {
+	values := make([]QueryValue, len(items))
+	for i, item := range items {
+		values[i] = StringValue(item)
+	}
+	return ListValue(values)
+}
+
+// addFrontmatterField converts a decoded YAML value and stores it, flattening nested maps.
+func addFrontmatterField(fields map[string]QueryValue, key string, value any) {
+	if nested, ok := value.(map[string]any); ok {
+		for childKey, childValue := range nested {
+			addFrontmatterField(fields, key+"."+normalizeQueryField(childKey), childValue)
+		}
+		return
+	}
+	fields[key] = frontmatterQueryValue(value)
+}
+
+// frontmatterQueryValue converts a decoded YAML value into a typed query value.
+// Strings that look like dates or wikilinks are promoted to those types.
+func frontmatterQueryValue(value any) QueryValue {
+	switch v := value.(type) {
+	case nil:
+		return NullValue()
+	case string:
+		return parseTypedString(v)
+	case bool:
+		return BoolValue(v)
+	case int:
+		return NumberValue(float64(v))
+	case int64:
+		return NumberValue(float64(v))
+	case uint64:
+		return NumberValue(float64(v))
+	case float64:
+		return NumberValue(v)
+	case time.Time:
+		return DateValue(v)
+	case []any:
+		items := make([]QueryValue, len(v))
+		for i, item := range v {
+			items[i] = frontmatterQueryValue(item)
+		}
+		return ListValue(items)
+	case []string:
+		return stringListValue(v)
+	}
+	return StringValue(fmt.Sprint(value))
+}
+
+// parseTypedString promotes a string to a date or link value when it is formatted as one.
+func parseTypedString(s string) QueryValue {
+	trimmed := strings.TrimSpace(s)
+	if m := queryLinkPattern.FindStringSubmatch(trimmed); m != nil {
+		return LinkValue(strings.TrimSpace(m[1]))
+	}
+	if date := parseQueryDate(trimmed); date.Type == QueryValueDate {
+		return date
+	}
+	return StringValue(s)
+}
+
+// parseInlineFieldValue interprets the raw text of an inline field.
+func parseInlineFieldValue(raw string) QueryValue {
+	raw = strings.TrimSpace(raw)
+	switch strings.ToLower(raw) {
+	case "":
+		return NullValue()
+	case "true":
+		return BoolValue(true)
+	case "false":
+		return BoolValue(false)
+	}
+	if n, err := strconv.ParseFloat(raw, 64); err == nil {
+		return NumberValue(n)
+	}
+	return parseTypedString(raw)
+}
+
+// parseQueryDate parses an ISO date or date-time, returning null when s is not a date.
+func parseQueryDate(s string) QueryValue {
+	if queryDatePattern.FindString(s) != s {
+		return NullValue()
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return DateValue(t)
+		}
+	}
+	return NullValue()
+}
+
+// extractInlineFields collects `key:: value` fields from note content, skipping fenced code blocks.
+// Keys are normalized with normalizeQueryField; repeated keys accumulate values in order.
+func extractInlineFields(content string) map[string][]string {
+	fields := make(map[string][]string)
+	inFence := false
+	fence := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			marker := trimmed[:3]
+			if !inFence {
+				inFence, fence = true, marker
+			} else if marker == fence {
+				inFence = false
+			}
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		if m := inlineFieldPattern.FindStringSubmatch(line); m != nil {
+			key := normalizeQueryField(m[1])
+			fields[key] = append(fields[key], m[2])
+			continue
+		}
+
+		for _, m := range bracketedFieldPattern.FindAllStringSubmatch(line, -1) {
+			key := normalizeQueryField(m[1])
+			fields[key] = append(fields[key], m[2])
+		}
+	}
+
+	return fields
+}
+
+func (e literalExpr) eval(*queryPage) (QueryValue, error) { return e.value, nil }
+
+func (e fieldExpr) eval(page *queryPage) (QueryValue, error) {
+	if value, ok := page.fields[e.path]; ok {
+		return value, nil
+	}
+	return NullValue(), nil
+}
+
+func (e listExpr) eval(page *queryPage) (QueryValue, error) {
+	items := make([]QueryValue, len(e.items))
+	for i, item := range e.items {
+		value, err := item.eval(page)
+		if err != nil {
+			return QueryValue{}, err
+		}
+		items[i] = value
+	}
+	return ListValue(items), nil
+}
+
+func (e unaryExpr) eval(page *queryPage) (QueryValue, error) {
+	value, err := e.operand.eval(page)
+	if err != nil {
+		return QueryValue{}, err
+	}
+	switch e.op {
+	case "not":
+		return BoolValue(!value.truthy()), nil
+	case "-":
+		switch value.Type {
+		case QueryValueNumber:
+			return NumberValue(-value.Number), nil
+		case QueryValueNull:
+			return NullValue(), nil
+		}
+		return QueryValue{}, queryEvalError(e.at, "cannot negate a %s", value.Type)
+	}
+	return NullValue(), nil
+}
+
+func (e binaryExpr) eval(page *queryPage) (QueryValue, error) {
+	left, err := e.left.eval(page)
+	if err != nil {
+		return QueryValue{}, err
+	}
+	switch e.op {
+	case "and", "or":
+		if left.truthy() == (e.op == "or") {
+			return BoolValue(left.truthy()), nil
+		}
+		right, err := e.right.eval(page)
+		if err != nil {
+			return QueryValue{}, err
+		}
+		return BoolValue(right.truthy()), nil
+	}
+
+	right, err := e.right.eval(page)
+	if err != nil {
+		return QueryValue{}, err
+	}
+
+	switch e.op {
+	case "=":
+		return BoolValue(queryValuesEqual(left, right)), nil
+	case "!=":
+		return BoolValue(!queryValuesEqual(left, right)), nil
+	case "<", "<=", ">", ">=":
+		// Ordering against null is false so missing fields drop out of range filters.
+		if left.Type == QueryValueNull || right.Type == QueryValueNull {
+			return BoolValue(false), nil
+		}
+		cmp := compareQueryValues(left, right)
+		switch e.op {
+		case "<":
+			return BoolValue(cmp < 0), nil
+		case "<=":
+			return BoolValue(cmp <= 0), nil
+		case ">":
+			return BoolValue(cmp > 0), nil
+		default:
+			return BoolValue(cmp >= 0), nil
+		}
+	}
+
+	// Arithmetic on a missing field is null, like the field.
+	if left.Type == QueryValueNull || right.Type == QueryValueNull {
+		return NullValue(), nil
+	}
+	isText := func(v QueryValue) bool { return v.Type == QueryValueString || v.Type == QueryValueLink }
+
+	switch {
+	case e.op == "+" && isText(left) && isText(right):
+		return StringValue(left.Display() + right.Display()), nil
+	case e.op == "+" && left.Type == QueryValueDate && right.Type == QueryValueNumber:
+		return DateValue(left.Date.AddDate(0, 0, int(right.Number))), nil
+	case e.op == "-" && left.Type == QueryValueDate && right.Type == QueryValueDate:
+		return NumberValue(math.Round(left.Date.Sub(right.Date).Hours() / 24)), nil
+	case e.op == "-" && left.Type == QueryValueDate && right.Type == QueryValueNumber:
+		return DateValue(left.Date.AddDate(0, 0, -int(right.Number))), nil
+	case left.Type != QueryValueNumber || right.Type != QueryValueNumber:
+		return QueryValue{}, queryEvalError(e.at, "cannot apply %s to a %s and a %s", e.op, left.Type, right.Type)
+	}
+
+	switch e.op {
+	case "+":
+		return NumberValue(left.Number + right.Number), nil
+	case "-":
+		return NumberValue(left.Number - right.Number), nil
+	case "*":
+		return NumberValue(left.Number * right.Number), nil
+	default:
+		if right.Number == 0 {
+			return QueryValue{}, queryEvalError(e.at, "division by zero")
+		}
+		return NumberValue(left.Number / right.Number), nil
+	}
+}
+
+func (e callExpr) eval(page *queryPage) (QueryValue, error) {
+	args := make([]QueryValue, len(e.args))
+	for i, arg := range e.args {
+		value, err := arg.eval(page)
+		if err != nil {
+			return QueryValue{}, err
+		}
+		args[i] = value
+	}
+	return callQueryFunction(e.name, args), nil
+}
+
+// callQueryFunction applies a query function to its evaluated arguments.
+func callQueryFunction(name string, args []QueryValue) QueryValue {
+	switch name {
+	case "contains":
+		haystack, needle := args[0], args[1]
+		switch haystack.Type {
+		case QueryValueList:
+			for _, item := range haystack.List {
+				if queryValuesEqual(item, needle) {
+					return BoolValue(true)
+				}
+			}
+			return BoolValue(false)
+		case QueryValueString:
+			return BoolValue(strings.Contains(strings.ToLower(haystack.String), strings.ToLower(needle.Display())))
+		case QueryValueLink:
+			return BoolValue(queryValuesEqual(haystack, needle))
+		}
+		return BoolValue(false)
+	case "length":
+		switch args[0].Type {
+		case QueryValueList:
+			return NumberValue(float64(len(args[0].List)))
+		case QueryValueString:
+			return NumberValue(float64(len([]rune(args[0].String))))
+		case QueryValueNull:
+			return NumberValue(0)
+		}
+		return NumberValue(1)
+	case "lower":
+		if args[0].Type == QueryValueString {
+			return StringValue(strings.ToLower(args[0].String))
+		}
+		return args[0]
+	case "upper":
+		if args[0].Type == QueryValueString {
+			return StringValue(strings.ToUpper(args[0].String))
+		}
+		return args[0]
+	case "date":
+		switch args[0].Type {
+		case QueryValueDate:
+			return args[0]
+		case QueryValueString:
+			switch strings.ToLower(args[0].String) {
+			case "today":
+				now := time.Now()
+				return DateValue(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+			case "now":
+				return DateValue(time.Now())
+			}
+			return parseQueryDate(strings.TrimSpace(args[0].String))
+		}
+		return NullValue()
+	case "number":
+		switch args[0].Type {
+		case QueryValueNumber:
+			return args[0]
+		case QueryValueString:
+			if n, err := strconv.ParseFloat(strings.TrimSpace(args[0].String), 64); err == nil {
+				return NumberValue(n)
+			}
+		}
+		return NullValue()
+	case "link":
+		if args[0].Type == QueryValueString || args[0].Type == QueryValueLink {
+			return LinkValue(args[0].String)
+		}
+		return NullValue()
+	case "default":
+		if args[0].Type == QueryValueNull {
+			return args[1]
+		}
+		return args[0]
+	}
+
+	return NullValue()
+}
+
+// queryEvalError reports a problem evaluating a query at the given token.
+func queryEvalError(tok queryToken, format string, args ...any) error {
+	return &domain.ErrQueryEval{Line: tok.line, Column: tok.column, Message: fmt.Sprintf(format, args...)}
+}
+
+func (s tagSource) matches(page *queryPage) bool {
+	for _, tag := range page.tags {
+		if isTagOrDescendant(tag, s.tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s folderSource) matches(page *queryPage) bool {
+	if s.folder == "" {
+		return true
+	}
+	return page.id == s.folder || strings.HasPrefix(page.id, s.folder+"/") ||
+		strings.TrimSuffix(page.id, filepath.Ext(page.id)) == s.folder
+}
+
+func (s linkSource) matches(page *queryPage) bool {
+	for _, target := range page.links {
+		if normalizeLinkTarget(target) == normalizeLinkTarget(s.target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s notSource) matches(page *queryPage) bool { return !s.inner.matches(page) }
+func (s andSource) matches(page *queryPage) bool {
+	return s.left.matches(page) && s.right.matches(page)
+}
+func (s orSource) matches(page *queryPage) bool { return s.left.matches(page) || s.right.matches(page) }
+
+// normalizeLinkTarget folds a link target for comparison ("Notes/Foo.md" matches "notes/foo").
+func normalizeLinkTarget(target string) string {
+	target = strings.TrimSpace(target)
+	if isMarkdownFile(target) {
+		target = strings.TrimSuffix(target, filepath.Ext(target))
+	}
+	return strings.ToLower(target)
+}
+
+// queryValuesEqual compares two values for equality, coercing strings to dates and links when needed.
+func queryValuesEqual(a, b QueryValue) bool {
+	if a.Type == QueryValueNull || b.Type == QueryValueNull {
+		return a.Type == b.Type
+	}
+	if a.Type == QueryValueList && b.Type == QueryValueList {
+		if len(a.List) != len(b.List) {
+			return false
+		}
+		for i := range a.List {
+			if !queryValuesEqual(a.List[i], b.List[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if a.Type == QueryValueLink || b.Type == QueryValueLink {
+		if (a.Type == QueryValueLink || a.Type == QueryValueString) && (b.Type == QueryValueLink || b.Type == QueryValueString) {
+			return normalizeLinkTarget(a.String) == normalizeLinkTarget(b.String)
+		}
+		return false
+	}
+	a, b = coerceQueryPair(a, b)
+	if a.Type != b.Type {
+		return false
+	}
+	return compareQueryValues(a, b) == 0
+}
+
+// coerceQueryPair converts a string operand to a date when compared against a date.
+func coerceQueryPair(a, b QueryValue) (QueryValue, QueryValue) {
+	if a.Type == QueryValueDate && b.Type == QueryValueString {
+		if d := parseQueryDate(b.String); d.Type == QueryValueDate {
+			b = d
+		}
+	}
+	if b.Type == QueryValueDate && a.Type == QueryValueString {
+		if d := parseQueryDate(a.String); d.Type == QueryValueDate {
+			a = d
+		}
+	}
+	return a, b
+}
+
+// queryTypeRank orders values of different types when sorting; nulls sort first.
+var queryTypeRank = map[QueryValueType]int{
+	QueryValueNull:    0,
+	QueryValueBoolean: 1,
+	QueryValueNumber:  2,
+	QueryValueDate:    3,
+	QueryValueString:  4,
+	QueryValueLink:    5,
+	QueryValueList:    6,
+}
+
+// compareQueryValues orders two values, returning -1, 0 or 1.
+// Values of different types are ordered by type so sorting is always total.
+func compareQueryValues(a, b QueryValue) int {
+	a, b = coerceQueryPair(a, b)
+	if a.Type != b.Type {
+		return compareInts(queryTypeRank[a.Type], queryTypeRank[b.Type])
+	}
+
+	switch a.Type {
+	case QueryValueBoolean:
+		if a.Bool == b.Bool {
+			return 0
+		}
+		if !a.Bool {
+			return -1
+		}
+		return 1
+	case QueryValueNumber:
+		switch {
+		case a.Number < b.Number:
+			return -1
+		case a.Number > b.Number:
+			return 1
+		}
+		return 0
+	case QueryValueDate:
+		return a.Date.Compare(b.Date)
+	case QueryValueString, QueryValueLink:
+		return strings.Compare(strings.ToLower(a.String), strings.ToLower(b.String))
+	case QueryValueList:
+		for i := 0; i < len(a.List) && i < len(b.List); i++ {
+			if cmp := compareQueryValues(a.List[i], b.List[i]); cmp != 0 {
+				return cmp
+			}
+		}
+		return compareInts(len(a.List), len(b.List))
+	}
+	return 0
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
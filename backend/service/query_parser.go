@@ -0,0 +1,793 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"notes/backend/domain"
+)
+
+// QueryType identifies the output shape of a metadata query.
+type QueryType string
+
+const (
+	QueryTypeTable QueryType = "table"
+	QueryTypeList  QueryType = "list"
+)
+
+// queryTokenKind categorizes lexical tokens of the query language.
+type queryTokenKind int
+
+const (
+	tokEOF queryTokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokDate
+	tokTag
+	tokLink
+	tokPunct
+)
+
+// queryToken is a single lexical token with its source position.
+type queryToken struct {
+	kind   queryTokenKind
+	text   string // Identifier/punctuation text, decoded string, tag name, or link target
+	offset int    // Byte offset of the token in the query source
+	end    int    // Byte offset just past the token
+	line   int
+	column int
+}
+
+// describe returns a human-readable description of the token for error messages.
+func (t queryToken) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return fmt.Sprintf("string %q", t.text)
+	case tokTag:
+		return fmt.Sprintf("tag #%s", t.text)
+	case tokLink:
+		return fmt.Sprintf("link [[%s]]", t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// queryExpr is an expression node in a parsed query. Evaluation errors are returned as
+// *domain.ErrQueryEval positioned at the offending operator or field.
+type queryExpr interface {
+	eval(page *queryPage) (QueryValue, error)
+}
+
+type (
+	literalExpr struct{ value QueryValue }
+	fieldExpr   struct {
+		path string
+		at   queryToken
+	}
+	unaryExpr struct {
+		op      string
+		operand queryExpr
+		at      queryToken
+	}
+	binaryExpr struct {
+		op          string
+		left, right queryExpr
+		at          queryToken
+	}
+	callExpr struct {
+		name string
+		args []queryExpr
+	}
+	listExpr struct{ items []queryExpr }
+)
+
+// querySource is a FROM clause predicate selecting candidate notes.
+type querySource interface {
+	matches(page *queryPage) bool
+}
+
+type (
+	tagSource    struct{ tag string }
+	folderSource struct{ folder string }
+	linkSource   struct{ target string }
+	notSource    struct{ inner querySource }
+	andSource    struct{ left, right querySource }
+	orSource     struct{ left, right querySource }
+)
+
+// queryColumn is a single TABLE column.
+type queryColumn struct {
+	expr  queryExpr
+	label string
+}
+
+// querySort is a single SORT key.
+type querySort struct {
+	expr       queryExpr
+	descending bool
+}
+
+// parsedQuery is the syntax tree of a complete query.
+type parsedQuery struct {
+	kind      QueryType
+	withoutID bool
+	columns   []queryColumn
+	from      querySource
+	where     queryExpr
+	sort      []querySort
+	limit     int
+}
+
+// queryFunctions lists the supported functions and their accepted argument counts.
+var queryFunctions = map[string][2]int{
+	"contains": {2, 2},
+	"length":   {1, 1},
+	"lower":    {1, 1},
+	"upper":    {1, 1},
+	"date":     {1, 1},
+	"number":   {1, 1},
+	"link":     {1, 1},
+	"default":  {2, 2},
+}
+
+// queryParser is a recursive-descent parser for the query language.
+type queryParser struct {
+	source string
+	tokens []queryToken
+	pos    int
+}
+
+// parseQuery parses query source into a syntax tree.
+// Returns *domain.ErrQuerySyntax describing the first problem found.
+func parseQuery(source string) (*parsedQuery, error) {
+	tokens, err := lexQuery(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{source: source, tokens: tokens}
+	return p.parse()
+}
+
+func (p *queryParser) parse() (*parsedQuery, error) {
+	q := &parsedQuery{}
+
+	switch {
+	case p.acceptKeyword("TABLE"):
+		q.kind = QueryTypeTable
+		if p.acceptKeyword("WITHOUT") {
+			if !p.acceptKeyword("ID") {
+				return nil, p.errorf(p.peek(), "expected ID after WITHOUT, found %s", p.peek().describe())
+			}
+			q.withoutID = true
+		}
+		if !p.atClauseStart() {
+			for {
+				column, err := p.parseColumn()
+				if err != nil {
+					return nil, err
+				}
+				q.columns = append(q.columns, column)
+				if !p.acceptPunct(",") {
+					break
+				}
+			}
+		}
+	case p.acceptKeyword("LIST"):
+		q.kind = QueryTypeList
+		if !p.atClauseStart() {
+			column, err := p.parseColumn()
+			if err != nil {
+				return nil, err
+			}
+			q.columns = append(q.columns, column)
+		}
+	default:
+		return nil, p.errorf(p.peek(), "expected TABLE or LIST, found %s", p.peek().describe())
+	}
+
+	seen := map[string]bool{}
+	for p.peek().kind != tokEOF {
+		tok := p.peek()
+		keyword := strings.ToUpper(tok.text)
+		if tok.kind != tokIdent || !isQueryClause(keyword) {
+			return nil, p.errorf(tok, "expected FROM, WHERE, SORT or LIMIT, found %s", tok.describe())
+		}
+		if seen[keyword] {
+			return nil, p.errorf(tok, "duplicate %s clause", keyword)
+		}
+		seen[keyword] = true
+		p.pos++
+
+		var err error
+		switch keyword {
+		case "FROM":
+			q.from, err = p.parseSourceOr()
+		case "WHERE":
+			q.where, err = p.parseExpr()
+		case "SORT":
+			q.sort, err = p.parseSortKeys()
+		case "LIMIT":
+			q.limit, err = p.parseLimit()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return q, nil
+}
+
+func (p *queryParser) parseColumn() (queryColumn, error) {
+	start := p.peek()
+	expr, err := p.parseExpr()
+	if err != nil {
+		return queryColumn{}, err
+	}
+	label := strings.TrimSpace(p.source[start.offset:p.tokens[p.pos-1].end])
+
+	if p.acceptKeyword("AS") {
+		tok := p.peek()
+		if tok.kind != tokString && tok.kind != tokIdent {
+			return queryColumn{}, p.errorf(tok, "expected column name after AS, found %s", tok.describe())
+		}
+		label = tok.text
+		p.pos++
+	}
+
+	return queryColumn{expr: expr, label: label}, nil
+}
+
+func (p *queryParser) parseSortKeys() ([]querySort, error) {
+	keys := []querySort{}
+	for {
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		key := querySort{expr: expr}
+		if p.acceptKeyword("DESC") {
+			key.descending = true
+		} else {
+			p.acceptKeyword("ASC")
+		}
+		keys = append(keys, key)
+		if !p.acceptPunct(",") {
+			return keys, nil
+		}
+	}
+}
+
+func (p *queryParser) parseLimit() (int, error) {
+	tok := p.peek()
+	if tok.kind != tokNumber {
+		return 0, p.errorf(tok, "expected a number after LIMIT, found %s", tok.describe())
+	}
+	n, err := strconv.Atoi(tok.text)
+	if err != nil || n < 0 {
+		return 0, p.errorf(tok, "LIMIT must be a non-negative whole number")
+	}
+	p.pos++
+	return n, nil
+}
+
+func (p *queryParser) parseSourceOr() (querySource, error) {
+	left, err := p.parseSourceAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseSourceAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orSource{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseSourceAnd() (querySource, error) {
+	left, err := p.parseSourceUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseSourceUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andSource{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseSourceUnary() (querySource, error) {
+	if p.acceptPunct("-") || p.acceptPunct("!") {
+		inner, err := p.parseSourceUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notSource{inner}, nil
+	}
+
+	tok := p.peek()
+	switch {
+	case tok.kind == tokTag:
+		p.pos++
+		return tagSource{tag: normalizeTagName(tok.text)}, nil
+	case tok.kind == tokString:
+		p.pos++
+		return folderSource{folder: strings.Trim(tok.text, "/")}, nil
+	case tok.kind == tokLink:
+		p.pos++
+		return linkSource{target: tok.text}, nil
+	case p.acceptPunct("("):
+		inner, err := p.parseSourceOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.acceptPunct(")") {
+			return nil, p.errorf(p.peek(), "expected ) to close source group, found %s", p.peek().describe())
+		}
+		return inner, nil
+	}
+
+	return nil, p.errorf(tok, "expected #tag, \"folder\" or [[link]] in FROM, found %s", tok.describe())
+}
+
+func (p *queryParser) parseExpr() (queryExpr, error) {
+	return p.parseOr()
+}
+
+func (p *queryParser) parseOr() (queryExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") || p.acceptPunct("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") || p.acceptPunct("&&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (queryExpr, error) {
+	if p.acceptKeyword("NOT") || p.acceptPunct("!") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return unaryExpr{op: "not", operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *queryParser) parseComparison() (queryExpr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	for _, op := range []string{"=", "==", "!=", "<=", ">=", "<", ">"} {
+		if p.acceptPunct(op) {
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			if op == "==" {
+				op = "="
+			}
+			return binaryExpr{op: op, left: left, right: right, at: tok}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAdditive() (queryExpr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		op := tok.text
+		if tok.kind != tokPunct || (op != "+" && op != "-") {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: op, left: left, right: right, at: tok}
+	}
+}
+
+func (p *queryParser) parseMultiplicative() (queryExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		op := tok.text
+		if tok.kind != tokPunct || (op != "*" && op != "/") {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: op, left: left, right: right, at: tok}
+	}
+}
+
+func (p *queryParser) parseUnary() (queryExpr, error) {
+	tok := p.peek()
+	if p.acceptPunct("-") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryExpr{op: "-", operand: operand, at: tok}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryExpr, error) {
+	tok := p.peek()
+
+	switch tok.kind {
+	case tokNumber:
+		p.pos++
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid number %q", tok.text)
+		}
+		return literalExpr{NumberValue(n)}, nil
+	case tokString:
+		p.pos++
+		return literalExpr{StringValue(tok.text)}, nil
+	case tokDate:
+		p.pos++
+		value := parseQueryDate(tok.text)
+		if value.Type != QueryValueDate {
+			return nil, p.errorf(tok, "invalid date %q", tok.text)
+		}
+		return literalExpr{value}, nil
+	case tokLink:
+		p.pos++
+		return literalExpr{LinkValue(tok.text)}, nil
+	case tokTag:
+		p.pos++
+		return literalExpr{StringValue("#" + tok.text)}, nil
+	case tokIdent:
+		return p.parseIdentifier()
+	case tokPunct:
+		if p.acceptPunct("(") {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.acceptPunct(")") {
+				return nil, p.errorf(p.peek(), "expected ), found %s", p.peek().describe())
+			}
+			return expr, nil
+		}
+		if p.acceptPunct("[") {
+			items := []queryExpr{}
+			if !p.acceptPunct("]") {
+				for {
+					item, err := p.parseExpr()
+					if err != nil {
+						return nil, err
+					}
+					items = append(items, item)
+					if p.acceptPunct("]") {
+						break
+					}
+					if !p.acceptPunct(",") {
+						return nil, p.errorf(p.peek(), "expected , or ] in list, found %s", p.peek().describe())
+					}
+				}
+			}
+			return listExpr{items: items}, nil
+		}
+	}
+
+	return nil, p.errorf(tok, "expected a value or field, found %s", tok.describe())
+}
+
+func (p *queryParser) parseIdentifier() (queryExpr, error) {
+	tok := p.peek()
+	p.pos++
+
+	switch strings.ToUpper(tok.text) {
+	case "TRUE":
+		return literalExpr{BoolValue(true)}, nil
+	case "FALSE":
+		return literalExpr{BoolValue(false)}, nil
+	case "NULL":
+		return literalExpr{NullValue()}, nil
+	}
+	if isQueryKeyword(strings.ToUpper(tok.text)) {
+		return nil, p.errorf(tok, "unexpected keyword %s", strings.ToUpper(tok.text))
+	}
+
+	if p.acceptPunct("(") {
+		name := strings.ToLower(tok.text)
+		arity, ok := queryFunctions[name]
+		if !ok {
+			return nil, p.errorf(tok, "unknown function %s()", tok.text)
+		}
+
+		args := []queryExpr{}
+		if !p.acceptPunct(")") {
+			for {
+				arg, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if p.acceptPunct(")") {
+					break
+				}
+				if !p.acceptPunct(",") {
+					return nil, p.errorf(p.peek(), "expected , or ) in call to %s(), found %s", name, p.peek().describe())
+				}
+			}
+		}
+		if len(args) < arity[0] || len(args) > arity[1] {
+			return nil, p.errorf(tok, "%s() takes %d argument(s), got %d", name, arity[0], len(args))
+		}
+		return callExpr{name: name, args: args}, nil
+	}
+
+	path := normalizeQueryField(tok.text)
+	for p.acceptPunct(".") {
+		next := p.peek()
+		if next.kind != tokIdent {
+			return nil, p.errorf(next, "expected field name after '.', found %s", next.describe())
+		}
+		p.pos++
+		path += "." + normalizeQueryField(next.text)
+	}
+
+	return fieldExpr{path: path, at: tok}, nil
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) acceptKeyword(keyword string) bool {
+	tok := p.peek()
+	if tok.kind == tokIdent && strings.EqualFold(tok.text, keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) acceptPunct(punct string) bool {
+	tok := p.peek()
+	if tok.kind == tokPunct && tok.text == punct {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// atClauseStart reports whether the next token begins a clause (or ends the query).
+func (p *queryParser) atClauseStart() bool {
+	tok := p.peek()
+	return tok.kind == tokEOF || (tok.kind == tokIdent && isQueryClause(strings.ToUpper(tok.text)))
+}
+
+func (p *queryParser) errorf(tok queryToken, format string, args ...any) error {
+	return &domain.ErrQuerySyntax{Line: tok.line, Column: tok.column, Message: fmt.Sprintf(format, args...)}
+}
+
+func isQueryClause(word string) bool {
+	switch word {
+	case "FROM", "WHERE", "SORT", "LIMIT":
+		return true
+	}
+	return false
+}
+
+func isQueryKeyword(word string) bool {
+	switch word {
+	case "TABLE", "LIST", "FROM", "WHERE", "SORT", "LIMIT", "AND", "OR", "NOT", "AS", "ASC", "DESC", "WITHOUT":
+		return true
+	}
+	return false
+}
+
+// normalizeQueryField folds a field name to its index key: lowercase, with spaces and hyphens as underscores.
+func normalizeQueryField(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// lexQuery splits query source into tokens, tracking line and column for error reporting.
+func lexQuery(source string) ([]queryToken, error) {
+	tokens := []queryToken{}
+	line, column := 1, 1
+	endLine, endColumn := 1, 1 // Position just past the last token, used for end-of-query errors
+	i := 0
+
+	advance := func(n int) {
+		for _, r := range source[i : i+n] {
+			if r == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+		}
+		i += n
+	}
+
+	for i < len(source) {
+		c := source[i]
+
+		if c == '\n' || c == ' ' || c == '\t' || c == '\r' {
+			advance(1)
+			continue
+		}
+
+		tok := queryToken{offset: i, line: line, column: column}
+		syntaxError := func(format string, args ...any) error {
+			return &domain.ErrQuerySyntax{Line: tok.line, Column: tok.column, Message: fmt.Sprintf(format, args...)}
+		}
+
+		switch {
+		case c == '"' || c == '\'':
+			var sb strings.Builder
+			j := i + 1
+			closed := false
+			for j < len(source) {
+				if source[j] == '\\' && j+1 < len(source) {
+					sb.WriteByte(source[j+1])
+					j += 2
+					continue
+				}
+				if source[j] == c {
+					closed = true
+					break
+				}
+				if source[j] == '\n' {
+					break
+				}
+				sb.WriteByte(source[j])
+				j++
+			}
+			if !closed {
+				return nil, syntaxError("unterminated string")
+			}
+			tok.kind = tokString
+			tok.text = sb.String()
+			advance(j + 1 - i)
+
+		case strings.HasPrefix(source[i:], "[["):
+			end := strings.Index(source[i:], "]]")
+			if end < 0 {
+				return nil, syntaxError("unterminated link, expected ]]")
+			}
+			target := source[i+2 : i+end]
+			if pipe := strings.Index(target, "|"); pipe >= 0 {
+				target = target[:pipe]
+			}
+			tok.kind = tokLink
+			tok.text = strings.TrimSpace(target)
+			advance(end + 2)
+
+		case c == '#':
+			j := i + 1
+			for j < len(source) && isQueryTagChar(source[j]) {
+				j++
+			}
+			if j == i+1 {
+				return nil, syntaxError("expected tag name after #")
+			}
+			tok.kind = tokTag
+			tok.text = source[i+1 : j]
+			advance(j - i)
+
+		case c >= '0' && c <= '9':
+			if m := queryDateLiteral(source[i:]); m != "" {
+				tok.kind = tokDate
+				tok.text = m
+				advance(len(m))
+				break
+			}
+			j := i
+			for j < len(source) && (source[j] >= '0' && source[j] <= '9' || source[j] == '.') {
+				j++
+			}
+			tok.kind = tokNumber
+			tok.text = source[i:j]
+			advance(j - i)
+
+		case isQueryIdentStart(source[i:]):
+			j := i
+			for j < len(source) {
+				r, size := utf8.DecodeRuneInString(source[j:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				j += size
+			}
+			tok.kind = tokIdent
+			tok.text = source[i:j]
+			advance(j - i)
+
+		default:
+			punct := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "=", "<", ">", "!", "+", "-", "*", "/", "(", ")", "[", "]", ",", "."} {
+				if strings.HasPrefix(source[i:], candidate) {
+					punct = candidate
+					break
+				}
+			}
+			if punct == "" {
+				return nil, syntaxError("unexpected character %q", c)
+			}
+			tok.kind = tokPunct
+			tok.text = punct
+			advance(len(punct))
+		}
+
+		tok.end = i
+		tokens = append(tokens, tok)
+		endLine, endColumn = line, column
+	}
+
+	tokens = append(tokens, queryToken{kind: tokEOF, offset: len(source), end: len(source), line: endLine, column: endColumn})
+	return tokens, nil
+}
+
+// queryDateLiteral returns the ISO date (optionally with a time) at the start of s, or "".
+func queryDateLiteral(s string) string {
+	return queryDatePattern.FindString(s)
+}
+
+func isQueryIdentStart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isQueryTagChar(c byte) bool {
+	return c == '-' || c == '_' || c == '/' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"bytes"
+	"html"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// kindQueryBlock is the AST node kind for ```query fenced blocks.
+var kindQueryBlock = ast.NewNodeKind("QueryBlock")
+
+// queryBlockNode replaces a ```query fenced code block so it can be rendered as query results.
+type queryBlockNode struct {
+	ast.BaseBlock
+	source string
+}
+
+func (n *queryBlockNode) Kind() ast.NodeKind {
+	return kindQueryBlock
+}
+
+func (n *queryBlockNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Source": n.source}, nil)
+}
+
+// queryBlockExtension renders ```query fenced blocks using the NoteService's query runner.
+type queryBlockExtension struct {
+	notes *NoteService
+}
+
+func (e *queryBlockExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(util.Prioritized(queryBlockTransformer{}, 100)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&queryBlockRenderer{notes: e.notes}, 100)))
+}
+
+// queryBlockTransformer swaps fenced code blocks with the "query" info string for queryBlockNodes.
+type queryBlockTransformer struct{}
+
+func (queryBlockTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	blocks := []*ast.FencedCodeBlock{}
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if block, ok := n.(*ast.FencedCodeBlock); ok && string(block.Language(source)) == "query" {
+			blocks = append(blocks, block)
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, block := range blocks {
+		var buf bytes.Buffer
+		lines := block.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			segment := lines.At(i)
+			buf.Write(segment.Value(source))
+		}
+		block.Parent().ReplaceChild(block.Parent(), block, &queryBlockNode{source: buf.String()})
+	}
+}
+
+// queryBlockRenderer writes query results as HTML tables and lists.
+// Without a query runner the block is rendered as a regular code block.
+type queryBlockRenderer struct {
+	notes *NoteService
+}
+
+func (r *queryBlockRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindQueryBlock, r.render)
+}
+
+func (r *queryBlockRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	block := node.(*queryBlockNode)
+	runner := r.notes.queryRunner
+	if runner == nil {
+		w.WriteString(`<pre><code class="language-query">`)
+		w.WriteString(html.EscapeString(block.source))
+		w.WriteString("</code></pre>\n")
+		return ast.WalkSkipChildren, nil
+	}
+
+	result, err := runner.RunQuery(block.source)
+	if err != nil {
+		w.WriteString(`<div class="query-error">`)
+		w.WriteString(html.EscapeString(err.Error()))
+		w.WriteString("</div>\n")
+		return ast.WalkSkipChildren, nil
+	}
+
+	writeQueryResultHTML(w, result)
+	return ast.WalkSkipChildren, nil
+}
+
+// writeQueryResultHTML renders a query result as an HTML table or list.
+func writeQueryResultHTML(w util.BufWriter, result *QueryResult) {
+	if len(result.Rows) == 0 {
+		w.WriteString(`<div class="query-empty">No results</div>` + "\n")
+		return
+	}
+
+	if result.Type == QueryTypeList {
+		w.WriteString(`<ul class="query-list">` + "\n")
+		for _, row := range result.Rows {
+			w.WriteString("<li>")
+			writeQueryNoteLink(w, row.NoteID, row.Title)
+			if len(row.Values) > 0 {
+				w.WriteString(": ")
+				writeQueryValueHTML(w, row.Values[0])
+			}
+			w.WriteString("</li>\n")
+		}
+		w.WriteString("</ul>\n")
+		return
+	}
+
+	w.WriteString(`<table class="query-table">` + "\n<thead>\n<tr>\n")
+	for _, column := range result.Columns {
+		w.WriteString("<th>" + html.EscapeString(column) + "</th>\n")
+	}
+	w.WriteString("</tr>\n</thead>\n<tbody>\n")
+	for _, row := range result.Rows {
+		w.WriteString("<tr>\n")
+		if !result.WithoutID {
+			w.WriteString("<td>")
+			writeQueryNoteLink(w, row.NoteID, row.Title)
+			w.WriteString("</td>\n")
+		}
+		for _, value := range row.Values {
+			w.WriteString("<td>")
+			writeQueryValueHTML(w, value)
+			w.WriteString("</td>\n")
+		}
+		w.WriteString("</tr>\n")
+	}
+	w.WriteString("</tbody>\n</table>\n")
+}
+
+func writeQueryNoteLink(w util.BufWriter, noteID, title string) {
+	w.WriteString(`<a class="internal-link" data-note-id="` + html.EscapeString(noteID) + `">`)
+	w.WriteString(html.EscapeString(title))
+	w.WriteString("</a>")
+}
+
+func writeQueryValueHTML(w util.BufWriter, value QueryValue) {
+	switch value.Type {
+	case QueryValueLink:
+		w.WriteString(`<a class="internal-link" data-target="` + html.EscapeString(value.String) + `">`)
+		w.WriteString(html.EscapeString(value.String))
+		w.WriteString("</a>")
+	case QueryValueList:
+		for i, item := range value.List {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			writeQueryValueHTML(w, item)
+		}
+	default:
+		w.WriteString(html.EscapeString(value.Display()))
+	}
+}
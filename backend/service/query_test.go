@@ -0,0 +1,283 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"notes/backend/domain"
+)
+
+func newTestQueryService(t *testing.T, files map[string]string) (*QueryService, *NoteService) {
+	t.Helper()
+
+	fs, err := NewFilesystemService()
+	if err != nil {
+		t.Fatalf("NewFilesystemService() error = %v", err)
+	}
+	t.Cleanup(func() { fs.Close() })
+
+	if _, err := fs.OpenWorkspace(t.TempDir()); err != nil {
+		t.Fatalf("OpenWorkspace() error = %v", err)
+	}
+
+	noteService := NewNoteService(fs)
+	queryService := NewQueryService()
+	noteService.SetQueryRunner(queryService)
+
+	for path, content := range files {
+		if err := fs.WriteFile(path, []byte(content)); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", path, err)
+		}
+		note, err := noteService.GetNote(path)
+		if err != nil {
+			t.Fatalf("GetNote(%s) error = %v", path, err)
+		}
+		if err := queryService.IndexNote(note); err != nil {
+			t.Fatalf("IndexNote(%s) error = %v", path, err)
+		}
+	}
+
+	return queryService, noteService
+}
+
+var queryTestFiles = map[string]string{
+	"projects/alpha.md": "---\ntitle: Alpha\nstatus: active\ndue: 2025-03-01\npriority: 2\ntags: [project]\nowner: \"[[people/ada]]\"\n---\n\n# Alpha\n",
+	"projects/beta.md":  "---\ntitle: Beta\nstatus: done\ndue: 2025-01-15\npriority: 1\ntags: [project/client]\n---\n\n# Beta\n",
+	"projects/gamma.md": "# Gamma\n\n#project\n\nstatus:: planning\ndue:: 2025-02-10\n- priority:: 3\n",
+	"journal/today.md":  "# Today\n\nRated this day [rating:: 4] out of 5.\n\n```\nstatus:: ignored\n```\n",
+}
+
+func TestQueryService_RunQuery(t *testing.T) {
+	queries, _ := newTestQueryService(t, queryTestFiles)
+
+	tests := []struct {
+		name    string
+		query   string
+		wantIDs []string
+		columns []string
+	}{
+		{
+			name:    "table from tag with where and sort",
+			query:   `TABLE status, due FROM #project WHERE status != "done" SORT due`,
+			wantIDs: []string{"projects/gamma.md", "projects/alpha.md"},
+			columns: []string{"File", "status", "due"},
+		},
+		{
+			name:    "nested tags match parent",
+			query:   `LIST FROM #project SORT priority DESC`,
+			wantIDs: []string{"projects/gamma.md", "projects/alpha.md", "projects/beta.md"},
+			columns: []string{},
+		},
+		{
+			name:    "folder source",
+			query:   `LIST FROM "journal"`,
+			wantIDs: []string{"journal/today.md"},
+			columns: []string{},
+		},
+		{
+			name:    "date comparison with literal",
+			query:   `TABLE WITHOUT ID file.name AS "Name" WHERE due < 2025-02-15 SORT file.name`,
+			wantIDs: []string{"projects/beta.md", "projects/gamma.md"},
+			columns: []string{"Name"},
+		},
+		{
+			name:    "numeric comparison and limit",
+			query:   "TABLE priority\nWHERE priority >= 2\nSORT priority ASC\nLIMIT 1",
+			wantIDs: []string{"projects/alpha.md"},
+			columns: []string{"File", "priority"},
+		},
+		{
+			name:    "link equality and bracketed inline field",
+			query:   `LIST WHERE owner = [[people/ada]] OR rating = 4`,
+			wantIDs: []string{"journal/today.md", "projects/alpha.md"},
+			columns: []string{},
+		},
+		{
+			name:    "negated source and functions",
+			query:   `LIST FROM -#project WHERE contains(file.name, "TOD")`,
+			wantIDs: []string{"journal/today.md"},
+			columns: []string{},
+		},
+		{
+			name:    "inline fields inside code blocks are ignored",
+			query:   `LIST WHERE status = "ignored"`,
+			wantIDs: []string{},
+			columns: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := queries.RunQuery(tt.query)
+			if err != nil {
+				t.Fatalf("RunQuery() error = %v", err)
+			}
+
+			ids := []string{}
+			for _, row := range result.Rows {
+				ids = append(ids, row.NoteID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("RunQuery() rows = %v, want %v", ids, tt.wantIDs)
+			}
+			if !slices.Equal(result.Columns, tt.columns) {
+				t.Errorf("RunQuery() columns = %v, want %v", result.Columns, tt.columns)
+			}
+		})
+	}
+}
+
+func TestQueryService_TypedValues(t *testing.T) {
+	queries, _ := newTestQueryService(t, queryTestFiles)
+
+	result, err := queries.RunQuery(`TABLE due, priority, owner, tags, due - date("2025-01-01") FROM "projects/alpha"`)
+	if err != nil {
+		t.Fatalf("RunQuery() error = %v", err)
+	}
+	if len(result.Rows) != 1 {
+		t.Fatalf("RunQuery() returned %d rows, want 1", len(result.Rows))
+	}
+
+	values := result.Rows[0].Values
+	wantTypes := []QueryValueType{QueryValueDate, QueryValueNumber, QueryValueLink, QueryValueList, QueryValueNumber}
+	for i, want := range wantTypes {
+		if values[i].Type != want {
+			t.Errorf("column %d type = %s, want %s", i, values[i].Type, want)
+		}
+	}
+
+	if !values[0].Date.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("due = %v, want 2025-03-01", values[0].Date)
+	}
+	if values[2].String != "people/ada" {
+		t.Errorf("owner = %q, want %q", values[2].String, "people/ada")
+	}
+	if values[4].Number != 59 {
+		t.Errorf("due - date() = %v, want 59 days", values[4].Number)
+	}
+
+	encoded, err := json.Marshal(values[1])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(encoded) != `{"type":"number","value":2}` {
+		t.Errorf("json.Marshal() = %s", encoded)
+	}
+}
+
+func TestQueryService_SyntaxErrors(t *testing.T) {
+	queries := NewQueryService()
+
+	tests := []struct {
+		name     string
+		query    string
+		line     int
+		column   int
+		contains string
+	}{
+		{"missing query type", "FROM #project", 1, 1, "expected TABLE or LIST"},
+		{"unterminated string", "LIST WHERE status = \"done", 1, 21, "unterminated string"},
+		{"unknown function", "LIST\nWHERE frobnicate(x)", 2, 7, "unknown function frobnicate()"},
+		{"bad from source", "LIST FROM status", 1, 11, "expected #tag"},
+		{"duplicate clause", "LIST LIMIT 1 LIMIT 2", 1, 14, "duplicate LIMIT clause"},
+		{"wrong arity", "LIST WHERE contains(tags)", 1, 12, "contains() takes 2 argument(s), got 1"},
+		{"trailing garbage", "TABLE status FROM #a )", 1, 22, "expected FROM, WHERE, SORT or LIMIT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := queries.RunQuery(tt.query)
+
+			var syntaxErr *domain.ErrQuerySyntax
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("RunQuery() error = %v, want *domain.ErrQuerySyntax", err)
+			}
+			if syntaxErr.Line != tt.line || syntaxErr.Column != tt.column {
+				t.Errorf("error position = %d:%d, want %d:%d", syntaxErr.Line, syntaxErr.Column, tt.line, tt.column)
+			}
+			if !strings.Contains(syntaxErr.Message, tt.contains) {
+				t.Errorf("error message = %q, want it to contain %q", syntaxErr.Message, tt.contains)
+			}
+		})
+	}
+}
+
+func TestQueryService_EvalErrors(t *testing.T) {
+	queries, _ := newTestQueryService(t, queryTestFiles)
+
+	tests := []struct {
+		name     string
+		query    string
+		line     int
+		column   int
+		contains string
+	}{
+		{"division by zero", "LIST WHERE 1/0", 1, 13, "division by zero"},
+		{"unknown sort field", "LIST\nSORT nonexistent", 2, 6, `no note has a field "nonexistent"`},
+		{"string plus number", `TABLE "x" + 1`, 1, 11, "cannot apply + to a string and a number"},
+		{"negated string", `TABLE -status`, 1, 7, "cannot negate a string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := queries.RunQuery(tt.query)
+
+			var evalErr *domain.ErrQueryEval
+			if !errors.As(err, &evalErr) {
+				t.Fatalf("RunQuery() error = %v, want *domain.ErrQueryEval", err)
+			}
+			if evalErr.Line != tt.line || evalErr.Column != tt.column {
+				t.Errorf("error position = %d:%d, want %d:%d", evalErr.Line, evalErr.Column, tt.line, tt.column)
+			}
+			if !strings.Contains(evalErr.Message, tt.contains) {
+				t.Errorf("error message = %q, want it to contain %q", evalErr.Message, tt.contains)
+			}
+		})
+	}
+
+	// Arithmetic on a field a note lacks is null rather than an error.
+	if _, err := queries.RunQuery("TABLE rating + 1 SORT priority"); err != nil {
+		t.Errorf("RunQuery() with missing fields error = %v", err)
+	}
+}
+
+func TestQueryService_RemoveNote(t *testing.T) {
+	queries, _ := newTestQueryService(t, queryTestFiles)
+
+	queries.RemoveNote("projects/alpha.md")
+
+	result, err := queries.RunQuery(`LIST FROM "projects"`)
+	if err != nil {
+		t.Fatalf("RunQuery() error = %v", err)
+	}
+	if len(result.Rows) != 2 {
+		t.Errorf("RunQuery() returned %d rows after removal, want 2", len(result.Rows))
+	}
+}
+
+func TestNoteService_RenderMarkdownQueryBlock(t *testing.T) {
+	_, notes := newTestQueryService(t, queryTestFiles)
+
+	html, err := notes.RenderMarkdown("# Projects\n\n```query\nTABLE status FROM #project WHERE status = \"done\"\n```\n\n```go\nfmt.Println()\n```\n")
+	if err != nil {
+		t.Fatalf("RenderMarkdown() error = %v", err)
+	}
+
+	for _, want := range []string{`<table class="query-table">`, `<th>status</th>`, `data-note-id="projects/beta.md"`, `<td>done</td>`, `<code class="language-go">`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("RenderMarkdown() missing %q:\n%s", want, html)
+		}
+	}
+
+	html, err = notes.RenderMarkdown("```query\nLIST WHERE (\n```\n")
+	if err != nil {
+		t.Fatalf("RenderMarkdown() error = %v", err)
+	}
+	if !strings.Contains(html, `<div class="query-error">query syntax error at line 1`) {
+		t.Errorf("RenderMarkdown() should render syntax errors inline:\n%s", html)
+	}
+}
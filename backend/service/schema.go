@@ -0,0 +1,525 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"notes/backend/domain"
+
+	"github.com/BurntSushi/toml"
+)
+
+// SchemaFileName is the name of the note type schema file inside the workspace config directory.
+const SchemaFileName = "types.toml"
+
+// SchemaFieldType is the declared type of a frontmatter field.
+type SchemaFieldType string
+
+const (
+	SchemaFieldString  SchemaFieldType = "string"
+	SchemaFieldNumber  SchemaFieldType = "number"
+	SchemaFieldBoolean SchemaFieldType = "boolean"
+	SchemaFieldDate    SchemaFieldType = "date"
+	SchemaFieldList    SchemaFieldType = "list"
+	SchemaFieldLink    SchemaFieldType = "link"
+	SchemaFieldEnum    SchemaFieldType = "enum"
+)
+
+// FieldSchema describes a single frontmatter field of a note type.
+type FieldSchema struct {
+	// Type is the field's value type; defaults to string
+	Type SchemaFieldType `toml:"type" json:"type"`
+	// Required fields must be present and non-empty
+	Required bool `toml:"required" json:"required"`
+	// Values lists the allowed values of an enum field, or of each item of a list field
+	Values []string `toml:"values" json:"values"`
+	// Default is written to new notes of the type; "today" and "now" are accepted for dates
+	Default any `toml:"default" json:"default"`
+	// Description documents the field for the UI
+	Description string `toml:"description" json:"description"`
+}
+
+// TypeSchema describes the frontmatter expected of notes with a given type.
+type TypeSchema struct {
+	Name        string                 `toml:"-" json:"name"`
+	Description string                 `toml:"description" json:"description"`
+	Fields      map[string]FieldSchema `toml:"fields" json:"fields"`
+}
+
+// schemaFile is the on-disk layout of types.toml:
+//
+//	[types.meeting.fields.date]
+//	type = "date"
+//	required = true
+type schemaFile struct {
+	Types map[string]TypeSchema `toml:"types"`
+}
+
+// DiagnosticSeverity ranks schema diagnostics.
+type DiagnosticSeverity string
+
+const (
+	DiagnosticError   DiagnosticSeverity = "error"
+	DiagnosticWarning DiagnosticSeverity = "warning"
+)
+
+// Diagnostic reports a note that does not match the schema of its type.
+type Diagnostic struct {
+	NoteID   string             `json:"noteId"`
+	Field    string             `json:"field"` // Empty for diagnostics about the note as a whole
+	Severity DiagnosticSeverity `json:"severity"`
+	Message  string             `json:"message"`
+}
+
+// LoadSchemas reads note type schemas from a TOML file.
+// A missing file yields no schemas; a file with unknown field types, enums without values
+// or defaults that don't match their field's type is rejected.
+func LoadSchemas(path string) (map[string]TypeSchema, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return map[string]TypeSchema{}, nil
+	}
+
+	var file schemaFile
+	if _, err := toml.DecodeFile(path, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode schema file: %w", err)
+	}
+
+	schemas := make(map[string]TypeSchema, len(file.Types))
+	for name, schema := range file.Types {
+		schema.Name = name
+		if schema.Fields == nil {
+			schema.Fields = map[string]FieldSchema{}
+		}
+		for fieldName, field := range schema.Fields {
+			if field.Type == "" {
+				field.Type = SchemaFieldString
+			}
+			if err := validateFieldSchema(field); err != nil {
+				return nil, fmt.Errorf("invalid schema for type %q, field %q: %w", name, fieldName, err)
+			}
+			schema.Fields[fieldName] = field
+		}
+		schemas[name] = schema
+	}
+
+	return schemas, nil
+}
+
+func validateFieldSchema(field FieldSchema) error {
+	switch field.Type {
+	case SchemaFieldString, SchemaFieldNumber, SchemaFieldBoolean, SchemaFieldDate, SchemaFieldList, SchemaFieldLink:
+	case SchemaFieldEnum:
+		if len(field.Values) == 0 {
+			return fmt.Errorf("enum fields need a list of values")
+		}
+	default:
+		return fmt.Errorf("unknown field type %q", field.Type)
+	}
+
+	if field.Default != nil {
+		if problem := checkFieldValue(field, resolveFieldDefault(field)); problem != "" {
+			return fmt.Errorf("default %s", problem)
+		}
+	}
+	return nil
+}
+
+// SchemaService validates notes against the schema of their type and keeps the resulting diagnostics.
+type SchemaService struct {
+	mu          sync.RWMutex
+	schemas     map[string]TypeSchema
+	diagnostics map[string][]Diagnostic
+}
+
+// NewSchemaService creates a schema service with no types defined.
+func NewSchemaService() *SchemaService {
+	return &SchemaService{
+		schemas:     make(map[string]TypeSchema),
+		diagnostics: make(map[string][]Diagnostic),
+	}
+}
+
+// Load replaces the known schemas with those in the file at path.
+// Existing diagnostics are kept until notes are indexed again.
+func (s *SchemaService) Load(path string) error {
+	schemas, err := LoadSchemas(path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.schemas = schemas
+	return nil
+}
+
+// Schemas returns all note type schemas sorted by name.
+func (s *SchemaService) Schemas() []TypeSchema {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schemas := make([]TypeSchema, 0, len(s.schemas))
+	for _, schema := range s.schemas {
+		schemas = append(schemas, schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].Name < schemas[j].Name
+	})
+	return schemas
+}
+
+// Schema returns the schema for a note type.
+func (s *SchemaService) Schema(noteType string) (TypeSchema, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schema, ok := s.schemas[noteType]
+	return schema, ok
+}
+
+// FieldType returns the declared type of a field for a note type.
+// Field names are matched after normalizeQueryField, so "Due Date" and "due_date" are the same field.
+func (s *SchemaService) FieldType(noteType, field string) (SchemaFieldType, bool) {
+	schema, ok := s.Schema(noteType)
+	if !ok {
+		return "", false
+	}
+
+	field = normalizeQueryField(field)
+	for name, fieldSchema := range schema.Fields {
+		if normalizeQueryField(name) == field {
+			return fieldSchema.Type, true
+		}
+	}
+	return "", false
+}
+
+// Defaults returns the default frontmatter values for a new note of the given type.
+func (s *SchemaService) Defaults(noteType string) map[string]any {
+	defaults := make(map[string]any)
+
+	schema, ok := s.Schema(noteType)
+	if !ok {
+		return defaults
+	}
+
+	for name, field := range schema.Fields {
+		if field.Default != nil {
+			defaults[name] = resolveFieldDefault(field)
+		}
+	}
+	return defaults
+}
+
+// Validate checks a note against the schema of its type.
+// Notes without a type, or with a type that has no schema, produce no diagnostics.
+func (s *SchemaService) Validate(note *domain.Note) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	if note.Type == "" {
+		return diagnostics
+	}
+
+	schema, ok := s.Schema(note.Type)
+	if !ok {
+		return diagnostics
+	}
+
+	names := make([]string, 0, len(schema.Fields))
+	for name := range schema.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field := schema.Fields[name]
+		value, present := noteFieldValue(note, name)
+
+		if !present || isEmptyFieldValue(value) {
+			if field.Required {
+				diagnostics = append(diagnostics, Diagnostic{
+					NoteID:   note.ID,
+					Field:    name,
+					Severity: DiagnosticError,
+					Message:  fmt.Sprintf("required field %q is missing", name),
+				})
+			}
+			continue
+		}
+
+		if problem := checkFieldValue(field, value); problem != "" {
+			diagnostics = append(diagnostics, Diagnostic{
+				NoteID:   note.ID,
+				Field:    name,
+				Severity: DiagnosticError,
+				Message:  fmt.Sprintf("field %q %s", name, problem),
+			})
+		}
+	}
+
+	return diagnostics
+}
+
+// IndexNote validates a note and stores its diagnostics.
+func (s *SchemaService) IndexNote(note *domain.Note) []Diagnostic {
+	diagnostics := s.Validate(note)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(diagnostics) == 0 {
+		delete(s.diagnostics, note.ID)
+	} else {
+		s.diagnostics[note.ID] = diagnostics
+	}
+	return diagnostics
+}
+
+// RemoveNote drops the diagnostics of a deleted note.
+func (s *SchemaService) RemoveNote(noteID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.diagnostics, noteID)
+}
+
+// Clear drops all stored diagnostics.
+func (s *SchemaService) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.diagnostics = make(map[string][]Diagnostic)
+}
+
+// Diagnostics returns the stored diagnostics for a note.
+func (s *SchemaService) Diagnostics(noteID string) []Diagnostic {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]Diagnostic{}, s.diagnostics[noteID]...)
+}
+
+// AllDiagnostics returns the stored diagnostics for every note, ordered by note and field.
+func (s *SchemaService) AllDiagnostics() []Diagnostic {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	all := []Diagnostic{}
+	for _, diagnostics := range s.diagnostics {
+		all = append(all, diagnostics...)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].NoteID != all[j].NoteID {
+			return all[i].NoteID < all[j].NoteID
+		}
+		return all[i].Field < all[j].Field
+	})
+	return all
+}
+
+// CoerceFields converts indexed query values to the types declared for the note's type,
+// so that a quoted "3" in a number field compares as 3 and a plain name in a link field links.
+func (s *SchemaService) CoerceFields(noteType string, fields map[string]QueryValue) {
+	if noteType == "" {
+		return
+	}
+	for key, value := range fields {
+		if fieldType, ok := s.FieldType(noteType, key); ok {
+			fields[key] = coerceToFieldType(value, fieldType)
+		}
+	}
+}
+
+// noteFieldValue looks up a schema field on a note: standard fields, then frontmatter, then page properties.
+func noteFieldValue(note *domain.Note, name string) (any, bool) {
+	switch name {
+	case "title":
+		return note.Title, note.Title != ""
+	case "aliases":
+		return note.Aliases, len(note.Aliases) > 0
+	case "tags":
+		tags := make([]string, len(note.Tags))
+		for i, tag := range note.Tags {
+			tags[i] = tag.Name
+		}
+		return tags, len(tags) > 0
+	case "created":
+		return note.CreatedAt, !note.CreatedAt.IsZero()
+	case "modified":
+		return note.ModifiedAt, !note.ModifiedAt.IsZero()
+	}
+
+	if value, ok := note.Frontmatter[name]; ok {
+		return value, true
+	}
+	if value, ok := note.Properties[propertyKey(name)]; ok {
+		return value, true
+	}
+	return nil, false
+}
+
+func isEmptyFieldValue(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []any:
+		return len(v) == 0
+	case []string:
+		return len(v) == 0
+	}
+	return false
+}
+
+// checkFieldValue describes why value doesn't fit field, or returns "" when it does.
+func checkFieldValue(field FieldSchema, value any) string {
+	switch field.Type {
+	case SchemaFieldString:
+		if isListOrMap(value) {
+			return "must be a single value"
+		}
+	case SchemaFieldNumber:
+		if _, ok := fieldNumber(value); !ok {
+			return fmt.Sprintf("must be a number, got %q", fmt.Sprint(value))
+		}
+	case SchemaFieldBoolean:
+		if _, ok := fieldBool(value); !ok {
+			return fmt.Sprintf("must be true or false, got %q", fmt.Sprint(value))
+		}
+	case SchemaFieldDate:
+		if _, ok := frontmatterTime(value); !ok {
+			return fmt.Sprintf("must be a date (YYYY-MM-DD), got %q", fmt.Sprint(value))
+		}
+	case SchemaFieldLink:
+		s, ok := value.(string)
+		if !ok || queryLinkPattern.FindStringSubmatch(strings.TrimSpace(s)) == nil {
+			return fmt.Sprintf("must be a [[wikilink]], got %q", fmt.Sprint(value))
+		}
+	case SchemaFieldEnum:
+		if isListOrMap(value) || !containsFold(field.Values, fmt.Sprint(value)) {
+			return fmt.Sprintf("must be one of %s, got %q", strings.Join(field.Values, ", "), fmt.Sprint(value))
+		}
+	case SchemaFieldList:
+		if len(field.Values) == 0 {
+			return ""
+		}
+		items := frontmatterList(value)
+		if items == nil {
+			items = []any{value}
+		}
+		for _, item := range items {
+			if !containsFold(field.Values, fmt.Sprint(item)) {
+				return fmt.Sprintf("items must be one of %s, got %q", strings.Join(field.Values, ", "), fmt.Sprint(item))
+			}
+		}
+	}
+	return ""
+}
+
+// resolveFieldDefault returns a field's default, expanding "today" and "now" for date fields.
+func resolveFieldDefault(field FieldSchema) any {
+	if field.Type == SchemaFieldDate {
+		switch field.Default {
+		case "today":
+			return time.Now().Format("2006-01-02")
+		case "now":
+			return time.Now().Format(time.RFC3339)
+		}
+	}
+	if field.Type == SchemaFieldList {
+		if s, ok := field.Default.(string); ok {
+			return []any{s}
+		}
+	}
+	return field.Default
+}
+
+// coerceToFieldType converts a query value to the given schema type when it can be read as one.
+func coerceToFieldType(value QueryValue, fieldType SchemaFieldType) QueryValue {
+	if value.Type == QueryValueNull {
+		return value
+	}
+
+	switch fieldType {
+	case SchemaFieldNumber:
+		if value.Type == QueryValueString {
+			if n, err := strconv.ParseFloat(strings.TrimSpace(value.String), 64); err == nil {
+				return NumberValue(n)
+			}
+		}
+	case SchemaFieldBoolean:
+		if value.Type == QueryValueString {
+			if b, ok := fieldBool(value.String); ok {
+				return BoolValue(b)
+			}
+		}
+	case SchemaFieldDate:
+		if value.Type == QueryValueString {
+			if d := parseQueryDate(strings.TrimSpace(value.String)); d.Type == QueryValueDate {
+				return d
+			}
+		}
+	case SchemaFieldLink:
+		if value.Type == QueryValueString {
+			return LinkValue(strings.TrimSpace(value.String))
+		}
+	case SchemaFieldList:
+		if value.Type != QueryValueList {
+			return ListValue([]QueryValue{value})
+		}
+	case SchemaFieldString, SchemaFieldEnum:
+		if value.Type != QueryValueString && value.Type != QueryValueList {
+			return StringValue(value.Display())
+		}
+	}
+	return value
+}
+
+func fieldNumber(value any) (float64, bool) {
+	if n, ok := frontmatterNumber(value); ok {
+		return n, true
+	}
+	if s, ok := value.(string); ok {
+		n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func fieldBool(value any) (bool, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+func isListOrMap(value any) bool {
+	switch value.(type) {
+	case []any, []string, map[string]any:
+		return true
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,405 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"notes/backend/domain"
+)
+
+const testSchemaFile = `
+[types.meeting]
+description = "Meeting notes"
+
+[types.meeting.fields.date]
+type = "date"
+required = true
+default = "today"
+
+[types.meeting.fields.status]
+type = "enum"
+values = ["planned", "held", "cancelled"]
+default = "planned"
+
+[types.meeting.fields.attendees]
+type = "list"
+
+[types.meeting.fields.host]
+type = "link"
+
+[types.meeting.fields.duration]
+type = "number"
+
+[types.meeting.fields.recorded]
+type = "boolean"
+default = false
+
+[types.project.fields.priority]
+type = "number"
+required = true
+
+[types.project.fields.stage]
+type = "list"
+values = ["design", "build", "ship"]
+`
+
+func newTestSchemaService(t *testing.T, content string) *SchemaService {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), SchemaFileName)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	schemas := NewSchemaService()
+	if err := schemas.Load(path); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return schemas
+}
+
+func TestLoadSchemas(t *testing.T) {
+	schemas, err := LoadSchemas(filepath.Join(t.TempDir(), "missing.toml"))
+	if err != nil {
+		t.Fatalf("LoadSchemas(missing) error = %v", err)
+	}
+	if len(schemas) != 0 {
+		t.Errorf("LoadSchemas(missing) = %v, want no schemas", schemas)
+	}
+
+	service := newTestSchemaService(t, testSchemaFile)
+	var names []string
+	for _, schema := range service.Schemas() {
+		names = append(names, schema.Name)
+	}
+	if want := []string{"meeting", "project"}; !slices.Equal(names, want) {
+		t.Errorf("Schemas() names = %v, want %v", names, want)
+	}
+
+	meeting, ok := service.Schema("meeting")
+	if !ok {
+		t.Fatal("Schema(meeting) not found")
+	}
+	if meeting.Description != "Meeting notes" {
+		t.Errorf("Description = %q, want %q", meeting.Description, "Meeting notes")
+	}
+	if got := meeting.Fields["status"].Type; got != SchemaFieldEnum {
+		t.Errorf("status type = %q, want %q", got, SchemaFieldEnum)
+	}
+
+	if got, ok := service.FieldType("project", "Priority"); !ok || got != SchemaFieldNumber {
+		t.Errorf("FieldType(project, Priority) = %q, %v, want number, true", got, ok)
+	}
+}
+
+func TestLoadSchemas_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "unknown type",
+			content: "[types.a.fields.x]\ntype = \"colour\"\n",
+			wantErr: `unknown field type "colour"`,
+		},
+		{
+			name:    "enum without values",
+			content: "[types.a.fields.x]\ntype = \"enum\"\n",
+			wantErr: "enum fields need a list of values",
+		},
+		{
+			name:    "default outside enum",
+			content: "[types.a.fields.x]\ntype = \"enum\"\nvalues = [\"a\"]\ndefault = \"b\"\n",
+			wantErr: "must be one of a",
+		},
+		{
+			name:    "non-numeric default",
+			content: "[types.a.fields.x]\ntype = \"number\"\ndefault = \"lots\"\n",
+			wantErr: "must be a number",
+		},
+		{
+			name:    "malformed toml",
+			content: "[types.a\n",
+			wantErr: "failed to decode schema file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), SchemaFileName)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			_, err := LoadSchemas(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadSchemas() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchemaService_Validate(t *testing.T) {
+	schemas := newTestSchemaService(t, testSchemaFile)
+
+	tests := []struct {
+		name       string
+		note       domain.Note
+		wantFields []string
+	}{
+		{
+			name: "valid meeting",
+			note: domain.Note{ID: "m.md", Type: "meeting", Frontmatter: map[string]any{
+				"date":      time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+				"status":    "Held",
+				"attendees": []any{"ada", "grace"},
+				"host":      "[[people/ada]]",
+				"duration":  45,
+				"recorded":  true,
+			}},
+		},
+		{
+			name:       "missing required date",
+			note:       domain.Note{ID: "m.md", Type: "meeting", Frontmatter: map[string]any{"status": "held"}},
+			wantFields: []string{"date"},
+		},
+		{
+			name:       "empty required value",
+			note:       domain.Note{ID: "m.md", Type: "meeting", Frontmatter: map[string]any{"date": "  "}},
+			wantFields: []string{"date"},
+		},
+		{
+			name: "wrong types",
+			note: domain.Note{ID: "m.md", Type: "meeting", Frontmatter: map[string]any{
+				"date":     "next tuesday",
+				"status":   "postponed",
+				"host":     "ada",
+				"duration": "long",
+				"recorded": "yes",
+			}},
+			wantFields: []string{"date", "duration", "host", "recorded", "status"},
+		},
+		{
+			name:       "quoted number and date strings are accepted",
+			note:       domain.Note{ID: "p.md", Type: "project", Frontmatter: map[string]any{"priority": "2"}},
+			wantFields: nil,
+		},
+		{
+			name:       "list items outside allowed values",
+			note:       domain.Note{ID: "p.md", Type: "project", Frontmatter: map[string]any{"priority": 1, "stage": []any{"design", "party"}}},
+			wantFields: []string{"stage"},
+		},
+		{
+			name:       "field read from page properties",
+			note:       domain.Note{ID: "p.md", Type: "project", Properties: map[string]string{"priority": "3"}},
+			wantFields: nil,
+		},
+		{
+			name:       "untyped note",
+			note:       domain.Note{ID: "n.md", Frontmatter: map[string]any{"status": "whatever"}},
+			wantFields: nil,
+		},
+		{
+			name:       "type without schema",
+			note:       domain.Note{ID: "n.md", Type: "recipe"},
+			wantFields: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics := schemas.Validate(&tt.note)
+
+			var fields []string
+			for _, d := range diagnostics {
+				fields = append(fields, d.Field)
+				if d.NoteID != tt.note.ID {
+					t.Errorf("diagnostic NoteID = %q, want %q", d.NoteID, tt.note.ID)
+				}
+				if d.Severity != DiagnosticError {
+					t.Errorf("diagnostic Severity = %q, want %q", d.Severity, DiagnosticError)
+				}
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("Validate() fields = %v, want %v (%+v)", fields, tt.wantFields, diagnostics)
+			}
+		})
+	}
+}
+
+func TestSchemaService_Diagnostics(t *testing.T) {
+	schemas := newTestSchemaService(t, testSchemaFile)
+
+	bad := &domain.Note{ID: "b.md", Type: "project"}
+	good := &domain.Note{ID: "a.md", Type: "project", Frontmatter: map[string]any{"priority": 1}}
+	missing := &domain.Note{ID: "c.md", Type: "meeting"}
+
+	schemas.IndexNote(bad)
+	schemas.IndexNote(good)
+	schemas.IndexNote(missing)
+
+	if got := schemas.Diagnostics("a.md"); len(got) != 0 {
+		t.Errorf("Diagnostics(a.md) = %+v, want none", got)
+	}
+	if got := schemas.Diagnostics("b.md"); len(got) != 1 || got[0].Field != "priority" {
+		t.Errorf("Diagnostics(b.md) = %+v, want one for priority", got)
+	}
+
+	var ids []string
+	for _, d := range schemas.AllDiagnostics() {
+		ids = append(ids, d.NoteID)
+	}
+	if want := []string{"b.md", "c.md"}; !slices.Equal(ids, want) {
+		t.Errorf("AllDiagnostics() notes = %v, want %v", ids, want)
+	}
+
+	bad.Frontmatter = map[string]any{"priority": 5}
+	schemas.IndexNote(bad)
+	schemas.RemoveNote("c.md")
+	if got := schemas.AllDiagnostics(); len(got) != 0 {
+		t.Errorf("AllDiagnostics() after fixes = %+v, want none", got)
+	}
+}
+
+func TestSchemaService_Defaults(t *testing.T) {
+	schemas := newTestSchemaService(t, testSchemaFile)
+
+	defaults := schemas.Defaults("meeting")
+	if got, want := defaults["date"], time.Now().Format("2006-01-02"); got != want {
+		t.Errorf("Defaults()[date] = %v, want %v", got, want)
+	}
+	if got := defaults["status"]; got != "planned" {
+		t.Errorf("Defaults()[status] = %v, want planned", got)
+	}
+	if got := defaults["recorded"]; got != false {
+		t.Errorf("Defaults()[recorded] = %v, want false", got)
+	}
+	if _, ok := defaults["host"]; ok {
+		t.Error("Defaults() includes host, which has no default")
+	}
+	if got := schemas.Defaults("recipe"); len(got) != 0 {
+		t.Errorf("Defaults(recipe) = %v, want none", got)
+	}
+}
+
+func TestNoteService_CreateTypedNote(t *testing.T) {
+	fs, err := NewFilesystemService()
+	if err != nil {
+		t.Fatalf("NewFilesystemService() error = %v", err)
+	}
+	defer fs.Close()
+
+	if _, err := fs.OpenWorkspace(t.TempDir()); err != nil {
+		t.Fatalf("OpenWorkspace() error = %v", err)
+	}
+
+	notes := NewNoteService(fs)
+	schemas := newTestSchemaService(t, testSchemaFile)
+
+	if _, err := notes.CreateTypedNote("Standup", "meetings", "meeting", schemas.Defaults("meeting")); err != nil {
+		t.Fatalf("CreateTypedNote() error = %v", err)
+	}
+
+	note, err := notes.GetNote(filepath.Join("meetings", "Standup.md"))
+	if err != nil {
+		t.Fatalf("GetNote() error = %v", err)
+	}
+	if note.Type != "meeting" {
+		t.Errorf("Type = %q, want meeting", note.Type)
+	}
+	if got := note.Frontmatter["status"]; got != "planned" {
+		t.Errorf("Frontmatter[status] = %v, want planned", got)
+	}
+	if diagnostics := schemas.Validate(note); len(diagnostics) != 0 {
+		t.Errorf("Validate() on new typed note = %+v, want none", diagnostics)
+	}
+}
+
+func TestQueryService_SchemaCoercion(t *testing.T) {
+	queries, notes := newTestQueryService(t, nil)
+	schemas := newTestSchemaService(t, testSchemaFile)
+	queries.SetSchemas(schemas)
+
+	files := map[string]string{
+		"a.md": "---\ntype: project\npriority: \"10\"\n---\n\n# A\n",
+		"b.md": "---\ntype: project\npriority: \"9\"\n---\n\n# B\n",
+		"c.md": "---\ntype: meeting\nhost: ada\n---\n\n# C\n",
+	}
+	for path, content := range files {
+		if err := notes.fs.WriteFile(path, []byte(content)); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", path, err)
+		}
+		note, err := notes.GetNote(path)
+		if err != nil {
+			t.Fatalf("GetNote(%s) error = %v", path, err)
+		}
+		if err := queries.IndexNote(note); err != nil {
+			t.Fatalf("IndexNote(%s) error = %v", path, err)
+		}
+	}
+
+	result, err := queries.RunQuery("LIST WHERE priority > 9")
+	if err != nil {
+		t.Fatalf("RunQuery() error = %v", err)
+	}
+	if len(result.Rows) != 1 || result.Rows[0].NoteID != "a.md" {
+		t.Errorf("RunQuery(priority > 9) = %+v, want only a.md", result.Rows)
+	}
+
+	result, err = queries.RunQuery(`LIST WHERE host = [[ada]]`)
+	if err != nil {
+		t.Fatalf("RunQuery() error = %v", err)
+	}
+	if len(result.Rows) != 1 || result.Rows[0].NoteID != "c.md" {
+		t.Errorf("RunQuery(host = [[ada]]) = %+v, want only c.md", result.Rows)
+	}
+}
+
+func TestSearchService_TypedFieldFilter(t *testing.T) {
+	search := NewSearchService()
+	search.SetSchemas(newTestSchemaService(t, testSchemaFile))
+
+	notes := []domain.Note{
+		{ID: "a.md", Title: "A", Path: "a.md", Content: "plan", Type: "project", Frontmatter: map[string]any{"priority": "2", "stage": []any{"design", "build"}}},
+		{ID: "b.md", Title: "B", Path: "b.md", Content: "plan", Type: "project", Frontmatter: map[string]any{"priority": 3}},
+		{ID: "c.md", Title: "C", Path: "c.md", Content: "plan", Type: "meeting", Frontmatter: map[string]any{"host": "[[people/ada]]", "priority": 2}},
+	}
+	if err := search.IndexAll(notes); err != nil {
+		t.Fatalf("IndexAll() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		query   SearchQuery
+		wantIDs []string
+	}{
+		{name: "type", query: SearchQuery{Type: "Project"}, wantIDs: []string{"a.md", "b.md"}},
+		{name: "typed number", query: SearchQuery{Type: "project", Fields: map[string]string{"priority": "2.0"}}, wantIDs: []string{"a.md"}},
+		{name: "list item", query: SearchQuery{Fields: map[string]string{"stage": "build"}}, wantIDs: []string{"a.md"}},
+		{name: "link without brackets", query: SearchQuery{Fields: map[string]string{"host": "people/ada"}}, wantIDs: []string{"c.md"}},
+		{name: "field present", query: SearchQuery{Fields: map[string]string{"Priority": ""}}, wantIDs: []string{"a.md", "b.md", "c.md"}},
+		{name: "no match", query: SearchQuery{Fields: map[string]string{"priority": "7"}}, wantIDs: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := search.Search(tt.query)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			var ids []string
+			for _, r := range results {
+				ids = append(ids, r.NoteID)
+			}
+			slices.Sort(ids)
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("Search() = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
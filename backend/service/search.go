@@ -16,10 +16,14 @@ type SearchService struct {
 	mu sync.RWMutex
 	// BM25 index
 	index *bm25s.BM25S
+	// Set when documents were added by IndexNotes and the BM25 index has not been rebuilt since
+	stale bool
 	// Document metadata (maps index position to note info)
 	docs []SearchDocument
 	// Tag index for fast tag filtering
 	tagIndex map[string][]int
+	// Note type schemas used to type field values; nil leaves values as parsed
+	schemas *SchemaService
 }
 
 // SearchDocument represents a searchable document with metadata.
@@ -29,17 +33,23 @@ type SearchDocument struct {
 	Path       string
 	Content    string
 	Tags       []string
+	Properties map[string]string // Page and block properties, keyed by normalized property key
+	Type       string
+	Fields     map[string]QueryValue // Frontmatter and inline fields, typed by the note type's schema
 	ModifiedAt time.Time
 }
 
 // SearchQuery represents a search request with filters.
 type SearchQuery struct {
-	Query      string     // Search query text
-	Tags       []string   // Filter by tags (AND logic)
-	PathPrefix string     // Filter by path prefix
-	DateFrom   *time.Time `ts_type:"string"`
-	DateTo     *time.Time `ts_type:"string"`
-	Limit      int        // Maximum number of results (0 = no limit)
+	Query      string            // Search query text
+	Tags       []string          // Filter by tags (AND logic); a tag also matches its nested descendants
+	PathPrefix string            // Filter by path prefix
+	Properties map[string]string // Filter by page or block property (AND logic); an empty value matches any note with the key
+	Type       string            // Filter by note type
+	Fields     map[string]string // Filter by typed field value (AND logic); an empty value matches any note with the field
+	DateFrom   *time.Time        `ts_type:"string"`
+	DateTo     *time.Time        `ts_type:"string"`
+	Limit      int               // Maximum number of results (0 = no limit)
 }
 
 // SearchResult represents a single search result with ranking score.
@@ -61,33 +71,35 @@ func NewSearchService() *SearchService {
 	}
 }
 
-// IndexNote adds or updates a note in the search index.
-func (s *SearchService) IndexNote(note *domain.Note) error {
+// Clear removes every indexed note, e.g. before indexing another workspace.
+func (s *SearchService) Clear() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	tags := make([]string, len(note.Tags))
-	for i, tag := range note.Tags {
-		tags[i] = tag.Name
-	}
+	s.index = nil
+	s.stale = false
+	s.docs = []SearchDocument{}
+	s.tagIndex = make(map[string][]int)
+}
 
-	content := s.buildSearchableContent(note)
+// SetSchemas makes field filters compare values as the types declared by note type schemas.
+func (s *SearchService) SetSchemas(schemas *SchemaService) {
+	s.schemas = schemas
+}
 
-	doc := SearchDocument{
-		NoteID:     note.ID,
-		Title:      note.Title,
-		Path:       note.Path,
-		Content:    content,
-		Tags:       tags,
-		ModifiedAt: note.ModifiedAt,
-	}
+// IndexNote adds or updates a note in the search index.
+func (s *SearchService) IndexNote(note *domain.Note) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc := s.newSearchDocument(note)
 
 	s.removeNoteFromIndex(note.ID)
 
 	docIdx := len(s.docs)
 	s.docs = append(s.docs, doc)
 
-	for _, tag := range tags {
+	for _, tag := range doc.Tags {
 		s.tagIndex[tag] = append(s.tagIndex[tag], docIdx)
 	}
 
@@ -96,6 +108,48 @@ func (s *SearchService) IndexNote(note *domain.Note) error {
 	return nil
 }
 
+// IndexNotes adds or updates several notes in the search index. Their scores are computed by the
+// next search, so indexing a workspace batch by batch rebuilds the BM25 index only when it is used.
+func (s *SearchService) IndexNotes(notes []*domain.Note) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	indexed := make(map[string]bool, len(notes))
+	for _, note := range notes {
+		indexed[note.ID] = true
+	}
+	docs := make([]SearchDocument, 0, len(s.docs)+len(notes))
+	for _, doc := range s.docs {
+		if !indexed[doc.NoteID] {
+			docs = append(docs, doc)
+		}
+	}
+	for _, note := range notes {
+		docs = append(docs, s.newSearchDocument(note))
+	}
+
+	s.docs = docs
+	s.tagIndex = make(map[string][]int)
+	for i, doc := range s.docs {
+		for _, tag := range doc.Tags {
+			s.tagIndex[tag] = append(s.tagIndex[tag], i)
+		}
+	}
+	s.stale = true
+
+	return nil
+}
+
+// refreshIndex rebuilds the BM25 index if documents were added since it was built.
+func (s *SearchService) refreshIndex() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stale {
+		s.rebuildBM25Index()
+	}
+}
+
 // RemoveNote removes a note from the search index.
 func (s *SearchService) RemoveNote(noteID string) {
 	s.mu.Lock()
@@ -107,7 +161,14 @@ func (s *SearchService) RemoveNote(noteID string) {
 
 // Search performs a full-text search with optional filters.
 func (s *SearchService) Search(query SearchQuery) ([]SearchResult, error) {
-	s.mu.RLock()
+	for {
+		s.refreshIndex()
+		s.mu.RLock()
+		if !s.stale {
+			break
+		}
+		s.mu.RUnlock() // Documents were added again in between
+	}
 	defer s.mu.RUnlock()
 
 	if s.index == nil || len(s.docs) == 0 {
@@ -180,26 +241,12 @@ func (s *SearchService) IndexAll(notes []domain.Note) error {
 	s.tagIndex = make(map[string][]int)
 
 	for _, note := range notes {
-		tags := make([]string, len(note.Tags))
-		for i, tag := range note.Tags {
-			tags[i] = tag.Name
-		}
-
-		content := s.buildSearchableContent(&note)
-
-		doc := SearchDocument{
-			NoteID:     note.ID,
-			Title:      note.Title,
-			Path:       note.Path,
-			Content:    content,
-			Tags:       tags,
-			ModifiedAt: note.ModifiedAt,
-		}
+		doc := s.newSearchDocument(&note)
 
 		docIdx := len(s.docs)
 		s.docs = append(s.docs, doc)
 
-		for _, tag := range tags {
+		for _, tag := range doc.Tags {
 			s.tagIndex[tag] = append(s.tagIndex[tag], docIdx)
 		}
 	}
@@ -209,6 +256,31 @@ func (s *SearchService) IndexAll(notes []domain.Note) error {
 	return nil
 }
 
+// newSearchDocument builds the indexed form of a note.
+func (s *SearchService) newSearchDocument(note *domain.Note) SearchDocument {
+	tags := make([]string, len(note.Tags))
+	for i, tag := range note.Tags {
+		tags[i] = tag.Name
+	}
+
+	fields := newQueryPage(note).fields
+	if s.schemas != nil {
+		s.schemas.CoerceFields(note.Type, fields)
+	}
+
+	return SearchDocument{
+		NoteID:     note.ID,
+		Title:      note.Title,
+		Path:       note.Path,
+		Content:    s.buildSearchableContent(note),
+		Tags:       tags,
+		Properties: collectNoteProperties(note),
+		Type:       note.Type,
+		Fields:     fields,
+		ModifiedAt: note.ModifiedAt,
+	}
+}
+
 // applyCandidateFilters returns document indices that match filter criteria.
 func (s *SearchService) applyCandidateFilters(query SearchQuery) []int {
 	candidates := make(map[int]bool)
@@ -219,11 +291,19 @@ func (s *SearchService) applyCandidateFilters(query SearchQuery) []int {
 	if len(query.Tags) > 0 {
 		tagMatches := make(map[int]int)
 		for _, tag := range query.Tags {
-			if indices, ok := s.tagIndex[tag]; ok {
+			tag = normalizeTagName(tag)
+			matched := make(map[int]bool)
+			for indexedTag, indices := range s.tagIndex {
+				if !isTagOrDescendant(indexedTag, tag) {
+					continue
+				}
 				for _, idx := range indices {
-					tagMatches[idx]++
+					matched[idx] = true
 				}
 			}
+			for idx := range matched {
+				tagMatches[idx]++
+			}
 		}
 
 		for idx := range candidates {
@@ -233,6 +313,30 @@ func (s *SearchService) applyCandidateFilters(query SearchQuery) []int {
 		}
 	}
 
+	if len(query.Properties) > 0 {
+		for idx := range candidates {
+			if !matchesProperties(s.docs[idx].Properties, query.Properties) {
+				delete(candidates, idx)
+			}
+		}
+	}
+
+	if query.Type != "" {
+		for idx := range candidates {
+			if !strings.EqualFold(s.docs[idx].Type, query.Type) {
+				delete(candidates, idx)
+			}
+		}
+	}
+
+	if len(query.Fields) > 0 {
+		for idx := range candidates {
+			if !s.matchesFields(s.docs[idx], query.Fields) {
+				delete(candidates, idx)
+			}
+		}
+	}
+
 	if query.PathPrefix != "" {
 		for idx := range candidates {
 			if !strings.HasPrefix(s.docs[idx].Path, query.PathPrefix) {
@@ -264,8 +368,55 @@ func (s *SearchService) applyCandidateFilters(query SearchQuery) []int {
 	return result
 }
 
+// matchesFields reports whether a document satisfies every field filter.
+// Filter values are parsed like inline fields and typed by the document's schema, so "3" matches
+// a number field holding 3 and "Alice" matches a link field holding [[Alice]].
+// A filter matches a list field when any item matches.
+func (s *SearchService) matchesFields(doc SearchDocument, filters map[string]string) bool {
+	for key, raw := range filters {
+		key = normalizeQueryField(key)
+		value, ok := doc.Fields[key]
+		if !ok || value.Type == QueryValueNull {
+			return false
+		}
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+
+		want := parseInlineFieldValue(raw)
+		if s.schemas != nil {
+			if fieldType, ok := s.schemas.FieldType(doc.Type, key); ok && fieldType != SchemaFieldList {
+				want = coerceToFieldType(want, fieldType)
+			}
+		}
+
+		if !fieldValueMatches(value, want) {
+			return false
+		}
+	}
+	return true
+}
+
+func fieldValueMatches(value, want QueryValue) bool {
+	if queryValuesEqual(value, want) {
+		return true
+	}
+	if value.Type == QueryValueString && want.Type != QueryValueList {
+		return strings.EqualFold(value.String, want.Display())
+	}
+	if value.Type == QueryValueList {
+		for _, item := range value.List {
+			if fieldValueMatches(item, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // rebuildBM25Index recreates the BM25 index from current documents.
 func (s *SearchService) rebuildBM25Index() {
+	s.stale = false
 	if len(s.docs) == 0 {
 		s.index = nil
 		return
@@ -41,6 +41,36 @@ func TestSearchService_IndexNote(t *testing.T) {
 	}
 }
 
+func TestSearchService_IndexNotes(t *testing.T) {
+	search := NewSearchService()
+
+	first := []*domain.Note{
+		{ID: "garden.md", Title: "Garden", Path: "garden.md", Content: "Plant tomatoes", ModifiedAt: time.Now()},
+		{ID: "kitchen.md", Title: "Kitchen", Path: "kitchen.md", Content: "Cook tomatoes", ModifiedAt: time.Now()},
+	}
+	if err := search.IndexNotes(first); err != nil {
+		t.Fatalf("IndexNotes() error = %v", err)
+	}
+	if results, _ := search.Search(SearchQuery{Query: "tomatoes"}); len(results) != 2 {
+		t.Errorf("Search() = %d results, want 2", len(results))
+	}
+
+	// A later batch adds notes and replaces those indexed before.
+	second := []*domain.Note{
+		{ID: "kitchen.md", Title: "Kitchen", Path: "kitchen.md", Content: "Bake bread", ModifiedAt: time.Now()},
+		{ID: "bakery.md", Title: "Bakery", Path: "bakery.md", Content: "Sell bread", ModifiedAt: time.Now()},
+	}
+	if err := search.IndexNotes(second); err != nil {
+		t.Fatalf("IndexNotes() error = %v", err)
+	}
+	if results, _ := search.Search(SearchQuery{Query: "tomatoes"}); len(results) != 1 || results[0].NoteID != "garden.md" {
+		t.Errorf("Search(tomatoes) = %+v, want only garden.md", results)
+	}
+	if results, _ := search.Search(SearchQuery{Query: "bread"}); len(results) != 2 {
+		t.Errorf("Search(bread) = %d results, want 2", len(results))
+	}
+}
+
 func TestSearchService_Search(t *testing.T) {
 	search := NewSearchService()
 
@@ -956,3 +986,49 @@ func TestSearchService_SnippetPreservesOriginalCase(t *testing.T) {
 		t.Errorf("Snippet should preserve original case 'Python', got: %q", snippet)
 	}
 }
+
+func TestSearchService_TagHierarchyFilter(t *testing.T) {
+	search := NewSearchService()
+
+	notes := []domain.Note{
+		{ID: "alpha.md", Title: "Alpha", Path: "alpha.md", Content: "alpha plan", Tags: []domain.Tag{{Name: "project/alpha"}}},
+		{ID: "root.md", Title: "Root", Path: "root.md", Content: "root plan", Tags: []domain.Tag{{Name: "project"}, {Name: "project/beta"}}},
+		{ID: "other.md", Title: "Other", Path: "other.md", Content: "other plan", Tags: []domain.Tag{{Name: "projects"}}},
+	}
+	if err := search.IndexAll(notes); err != nil {
+		t.Fatalf("IndexAll() error = %v", err)
+	}
+
+	results, err := search.Search(SearchQuery{Tags: []string{"project"}})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Search(project) = %d results, want 2", len(results))
+	}
+	for _, r := range results {
+		if r.NoteID == "other.md" {
+			t.Error("Search(project) matched sibling tag 'projects'")
+		}
+	}
+
+	results, err = search.Search(SearchQuery{Tags: []string{"project/alpha"}})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 1 || results[0].NoteID != "alpha.md" {
+		t.Errorf("Search(project/alpha) = %+v, want only alpha.md", results)
+	}
+}
+
+func TestSearchService_Clear(t *testing.T) {
+	search := NewSearchService()
+	search.IndexNote(&domain.Note{ID: "a.md", Title: "Alpha", Content: "alpha content", Frontmatter: map[string]any{}, ModifiedAt: time.Now()})
+
+	search.Clear()
+
+	results, err := search.Search(SearchQuery{Query: "alpha"})
+	if err != nil || len(results) != 0 {
+		t.Errorf("Search() after Clear() = %d results, %v", len(results), err)
+	}
+}
@@ -0,0 +1,71 @@
+// Client-side search over search-index.json. Browsers only allow fetching the index
+// when the site is served over HTTP, not opened from the file system.
+(function () {
+  const input = document.getElementById("search");
+  const results = document.getElementById("search-results");
+  if (!input || !results) {
+    return;
+  }
+
+  const root = input.dataset.root || "";
+  let index = null;
+
+  function load() {
+    if (!index) {
+      index = fetch(root + "search-index.json")
+        .then((response) => response.json())
+        .catch(() => []);
+    }
+    return index;
+  }
+
+  function score(entry, terms) {
+    const title = entry.title.toLowerCase();
+    const tags = entry.tags.join(" ").toLowerCase();
+    const text = entry.text.toLowerCase();
+    let total = 0;
+    for (const term of terms) {
+      if (title.includes(term)) {
+        total += 10;
+      } else if (tags.includes(term)) {
+        total += 5;
+      } else if (text.includes(term)) {
+        total += 1;
+      } else {
+        return 0;
+      }
+    }
+    return total;
+  }
+
+  function show(matches) {
+    results.replaceChildren(
+      ...matches.map((entry) => {
+        const link = document.createElement("a");
+        link.href = root + entry.url;
+        link.textContent = entry.title;
+        const item = document.createElement("li");
+        item.appendChild(link);
+        return item;
+      }),
+    );
+  }
+
+  input.addEventListener("focus", load);
+  input.addEventListener("input", () => {
+    const terms = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+    if (terms.length === 0) {
+      show([]);
+      return;
+    }
+    load().then((entries) => {
+      const matches = entries
+        .map((entry) => ({ entry, score: score(entry, terms) }))
+        .filter((match) => match.score > 0)
+        .sort((a, b) => b.score - a.score || a.entry.title.localeCompare(b.entry.title))
+        .slice(0, 20)
+        .map((match) => match.entry);
+      show(matches);
+    });
+  });
+})();
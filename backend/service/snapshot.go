@@ -0,0 +1,318 @@
+package service
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"notes/backend/domain"
+)
+
+// SnapshotRetention decides which snapshots of a note are kept as they age.
+type SnapshotRetention struct {
+	KeepAll    time.Duration `json:"keepAll"`    // Every snapshot younger than this is kept
+	KeepHourly time.Duration `json:"keepHourly"` // Up to this age, the newest snapshot of each hour is kept
+	KeepDaily  time.Duration `json:"keepDaily"`  // Up to this age, the newest snapshot of each day is kept; 0 keeps daily snapshots forever
+}
+
+// DefaultSnapshotRetention keeps every save for a day, hourly snapshots for a week and daily snapshots after that.
+var DefaultSnapshotRetention = SnapshotRetention{
+	KeepAll:    24 * time.Hour,
+	KeepHourly: 7 * 24 * time.Hour,
+}
+
+// SnapshotStore keeps prior versions of notes for workspaces without git history.
+// Versions are indexed in the database and their content is stored gzip-compressed in dir,
+// one file per distinct content hash, so identical versions are stored once.
+type SnapshotStore struct {
+	db        *sql.DB
+	dir       string
+	retention SnapshotRetention
+	now       func() time.Time
+}
+
+// NewSnapshotStore creates a SnapshotStore with DefaultSnapshotRetention.
+// The database should already have snapshot table migrations applied.
+func NewSnapshotStore(db *sql.DB, dir string) *SnapshotStore {
+	return &SnapshotStore{
+		db:        db,
+		dir:       dir,
+		retention: DefaultSnapshotRetention,
+		now:       time.Now,
+	}
+}
+
+// SetRetention changes which snapshots are kept. It applies from the next save of each note.
+func (ss *SnapshotStore) SetRetention(retention SnapshotRetention) {
+	ss.retention = retention
+}
+
+// Save stores content as the newest snapshot of a note, then prunes the note's snapshots
+// by the retention policy. Content identical to the newest snapshot is not stored again.
+func (ss *SnapshotStore) Save(workspaceID, noteID string, content []byte) error {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+
+	versions, err := GetNoteVersions(ss.db, workspaceID, noteID)
+	if err != nil {
+		return err
+	}
+	if len(versions) > 0 && versions[0].Hash == hash {
+		return nil
+	}
+
+	if err := ss.writeContent(hash, content); err != nil {
+		return err
+	}
+	if _, err := SaveNoteVersion(ss.db, NoteVersionRecord{
+		WorkspaceID: workspaceID,
+		NoteID:      noteID,
+		Hash:        hash,
+		Size:        int64(len(content)),
+		CreatedAt:   ss.now().UTC(),
+	}); err != nil {
+		return err
+	}
+
+	return ss.Prune(workspaceID, noteID)
+}
+
+// List returns the snapshots of a note, newest first.
+func (ss *SnapshotStore) List(workspaceID, noteID string) ([]NoteVersionRecord, error) {
+	return GetNoteVersions(ss.db, workspaceID, noteID)
+}
+
+// Content returns the content of a note's snapshot. The hash may be abbreviated to a unique
+// prefix of at least 7 characters.
+func (ss *SnapshotStore) Content(workspaceID, noteID, hash string) ([]byte, error) {
+	versions, err := GetNoteVersions(ss.db, workspaceID, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, version := range versions {
+		if version.Hash == hash || (len(hash) >= 7 && strings.HasPrefix(version.Hash, hash)) {
+			return ss.readContent(version.Hash)
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "version", ID: noteID + "@" + hash}
+}
+
+// Prune deletes the snapshots of a note that the retention policy no longer keeps,
+// and removes content files no snapshot refers to anymore.
+func (ss *SnapshotStore) Prune(workspaceID, noteID string) error {
+	versions, err := GetNoteVersions(ss.db, workspaceID, noteID)
+	if err != nil {
+		return err
+	}
+
+	now := ss.now()
+	kept := map[string]bool{}
+	removed := map[string]bool{}
+	for _, version := range versions {
+		age := now.Sub(version.CreatedAt)
+
+		var bucket string
+		switch {
+		case age < ss.retention.KeepAll:
+			continue
+		case age < ss.retention.KeepHourly:
+			bucket = version.CreatedAt.Local().Format("2006-01-02T15")
+		case ss.retention.KeepDaily <= 0 || age < ss.retention.KeepDaily:
+			bucket = version.CreatedAt.Local().Format("2006-01-02")
+		}
+
+		// Versions are newest first, so the first version seen in a bucket is the one kept.
+		if bucket != "" && !kept[bucket] {
+			kept[bucket] = true
+			continue
+		}
+		if err := DeleteNoteVersion(ss.db, version.ID); err != nil {
+			return err
+		}
+		removed[version.Hash] = true
+	}
+
+	for hash := range removed {
+		count, err := CountNoteVersionsWithHash(ss.db, hash)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			if err := os.Remove(ss.contentPath(hash)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove snapshot content: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// contentPath returns the file holding the compressed content with the given hash.
+func (ss *SnapshotStore) contentPath(hash string) string {
+	return filepath.Join(ss.dir, hash[:2], hash[2:]+".gz")
+}
+
+// writeContent stores compressed content under its hash unless it is already stored.
+// The file is written under a temporary name and renamed, so a partial write is never read back.
+func (ss *SnapshotStore) writeContent(hash string, content []byte) error {
+	path := ss.contentPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".snapshot-*")
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	zw := gzip.NewWriter(tmp)
+	_, err = zw.Write(content)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// readContent reads and decompresses the content with the given hash.
+func (ss *SnapshotStore) readContent(hash string) ([]byte, error) {
+	file, err := os.Open(ss.contentPath(hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, &domain.ErrNotFound{Resource: "snapshot", ID: hash}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	defer file.Close()
+
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	return content, nil
+}
+
+// ListNoteVersions returns the snapshots of a note kept by SaveNote, newest first.
+// Returns an empty list when no snapshot store is attached.
+func (s *NoteService) ListNoteVersions(noteID string) ([]domain.NoteVersion, error) {
+	versions := []domain.NoteVersion{}
+	if s.snapshots == nil {
+		return versions, nil
+	}
+	workspace, err := s.fs.GetCurrentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.snapshots.List(workspace.ID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		versions = append(versions, domain.NoteVersion{
+			Hash:   record.Hash,
+			Time:   record.CreatedAt,
+			Path:   noteID,
+			Change: domain.NoteModified,
+		})
+	}
+	return versions, nil
+}
+
+// GetNoteVersion returns a note's content from one of its snapshots.
+func (s *NoteService) GetNoteVersion(noteID, hash string) (string, error) {
+	if s.snapshots == nil {
+		return "", &domain.ErrNotFound{Resource: "version", ID: noteID + "@" + hash}
+	}
+	workspace, err := s.fs.GetCurrentWorkspace()
+	if err != nil {
+		return "", err
+	}
+
+	content, err := s.snapshots.Content(workspace.ID, noteID, hash)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// DiffNoteVersions returns the line diff of a note between two of its snapshots.
+// An empty toHash compares against the note as it is on disk now.
+func (s *NoteService) DiffNoteVersions(noteID, fromHash, toHash string) ([]DiffLine, error) {
+	before, err := s.GetNoteVersion(noteID, fromHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var after string
+	if toHash == "" {
+		content, err := s.fs.ReadFile(noteID)
+		var notFound *domain.ErrNotFound
+		if err != nil && !errors.As(err, &notFound) {
+			return nil, err
+		}
+		after = string(content)
+	} else if after, err = s.GetNoteVersion(noteID, toHash); err != nil {
+		return nil, err
+	}
+
+	return DiffLines(before, after), nil
+}
+
+// RestoreNoteVersion overwrites a note with the content of one of its snapshots and returns
+// the restored note. The content being replaced is snapshotted first, so a restore can be undone.
+func (s *NoteService) RestoreNoteVersion(noteID, hash string) (*domain.Note, error) {
+	content, err := s.GetNoteVersion(noteID, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if current, err := s.fs.ReadFile(noteID); err == nil && string(current) != content {
+		if err := s.snapshot(noteID, current); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.fs.WriteFile(noteID, []byte(content)); err != nil {
+		return nil, err
+	}
+	return s.GetNote(noteID)
+}
+
+// snapshot keeps content as a prior version of a note when a snapshot store is attached.
+func (s *NoteService) snapshot(noteID string, content []byte) error {
+	if s.snapshots == nil {
+		return nil
+	}
+	workspace, err := s.fs.GetCurrentWorkspace()
+	if err != nil {
+		return err
+	}
+	if err := s.snapshots.Save(workspace.ID, noteID, content); err != nil {
+		return fmt.Errorf("failed to snapshot note: %w", err)
+	}
+	return nil
+}
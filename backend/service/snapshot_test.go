@@ -0,0 +1,185 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"notes/backend/domain"
+)
+
+// newTestSnapshotStore returns a snapshot store backed by a migrated temporary database.
+func newTestSnapshotStore(t *testing.T) *SnapshotStore {
+	t.Helper()
+
+	db := setupTestDB(t)
+	t.Cleanup(func() { db.Close() })
+	return NewSnapshotStore(db, filepath.Join(t.TempDir(), "snapshots"))
+}
+
+// countSnapshotFiles counts the content files in a snapshot store's directory.
+func countSnapshotFiles(t *testing.T, ss *SnapshotStore) int {
+	t.Helper()
+
+	count := 0
+	filepath.WalkDir(ss.dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			count++
+		}
+		return nil
+	})
+	return count
+}
+
+func TestSnapshotStore_SaveAndContent(t *testing.T) {
+	ss := newTestSnapshotStore(t)
+
+	for _, content := range []string{"first", "first", "second", "first"} {
+		if err := ss.Save("ws-1", "note.md", []byte(content)); err != nil {
+			t.Fatalf("Save(%q) error = %v", content, err)
+		}
+	}
+
+	versions, err := ss.List("ws-1", "note.md")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("List() returned %d versions, want 3 (repeated saves are deduplicated)", len(versions))
+	}
+	if versions[0].Hash != versions[2].Hash || versions[0].Size != int64(len("first")) {
+		t.Errorf("versions = %+v, want newest and oldest to share content", versions)
+	}
+	if files := countSnapshotFiles(t, ss); files != 2 {
+		t.Errorf("stored %d content files, want 2", files)
+	}
+
+	data, err := os.ReadFile(ss.contentPath(versions[1].Hash))
+	if err != nil || len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		t.Errorf("content file is not gzip-compressed: %v", err)
+	}
+
+	content, err := ss.Content("ws-1", "note.md", versions[1].Hash[:8])
+	if err != nil || string(content) != "second" {
+		t.Errorf("Content(short hash) = %q, %v; want %q", content, err, "second")
+	}
+
+	var notFound *domain.ErrNotFound
+	if _, err := ss.Content("ws-2", "note.md", versions[1].Hash); !errors.As(err, &notFound) {
+		t.Errorf("Content(other workspace) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSnapshotStore_Retention(t *testing.T) {
+	ss := newTestSnapshotStore(t)
+	ss.SetRetention(SnapshotRetention{KeepAll: 24 * time.Hour, KeepHourly: 7 * 24 * time.Hour, KeepDaily: 30 * 24 * time.Hour})
+
+	now := time.Date(2025, 7, 20, 12, 0, 0, 0, time.Local)
+	saves := []time.Time{
+		now.Add(-40 * 24 * time.Hour),             // Older than KeepDaily: removed
+		now.Add(-10 * 24 * time.Hour),             // Same day, older: removed
+		now.Add(-10*24*time.Hour + 2*time.Hour),   // Newest of its day: kept
+		now.Add(-3 * 24 * time.Hour),              // Same hour, older: removed
+		now.Add(-3*24*time.Hour + 20*time.Minute), // Newest of its hour: kept
+		now.Add(-3*24*time.Hour + 90*time.Minute), // Next hour: kept
+		now.Add(-5 * time.Hour),                   // Within a day: kept
+		now.Add(-5*time.Hour + time.Minute),       // Within a day: kept
+		now.Add(-5*time.Hour + 2*time.Minute),     // Within a day: kept
+	}
+	for i, at := range saves {
+		ss.now = func() time.Time { return at }
+		if err := ss.Save("ws-1", "note.md", []byte(fmt.Sprintf("version %d", i))); err != nil {
+			t.Fatalf("Save(%d) error = %v", i, err)
+		}
+	}
+
+	ss.now = func() time.Time { return now }
+	if err := ss.Prune("ws-1", "note.md"); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+
+	versions, err := ss.List("ws-1", "note.md")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	got := []string{}
+	for _, version := range versions {
+		content, err := ss.Content("ws-1", "note.md", version.Hash)
+		if err != nil {
+			t.Fatalf("Content() error = %v", err)
+		}
+		got = append(got, string(content))
+	}
+	want := []string{"version 8", "version 7", "version 6", "version 5", "version 4", "version 2"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("kept versions = %v, want %v", got, want)
+	}
+	if files := countSnapshotFiles(t, ss); files != len(want) {
+		t.Errorf("stored %d content files, want %d after pruning", files, len(want))
+	}
+}
+
+func TestNoteService_NoteVersions(t *testing.T) {
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{"note.md": "# Note\n\nFirst line\n"})
+
+	if versions, err := notes.ListNoteVersions("note.md"); err != nil || len(versions) != 0 {
+		t.Errorf("ListNoteVersions() without a store = %+v, %v; want empty", versions, err)
+	}
+
+	notes.SetSnapshotStore(newTestSnapshotStore(t))
+	note, err := notes.GetNote("note.md")
+	if err != nil {
+		t.Fatalf("GetNote() error = %v", err)
+	}
+	original := note.Content
+
+	note.Content = original + "Second line\n"
+	if err := notes.SaveNote(note); err != nil {
+		t.Fatalf("SaveNote() error = %v", err)
+	}
+	note.Content = original + "Third line\n"
+	if err := notes.SaveNote(note); err != nil {
+		t.Fatalf("SaveNote() error = %v", err)
+	}
+
+	versions, err := notes.ListNoteVersions(note.ID)
+	if err != nil {
+		t.Fatalf("ListNoteVersions() error = %v", err)
+	}
+	if len(versions) != 2 || versions[0].Path != note.ID || versions[0].Time.IsZero() {
+		t.Fatalf("ListNoteVersions() = %+v, want 2 versions", versions)
+	}
+
+	previous, err := notes.GetNoteVersion(note.ID, versions[0].Hash)
+	if err != nil {
+		t.Fatalf("GetNoteVersion() error = %v", err)
+	}
+
+	diff, err := notes.DiffNoteVersions(note.ID, versions[0].Hash, "")
+	if err != nil {
+		t.Fatalf("DiffNoteVersions() error = %v", err)
+	}
+	changed := ChangedLines(diff)
+	if len(changed) != 2 || changed[0].Op != DiffDelete || changed[0].Text != "Second line" || changed[1].Text != "Third line" {
+		t.Errorf("DiffNoteVersions() changes = %+v", changed)
+	}
+
+	restored, err := notes.RestoreNoteVersion(note.ID, versions[0].Hash)
+	if err != nil {
+		t.Fatalf("RestoreNoteVersion() error = %v", err)
+	}
+	if restored.Content != original+"Second line\n" {
+		t.Errorf("restored content = %q", restored.Content)
+	}
+
+	versions, _ = notes.ListNoteVersions(note.ID)
+	if len(versions) != 3 {
+		t.Fatalf("ListNoteVersions() after restore = %d versions, want 3", len(versions))
+	}
+	if replaced, _ := notes.GetNoteVersion(note.ID, versions[0].Hash); replaced == previous {
+		t.Error("restore did not snapshot the replaced content")
+	}
+}
@@ -1,8 +1,12 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 
 	"notes/backend/domain"
 )
@@ -75,6 +79,7 @@ func NewGraphStore(db *sql.DB) *GraphStore {
 // Provides CRUD operations for tasks with metadata tracking.
 type TaskStore struct {
 	db *sql.DB
+	tx *sql.Tx // Set on the store passed to a Batch callback
 }
 
 // NewTaskStore creates a new TaskStore with an open database connection.
@@ -85,6 +90,20 @@ func NewTaskStore(db *sql.DB) *TaskStore {
 	}
 }
 
+// MetadataStore manages note timestamps and workspace settings in the SQLite database.
+// Rows are keyed by workspace ID so several workspaces can share one database.
+type MetadataStore struct {
+	db *sql.DB
+}
+
+// NewMetadataStore creates a new MetadataStore with an open database connection.
+// The database should already have metadata table migrations applied.
+func NewMetadataStore(db *sql.DB) *MetadataStore {
+	return &MetadataStore{
+		db: db,
+	}
+}
+
 // CreatePage inserts a new page into the graph.
 func (gs *GraphStore) CreatePage(page Page) error {
 	return CreatePage(gs.db, page)
@@ -120,32 +139,164 @@ func (gs *GraphStore) Close() error {
 	return gs.db.Close()
 }
 
+// Batch runs fn with a store whose reads and writes all go through one transaction,
+// committed when fn returns nil and rolled back otherwise.
+func (ts *TaskStore) Batch(fn func(store *TaskStore) error) error {
+	if ts.tx != nil {
+		return fn(ts)
+	}
+	tx, err := ts.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin task transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(&TaskStore{db: ts.db, tx: tx}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tasks: %w", err)
+	}
+	return nil
+}
+
+// conn returns the transaction the store is batching in, or its database.
+func (ts *TaskStore) conn() queryer {
+	if ts.tx != nil {
+		return ts.tx
+	}
+	return ts.db
+}
+
 // SaveTask persists a task to the database.
 func (ts *TaskStore) SaveTask(task *domain.Task) error {
-	return SaveTask(ts.db, task)
+	return SaveTask(ts.conn(), task)
 }
 
 // GetTaskByID retrieves a task by its ID.
 func (ts *TaskStore) GetTaskByID(id string) (*domain.Task, error) {
-	return GetTaskByID(ts.db, id)
+	return GetTaskByID(ts.conn(), id)
 }
 
 // GetTasksForNote retrieves all tasks for a specific note.
 func (ts *TaskStore) GetTasksForNote(noteID string) ([]domain.Task, error) {
-	return GetTasksForNote(ts.db, noteID)
+	return GetTasksForNote(ts.conn(), noteID)
+}
+
+// GetAllTasks retrieves every stored task.
+func (ts *TaskStore) GetAllTasks() ([]domain.Task, error) {
+	return GetAllTasks(ts.conn())
 }
 
 // DeleteTasksForNote removes all tasks associated with a note.
 func (ts *TaskStore) DeleteTasksForNote(noteID string) error {
-	return DeleteTasksForNote(ts.db, noteID)
+	return DeleteTasksForNote(ts.conn(), noteID)
+}
+
+// DeleteAllTasks removes every stored task.
+func (ts *TaskStore) DeleteAllTasks() error {
+	return DeleteAllTasks(ts.conn())
+}
+
+// GetNoteMetadata retrieves the stored timestamps for a note, or nil when none are stored.
+func (ms *MetadataStore) GetNoteMetadata(workspaceID, noteID string) (*NoteMetadata, error) {
+	return GetNoteMetadata(ms.db, workspaceID, noteID)
 }
 
-// Stores holds WorkspaceStore, GraphStore, and TaskStore for a workspace.
+// SaveNoteMetadata persists the timestamps for a note.
+func (ms *MetadataStore) SaveNoteMetadata(meta NoteMetadata) error {
+	return SaveNoteMetadata(ms.db, meta)
+}
+
+// DeleteNoteMetadata removes the stored timestamps for a note.
+func (ms *MetadataStore) DeleteNoteMetadata(workspaceID, noteID string) error {
+	return DeleteNoteMetadata(ms.db, workspaceID, noteID)
+}
+
+// frontmatterPolicyKey is the workspace_settings key holding the frontmatter policy.
+const frontmatterPolicyKey = "frontmatter_policy"
+
+// GetFrontmatterPolicy returns the workspace's frontmatter policy, defaulting to preserve-existing.
+func (ms *MetadataStore) GetFrontmatterPolicy(workspaceID string) (domain.FrontmatterPolicy, error) {
+	value, ok, err := GetWorkspaceSetting(ms.db, workspaceID, frontmatterPolicyKey)
+	if err != nil {
+		return "", err
+	}
+	policy := domain.FrontmatterPolicy(value)
+	if !ok || !policy.Valid() {
+		return domain.FrontmatterPreserveExisting, nil
+	}
+	return policy, nil
+}
+
+// SetFrontmatterPolicy stores the workspace's frontmatter policy.
+func (ms *MetadataStore) SetFrontmatterPolicy(workspaceID string, policy domain.FrontmatterPolicy) error {
+	if !policy.Valid() {
+		return fmt.Errorf("invalid frontmatter policy %q", policy)
+	}
+	return SetWorkspaceSetting(ms.db, workspaceID, frontmatterPolicyKey, string(policy))
+}
+
+// attachmentFolderKey is the workspace_settings key holding the attachment folder.
+const attachmentFolderKey = "attachment_folder"
+
+// GetAttachmentFolder returns the workspace's attachment folder, defaulting to DefaultAttachmentFolder.
+func (ms *MetadataStore) GetAttachmentFolder(workspaceID string) (string, error) {
+	value, ok, err := GetWorkspaceSetting(ms.db, workspaceID, attachmentFolderKey)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return DefaultAttachmentFolder, nil
+	}
+	folder, err := normalizeAttachmentFolder(value)
+	if err != nil {
+		return DefaultAttachmentFolder, nil
+	}
+	return folder, nil
+}
+
+// SetAttachmentFolder stores the workspace's attachment folder.
+func (ms *MetadataStore) SetAttachmentFolder(workspaceID, folder string) error {
+	folder, err := normalizeAttachmentFolder(folder)
+	if err != nil {
+		return err
+	}
+	return SetWorkspaceSetting(ms.db, workspaceID, attachmentFolderKey, folder)
+}
+
+// autoCommitDelayKey is the workspace_settings key holding the git auto-commit delay in seconds.
+const autoCommitDelayKey = "auto_commit_delay"
+
+// GetAutoCommitDelay returns the workspace's git auto-commit delay in seconds, defaulting to 0 (off).
+func (ms *MetadataStore) GetAutoCommitDelay(workspaceID string) (int, error) {
+	value, ok, err := GetWorkspaceSetting(ms.db, workspaceID, autoCommitDelayKey)
+	if err != nil {
+		return 0, err
+	}
+	seconds, err := strconv.Atoi(value)
+	if !ok || err != nil || seconds < 0 {
+		return 0, nil
+	}
+	return seconds, nil
+}
+
+// SetAutoCommitDelay stores the workspace's git auto-commit delay in seconds; 0 turns auto-commit off.
+func (ms *MetadataStore) SetAutoCommitDelay(workspaceID string, seconds int) error {
+	if seconds < 0 {
+		return fmt.Errorf("invalid auto-commit delay %d", seconds)
+	}
+	return SetWorkspaceSetting(ms.db, workspaceID, autoCommitDelayKey, strconv.Itoa(seconds))
+}
+
+// Stores holds WorkspaceStore, GraphStore, TaskStore, MetadataStore, and SnapshotStore for a workspace.
 // Provides a unified interface for all persistence operations.
 type Stores struct {
 	Workspace *WorkspaceStore
 	Graph     *GraphStore
 	Task      *TaskStore
+	Metadata  *MetadataStore
+	Snapshots *SnapshotStore
 }
 
 // NewStores creates and initializes both WorkspaceStore and GraphStore.
@@ -196,6 +347,8 @@ func NewStores(appName, workspaceName string, logger *runtimeLogger) (*Stores, e
 		Workspace: NewWorkspaceStore(dirs),
 		Graph:     NewGraphStore(db),
 		Task:      NewTaskStore(db),
+		Metadata:  NewMetadataStore(db),
+		Snapshots: NewSnapshotStore(db, dirs.SnapshotDir),
 	}, nil
 }
 
@@ -219,3 +372,179 @@ func (s *Stores) Close(logger *runtimeLogger) error {
 
 	return err
 }
+
+// DefaultWorkspaceName is the store directory every workspace shared before stores were kept per workspace.
+// It still holds the stores used while no workspace is open.
+const DefaultWorkspaceName = "default"
+
+// OpenWorkspaceStores opens the stores of a workspace in a directory keyed by its ID.
+// The first time a workspace is opened, its data is copied over from the shared DefaultWorkspaceName
+// stores: rows keyed by the workspace ID, the snapshots they refer to, and the UI state in
+// workspace.toml when it refers to this workspace's notes. Tasks are rebuilt by the first index build.
+func OpenWorkspaceStores(appName string, workspace *domain.Workspace, logger *runtimeLogger) (*Stores, error) {
+	dirs, err := NewAppDirs(appName, workspace.ID, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create app dirs: %w", err)
+	}
+	_, statErr := os.Stat(dirs.DBPath)
+	firstOpen := os.IsNotExist(statErr)
+
+	stores, err := NewStores(appName, workspace.ID, logger)
+	if err != nil {
+		return nil, err
+	}
+	if !firstOpen {
+		return stores, nil
+	}
+
+	legacy, err := NewAppDirs(appName, DefaultWorkspaceName, logger)
+	if err != nil {
+		stores.Close(logger)
+		return nil, fmt.Errorf("failed to create app dirs: %w", err)
+	}
+	if err := migrateLegacyStores(legacy, dirs, stores, workspace); err != nil {
+		// Remove the half-migrated database so the migration runs again next time.
+		stores.Close(logger)
+		os.Remove(dirs.DBPath)
+		return nil, fmt.Errorf("failed to migrate %s stores: %w", DefaultWorkspaceName, err)
+	}
+	if logger != nil {
+		logger.Infof("Migrated %s stores to workspace %s", DefaultWorkspaceName, workspace.ID)
+	}
+	return stores, nil
+}
+
+// migrateLegacyStores copies a workspace's data from the shared legacy stores into its own stores.
+func migrateLegacyStores(legacy, dirs *AppDirs, stores *Stores, workspace *domain.Workspace) error {
+	if _, err := os.Stat(legacy.DBPath); err == nil {
+		// Bring the legacy schema up to date so every table copied below exists.
+		legacyDB, err := OpenGraphDB(legacy.DBPath, nil)
+		if err != nil {
+			return err
+		}
+		err = Migrate(legacyDB, nil)
+		legacyDB.Close()
+		if err != nil {
+			return err
+		}
+
+		if err := migrateLegacyRows(stores.Metadata.db, legacy.DBPath, workspace); err != nil {
+			return err
+		}
+		if err := copyLegacySnapshots(stores.Metadata.db, legacy.SnapshotDir, dirs.SnapshotDir); err != nil {
+			return err
+		}
+	}
+
+	return migrateLegacyWorkspaceSnapshot(legacy.WorkspacePath, dirs.WorkspacePath, workspace.RootPath)
+}
+
+// migrateLegacyRows copies the workspace's rows from the legacy database into db.
+// Tasks are not keyed by workspace, so none are copied; the workspace's first index build
+// extracts them again from its own notes.
+func migrateLegacyRows(db *sql.DB, legacyPath string, workspace *domain.Workspace) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS legacy", legacyPath); err != nil {
+		return fmt.Errorf("failed to attach legacy database: %w", err)
+	}
+	defer conn.ExecContext(ctx, "DETACH DATABASE legacy")
+
+	statements := []string{
+		`INSERT OR IGNORE INTO note_metadata SELECT * FROM legacy.note_metadata WHERE workspace_id = ?`,
+		`INSERT OR IGNORE INTO workspace_settings SELECT * FROM legacy.workspace_settings WHERE workspace_id = ?`,
+		`INSERT INTO note_versions (workspace_id, note_id, hash, size, created_at)
+			SELECT workspace_id, note_id, hash, size, created_at FROM legacy.note_versions WHERE workspace_id = ? ORDER BY id`,
+	}
+	for _, statement := range statements {
+		if _, err := conn.ExecContext(ctx, statement, workspace.ID); err != nil {
+			return fmt.Errorf("failed to copy legacy rows: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// copyLegacySnapshots copies the snapshot content files referenced by db from the legacy snapshot directory.
+func copyLegacySnapshots(db *sql.DB, legacyDir, dir string) error {
+	rows, err := db.Query(`SELECT DISTINCT hash FROM note_versions`)
+	if err != nil {
+		return fmt.Errorf("failed to read note versions: %w", err)
+	}
+	defer rows.Close()
+
+	legacy := &SnapshotStore{dir: legacyDir}
+	target := &SnapshotStore{dir: dir}
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return fmt.Errorf("failed to read note versions: %w", err)
+		}
+		data, err := os.ReadFile(legacy.contentPath(hash))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read snapshot: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(target.contentPath(hash)), 0755); err != nil {
+			return fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+		if err := os.WriteFile(target.contentPath(hash), data, 0644); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+	}
+	return rows.Err()
+}
+
+// migrateLegacyWorkspaceSnapshot copies the shared workspace.toml to a workspace when the notes it
+// refers to exist there, keeping only those notes. A snapshot referring to no notes is copied as is.
+func migrateLegacyWorkspaceSnapshot(legacyPath, path, workspaceRoot string) error {
+	if _, err := os.Stat(legacyPath); err != nil {
+		return nil
+	}
+	snapshot, err := LoadWorkspaceSnapshot(legacyPath)
+	if err != nil {
+		return err
+	}
+
+	exists := func(noteID string) bool {
+		_, err := os.Stat(filepath.Join(workspaceRoot, filepath.FromSlash(noteID)))
+		return err == nil
+	}
+	filter := func(noteIDs []string) ([]string, int) {
+		kept := []string{}
+		for _, id := range noteIDs {
+			if exists(id) {
+				kept = append(kept, id)
+			}
+		}
+		return kept, len(noteIDs)
+	}
+
+	ui := &snapshot.UI
+	referenced, found := 0, 0
+	if ui.ActivePage != "" {
+		referenced++
+		if exists(ui.ActivePage) {
+			found++
+		} else {
+			ui.ActivePage = ""
+		}
+	}
+	var total int
+	ui.PinnedPages, total = filter(ui.PinnedPages)
+	referenced, found = referenced+total, found+len(ui.PinnedPages)
+	ui.RecentPages, total = filter(ui.RecentPages)
+	referenced, found = referenced+total, found+len(ui.RecentPages)
+
+	if referenced > 0 && found == 0 {
+		return nil
+	}
+	return SaveWorkspaceSnapshot(path, snapshot)
+}
@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	"notes/backend/domain"
 )
 
 func TestNewStores(t *testing.T) {
@@ -222,3 +224,160 @@ func TestGraphStore_Integration(t *testing.T) {
 		t.Error("backlinks should be cascaded on page delete")
 	}
 }
+
+func TestMetadataStore(t *testing.T) {
+	tempDir := t.TempDir()
+	stores, err := NewStores(filepath.Join(tempDir, "testapp"), "testworkspace", nil)
+	if err != nil {
+		t.Fatalf("NewStores() error = %v", err)
+	}
+	defer stores.Close(nil)
+
+	policy, err := stores.Metadata.GetFrontmatterPolicy("ws-1")
+	if err != nil {
+		t.Fatalf("GetFrontmatterPolicy() error = %v", err)
+	}
+	if policy != domain.FrontmatterPreserveExisting {
+		t.Errorf("default policy = %q, want %q", policy, domain.FrontmatterPreserveExisting)
+	}
+
+	if err := stores.Metadata.SetFrontmatterPolicy("ws-1", domain.FrontmatterNever); err != nil {
+		t.Fatalf("SetFrontmatterPolicy() error = %v", err)
+	}
+	if err := stores.Metadata.SetFrontmatterPolicy("ws-1", "sometimes"); err == nil {
+		t.Error("SetFrontmatterPolicy() accepted an invalid policy")
+	}
+
+	policy, _ = stores.Metadata.GetFrontmatterPolicy("ws-1")
+	if policy != domain.FrontmatterNever {
+		t.Errorf("policy = %q, want %q", policy, domain.FrontmatterNever)
+	}
+	policy, _ = stores.Metadata.GetFrontmatterPolicy("ws-2")
+	if policy != domain.FrontmatterPreserveExisting {
+		t.Errorf("other workspace policy = %q, want %q", policy, domain.FrontmatterPreserveExisting)
+	}
+
+	if delay, _ := stores.Metadata.GetAutoCommitDelay("ws-1"); delay != 0 {
+		t.Errorf("default auto-commit delay = %d, want 0", delay)
+	}
+	if err := stores.Metadata.SetAutoCommitDelay("ws-1", 30); err != nil {
+		t.Fatalf("SetAutoCommitDelay() error = %v", err)
+	}
+	if err := stores.Metadata.SetAutoCommitDelay("ws-1", -1); err == nil {
+		t.Error("SetAutoCommitDelay() accepted a negative delay")
+	}
+	if delay, _ := stores.Metadata.GetAutoCommitDelay("ws-1"); delay != 30 {
+		t.Errorf("auto-commit delay = %d, want 30", delay)
+	}
+
+	created := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	modified := time.Date(2025, 1, 20, 15, 30, 0, 0, time.UTC)
+	meta := NoteMetadata{WorkspaceID: "ws-1", NoteID: "note.md", CreatedAt: created, ModifiedAt: modified}
+	if err := stores.Metadata.SaveNoteMetadata(meta); err != nil {
+		t.Fatalf("SaveNoteMetadata() error = %v", err)
+	}
+
+	got, err := stores.Metadata.GetNoteMetadata("ws-1", "note.md")
+	if err != nil {
+		t.Fatalf("GetNoteMetadata() error = %v", err)
+	}
+	if got == nil || !got.CreatedAt.Equal(created) || !got.ModifiedAt.Equal(modified) {
+		t.Errorf("GetNoteMetadata() = %+v, want created %v modified %v", got, created, modified)
+	}
+
+	if other, _ := stores.Metadata.GetNoteMetadata("ws-2", "note.md"); other != nil {
+		t.Error("metadata leaked across workspaces")
+	}
+
+	if err := stores.Metadata.DeleteNoteMetadata("ws-1", "note.md"); err != nil {
+		t.Fatalf("DeleteNoteMetadata() error = %v", err)
+	}
+	if got, _ := stores.Metadata.GetNoteMetadata("ws-1", "note.md"); got != nil {
+		t.Error("metadata should be deleted")
+	}
+}
+
+func TestOpenWorkspaceStores_MigratesDefaultStores(t *testing.T) {
+	appName := filepath.Join(t.TempDir(), "testapp")
+	t.Cleanup(func() {
+		for _, name := range []string{DefaultWorkspaceName, "ws-a", "ws-b"} {
+			cleanupTestWorkspace(t, appName, name)
+		}
+	})
+
+	legacy, err := NewStores(appName, DefaultWorkspaceName, nil)
+	if err != nil {
+		t.Fatalf("NewStores() error = %v", err)
+	}
+	legacy.Metadata.SetAttachmentFolder("ws-a", "files")
+	legacy.Metadata.SetAttachmentFolder("ws-b", "media")
+	legacy.Task.SaveTask(&domain.Task{ID: "t1", NoteID: "a.md", NotePath: "a.md", Content: "Kept", CreatedAt: time.Now()})
+	legacy.Task.SaveTask(&domain.Task{ID: "t2", NoteID: "elsewhere.md", NotePath: "elsewhere.md", Content: "Left", CreatedAt: time.Now()})
+	if err := legacy.Snapshots.Save("ws-a", "a.md", []byte("old a")); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	snapshot := DefaultWorkspaceSnapshot()
+	snapshot.UI.ActivePage = "a.md"
+	snapshot.UI.PinnedPages = []string{"a.md", "elsewhere.md"}
+	legacy.Workspace.SaveSnapshot(snapshot)
+	legacy.Close(nil)
+
+	rootA := t.TempDir()
+	writeTestTree(t, rootA, map[string]string{"a.md": "# A\n"})
+	stores, err := OpenWorkspaceStores(appName, &domain.Workspace{ID: "ws-a", RootPath: rootA}, nil)
+	if err != nil {
+		t.Fatalf("OpenWorkspaceStores() error = %v", err)
+	}
+
+	if folder, _ := stores.Metadata.GetAttachmentFolder("ws-a"); folder != "files" {
+		t.Errorf("migrated attachment folder = %q, want %q", folder, "files")
+	}
+	if folder, _ := stores.Metadata.GetAttachmentFolder("ws-b"); folder != DefaultAttachmentFolder {
+		t.Errorf("other workspace's setting leaked: %q", folder)
+	}
+	// Legacy tasks cannot be told apart by workspace; the index build extracts them again.
+	for _, noteID := range []string{"a.md", "elsewhere.md"} {
+		if tasks, _ := stores.Task.GetTasksForNote(noteID); len(tasks) != 0 {
+			t.Errorf("migrated legacy tasks of %s: %+v", noteID, tasks)
+		}
+	}
+	versions, _ := stores.Snapshots.List("ws-a", "a.md")
+	if len(versions) != 1 {
+		t.Fatalf("migrated %d snapshots, want 1", len(versions))
+	}
+	if content, err := stores.Snapshots.Content("ws-a", "a.md", versions[0].Hash); err != nil || string(content) != "old a" {
+		t.Errorf("migrated snapshot content = %q, %v", content, err)
+	}
+	ui, _ := stores.Workspace.LoadSnapshot()
+	if ui.UI.ActivePage != "a.md" || len(ui.UI.PinnedPages) != 1 || ui.UI.PinnedPages[0] != "a.md" {
+		t.Errorf("migrated UI state = %+v, want only a.md", ui.UI)
+	}
+
+	// Reopening does not migrate again.
+	stores.Metadata.SetAttachmentFolder("ws-a", "changed")
+	stores.Close(nil)
+	stores, err = OpenWorkspaceStores(appName, &domain.Workspace{ID: "ws-a", RootPath: rootA}, nil)
+	if err != nil {
+		t.Fatalf("OpenWorkspaceStores() reopen error = %v", err)
+	}
+	if folder, _ := stores.Metadata.GetAttachmentFolder("ws-a"); folder != "changed" {
+		t.Errorf("attachment folder after reopen = %q, want %q", folder, "changed")
+	}
+	if versions, _ := stores.Snapshots.List("ws-a", "a.md"); len(versions) != 1 {
+		t.Errorf("snapshots after reopen = %d, want 1", len(versions))
+	}
+	stores.Close(nil)
+
+	// The UI state refers to notes another workspace does not have, so it is not copied there.
+	storesB, err := OpenWorkspaceStores(appName, &domain.Workspace{ID: "ws-b", RootPath: t.TempDir()}, nil)
+	if err != nil {
+		t.Fatalf("OpenWorkspaceStores(ws-b) error = %v", err)
+	}
+	defer storesB.Close(nil)
+	if ui, _ := storesB.Workspace.LoadSnapshot(); ui.UI.ActivePage != "" || len(ui.UI.PinnedPages) != 0 {
+		t.Errorf("UI state copied to an unrelated workspace: %+v", ui.UI)
+	}
+	if folder, _ := storesB.Metadata.GetAttachmentFolder("ws-b"); folder != "media" {
+		t.Errorf("ws-b attachment folder = %q, want %q", folder, "media")
+	}
+}
@@ -0,0 +1,525 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+	"gopkg.in/yaml.v3"
+)
+
+// tagNamePattern validates a complete tag name, using the same rules as inlineTagPattern.
+var tagNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*(?:/[a-zA-Z0-9_-]+)*$`)
+
+// tagRewriter maps an existing tag name to its replacement.
+// Returns the new name and true when the tag should change; an empty new name removes the tag.
+type tagRewriter func(tag string) (string, bool)
+
+// normalizeTagName trims whitespace, the leading # and any trailing slash from a tag name.
+func normalizeTagName(name string) string {
+	name = strings.TrimSpace(name)
+	name = strings.TrimPrefix(name, "#")
+	return strings.TrimSuffix(name, "/")
+}
+
+// validateTagName reports whether name can be written as an inline #tag.
+func validateTagName(name string) error {
+	if !tagNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid tag name %q", name)
+	}
+	return nil
+}
+
+// tagParent returns the parent of a nested tag ("a/b/c" -> "a/b"), or "" for a top-level tag.
+func tagParent(name string) string {
+	idx := strings.LastIndex(name, "/")
+	if idx == -1 {
+		return ""
+	}
+	return name[:idx]
+}
+
+// tagAncestors returns all ancestors of a nested tag from the root down ("a/b/c" -> ["a", "a/b"]).
+func tagAncestors(name string) []string {
+	parts := strings.Split(name, "/")
+	ancestors := make([]string, 0, len(parts)-1)
+	for i := 1; i < len(parts); i++ {
+		ancestors = append(ancestors, strings.Join(parts[:i], "/"))
+	}
+	return ancestors
+}
+
+// isTagOrDescendant reports whether tag equals parent or is nested beneath it.
+func isTagOrDescendant(tag, parent string) bool {
+	return tag == parent || strings.HasPrefix(tag, parent+"/")
+}
+
+// renameTagRewriter renames oldTag to newTag, moving nested descendants along with it.
+func renameTagRewriter(oldTag, newTag string) tagRewriter {
+	return func(tag string) (string, bool) {
+		if !isTagOrDescendant(tag, oldTag) {
+			return tag, false
+		}
+		return newTag + tag[len(oldTag):], true
+	}
+}
+
+// mergeTagsRewriter folds each source tag (and its descendants) into target.
+// When target is nested under a source, tags already at or under target are left alone.
+func mergeTagsRewriter(sources []string, target string) tagRewriter {
+	return func(tag string) (string, bool) {
+		for _, source := range sources {
+			if source == target || !isTagOrDescendant(tag, source) {
+				continue
+			}
+			if isTagOrDescendant(target, source) && isTagOrDescendant(tag, target) {
+				return tag, false
+			}
+			return target + tag[len(source):], true
+		}
+		return tag, false
+	}
+}
+
+// deleteTagRewriter removes a tag and all tags nested beneath it.
+func deleteTagRewriter(deleted string) tagRewriter {
+	return func(tag string) (string, bool) {
+		if isTagOrDescendant(tag, deleted) {
+			return "", true
+		}
+		return tag, false
+	}
+}
+
+// TagOperationResult reports the notes affected by a tag rename, merge, or delete.
+type TagOperationResult struct {
+	Changes []NoteChange `json:"changes"`
+	Applied bool         `json:"applied"` // False when the operation ran as a dry run
+}
+
+// NoteIDs returns the IDs of all notes affected by the operation.
+func (r *TagOperationResult) NoteIDs() []string {
+	ids := make([]string, 0, len(r.Changes))
+	for _, change := range r.Changes {
+		ids = append(ids, change.NoteID)
+	}
+	return ids
+}
+
+// RenameTag renames a tag across every note in the workspace, rewriting both frontmatter
+// tags and inline #tags. Nested tags move with their parent, so renaming "project" to "work"
+// also turns "project/alpha" into "work/alpha". Tags inside code are left untouched.
+// A tag cannot be renamed into its own subtree ("project" to "project/alpha").
+// With dryRun set, nothing is written and the result only previews the changes.
+func (s *NoteService) RenameTag(oldTag, newTag string, dryRun bool) (*TagOperationResult, error) {
+	oldTag = normalizeTagName(oldTag)
+	newTag = normalizeTagName(newTag)
+
+	if err := validateTagName(oldTag); err != nil {
+		return nil, err
+	}
+	if err := validateTagName(newTag); err != nil {
+		return nil, err
+	}
+	if oldTag == newTag {
+		return &TagOperationResult{Changes: []NoteChange{}, Applied: !dryRun}, nil
+	}
+	if isTagOrDescendant(newTag, oldTag) {
+		return nil, fmt.Errorf("cannot rename tag %q into its own subtree %q", oldTag, newTag)
+	}
+
+	return s.rewriteTagsInWorkspace(renameTagRewriter(oldTag, newTag), dryRun)
+}
+
+// MergeTags replaces every source tag with target across the workspace.
+// Descendants of a source tag are re-parented under target, and duplicate frontmatter
+// tags produced by the merge are collapsed. Merging "project" into "project/alpha" leaves
+// "project/alpha" and its descendants as they are.
+// With dryRun set, nothing is written and the result only previews the changes.
+func (s *NoteService) MergeTags(sources []string, target string, dryRun bool) (*TagOperationResult, error) {
+	target = normalizeTagName(target)
+	if err := validateTagName(target); err != nil {
+		return nil, err
+	}
+
+	normalized := make([]string, 0, len(sources))
+	for _, source := range sources {
+		source = normalizeTagName(source)
+		if err := validateTagName(source); err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, source)
+	}
+
+	// Longer (more specific) sources first so nested sources win over their parents.
+	sort.Slice(normalized, func(i, j int) bool {
+		return len(normalized[i]) > len(normalized[j])
+	})
+
+	return s.rewriteTagsInWorkspace(mergeTagsRewriter(normalized, target), dryRun)
+}
+
+// DeleteTag removes a tag and its nested descendants from every note in the workspace.
+// Inline occurrences are removed from the text and the tag is dropped from frontmatter.
+// With dryRun set, nothing is written and the result only previews the changes.
+func (s *NoteService) DeleteTag(tag string, dryRun bool) (*TagOperationResult, error) {
+	tag = normalizeTagName(tag)
+	if err := validateTagName(tag); err != nil {
+		return nil, err
+	}
+
+	return s.rewriteTagsInWorkspace(deleteTagRewriter(tag), dryRun)
+}
+
+// rewriteTagsInWorkspace applies rewrite to every note and, unless dryRun is set,
+// writes back the ones that changed. If a write fails, the result lists the notes written
+// before it, alongside the error.
+func (s *NoteService) rewriteTagsInWorkspace(rewrite tagRewriter, dryRun bool) (*TagOperationResult, error) {
+	files, err := s.fs.LoadMarkdownFiles()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	type pendingWrite struct {
+		path    string
+		content []byte
+	}
+
+	result := &TagOperationResult{Changes: []NoteChange{}}
+	writes := []pendingWrite{}
+
+	for _, relPath := range files {
+		content, err := s.fs.ReadFile(relPath)
+		if err != nil {
+			return nil, err
+		}
+
+		updated, ok, err := s.rewriteTags(content, rewrite)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewrite tags in %s: %w", relPath, err)
+		}
+		if !ok {
+			continue
+		}
+
+		result.Changes = append(result.Changes, NoteChange{
+			NoteID: relPath,
+			Diff:   ChangedLines(DiffLines(string(content), string(updated))),
+		})
+		writes = append(writes, pendingWrite{path: relPath, content: updated})
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	result.Applied = true
+	for i, w := range writes {
+		if err := s.fs.WriteFile(w.path, w.content); err != nil {
+			result.Changes = result.Changes[:i]
+			return result, fmt.Errorf("failed to write %s: %w", w.path, err)
+		}
+	}
+
+	return result, nil
+}
+
+// rewriteTags applies rewrite to the frontmatter tags and inline tags of a raw note.
+// Returns the updated content and whether anything changed.
+func (s *NoteService) rewriteTags(content []byte, rewrite tagRewriter) ([]byte, bool, error) {
+	header := []byte{}
+	bodyStart := 0
+	headerChanged := false
+
+	if yamlStart, yamlEnd, start, ok := splitFrontmatter(content); ok {
+		fm, changed, err := rewriteFrontmatterTags(content[yamlStart:yamlEnd], rewrite)
+		if err != nil {
+			return nil, false, err
+		}
+
+		bodyStart = start
+		header = content[:start]
+		if changed {
+			header = make([]byte, 0, start)
+			header = append(header, content[:yamlStart]...)
+			header = append(header, fm...)
+			header = append(header, content[yamlEnd:start]...)
+			headerChanged = true
+		}
+	}
+
+	body, bodyChanged := s.rewriteInlineTags(content[bodyStart:], rewrite)
+	if !headerChanged && !bodyChanged {
+		return content, false, nil
+	}
+
+	updated := make([]byte, 0, len(header)+len(body))
+	updated = append(updated, header...)
+	updated = append(updated, body...)
+	return updated, true, nil
+}
+
+// splitFrontmatter locates YAML frontmatter delimited by --- lines at the start of content.
+// Returns the byte range of the YAML between the delimiters and the offset where the body begins
+// (just after the closing delimiter line). ok is false when content has no frontmatter.
+func splitFrontmatter(content []byte) (yamlStart, yamlEnd, bodyStart int, ok bool) {
+	switch {
+	case bytes.HasPrefix(content, []byte("---\n")):
+		yamlStart = 4
+	case bytes.HasPrefix(content, []byte("---\r\n")):
+		yamlStart = 5
+	default:
+		return 0, 0, 0, false
+	}
+
+	pos := yamlStart
+	for pos <= len(content) {
+		lineEnd := bytes.IndexByte(content[pos:], '\n')
+		next := len(content)
+		line := content[pos:]
+		if lineEnd != -1 {
+			line = content[pos : pos+lineEnd]
+			next = pos + lineEnd + 1
+		}
+
+		if bytes.Equal(bytes.TrimSpace(line), []byte("---")) {
+			return yamlStart, pos, next, true
+		}
+
+		if lineEnd == -1 {
+			break
+		}
+		pos = next
+	}
+
+	return 0, 0, 0, false
+}
+
+// rewriteFrontmatterTags applies rewrite to the values of the `tags` key in raw YAML frontmatter.
+// Tags written with a leading # keep it. Duplicate tags produced by the rewrite are collapsed,
+// and a tags key left without values is removed.
+func rewriteFrontmatterTags(raw []byte, rewrite tagRewriter) ([]byte, bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, false, fmt.Errorf("failed to parse frontmatter: %w", err)
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return raw, false, nil
+	}
+
+	mapping := doc.Content[0]
+	changed := false
+	edits := []frontmatterEdit{}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != "tags" {
+			continue
+		}
+
+		value := mapping.Content[i+1]
+		switch value.Kind {
+		case yaml.ScalarNode:
+			if rewriteTagScalar(value, rewrite) {
+				changed = true
+				if value.Value == "" || value.Value == "#" {
+					edits = append(edits, frontmatterEdit{key: "tags", delete: true})
+				} else {
+					edits = append(edits, frontmatterEdit{key: "tags", value: value})
+				}
+			}
+		case yaml.SequenceNode:
+			seen := make(map[string]bool)
+			kept := value.Content[:0]
+			for _, item := range value.Content {
+				if item.Kind == yaml.ScalarNode && rewriteTagScalar(item, rewrite) {
+					changed = true
+				}
+				name := normalizeTagName(item.Value)
+				if item.Kind == yaml.ScalarNode && (name == "" || seen[name]) {
+					changed = true
+					continue
+				}
+				seen[name] = true
+				kept = append(kept, item)
+			}
+			value.Content = kept
+			if changed {
+				if len(kept) == 0 {
+					edits = append(edits, frontmatterEdit{key: "tags", delete: true})
+				} else {
+					edits = append(edits, frontmatterEdit{key: "tags", value: value})
+				}
+			}
+		}
+	}
+
+	if !changed {
+		return raw, false, nil
+	}
+
+	edited, err := editFrontmatter(raw, edits)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(bytes.TrimSpace(edited)) == 0 {
+		return []byte{}, true, nil
+	}
+
+	return edited, true, nil
+}
+
+// rewriteTagScalar applies rewrite to a single YAML tag scalar, preserving a leading #.
+func rewriteTagScalar(node *yaml.Node, rewrite tagRewriter) bool {
+	prefix := ""
+	if strings.HasPrefix(node.Value, "#") {
+		prefix = "#"
+	}
+
+	newName, ok := rewrite(normalizeTagName(node.Value))
+	if !ok {
+		return false
+	}
+
+	if newName == "" {
+		node.Value = ""
+	} else {
+		node.Value = prefix + newName
+	}
+	return true
+}
+
+// rewriteInlineTags applies rewrite to every #tag in Markdown body content.
+// Tags inside fenced code blocks, indented code blocks and inline code spans are not touched.
+// Removed tags take the # marker with them; surrounding text is otherwise preserved.
+func (s *NoteService) rewriteInlineTags(content []byte, rewrite tagRewriter) ([]byte, bool) {
+	skip := append(s.codeRanges(content), linkRanges(content)...)
+
+	var buf bytes.Buffer
+	last := 0
+	changed := false
+
+	for _, match := range inlineTagPattern.FindAllSubmatchIndex(content, -1) {
+		nameStart, nameEnd := match[2], match[3]
+		hashPos := nameStart - 1
+		if inByteRanges(skip, hashPos) {
+			continue
+		}
+
+		newName, ok := rewrite(string(content[nameStart:nameEnd]))
+		if !ok {
+			continue
+		}
+
+		changed = true
+		if newName == "" {
+			// Drop one adjoining space so removal doesn't leave double or trailing spaces.
+			cut := hashPos
+			spaceBefore := hashPos > last && content[hashPos-1] == ' '
+			atLineEnd := nameEnd == len(content) || content[nameEnd] == '\n' || content[nameEnd] == '\r'
+			switch {
+			case spaceBefore && nameEnd < len(content) && content[nameEnd] == ' ':
+				nameEnd++
+			case spaceBefore && atLineEnd:
+				cut--
+			}
+			buf.Write(content[last:cut])
+			last = nameEnd
+			continue
+		}
+
+		buf.Write(content[last:nameStart])
+		buf.WriteString(newName)
+		last = nameEnd
+	}
+
+	if !changed {
+		return content, false
+	}
+
+	buf.Write(content[last:])
+	return buf.Bytes(), true
+}
+
+// byteRange is a half-open [start, end) range of source bytes.
+type byteRange struct{ start, end int }
+
+// inByteRanges reports whether pos lies in one of ranges.
+func inByteRanges(ranges []byteRange, pos int) bool {
+	for _, r := range ranges {
+		if pos >= r.start && pos < r.end {
+			return true
+		}
+	}
+	return false
+}
+
+// wikilinkSpanPattern matches a whole wikilink, including [[#heading]] links to the same note.
+var wikilinkSpanPattern = regexp.MustCompile(`!?\[\[[^\[\]\n]*\]\]`)
+
+// urlPattern matches bare and angle-bracketed URLs such as https://example.com/page#section.
+var urlPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s<>()\[\]]+`)
+
+// linkRanges returns the byte ranges of wikilinks, Markdown link destinations and URLs in content,
+// whose "#" starts a heading or fragment rather than a tag.
+func linkRanges(content []byte) []byteRange {
+	var ranges []byteRange
+	for _, m := range wikilinkSpanPattern.FindAllIndex(content, -1) {
+		ranges = append(ranges, byteRange{m[0], m[1]})
+	}
+	for _, m := range attachmentMarkdownLinkPattern.FindAllSubmatchIndex(content, -1) {
+		ranges = append(ranges, byteRange{m[6], m[7]})
+	}
+	for _, m := range urlPattern.FindAllIndex(content, -1) {
+		ranges = append(ranges, byteRange{m[0], m[1]})
+	}
+	return ranges
+}
+
+// codeRanges returns the byte ranges of code blocks and inline code spans in Markdown content.
+func (s *NoteService) codeRanges(content []byte) []byteRange {
+	doc := s.parser.Parser().Parse(text.NewReader(content))
+
+	var ranges []byteRange
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n.Kind() {
+		case ast.KindFencedCodeBlock, ast.KindCodeBlock:
+			lines := n.Lines()
+			if lines.Len() > 0 {
+				ranges = append(ranges, byteRange{lines.At(0).Start, lines.At(lines.Len() - 1).Stop})
+			}
+			return ast.WalkSkipChildren, nil
+		case ast.KindCodeSpan:
+			start, end := -1, -1
+			for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+				if t, ok := child.(*ast.Text); ok {
+					if start == -1 {
+						start = t.Segment.Start
+					}
+					end = t.Segment.Stop
+				}
+			}
+			if start != -1 {
+				ranges = append(ranges, byteRange{start, end})
+			}
+			return ast.WalkSkipChildren, nil
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start < ranges[j].start
+	})
+
+	return ranges
+}
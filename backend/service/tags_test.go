@@ -0,0 +1,330 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTagAncestors(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want []string
+	}{
+		{"project", []string{}},
+		{"project/alpha", []string{"project"}},
+		{"a/b/c", []string{"a", "a/b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got := tagAncestors(tt.tag)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("tagAncestors(%q) = %v, want %v", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTagOrDescendant(t *testing.T) {
+	tests := []struct {
+		tag    string
+		parent string
+		want   bool
+	}{
+		{"project", "project", true},
+		{"project/alpha", "project", true},
+		{"project/alpha/x", "project", true},
+		{"projects", "project", false},
+		{"project", "project/alpha", false},
+	}
+
+	for _, tt := range tests {
+		if got := isTagOrDescendant(tt.tag, tt.parent); got != tt.want {
+			t.Errorf("isTagOrDescendant(%q, %q) = %v, want %v", tt.tag, tt.parent, got, tt.want)
+		}
+	}
+}
+
+func TestNoteService_RewriteInlineTags(t *testing.T) {
+	noteService := NewNoteService(nil)
+	rewrite := renameTagRewriter("project", "work")
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "simple tag",
+			content: "Working on #project today",
+			want:    "Working on #work today",
+		},
+		{
+			name:    "nested descendant",
+			content: "See #project/alpha and #project/alpha/beta",
+			want:    "See #work/alpha and #work/alpha/beta",
+		},
+		{
+			name:    "similar prefix untouched",
+			content: "#projects and #project",
+			want:    "#projects and #work",
+		},
+		{
+			name:    "inline code untouched",
+			content: "Use `#project` or #project",
+			want:    "Use `#project` or #work",
+		},
+		{
+			name:    "fenced code untouched",
+			content: "#project\n\n```\n#project\n```\n",
+			want:    "#work\n\n```\n#project\n```\n",
+		},
+		{
+			name:    "wikilink headings untouched",
+			content: "[[Plan #project]], [[#project]] and [[Plan|see #project]] #project",
+			want:    "[[Plan #project]], [[#project]] and [[Plan|see #project]] #work",
+		},
+		{
+			name:    "URL fragments untouched",
+			content: "https://example.com/page#project <https://x.org/#project> [doc](plan.md#project) #project",
+			want:    "https://example.com/page#project <https://x.org/#project> [doc](plan.md#project) #work",
+		},
+		{
+			name:    "tags in link text rewritten",
+			content: "[about #project](plan.md)",
+			want:    "[about #work](plan.md)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := noteService.rewriteInlineTags([]byte(tt.content), rewrite)
+			if string(got) != tt.want {
+				t.Errorf("rewriteInlineTags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteFrontmatterTags(t *testing.T) {
+	raw := "title: Test # keep me\ntags:\n  - project/alpha\n  - \"#project\"\n  - other\nstatus: draft\n"
+
+	got, changed, err := rewriteFrontmatterTags([]byte(raw), renameTagRewriter("project", "work"))
+	if err != nil {
+		t.Fatalf("rewriteFrontmatterTags() error = %v", err)
+	}
+	if !changed {
+		t.Fatal("rewriteFrontmatterTags() reported no change")
+	}
+
+	out := string(got)
+	for _, want := range []string{"work/alpha", "#work", "other", "# keep me"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("rewritten frontmatter missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "title") > strings.Index(out, "tags") || strings.Index(out, "tags") > strings.Index(out, "status") {
+		t.Errorf("rewritten frontmatter reordered keys:\n%s", out)
+	}
+
+	_, changed, err = rewriteFrontmatterTags([]byte("tags: [unrelated]\n"), renameTagRewriter("project", "work"))
+	if err != nil {
+		t.Fatalf("rewriteFrontmatterTags() error = %v", err)
+	}
+	if changed {
+		t.Error("rewriteFrontmatterTags() changed frontmatter without matching tags")
+	}
+}
+
+func TestNoteService_RenameTag(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	fs, err := NewFilesystemService()
+	if err != nil {
+		t.Fatalf("NewFilesystemService() error = %v", err)
+	}
+	defer fs.Close()
+
+	if _, err := fs.OpenWorkspace(tmpDir); err != nil {
+		t.Fatalf("OpenWorkspace() error = %v", err)
+	}
+
+	noteService := NewNoteService(fs)
+
+	files := map[string]string{
+		"a.md":        "---\ntags:\n  - project\n---\n\n# A\n\nBody with #project/alpha\n",
+		"b.md":        "# B\n\n`#project` stays in code\n",
+		"c.md":        "# C\n\nUnrelated #other\n",
+		"nested/d.md": "# D\n\n#project here\n",
+		"projects.md": "# Projects\n\n#projects is a different tag\n",
+	}
+	for path, content := range files {
+		if err := fs.WriteFile(path, []byte(content)); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", path, err)
+		}
+	}
+
+	preview, err := noteService.RenameTag("#project", "work", true)
+	if err != nil {
+		t.Fatalf("RenameTag(dryRun) error = %v", err)
+	}
+	if preview.Applied {
+		t.Error("RenameTag(dryRun) reported Applied = true")
+	}
+	if len(preview.Changes) != 2 || len(preview.Changes[0].Diff) == 0 {
+		t.Errorf("RenameTag(dryRun) changes = %+v, want 2 notes with diffs", preview.Changes)
+	}
+	untouched, _ := fs.ReadFile("a.md")
+	if string(untouched) != files["a.md"] {
+		t.Errorf("dry run modified a.md: %q", untouched)
+	}
+
+	result, err := noteService.RenameTag("#project", "work", false)
+	if err != nil {
+		t.Fatalf("RenameTag() error = %v", err)
+	}
+	if !result.Applied {
+		t.Error("RenameTag() reported Applied = false")
+	}
+
+	changed := result.NoteIDs()
+	if want := []string{"a.md", "nested/d.md"}; !slices.Equal(changed, want) {
+		t.Errorf("RenameTag() changed = %v, want %v", changed, want)
+	}
+
+	note, err := noteService.GetNote("a.md")
+	if err != nil {
+		t.Fatalf("GetNote() error = %v", err)
+	}
+	tagNames := []string{}
+	for _, tag := range note.Tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+	if want := []string{"work", "work/alpha"}; !slices.Equal(tagNames, want) {
+		t.Errorf("tags after rename = %v, want %v", tagNames, want)
+	}
+
+	unchanged, _ := fs.ReadFile("b.md")
+	if string(unchanged) != files["b.md"] {
+		t.Errorf("b.md was modified: %q", unchanged)
+	}
+
+	if _, err := noteService.RenameTag("project", "bad tag", false); err == nil {
+		t.Error("RenameTag() with invalid new name should fail")
+	}
+	if _, err := noteService.RenameTag("work", "work/archive", false); err == nil {
+		t.Error("RenameTag() into the tag's own subtree should fail")
+	}
+}
+
+func TestNoteService_MergeTags(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	fs, err := NewFilesystemService()
+	if err != nil {
+		t.Fatalf("NewFilesystemService() error = %v", err)
+	}
+	defer fs.Close()
+
+	if _, err := fs.OpenWorkspace(tmpDir); err != nil {
+		t.Fatalf("OpenWorkspace() error = %v", err)
+	}
+
+	noteService := NewNoteService(fs)
+
+	files := map[string]string{
+		"a.md": "---\ntags: [todo, task, other]\n---\n\n# A\n\nSee #todo/urgent\n",
+		"b.md": "# B\n\nA #task item\n",
+		"c.md": "# C\n\nNothing here\n",
+	}
+	for path, content := range files {
+		if err := fs.WriteFile(path, []byte(content)); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", path, err)
+		}
+	}
+
+	result, err := noteService.MergeTags([]string{"todo", "#task"}, "action", false)
+	if err != nil {
+		t.Fatalf("MergeTags() error = %v", err)
+	}
+
+	if want := []string{"a.md", "b.md"}; !slices.Equal(result.NoteIDs(), want) {
+		t.Errorf("MergeTags() changed = %v, want %v", result.NoteIDs(), want)
+	}
+
+	a, _ := fs.ReadFile("a.md")
+	if want := "---\ntags: [action, other]\n---\n\n# A\n\nSee #action/urgent\n"; string(a) != want {
+		t.Errorf("a.md = %q, want %q", a, want)
+	}
+
+	b, _ := fs.ReadFile("b.md")
+	if want := "# B\n\nA #action item\n"; string(b) != want {
+		t.Errorf("b.md = %q, want %q", b, want)
+	}
+
+	if _, err := noteService.MergeTags([]string{"bad tag"}, "action", false); err == nil {
+		t.Error("MergeTags() with invalid source should fail")
+	}
+
+	// A target nested under a source keeps its own name and descendants.
+	if err := fs.WriteFile("d.md", []byte("# D\n\n#project #project/alpha #project/alpha/x #project/beta\n")); err != nil {
+		t.Fatalf("WriteFile(d.md) error = %v", err)
+	}
+	if _, err := noteService.MergeTags([]string{"project"}, "project/alpha", false); err != nil {
+		t.Fatalf("MergeTags() into a nested target error = %v", err)
+	}
+	d, _ := fs.ReadFile("d.md")
+	if want := "# D\n\n#project/alpha #project/alpha #project/alpha/x #project/alpha/beta\n"; string(d) != want {
+		t.Errorf("d.md = %q, want %q", d, want)
+	}
+}
+
+func TestNoteService_DeleteTag(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	fs, err := NewFilesystemService()
+	if err != nil {
+		t.Fatalf("NewFilesystemService() error = %v", err)
+	}
+	defer fs.Close()
+
+	if _, err := fs.OpenWorkspace(tmpDir); err != nil {
+		t.Fatalf("OpenWorkspace() error = %v", err)
+	}
+
+	noteService := NewNoteService(fs)
+
+	files := map[string]string{
+		"a.md": "---\ntitle: A\ntags:\n  - draft\n---\n\nStill a #draft/wip note #draft\n",
+		"b.md": "# B\n\n```\n#draft in code\n```\n",
+	}
+	for path, content := range files {
+		if err := fs.WriteFile(path, []byte(content)); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", path, err)
+		}
+	}
+
+	preview, err := noteService.DeleteTag("draft", true)
+	if err != nil {
+		t.Fatalf("DeleteTag(dryRun) error = %v", err)
+	}
+
+	if want := []string{"a.md"}; !slices.Equal(preview.NoteIDs(), want) {
+		t.Errorf("DeleteTag(dryRun) changed = %v, want %v", preview.NoteIDs(), want)
+	}
+
+	if _, err := noteService.DeleteTag("draft", false); err != nil {
+		t.Fatalf("DeleteTag() error = %v", err)
+	}
+
+	a, _ := fs.ReadFile("a.md")
+	if want := "---\ntitle: A\n---\n\nStill a note\n"; string(a) != want {
+		t.Errorf("a.md = %q, want %q", a, want)
+	}
+
+	b, _ := fs.ReadFile("b.md")
+	if string(b) != files["b.md"] {
+		t.Errorf("b.md was modified: %q", b)
+	}
+}
@@ -20,6 +20,8 @@ type TaskService struct {
 	byStatus map[bool][]string
 	// noteModified tracks note modification times for filtering
 	noteModified map[string]time.Time
+	// cleared holds the tasks removed by Clear, so tasks indexed again keep their timestamps
+	cleared map[string]domain.Task
 	// store handles SQLite persistence
 	store *TaskStore
 	// logger records runtime diagnostics
@@ -42,8 +44,61 @@ func (s *TaskService) SetLogger(ctx context.Context) {
 	s.logger.attach(ctx)
 }
 
+// SetStore switches the database tasks are persisted to and clears the in-memory indexes,
+// e.g. when another workspace is opened.
+func (s *TaskService) SetStore(store *TaskStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = store
+	s.tasks = make(map[string]*domain.Task)
+	s.byNoteID = make(map[string][]string)
+	s.byStatus = make(map[bool][]string)
+	s.noteModified = make(map[string]time.Time)
+	s.cleared = nil
+}
+
+// Clear removes every task from the indexes and from the database, in one transaction.
+// Tasks indexed again afterwards keep the creation and completion times they had.
+func (s *TaskService) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var cleared []domain.Task
+	err := s.store.Batch(func(store *TaskStore) error {
+		var err error
+		if cleared, err = store.GetAllTasks(); err != nil {
+			return err
+		}
+		return store.DeleteAllTasks()
+	})
+	if err != nil {
+		return err
+	}
+
+	s.tasks = make(map[string]*domain.Task)
+	s.byNoteID = make(map[string][]string)
+	s.byStatus = make(map[bool][]string)
+	s.noteModified = make(map[string]time.Time)
+	s.cleared = make(map[string]domain.Task, len(cleared))
+	for _, task := range cleared {
+		s.cleared[task.ID] = task
+	}
+
+	s.logger.Debugf("cleared %d tasks", len(cleared))
+	return nil
+}
+
+// NoteTasks are the tasks found in one note, for indexing several notes at once with IndexNotes.
+type NoteTasks struct {
+	NoteID     string
+	NotePath   string
+	Tasks      []domain.Task
+	ModifiedAt time.Time
+}
+
 // IndexNote parses tasks from a note and updates the index.
-// Removes old tasks for the note and indexes new ones. Persists to SQLite and loads existing metadata.
+// Removes old tasks for the note and indexes new ones. Persists to SQLite, in one transaction, and loads existing metadata.
 func (s *TaskService) IndexNote(noteID string, notePath string, tasks []domain.Task, modifiedAt time.Time) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -51,11 +106,48 @@ func (s *TaskService) IndexNote(noteID string, notePath string, tasks []domain.T
 	start := time.Now()
 	s.logger.Debugf("indexing %d tasks from note %s (%s)", len(tasks), noteID, notePath)
 
+	err := s.store.Batch(func(store *TaskStore) error {
+		return s.indexNote(store, noteID, tasks, modifiedAt)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Infof("indexed %d tasks for note %s in %s", len(tasks), noteID, time.Since(start))
+	return nil
+}
+
+// IndexNotes indexes the tasks of several notes like IndexNote, persisting them all in one transaction.
+func (s *TaskService) IndexNotes(notes []NoteTasks) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	count := 0
+	err := s.store.Batch(func(store *TaskStore) error {
+		for _, note := range notes {
+			if err := s.indexNote(store, note.NoteID, note.Tasks, note.ModifiedAt); err != nil {
+				return err
+			}
+			count += len(note.Tasks)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Debugf("indexed %d tasks from %d notes in %s", count, len(notes), time.Since(start))
+	return nil
+}
+
+// indexNote replaces a note's tasks in the indexes and in store. Caller must hold the lock.
+func (s *TaskService) indexNote(store *TaskStore, noteID string, tasks []domain.Task, modifiedAt time.Time) error {
 	s.removeNoteFromIndexes(noteID)
 
 	s.noteModified[noteID] = modifiedAt
 
-	existingTasks, err := s.store.GetTasksForNote(noteID)
+	existingTasks, err := store.GetTasksForNote(noteID)
 	if err != nil {
 		existingTasks = []domain.Task{}
 	}
@@ -64,6 +156,19 @@ func (s *TaskService) IndexNote(noteID string, notePath string, tasks []domain.T
 	for i := range existingTasks {
 		existingByID[existingTasks[i].ID] = &existingTasks[i]
 	}
+	// Tasks without a block ID get a new ID each time the note is parsed, so the stored rows are
+	// replaced rather than updated.
+	if len(existingTasks) > 0 {
+		if err := store.DeleteTasksForNote(noteID); err != nil {
+			return err
+		}
+	}
+	for _, task := range tasks {
+		if previous, ok := s.cleared[task.ID]; ok && existingByID[task.ID] == nil {
+			existingByID[task.ID] = &previous
+		}
+		delete(s.cleared, task.ID)
+	}
 
 	for i := range tasks {
 		task := &tasks[i]
@@ -95,13 +200,12 @@ func (s *TaskService) IndexNote(noteID string, notePath string, tasks []domain.T
 		s.byNoteID[noteID] = append(s.byNoteID[noteID], task.ID)
 		s.byStatus[task.IsCompleted] = append(s.byStatus[task.IsCompleted], task.ID)
 
-		if err := s.store.SaveTask(task); err != nil {
+		if err := store.SaveTask(task); err != nil {
 			s.logger.Errorf("failed to persist task %s (%s): %v", task.ID, noteID, err)
 			return err
 		}
 	}
 
-	s.logger.Infof("indexed %d tasks for note %s in %s", len(tasks), noteID, time.Since(start))
 	return nil
 }
 
@@ -9,7 +9,7 @@ import (
 	"notes/backend/domain"
 )
 
-func cleanupTestWorkspace(t *testing.T, appName, workspaceName string) {
+func cleanupTestWorkspace(t testing.TB, appName, workspaceName string) {
 	t.Helper()
 
 	dirs, err := NewAppDirs(appName, workspaceName, nil)
@@ -148,6 +148,32 @@ tags: [work, important]
 				{content: "Another with spaces", isCompleted: true},
 			},
 		},
+		{
+			name:          "task-like lines in code blocks ignored",
+			content:       "- [ ] Real task\n\n```\n- [ ] Example in code\n```\n\n    - [ ] Indented code",
+			expectedCount: 1,
+			expectedTasks: []struct {
+				content     string
+				isCompleted bool
+			}{
+				{content: "Real task", isCompleted: false},
+			},
+		},
+		{
+			name: "nested tasks",
+			content: `- [ ] Parent
+  - [x] Child
+    - [ ] Grandchild`,
+			expectedCount: 3,
+			expectedTasks: []struct {
+				content     string
+				isCompleted bool
+			}{
+				{content: "Parent", isCompleted: false},
+				{content: "Child", isCompleted: true},
+				{content: "Grandchild", isCompleted: false},
+			},
+		},
 		{
 			name:          "empty content",
 			content:       ``,
@@ -312,6 +338,118 @@ func TestTaskService_IndexNote(t *testing.T) {
 	}
 }
 
+func TestTaskService_IndexNotes(t *testing.T) {
+	cleanupTestWorkspace(t, "test-app", "test-workspace-batch")
+
+	stores, err := NewStores("test-app", "test-workspace-batch", nil)
+	if err != nil {
+		t.Fatalf("failed to create stores: %v", err)
+	}
+	defer stores.Close(nil)
+	defer cleanupTestWorkspace(t, "test-app", "test-workspace-batch")
+
+	taskService := NewTaskService(stores.Task)
+	now := time.Now()
+
+	batch := []NoteTasks{}
+	for _, noteID := range []string{"a.md", "b.md", "c.md"} {
+		batch = append(batch, NoteTasks{
+			NoteID:   noteID,
+			NotePath: noteID,
+			Tasks: []domain.Task{
+				{ID: noteID + "-open", NoteID: noteID, NotePath: noteID, Content: "Open", LineNumber: 1},
+				{ID: noteID + "-done", NoteID: noteID, NotePath: noteID, Content: "Done", IsCompleted: true, LineNumber: 2},
+			},
+			ModifiedAt: now,
+		})
+	}
+
+	if err := taskService.IndexNotes(batch); err != nil {
+		t.Fatalf("IndexNotes() error = %v", err)
+	}
+
+	info, err := taskService.GetAllTasks(domain.TaskFilter{})
+	if err != nil {
+		t.Fatalf("GetAllTasks() error = %v", err)
+	}
+	if info.TotalCount != 6 {
+		t.Errorf("GetAllTasks() total = %d, want 6", info.TotalCount)
+	}
+
+	stored, err := stores.Task.GetTasksForNote("b.md")
+	if err != nil || len(stored) != 2 {
+		t.Fatalf("stored tasks for b.md = %+v, %v, want 2", stored, err)
+	}
+	if stored[1].CompletedAt == nil {
+		t.Error("completed task was persisted without a completion time")
+	}
+
+	// A new batch replaces a note's tasks rather than adding to them.
+	batch[1].Tasks = batch[1].Tasks[:1]
+	if err := taskService.IndexNotes(batch[1:2]); err != nil {
+		t.Fatalf("IndexNotes() again error = %v", err)
+	}
+	if tasks, _ := taskService.GetTasksForNote("b.md"); len(tasks) != 1 {
+		t.Errorf("GetTasksForNote(b.md) = %+v, want 1 task", tasks)
+	}
+	if stored, _ := stores.Task.GetTasksForNote("b.md"); len(stored) != 1 {
+		t.Errorf("stored tasks for b.md = %+v, want the removed task deleted", stored)
+	}
+}
+
+func TestTaskService_Clear(t *testing.T) {
+	cleanupTestWorkspace(t, "test-app", "test-workspace-clear")
+
+	stores, err := NewStores("test-app", "test-workspace-clear", nil)
+	if err != nil {
+		t.Fatalf("failed to create stores: %v", err)
+	}
+	defer stores.Close(nil)
+	defer cleanupTestWorkspace(t, "test-app", "test-workspace-clear")
+
+	taskService := NewTaskService(stores.Task)
+	created := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+
+	done := domain.Task{ID: "a-done", NoteID: "a.md", NotePath: "a.md", Content: "Done", IsCompleted: true, CreatedAt: created, LineNumber: 1}
+	other := domain.Task{ID: "b-open", NoteID: "b.md", NotePath: "b.md", Content: "Open", CreatedAt: created, LineNumber: 1}
+	if err := taskService.IndexNote("a.md", "a.md", []domain.Task{done}, created); err != nil {
+		t.Fatalf("IndexNote(a.md) error = %v", err)
+	}
+	if err := taskService.IndexNote("b.md", "b.md", []domain.Task{other}, created); err != nil {
+		t.Fatalf("IndexNote(b.md) error = %v", err)
+	}
+	stored, _ := stores.Task.GetTaskByID("a-done")
+	completedAt := *stored.CompletedAt
+
+	if err := taskService.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if info, _ := taskService.GetAllTasks(domain.TaskFilter{}); info.TotalCount != 0 {
+		t.Errorf("GetAllTasks() after Clear() total = %d, want 0", info.TotalCount)
+	}
+	if all, _ := stores.Task.GetAllTasks(); len(all) != 0 {
+		t.Errorf("stored tasks after Clear() = %+v, want none", all)
+	}
+
+	// A task indexed again keeps its timestamps; the note not indexed again stays gone.
+	done.CreatedAt = time.Time{}
+	done.CompletedAt = nil
+	if err := taskService.IndexNote("a.md", "a.md", []domain.Task{done}, time.Now()); err != nil {
+		t.Fatalf("IndexNote(a.md) again error = %v", err)
+	}
+	info, err := taskService.GetAllTasks(domain.TaskFilter{})
+	if err != nil {
+		t.Fatalf("GetAllTasks() error = %v", err)
+	}
+	if info.TotalCount != 1 || info.Tasks[0].ID != "a-done" {
+		t.Fatalf("GetAllTasks() = %+v, want only a-done", info.Tasks)
+	}
+	task := info.Tasks[0]
+	if !task.CreatedAt.Equal(created) || task.CompletedAt == nil || !task.CompletedAt.Equal(completedAt) {
+		t.Errorf("reindexed task times = %v, %v, want %v, %v", task.CreatedAt, task.CompletedAt, created, completedAt)
+	}
+}
+
 func TestTaskService_GetAllTasks(t *testing.T) {
 	tmpDir := filepath.Join(os.TempDir(), "test-task-getall")
 	defer os.RemoveAll(tmpDir)
@@ -755,3 +893,39 @@ func TestTaskService_AdvancedFiltering(t *testing.T) {
 		})
 	}
 }
+
+func TestTaskService_SetStore(t *testing.T) {
+	cleanupTestWorkspace(t, "test-app", "test-workspace-store-a")
+	cleanupTestWorkspace(t, "test-app", "test-workspace-store-b")
+
+	first, err := NewStores("test-app", "test-workspace-store-a", nil)
+	if err != nil {
+		t.Fatalf("failed to create stores: %v", err)
+	}
+	defer first.Close(nil)
+	second, err := NewStores("test-app", "test-workspace-store-b", nil)
+	if err != nil {
+		t.Fatalf("failed to create stores: %v", err)
+	}
+	defer second.Close(nil)
+
+	taskService := NewTaskService(first.Task)
+	now := time.Now()
+	tasks := []domain.Task{{ID: "task-1", NoteID: "note.md", NotePath: "note.md", Content: "First", CreatedAt: now, LineNumber: 1}}
+	if err := taskService.IndexNote("note.md", "note.md", tasks, now); err != nil {
+		t.Fatalf("failed to index note: %v", err)
+	}
+
+	taskService.SetStore(second.Task)
+
+	info, err := taskService.GetAllTasks(domain.TaskFilter{})
+	if err != nil {
+		t.Fatalf("failed to get tasks: %v", err)
+	}
+	if info.TotalCount != 0 {
+		t.Errorf("tasks after SetStore() = %d, want 0", info.TotalCount)
+	}
+	if stored, _ := second.Task.GetTasksForNote("note.md"); len(stored) != 0 {
+		t.Errorf("tasks leaked into the new store: %+v", stored)
+	}
+}
@@ -0,0 +1,471 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"notes/backend/domain"
+)
+
+// TrashDirName is the workspace folder deleted notes are moved into. It is never indexed or watched.
+const TrashDirName = ".trash"
+
+// DefaultTrashRetention is how many days deleted notes are kept in the trash by default.
+const DefaultTrashRetention = 30
+
+// trashEntryFileName is the file holding an entry's TrashEntry, beside the deleted note.
+const trashEntryFileName = "entry.json"
+
+// SetTrashRetention sets how many days deleted notes are kept before PurgeTrash removes them.
+// 0 keeps them until the trash is emptied.
+func (s *NoteService) SetTrashRetention(days int) {
+	s.trashRetention = days
+}
+
+// TrashNote moves a note into the workspace trash, as .trash/<entry>/<file name> beside an entry.json
+// recording where it came from, when it was deleted and the notes linking to it (backlinks),
+// so RestoreNote can put it back and reconnect them. A note that does not parse is still deleted,
+// titled after its file name.
+func (s *NoteService) TrashNote(id string, backlinks []string) (*domain.TrashEntry, error) {
+	info, err := s.fs.StatFile(id)
+	if err != nil {
+		return nil, err
+	}
+	title, createdAt := s.trashedNoteDetails(id, info)
+
+	if backlinks == nil {
+		backlinks = []string{}
+	}
+	entry := domain.TrashEntry{
+		OriginalPath: filepath.ToSlash(id),
+		Notes:        []string{filepath.ToSlash(id)},
+		Title:        title,
+		DeletedAt:    time.Now().UTC(),
+		CreatedAt:    createdAt,
+		Backlinks:    backlinks,
+		Size:         info.Size(),
+	}
+	entry.ID, err = s.newTrashEntryID(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.moveToTrash(entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// trashedNoteDetails returns the title and creation time recorded for a deleted note. They are read
+// from the note when it parses, or else taken from its file name and its stored or file times.
+func (s *NoteService) trashedNoteDetails(id string, info os.FileInfo) (string, time.Time) {
+	if note, err := s.GetNote(id); err == nil {
+		return note.Title, note.CreatedAt
+	}
+
+	title := strings.TrimSuffix(info.Name(), filepath.Ext(info.Name()))
+	if meta := s.storedMetadata(id); meta != nil && !meta.CreatedAt.IsZero() {
+		return title, meta.CreatedAt
+	}
+	return title, info.ModTime()
+}
+
+// TrashFolder moves a folder with everything in it into the workspace trash as a single entry,
+// recording the notes inside it and the notes outside it that linked to them (backlinks).
+func (s *NoteService) TrashFolder(folder string, backlinks []string) (*domain.TrashEntry, error) {
+	folder, err := s.checkFolderPath(folder)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.fs.StatFile(filepath.FromSlash(folder))
+	if err != nil {
+		return nil, &domain.ErrNotFound{Resource: "folder", ID: folder}
+	}
+	if !info.IsDir() {
+		return nil, &domain.ErrInvalidPath{Path: folder, Reason: "not a folder"}
+	}
+
+	notes, err := s.notesInFolder(folder)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := s.fs.workspacePath(filepath.FromSlash(folder))
+	if err != nil {
+		return nil, err
+	}
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+
+	if backlinks == nil {
+		backlinks = []string{}
+	}
+	entry := domain.TrashEntry{
+		OriginalPath: folder,
+		Folder:       true,
+		Notes:        notes,
+		Title:        path.Base(folder),
+		DeletedAt:    time.Now().UTC(),
+		Backlinks:    backlinks,
+		Size:         size,
+	}
+	entry.ID, err = s.newTrashEntryID(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.moveToTrash(entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// moveToTrash writes a new trash entry and moves its note or folder into it, forgetting the
+// stored metadata of the notes.
+func (s *NoteService) moveToTrash(entry domain.TrashEntry) error {
+	if err := s.writeTrashEntry(entry); err != nil {
+		return err
+	}
+	trashed := path.Join(TrashDirName, entry.ID, path.Base(entry.OriginalPath))
+	if err := s.fs.MoveFile(filepath.FromSlash(entry.OriginalPath), filepath.FromSlash(trashed)); err != nil {
+		s.removeTrashEntry(entry.ID)
+		return err
+	}
+	for _, id := range entry.Notes {
+		if err := s.forgetMetadata(filepath.FromSlash(id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListTrash returns the notes in the trash, most recently deleted first.
+// Entries whose entry.json is missing or unreadable are skipped.
+func (s *NoteService) ListTrash() ([]domain.TrashEntry, error) {
+	dir, err := s.fs.workspacePath(TrashDirName)
+	if err != nil {
+		return nil, err
+	}
+
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read trash: %w", err)
+	}
+
+	entries := []domain.TrashEntry{}
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		entry, err := s.readTrashEntry(dirEntry.Name())
+		if err != nil {
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.After(entries[j].DeletedAt)
+	})
+	return entries, nil
+}
+
+// RestoreNote moves a note or folder out of the trash back to its original path, or, if another file
+// took that path meanwhile, to "name (restored).md" or "folder (restored)" beside it. A note's creation
+// time is restored with it, and when it lands beside its original path the links of the notes that linked
+// to it are rewritten to follow. Re-indexing the notes and the notes that linked to them is left to the caller.
+func (s *NoteService) RestoreNote(entryID string) (*domain.RestoredNote, error) {
+	entry, err := s.readTrashEntry(entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	trashed := path.Join(TrashDirName, entry.ID, path.Base(entry.OriginalPath))
+	restored := entry.OriginalPath
+	for n := 1; ; n++ {
+		if _, err := s.fs.StatFile(restored); err != nil {
+			break
+		}
+		ext := path.Ext(entry.OriginalPath)
+		if entry.Folder {
+			ext = ""
+		}
+		suffix := " (restored)"
+		if n > 1 {
+			suffix = fmt.Sprintf(" (restored %d)", n)
+		}
+		restored = strings.TrimSuffix(entry.OriginalPath, ext) + suffix + ext
+	}
+
+	if err := s.fs.MoveFile(filepath.FromSlash(trashed), filepath.FromSlash(restored)); err != nil {
+		return nil, err
+	}
+	if err := s.removeTrashEntry(entry.ID); err != nil {
+		return nil, fmt.Errorf("restored %s but kept its trash entry: %w", restored, err)
+	}
+
+	noteIDs := []string{}
+	for _, id := range entry.Notes {
+		noteIDs = append(noteIDs, restored+strings.TrimPrefix(id, entry.OriginalPath))
+	}
+
+	if !entry.Folder && !entry.CreatedAt.IsZero() {
+		if note, err := s.GetNote(restored); err == nil {
+			note.CreatedAt = entry.CreatedAt
+			if err := s.recordMetadata(note); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	relinked := []string{}
+	if restored != entry.OriginalPath {
+		relinked, err = s.relinkRestored(entry, restored, noteIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &domain.RestoredNote{Entry: *entry, Path: restored, NoteIDs: noteIDs, Relinked: relinked}, nil
+}
+
+// relinkRestored points the links to a note or folder restored beside its original path at where it
+// was restored, and returns the IDs of the notes it rewrote. For a folder these are path links from
+// its backlinks and from the notes inside it; for a note, also wikilinks by its bare name.
+func (s *NoteService) relinkRestored(entry *domain.TrashEntry, restored string, noteIDs []string) ([]string, error) {
+	original := entry.OriginalPath
+	moved := func(p string) string {
+		if p == original || (entry.Folder && strings.HasPrefix(p, original+"/")) {
+			return restored + strings.TrimPrefix(p, original)
+		}
+		return p
+	}
+
+	// Notes to rewrite, by the ID they have now and the one they had at deletion.
+	type linkingNote struct{ oldID, newID string }
+	linking := []linkingNote{}
+	for _, id := range entry.Backlinks {
+		linking = append(linking, linkingNote{id, id})
+	}
+	if entry.Folder {
+		for i, id := range entry.Notes {
+			linking = append(linking, linkingNote{id, noteIDs[i]})
+		}
+	}
+
+	relinked := []string{}
+	seen := map[string]bool{}
+	for _, note := range linking {
+		if seen[note.newID] {
+			continue
+		}
+		seen[note.newID] = true
+
+		content, err := s.fs.ReadFile(filepath.FromSlash(note.newID))
+		if err != nil {
+			// The linking note was deleted or moved since; there is nothing to rewrite.
+			continue
+		}
+		updated, changed := s.rewriteMovedLinks(content, note.oldID, note.newID, moved)
+		if !entry.Folder {
+			var renamed bool
+			updated, renamed = s.rewriteRestoredWikilinks(updated, original, restored)
+			changed = changed || renamed
+		}
+		if !changed {
+			continue
+		}
+		if err := s.fs.WriteFile(filepath.FromSlash(note.newID), updated); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", note.newID, err)
+		}
+		relinked = append(relinked, note.newID)
+	}
+	return relinked, nil
+}
+
+// rewriteRestoredWikilinks points the wikilinks to a note's original path, by path or by bare name and
+// with or without the extension, at where it was restored, keeping the written form. Links inside code
+// blocks and code spans are left alone.
+func (s *NoteService) rewriteRestoredWikilinks(content []byte, original, restored string) ([]byte, bool) {
+	bodyStart := 0
+	if _, _, start, ok := splitFrontmatter(content); ok {
+		bodyStart = start
+	}
+	codeRanges := s.codeRanges(content[bodyStart:])
+	inCode := func(pos int) bool {
+		pos -= bodyStart
+		for _, r := range codeRanges {
+			if pos >= r.start && pos < r.end {
+				return true
+			}
+		}
+		return false
+	}
+
+	var buf bytes.Buffer
+	last := 0
+	for _, m := range attachmentWikilinkPattern.FindAllSubmatchIndex(content, -1) {
+		if inCode(m[0]) {
+			continue
+		}
+		target := string(content[m[4]:m[5]])
+		byPath := strings.Contains(target, "/")
+		want := path.Base(original)
+		if byPath {
+			want = original
+		}
+		if normalizeLinkTarget(target) != normalizeLinkTarget(want) {
+			continue
+		}
+
+		updated := path.Base(restored)
+		if byPath {
+			updated = restored
+		}
+		if !isMarkdownFile(target) {
+			updated = strings.TrimSuffix(updated, path.Ext(updated))
+		}
+		buf.Write(content[last:m[4]])
+		buf.WriteString(updated)
+		last = m[5]
+	}
+	if last == 0 {
+		return content, false
+	}
+	buf.Write(content[last:])
+	return buf.Bytes(), true
+}
+
+// EmptyTrash permanently deletes every note in the trash and returns how many were deleted.
+func (s *NoteService) EmptyTrash() (int, error) {
+	return s.purgeTrash(func(domain.TrashEntry) bool { return true })
+}
+
+// PurgeTrash permanently deletes the notes deleted longer ago than the trash retention and
+// returns how many were deleted. With a retention of 0 nothing is purged.
+func (s *NoteService) PurgeTrash() (int, error) {
+	if s.trashRetention <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().Add(-time.Duration(s.trashRetention) * 24 * time.Hour)
+	return s.purgeTrash(func(entry domain.TrashEntry) bool { return entry.DeletedAt.Before(cutoff) })
+}
+
+// purgeTrash permanently deletes the trash entries selected by purge.
+func (s *NoteService) purgeTrash(purge func(domain.TrashEntry) bool) (int, error) {
+	entries, err := s.ListTrash()
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, entry := range entries {
+		if !purge(entry) {
+			continue
+		}
+		if err := s.removeTrashEntry(entry.ID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// newTrashEntryID names a trash entry after its deletion time and the note's file name or the
+// folder's name, numbering it if one of the same name was deleted in the same second.
+func (s *NoteService) newTrashEntryID(entry domain.TrashEntry) (string, error) {
+	stem := path.Base(entry.OriginalPath)
+	if !entry.Folder {
+		stem = strings.TrimSuffix(stem, path.Ext(stem))
+	}
+	base := entry.DeletedAt.Format("20060102T150405Z") + " " + stem
+
+	id := base
+	for n := 2; ; n++ {
+		if _, err := s.fs.StatFile(path.Join(TrashDirName, id)); err != nil {
+			return id, nil
+		}
+		id = fmt.Sprintf("%s %d", base, n)
+	}
+}
+
+// trashEntryPath returns the workspace-relative path of a file in a trash entry,
+// rejecting entry IDs that are not a single folder name.
+func trashEntryPath(entryID, name string) (string, error) {
+	if entryID == "" || entryID != filepath.Base(entryID) || strings.HasPrefix(entryID, ".") {
+		return "", &domain.ErrInvalidPath{Path: entryID, Reason: "not a trash entry"}
+	}
+	return path.Join(TrashDirName, entryID, name), nil
+}
+
+// readTrashEntry reads the entry.json of a trash entry.
+func (s *NoteService) readTrashEntry(entryID string) (*domain.TrashEntry, error) {
+	entryPath, err := trashEntryPath(entryID, trashEntryFileName)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.fs.ReadFile(entryPath)
+	if err != nil {
+		var notFound *domain.ErrNotFound
+		if errors.As(err, &notFound) {
+			return nil, &domain.ErrNotFound{Resource: "trash entry", ID: entryID}
+		}
+		return nil, err
+	}
+
+	var entry domain.TrashEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to read trash entry: %w", err)
+	}
+	entry.ID = entryID
+	if entry.Backlinks == nil {
+		entry.Backlinks = []string{}
+	}
+	if entry.Notes == nil {
+		entry.Notes = []string{}
+	}
+	return &entry, nil
+}
+
+// writeTrashEntry writes the entry.json of a trash entry.
+func (s *NoteService) writeTrashEntry(entry domain.TrashEntry) error {
+	entryPath, err := trashEntryPath(entry.ID, trashEntryFileName)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode trash entry: %w", err)
+	}
+	return s.fs.WriteFile(entryPath, data)
+}
+
+// removeTrashEntry deletes a trash entry's folder with everything in it.
+func (s *NoteService) removeTrashEntry(entryID string) error {
+	entryPath, err := trashEntryPath(entryID, "")
+	if err != nil {
+		return err
+	}
+	dir, err := s.fs.workspacePath(entryPath)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to delete trash entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"errors"
+	"path"
+	"slices"
+	"testing"
+	"time"
+
+	"notes/backend/domain"
+)
+
+func TestNoteService_TrashAndRestore(t *testing.T) {
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{
+		"projects/plan.md": "# Plan\n\nSteps\n",
+		"index.md":         "# Index\n\nSee [[plan]]\n",
+	})
+
+	entry, err := notes.TrashNote("projects/plan.md", []string{"index.md"})
+	if err != nil {
+		t.Fatalf("TrashNote() error = %v", err)
+	}
+	if entry.OriginalPath != "projects/plan.md" || entry.Title != "Plan" || !slices.Equal(entry.Backlinks, []string{"index.md"}) {
+		t.Errorf("TrashNote() = %+v", entry)
+	}
+	if _, err := notes.fs.StatFile("projects/plan.md"); err == nil {
+		t.Error("trashed note is still at its original path")
+	}
+	if content, err := notes.fs.ReadFile(path.Join(TrashDirName, entry.ID, "plan.md")); err != nil || string(content) != "# Plan\n\nSteps\n" {
+		t.Errorf("trashed file = %q, %v", content, err)
+	}
+
+	files, err := notes.fs.LoadMarkdownFiles()
+	if err != nil {
+		t.Fatalf("LoadMarkdownFiles() error = %v", err)
+	}
+	if !slices.Equal(files, []string{"index.md"}) {
+		t.Errorf("LoadMarkdownFiles() = %v, want the trash skipped", files)
+	}
+
+	trash, err := notes.ListTrash()
+	if err != nil {
+		t.Fatalf("ListTrash() error = %v", err)
+	}
+	if len(trash) != 1 || trash[0].ID != entry.ID || trash[0].DeletedAt.IsZero() {
+		t.Fatalf("ListTrash() = %+v", trash)
+	}
+
+	restored, err := notes.RestoreNote(entry.ID)
+	if err != nil {
+		t.Fatalf("RestoreNote() error = %v", err)
+	}
+	if restored.Path != "projects/plan.md" || !slices.Equal(restored.Entry.Backlinks, []string{"index.md"}) {
+		t.Errorf("RestoreNote() = %+v", restored)
+	}
+	if note, err := notes.GetNote("projects/plan.md"); err != nil || note.Title != "Plan" {
+		t.Errorf("restored note = %+v, %v", note, err)
+	}
+	if trash, _ := notes.ListTrash(); len(trash) != 0 {
+		t.Errorf("ListTrash() after restore = %+v, want empty", trash)
+	}
+
+	var notFound *domain.ErrNotFound
+	if _, err := notes.RestoreNote(entry.ID); !errors.As(err, &notFound) {
+		t.Errorf("RestoreNote() of a restored entry error = %v, want ErrNotFound", err)
+	}
+	var invalid *domain.ErrInvalidPath
+	if _, err := notes.RestoreNote("../index.md"); !errors.As(err, &invalid) {
+		t.Errorf("RestoreNote() outside the trash error = %v, want ErrInvalidPath", err)
+	}
+}
+
+func TestNoteService_RestoreNoteToAlternatePath(t *testing.T) {
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{"note.md": "# Old\n"})
+
+	first, err := notes.TrashNote("note.md", nil)
+	if err != nil {
+		t.Fatalf("TrashNote() error = %v", err)
+	}
+	if err := notes.fs.WriteFile("note.md", []byte("# New\n")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	second, err := notes.TrashNote("note.md", nil)
+	if err != nil {
+		t.Fatalf("TrashNote() again error = %v", err)
+	}
+	if first.ID == second.ID {
+		t.Fatalf("two deletions in the same second share entry %q", first.ID)
+	}
+	if err := notes.fs.WriteFile("note.md", []byte("# Newest\n")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	for _, tt := range []struct {
+		entry   string
+		want    string
+		content string
+	}{
+		{first.ID, "note (restored).md", "# Old\n"},
+		{second.ID, "note (restored 2).md", "# New\n"},
+	} {
+		restored, err := notes.RestoreNote(tt.entry)
+		if err != nil {
+			t.Fatalf("RestoreNote(%q) error = %v", tt.entry, err)
+		}
+		if restored.Path != tt.want {
+			t.Errorf("RestoreNote(%q) path = %q, want %q", tt.entry, restored.Path, tt.want)
+		}
+		if content, _ := notes.fs.ReadFile(restored.Path); string(content) != tt.content {
+			t.Errorf("restored %s = %q, want %q", restored.Path, content, tt.content)
+		}
+	}
+	if content, _ := notes.fs.ReadFile("note.md"); string(content) != "# Newest\n" {
+		t.Errorf("note.md = %q, want the file that took the path kept", content)
+	}
+}
+
+func TestNoteService_RestoreNoteRelinksBacklinks(t *testing.T) {
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{
+		"projects/plan.md": "# Plan\n",
+		"index.md":         "# Index\n\n[[plan]], [[projects/plan|the plan]], [[Plan.md#Steps]], [doc](projects/plan.md)\n\n`[[plan]]`\n",
+		"other.md":         "# Other\n\n[[plan]]\n",
+	})
+
+	entry, err := notes.TrashNote("projects/plan.md", []string{"index.md"})
+	if err != nil {
+		t.Fatalf("TrashNote() error = %v", err)
+	}
+	if err := notes.fs.WriteFile("projects/plan.md", []byte("# Another plan\n")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	restored, err := notes.RestoreNote(entry.ID)
+	if err != nil {
+		t.Fatalf("RestoreNote() error = %v", err)
+	}
+	if restored.Path != "projects/plan (restored).md" || !slices.Equal(restored.Relinked, []string{"index.md"}) {
+		t.Errorf("RestoreNote() = %+v, want index.md relinked", restored)
+	}
+	want := "# Index\n\n[[plan (restored)]], [[projects/plan (restored)|the plan]], [[plan (restored).md#Steps]], " +
+		"[doc](projects/plan%20(restored).md)\n\n`[[plan]]`\n"
+	if content, _ := notes.fs.ReadFile("index.md"); string(content) != want {
+		t.Errorf("index.md = %q, want %q", content, want)
+	}
+	if content, _ := notes.fs.ReadFile("other.md"); string(content) != "# Other\n\n[[plan]]\n" {
+		t.Errorf("other.md = %q, want notes that were not backlinks left alone", content)
+	}
+}
+
+func TestNoteService_TrashNoteInvalidFrontmatter(t *testing.T) {
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{
+		"broken.md": "---\ntitle: [unclosed\n---\n# Broken\n",
+	})
+	if _, err := notes.GetNote("broken.md"); err == nil {
+		t.Fatal("GetNote() of a note with invalid frontmatter succeeded")
+	}
+
+	entry, err := notes.TrashNote("broken.md", nil)
+	if err != nil {
+		t.Fatalf("TrashNote() error = %v", err)
+	}
+	if entry.Title != "broken" || entry.CreatedAt.IsZero() {
+		t.Errorf("TrashNote() = %+v, want the file name and time", entry)
+	}
+	if _, err := notes.fs.StatFile("broken.md"); err == nil {
+		t.Error("trashed note is still at its original path")
+	}
+}
+
+func TestNoteService_PurgeTrash(t *testing.T) {
+	_, notes, _ := newTestAttachmentWorkspace(t, map[string]string{
+		"old.md":    "# Old\n",
+		"recent.md": "# Recent\n",
+	})
+
+	old, err := notes.TrashNote("old.md", nil)
+	if err != nil {
+		t.Fatalf("TrashNote() error = %v", err)
+	}
+	old.DeletedAt = time.Now().Add(-40 * 24 * time.Hour)
+	if err := notes.writeTrashEntry(*old); err != nil {
+		t.Fatalf("writeTrashEntry() error = %v", err)
+	}
+	if _, err := notes.TrashNote("recent.md", nil); err != nil {
+		t.Fatalf("TrashNote() error = %v", err)
+	}
+
+	notes.SetTrashRetention(0)
+	if purged, err := notes.PurgeTrash(); err != nil || purged != 0 {
+		t.Errorf("PurgeTrash() without retention = %d, %v, want nothing purged", purged, err)
+	}
+
+	notes.SetTrashRetention(DefaultTrashRetention)
+	if purged, err := notes.PurgeTrash(); err != nil || purged != 1 {
+		t.Errorf("PurgeTrash() = %d, %v, want 1", purged, err)
+	}
+	trash, _ := notes.ListTrash()
+	if len(trash) != 1 || trash[0].OriginalPath != "recent.md" {
+		t.Errorf("ListTrash() after purge = %+v, want only recent.md", trash)
+	}
+
+	if purged, err := notes.EmptyTrash(); err != nil || purged != 1 {
+		t.Errorf("EmptyTrash() = %d, %v, want 1", purged, err)
+	}
+	if trash, _ := notes.ListTrash(); len(trash) != 0 {
+		t.Errorf("ListTrash() after EmptyTrash() = %+v, want empty", trash)
+	}
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"notes/backend/domain"
+	"notes/backend/paths"
+
+	"github.com/BurntSushi/toml"
+)
+
+// WorkspaceConfigFileName is the name of the workspace settings file inside the workspace config directory.
+const WorkspaceConfigFileName = "config.toml"
+
+// WorkspaceConfigVersion is the config.toml format version written by this version of the app.
+// Files with a newer version are rejected rather than misread.
+const WorkspaceConfigVersion = 1
+
+// WorkspaceConfigChangedEvent is the event name the app emits WorkspaceConfigChange values under.
+const WorkspaceConfigChangedEvent = "workspace:config-changed"
+
+// WorkspaceConfigChange reports the new config of a workspace whose config.toml changed.
+type WorkspaceConfigChange struct {
+	WorkspaceID string                 `json:"workspaceId"`
+	Config      domain.WorkspaceConfig `json:"config"`
+}
+
+// workspaceConfigFile is the on-disk layout of config.toml:
+//
+//	version = 1
+//	default_tags = ["inbox"]
+//	ignore_patterns = [".git", "node_modules", "*.tmp"]
+//	attachment_folder = "attachments"
+//	template_folder = "templates"
+//	link_style = "wikilink"
+//	trash_retention_days = 30
+//
+//	[daily_notes]
+//	format = "2006-01-02"
+//	folder = "journal"
+type workspaceConfigFile struct {
+	Version          int              `toml:"version"`
+	DefaultTags      []string         `toml:"default_tags"`
+	IgnorePatterns   []string         `toml:"ignore_patterns"`
+	AttachmentFolder string           `toml:"attachment_folder"`
+	TemplateFolder   string           `toml:"template_folder"`
+	LinkStyle        domain.LinkStyle `toml:"link_style"`
+	TrashRetention   int              `toml:"trash_retention_days"`
+	DailyNotes       dailyNotesConfig `toml:"daily_notes"`
+}
+
+// dailyNotesConfig is the [daily_notes] table of config.toml.
+type dailyNotesConfig struct {
+	Format string `toml:"format"`
+	Folder string `toml:"folder"`
+}
+
+// DefaultWorkspaceConfig returns the configuration of a workspace without a config.toml.
+func DefaultWorkspaceConfig() domain.WorkspaceConfig {
+	return domain.WorkspaceConfig{
+		Version:           WorkspaceConfigVersion,
+		DailyNoteFormat:   "2006-01-02",
+		DailyNoteFolder:   "",
+		DefaultTags:       []string{},
+		IgnorePatterns:    defaultIgnorePatterns(),
+		AttachmentFolder:  DefaultAttachmentFolder,
+		TemplateFolder:    "",
+		LinkStyle:         domain.LinkStyleWikilink,
+		FrontmatterPolicy: domain.FrontmatterPreserveExisting,
+		TrashRetention:    DefaultTrashRetention,
+	}
+}
+
+// WorkspaceConfigPath returns the path of a workspace's config.toml.
+func WorkspaceConfigPath(workspaceRoot string) (string, error) {
+	configDir, err := paths.WorkspaceConfigPath(workspaceRoot, "KnowledgeLab")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, WorkspaceConfigFileName), nil
+}
+
+// LoadWorkspaceConfig reads and validates a workspace's config.toml.
+// A missing file yields DefaultWorkspaceConfig, and keys missing from the file keep their defaults.
+// Unknown keys, a newer format version and invalid values are rejected.
+func LoadWorkspaceConfig(path string) (domain.WorkspaceConfig, error) {
+	defaults := DefaultWorkspaceConfig()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return defaults, nil
+	}
+
+	file := newWorkspaceConfigFile(defaults)
+	file.Version = 0
+	meta, err := toml.DecodeFile(path, &file)
+	if err != nil {
+		return domain.WorkspaceConfig{}, fmt.Errorf("failed to decode workspace config: %w", err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return domain.WorkspaceConfig{}, fmt.Errorf("invalid workspace config: unknown key %q", undecoded[0].String())
+	}
+	if file.Version == 0 {
+		file.Version = WorkspaceConfigVersion
+	}
+
+	config := defaults
+	file.apply(&config)
+	return ValidateWorkspaceConfig(config)
+}
+
+// SaveWorkspaceConfig validates config and writes its portable settings to config.toml,
+// creating the workspace config directory if needed. Returns the config as saved.
+func SaveWorkspaceConfig(path string, config domain.WorkspaceConfig) (domain.WorkspaceConfig, error) {
+	config, err := ValidateWorkspaceConfig(config)
+	if err != nil {
+		return domain.WorkspaceConfig{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return domain.WorkspaceConfig{}, fmt.Errorf("failed to create workspace config directory: %w", err)
+	}
+
+	// Write to a temporary file and rename it, so the watcher never reloads a half-written file.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.toml")
+	if err != nil {
+		return domain.WorkspaceConfig{}, fmt.Errorf("failed to write workspace config: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	err = toml.NewEncoder(tmp).Encode(newWorkspaceConfigFile(config))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return domain.WorkspaceConfig{}, fmt.Errorf("failed to write workspace config: %w", err)
+	}
+	return config, nil
+}
+
+// ValidateWorkspaceConfig checks a workspace config and returns it with folders normalized
+// and empty lists defaulted. A version of 0 is taken to be WorkspaceConfigVersion.
+func ValidateWorkspaceConfig(config domain.WorkspaceConfig) (domain.WorkspaceConfig, error) {
+	invalid := func(key, format string, args ...any) (domain.WorkspaceConfig, error) {
+		return domain.WorkspaceConfig{}, fmt.Errorf("invalid workspace config: %s %s", key, fmt.Sprintf(format, args...))
+	}
+
+	if config.Version == 0 {
+		config.Version = WorkspaceConfigVersion
+	}
+	if config.Version < 0 || config.Version > WorkspaceConfigVersion {
+		return invalid("version", "%d is not supported (latest is %d)", config.Version, WorkspaceConfigVersion)
+	}
+
+	// The format must keep the full date, so daily notes of different days never share a name.
+	day := time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC)
+	if parsed, err := time.Parse(config.DailyNoteFormat, day.Format(config.DailyNoteFormat)); err != nil || !parsed.Equal(day) {
+		return invalid("daily_notes.format", "%q is not a Go date layout with year, month and day", config.DailyNoteFormat)
+	}
+
+	var err error
+	if config.DailyNoteFolder, err = normalizeConfigFolder(config.DailyNoteFolder); err != nil {
+		return invalid("daily_notes.folder", "%q %v", config.DailyNoteFolder, err)
+	}
+	if config.TemplateFolder, err = normalizeConfigFolder(config.TemplateFolder); err != nil {
+		return invalid("template_folder", "%q %v", config.TemplateFolder, err)
+	}
+	folder, err := normalizeAttachmentFolder(config.AttachmentFolder)
+	if err != nil {
+		return invalid("attachment_folder", "%q: %v", config.AttachmentFolder, err)
+	}
+	config.AttachmentFolder = folder
+
+	if config.LinkStyle == "" {
+		config.LinkStyle = domain.LinkStyleWikilink
+	}
+	if !config.LinkStyle.Valid() {
+		return invalid("link_style", "%q must be %q or %q", config.LinkStyle, domain.LinkStyleWikilink, domain.LinkStyleMarkdown)
+	}
+
+	if config.TrashRetention < 0 {
+		return invalid("trash_retention_days", "%d must not be negative", config.TrashRetention)
+	}
+
+	tags := []string{}
+	for _, tag := range config.DefaultTags {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if tag == "" || strings.ContainsAny(tag, " \t") {
+			return invalid("default_tags", "%q is not a tag", tag)
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	config.DefaultTags = tags
+
+	if config.IgnorePatterns == nil {
+		config.IgnorePatterns = []string{}
+	}
+	for _, pattern := range config.IgnorePatterns {
+		if _, err := filepath.Match(pattern, ""); err != nil || strings.TrimSpace(pattern) == "" {
+			return invalid("ignore_patterns", "%q is not a valid pattern", pattern)
+		}
+	}
+
+	if config.FrontmatterPolicy != "" && !config.FrontmatterPolicy.Valid() {
+		return invalid("frontmatter policy", "%q is not supported", config.FrontmatterPolicy)
+	}
+	if config.AutoCommitDelay < 0 {
+		return invalid("auto-commit delay", "%d must not be negative", config.AutoCommitDelay)
+	}
+	return config, nil
+}
+
+// normalizeConfigFolder cleans a workspace-relative folder, rejecting folders outside the workspace.
+func normalizeConfigFolder(folder string) (string, error) {
+	folder = strings.TrimSpace(filepath.ToSlash(folder))
+	if folder == "" {
+		return "", nil
+	}
+	if path.IsAbs(folder) || filepath.IsAbs(folder) {
+		return "", fmt.Errorf("must be relative to the workspace")
+	}
+
+	cleaned := path.Clean(folder)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("must be inside the workspace")
+	}
+	if cleaned == "." {
+		return "", nil
+	}
+	return cleaned, nil
+}
+
+// newWorkspaceConfigFile returns the portable settings of a config in their on-disk layout.
+func newWorkspaceConfigFile(config domain.WorkspaceConfig) workspaceConfigFile {
+	return workspaceConfigFile{
+		Version:          config.Version,
+		DefaultTags:      config.DefaultTags,
+		IgnorePatterns:   config.IgnorePatterns,
+		AttachmentFolder: config.AttachmentFolder,
+		TemplateFolder:   config.TemplateFolder,
+		LinkStyle:        config.LinkStyle,
+		TrashRetention:   config.TrashRetention,
+		DailyNotes: dailyNotesConfig{
+			Format: config.DailyNoteFormat,
+			Folder: config.DailyNoteFolder,
+		},
+	}
+}
+
+// apply copies the settings read from config.toml into config.
+func (f workspaceConfigFile) apply(config *domain.WorkspaceConfig) {
+	config.Version = f.Version
+	config.DefaultTags = f.DefaultTags
+	config.IgnorePatterns = f.IgnorePatterns
+	config.AttachmentFolder = f.AttachmentFolder
+	config.TemplateFolder = f.TemplateFolder
+	config.LinkStyle = f.LinkStyle
+	config.TrashRetention = f.TrashRetention
+	config.DailyNoteFormat = f.DailyNotes.Format
+	config.DailyNoteFolder = f.DailyNotes.Folder
+}
+
+// sameWorkspaceConfigFile reports whether two configs have the same portable settings.
+func sameWorkspaceConfigFile(a, b domain.WorkspaceConfig) bool {
+	return reflect.DeepEqual(newWorkspaceConfigFile(a), newWorkspaceConfigFile(b))
+}
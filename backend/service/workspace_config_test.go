@@ -0,0 +1,321 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"notes/backend/domain"
+)
+
+// writeTestWorkspaceConfig writes a config.toml into a workspace's config directory.
+func writeTestWorkspaceConfig(t *testing.T, root, content string) string {
+	t.Helper()
+
+	path, err := WorkspaceConfigPath(root)
+	if err != nil {
+		t.Fatalf("WorkspaceConfigPath() error = %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestLoadWorkspaceConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string // "" means no config.toml
+		check   func(t *testing.T, config domain.WorkspaceConfig)
+		wantErr string
+	}{
+		{
+			name: "missing file yields defaults",
+			check: func(t *testing.T, config domain.WorkspaceConfig) {
+				want := DefaultWorkspaceConfig()
+				if config.Version != want.Version || config.DailyNoteFormat != want.DailyNoteFormat ||
+					config.AttachmentFolder != want.AttachmentFolder || config.LinkStyle != want.LinkStyle ||
+					!slices.Equal(config.IgnorePatterns, want.IgnorePatterns) {
+					t.Errorf("config = %+v, want defaults %+v", config, want)
+				}
+			},
+		},
+		{
+			name: "partial file keeps other defaults",
+			content: `
+default_tags = ["#inbox", "draft", "inbox"]
+link_style = "markdown"
+
+[daily_notes]
+folder = "journal/"
+`,
+			check: func(t *testing.T, config domain.WorkspaceConfig) {
+				if config.Version != WorkspaceConfigVersion {
+					t.Errorf("Version = %d, want %d", config.Version, WorkspaceConfigVersion)
+				}
+				if !slices.Equal(config.DefaultTags, []string{"inbox", "draft"}) {
+					t.Errorf("DefaultTags = %v, want [inbox draft]", config.DefaultTags)
+				}
+				if config.LinkStyle != domain.LinkStyleMarkdown || config.DailyNoteFolder != "journal" {
+					t.Errorf("config = %+v", config)
+				}
+				if config.DailyNoteFormat != "2006-01-02" || config.AttachmentFolder != DefaultAttachmentFolder {
+					t.Errorf("config = %+v, want default daily format and attachment folder", config)
+				}
+			},
+		},
+		{
+			name:    "unknown key",
+			content: "version = 1\nlink_styel = \"markdown\"\n",
+			wantErr: "unknown key",
+		},
+		{
+			name:    "newer version",
+			content: "version = 99\n",
+			wantErr: "version",
+		},
+		{
+			name:    "daily format without day",
+			content: "[daily_notes]\nformat = \"2006-01\"\n",
+			wantErr: "daily_notes.format",
+		},
+		{
+			name:    "folder outside the workspace",
+			content: "template_folder = \"../templates\"\n",
+			wantErr: "template_folder",
+		},
+		{
+			name:    "unknown link style",
+			content: "link_style = \"html\"\n",
+			wantErr: "link_style",
+		},
+		{
+			name:    "invalid ignore pattern",
+			content: "ignore_patterns = [\"[\"]\n",
+			wantErr: "ignore_patterns",
+		},
+		{
+			name:    "malformed toml",
+			content: "version = \n",
+			wantErr: "decode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			path, _ := WorkspaceConfigPath(root)
+			if tt.content != "" {
+				path = writeTestWorkspaceConfig(t, root, tt.content)
+			}
+
+			config, err := LoadWorkspaceConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadWorkspaceConfig() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadWorkspaceConfig() error = %v", err)
+			}
+			tt.check(t, config)
+		})
+	}
+}
+
+func TestSaveWorkspaceConfig(t *testing.T) {
+	path, _ := WorkspaceConfigPath(t.TempDir())
+
+	config := DefaultWorkspaceConfig()
+	config.DailyNoteFormat = "02 Jan 2006"
+	config.DailyNoteFolder = "daily"
+	config.DefaultTags = []string{"inbox"}
+	config.IgnorePatterns = []string{".git", "*.bak"}
+	config.TemplateFolder = "templates/"
+	config.LinkStyle = domain.LinkStyleMarkdown
+
+	saved, err := SaveWorkspaceConfig(path, config)
+	if err != nil {
+		t.Fatalf("SaveWorkspaceConfig() error = %v", err)
+	}
+	if saved.TemplateFolder != "templates" {
+		t.Errorf("saved TemplateFolder = %q, want it normalized", saved.TemplateFolder)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(content), "frontmatter") || !strings.Contains(string(content), "[daily_notes]") {
+		t.Errorf("config.toml = %s, want only portable settings", content)
+	}
+
+	loaded, err := LoadWorkspaceConfig(path)
+	if err != nil {
+		t.Fatalf("LoadWorkspaceConfig() error = %v", err)
+	}
+	if !sameWorkspaceConfigFile(loaded, saved) {
+		t.Errorf("loaded = %+v, want %+v", loaded, saved)
+	}
+
+	config.LinkStyle = "html"
+	if _, err := SaveWorkspaceConfig(path, config); err == nil {
+		t.Error("SaveWorkspaceConfig() accepted an invalid link style")
+	}
+	if reloaded, _ := LoadWorkspaceConfig(path); reloaded.LinkStyle != domain.LinkStyleMarkdown {
+		t.Error("an invalid config overwrote config.toml")
+	}
+}
+
+func TestFilesystemService_ReloadsWorkspaceConfig(t *testing.T) {
+	root := t.TempDir()
+	path := writeTestWorkspaceConfig(t, root, "ignore_patterns = [\".git\", \"drafts\"]\n")
+
+	fs, err := NewFilesystemService()
+	if err != nil {
+		t.Fatalf("NewFilesystemService() error = %v", err)
+	}
+	defer fs.Close()
+
+	info, err := fs.OpenWorkspace(root)
+	if err != nil {
+		t.Fatalf("OpenWorkspace() error = %v", err)
+	}
+	if !slices.Equal(info.Workspace.IgnorePatterns, []string{".git", "drafts"}) {
+		t.Errorf("IgnorePatterns = %v, want those from config.toml", info.Workspace.IgnorePatterns)
+	}
+
+	changes := make(chan domain.WorkspaceConfig, 10)
+	fs.SetConfigHook(func(config domain.WorkspaceConfig) { changes <- config })
+
+	waitForChange := func() domain.WorkspaceConfig {
+		t.Helper()
+		select {
+		case config := <-changes:
+			return config
+		case <-time.After(2 * time.Second):
+			t.Fatal("config change not detected")
+		}
+		return domain.WorkspaceConfig{}
+	}
+
+	if err := os.WriteFile(path, []byte("link_style = \"markdown\"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if config := waitForChange(); config.LinkStyle != domain.LinkStyleMarkdown {
+		t.Errorf("reloaded LinkStyle = %q, want markdown", config.LinkStyle)
+	}
+	if workspace, _ := fs.GetCurrentWorkspace(); !slices.Equal(workspace.IgnorePatterns, defaultIgnorePatterns()) {
+		t.Errorf("IgnorePatterns after reload = %v, want defaults", workspace.IgnorePatterns)
+	}
+
+	// An invalid edit keeps the previous config.
+	if err := os.WriteFile(path, []byte("link_style = \"html\"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	time.Sleep(3 * configReloadDelay)
+	if config, _ := fs.WorkspaceConfig(); config.LinkStyle != domain.LinkStyleMarkdown {
+		t.Errorf("LinkStyle after invalid edit = %q, want markdown kept", config.LinkStyle)
+	}
+
+	config, _ := fs.WorkspaceConfig()
+	config.DefaultTags = []string{"inbox"}
+	if _, err := fs.SaveWorkspaceConfig(config); err != nil {
+		t.Fatalf("SaveWorkspaceConfig() error = %v", err)
+	}
+	if config := waitForChange(); !slices.Equal(config.DefaultTags, []string{"inbox"}) {
+		t.Errorf("saved DefaultTags = %v, want [inbox]", config.DefaultTags)
+	}
+}
+
+func TestWorkspaceManager_Config(t *testing.T) {
+	m := newTestWorkspaceManager(t)
+	root := t.TempDir()
+	writeTestTree(t, root, map[string]string{"index.md": "# Index\n"})
+
+	session, _, err := m.Open(root)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	changes := make(chan domain.WorkspaceConfig, 10)
+	m.SetConfigHook(func(id string, config domain.WorkspaceConfig) {
+		if id == session.ID() {
+			changes <- config
+		}
+	})
+
+	config, err := m.Config("")
+	if err != nil {
+		t.Fatalf("Config() error = %v", err)
+	}
+	config.DefaultTags = []string{"inbox"}
+	config.AttachmentFolder = "./assets"
+	config.LinkStyle = domain.LinkStyleMarkdown
+	config.FrontmatterPolicy = domain.FrontmatterNever
+	config.AutoCommitDelay = 30
+
+	saved, err := m.SaveConfig(session.ID(), config)
+	if err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+	if saved.FrontmatterPolicy != domain.FrontmatterNever || saved.AutoCommitDelay != 30 || saved.AttachmentFolder != "./assets" {
+		t.Errorf("SaveConfig() = %+v", saved)
+	}
+	select {
+	case change := <-changes:
+		if change.LinkStyle != domain.LinkStyleMarkdown || change.FrontmatterPolicy != domain.FrontmatterNever {
+			t.Errorf("config hook got %+v", change)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("config hook not called")
+	}
+
+	if session.Notes.FrontmatterPolicy() != domain.FrontmatterNever || session.Info.Config.LinkStyle != domain.LinkStyleMarkdown {
+		t.Error("saved config was not applied to the workspace")
+	}
+	note, err := session.Notes.CreateNote("Fresh", "")
+	if err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+	if !slices.Equal(note.FrontmatterTags, []string{"inbox"}) {
+		t.Errorf("new note frontmatter tags = %v, want the default tags", note.FrontmatterTags)
+	}
+
+	if _, err := m.SaveConfig("", domain.WorkspaceConfig{DailyNoteFormat: "Jan"}); err == nil {
+		t.Error("SaveConfig() accepted an invalid daily note format")
+	}
+}
+
+func TestWorkspaceManager_MovesAttachmentFolderToConfig(t *testing.T) {
+	m := newTestWorkspaceManager(t)
+	root := t.TempDir()
+
+	session, _, err := m.Open(root)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := session.Stores.Metadata.SetAttachmentFolder(session.ID(), "media"); err != nil {
+		t.Fatalf("SetAttachmentFolder() error = %v", err)
+	}
+	if err := m.Close(session.ID()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	session, _, err = m.Open(root)
+	if err != nil {
+		t.Fatalf("Open() again error = %v", err)
+	}
+	if !session.FS.HasWorkspaceConfig() {
+		t.Fatal("the database attachment folder was not moved to config.toml")
+	}
+	if config, _ := m.Config(""); config.AttachmentFolder != "media" {
+		t.Errorf("AttachmentFolder = %q, want media", config.AttachmentFolder)
+	}
+}
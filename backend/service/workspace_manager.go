@@ -0,0 +1,558 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"notes/backend/domain"
+	"notes/backend/paths"
+)
+
+// WorkspaceSession is an open workspace with its own filesystem watcher, services and stores.
+// Sessions are created by WorkspaceManager.Open and share nothing but the theme service.
+type WorkspaceSession struct {
+	Info        *domain.WorkspaceInfo
+	FS          *FilesystemService
+	Notes       *NoteService
+	Graph       *GraphService
+	Search      *SearchService
+	Query       *QueryService
+	Schemas     *SchemaService
+	Attachments *AttachmentService
+	Importer    *ImportService
+	Exporter    *ExportService
+	History     *HistoryService
+	Tasks       *TaskService
+	Indexer     *Indexer
+	Stores      *Stores
+}
+
+// ID returns the ID of the session's workspace.
+func (w *WorkspaceSession) ID() string {
+	return w.Info.Workspace.ID
+}
+
+// LoadTypeSchemas reads the note type schemas from the workspace config directory.
+func (w *WorkspaceSession) LoadTypeSchemas() error {
+	configDir, err := paths.WorkspaceConfigPath(w.Info.Workspace.RootPath, "KnowledgeLab")
+	if err != nil {
+		return err
+	}
+	return w.Schemas.Load(filepath.Join(configDir, SchemaFileName))
+}
+
+// WorkspaceSearchResult is a search result tagged with the workspace it was found in.
+type WorkspaceSearchResult struct {
+	WorkspaceID   string `json:"workspaceId"`
+	WorkspaceName string `json:"workspaceName"`
+	SearchResult
+}
+
+// WorkspaceManager keeps several workspaces open at once. One of them is active:
+// it is the workspace used when a caller passes an empty workspace ID.
+type WorkspaceManager struct {
+	mu         sync.RWMutex
+	appName    string
+	themes     *ThemeService
+	ctx        context.Context
+	logger     runtimeLogger
+	commitHook func(hash string, err error)
+	configHook func(workspaceID string, config domain.WorkspaceConfig)
+	indexHook  func(status IndexStatus)
+	sessions   map[string]*WorkspaceSession
+	order      []string // IDs of the open workspaces, in the order they were opened
+	active     string
+}
+
+// NewWorkspaceManager creates a manager whose workspaces keep their stores under appName's config directory.
+func NewWorkspaceManager(appName string, themes *ThemeService) *WorkspaceManager {
+	return &WorkspaceManager{
+		appName:  appName,
+		themes:   themes,
+		sessions: make(map[string]*WorkspaceSession),
+	}
+}
+
+// SetLogger attaches the runtime logger context, also used by workspaces opened afterwards.
+func (m *WorkspaceManager) SetLogger(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ctx = ctx
+	m.logger.attach(ctx)
+	for _, session := range m.sessions {
+		session.FS.SetLogger(ctx)
+		session.Tasks.SetLogger(ctx)
+		session.Indexer.SetLogger(ctx)
+	}
+}
+
+// SetCommitHook sets the function told about the outcome of every automatic git commit,
+// in open workspaces and workspaces opened afterwards.
+func (m *WorkspaceManager) SetCommitHook(hook func(hash string, err error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.commitHook = hook
+	for _, session := range m.sessions {
+		session.History.SetCommitHook(hook)
+	}
+}
+
+// SetConfigHook sets the function told when an open workspace's config.toml changes,
+// whether saved through the app or edited on disk. It receives the workspace's full config.
+func (m *WorkspaceManager) SetConfigHook(hook func(workspaceID string, config domain.WorkspaceConfig)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.configHook = hook
+}
+
+// SetIndexProgressHook sets the function told about the progress of index builds,
+// in open workspaces and workspaces opened afterwards.
+func (m *WorkspaceManager) SetIndexProgressHook(hook func(status IndexStatus)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.indexHook = hook
+	for _, session := range m.sessions {
+		session.Indexer.SetProgressHook(hook)
+	}
+}
+
+// Open opens the workspace at path and makes it the active workspace. A workspace that is
+// already open is only activated. The returned flag reports whether the workspace was newly
+// opened, in which case its indexes are empty and still have to be built.
+func (m *WorkspaceManager) Open(path string) (*WorkspaceSession, bool, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, false, &domain.ErrInvalidPath{Path: path, Reason: err.Error()}
+	}
+	if session := m.activate(generateWorkspaceID(absPath)); session != nil {
+		return session, false, nil
+	}
+
+	session, err := m.newSession(absPath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Another caller may have opened the same workspace meanwhile; keep theirs.
+	if existing, ok := m.sessions[session.ID()]; ok {
+		m.active = existing.ID()
+		m.closeSession(session)
+		return existing, false, nil
+	}
+	m.sessions[session.ID()] = session
+	m.order = append(m.order, session.ID())
+	m.active = session.ID()
+	return session, true, nil
+}
+
+// activate makes an open workspace the active one and returns it, or returns nil if it is not open.
+func (m *WorkspaceManager) activate(id string) *WorkspaceSession {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[id]
+	if ok {
+		m.active = id
+	}
+	return session
+}
+
+// newSession opens a workspace and wires up its services, stores and persisted settings.
+func (m *WorkspaceManager) newSession(path string) (*WorkspaceSession, error) {
+	m.mu.RLock()
+	ctx, hook, indexHook := m.ctx, m.commitHook, m.indexHook
+	m.mu.RUnlock()
+
+	fs, err := NewFilesystemService()
+	if err != nil {
+		return nil, err
+	}
+	if ctx != nil {
+		fs.SetLogger(ctx)
+	}
+
+	info, err := fs.OpenWorkspace(path)
+	if err != nil {
+		fs.Close()
+		return nil, err
+	}
+
+	stores, err := OpenWorkspaceStores(m.appName, &info.Workspace, nil)
+	if err != nil {
+		fs.Close()
+		return nil, fmt.Errorf("failed to open workspace stores: %w", err)
+	}
+
+	notes := NewNoteService(fs)
+	graph := NewGraphService()
+	session := &WorkspaceSession{
+		Info:        info,
+		FS:          fs,
+		Notes:       notes,
+		Graph:       graph,
+		Search:      NewSearchService(),
+		Query:       NewQueryService(),
+		Schemas:     NewSchemaService(),
+		Attachments: NewAttachmentService(fs),
+		Importer:    NewImportService(fs, notes),
+		Exporter:    NewExportService(fs, notes, graph, m.themes),
+		History:     NewHistoryService(fs),
+		Tasks:       NewTaskService(stores.Task),
+		Stores:      stores,
+	}
+	session.Indexer = NewIndexer(session)
+
+	notes.SetQueryRunner(session.Query)
+	session.Query.SetSchemas(session.Schemas)
+	session.Search.SetSchemas(session.Schemas)
+	notes.SetAttachmentService(session.Attachments)
+	graph.SetAttachments(session.Attachments)
+	notes.SetMetadataStore(stores.Metadata)
+	if ctx != nil {
+		session.Tasks.SetLogger(ctx)
+		session.Indexer.SetLogger(ctx)
+	}
+	if hook != nil {
+		session.History.SetCommitHook(hook)
+	}
+	if indexHook != nil {
+		session.Indexer.SetProgressHook(indexHook)
+	}
+
+	if err := m.loadSettings(session); err != nil {
+		fs.Close()
+		stores.Close(nil)
+		return nil, err
+	}
+	fs.SetConfigHook(func(config domain.WorkspaceConfig) {
+		m.configChanged(session, config)
+	})
+	return session, nil
+}
+
+// loadSettings applies a workspace's persisted settings to its services and its Info.Config.
+func (m *WorkspaceManager) loadSettings(session *WorkspaceSession) error {
+	info := session.Info
+	metadata := session.Stores.Metadata
+
+	if !session.History.IsRepository() {
+		session.Notes.SetSnapshotStore(session.Stores.Snapshots)
+	}
+
+	policy, err := metadata.GetFrontmatterPolicy(info.Workspace.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load frontmatter policy: %w", err)
+	}
+	session.Notes.SetFrontmatterPolicy(policy)
+	info.Config.FrontmatterPolicy = policy
+
+	delay, err := metadata.GetAutoCommitDelay(info.Workspace.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load auto-commit delay: %w", err)
+	}
+	session.History.SetAutoCommit(time.Duration(delay) * time.Second)
+	info.Config.AutoCommitDelay = delay
+
+	config := info.Config
+	if !session.FS.HasWorkspaceConfig() {
+		// The attachment folder used to be kept in the database; move a customized one to config.toml.
+		folder, err := metadata.GetAttachmentFolder(info.Workspace.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load attachment folder: %w", err)
+		}
+		if folder != config.AttachmentFolder {
+			config.AttachmentFolder = folder
+			if config, err = session.FS.SaveWorkspaceConfig(config); err != nil {
+				return fmt.Errorf("failed to move attachment folder to config.toml: %w", err)
+			}
+		}
+	}
+	m.applyConfig(session, config)
+
+	if purged, err := session.Notes.PurgeTrash(); err != nil {
+		m.logger.Warnf("failed to purge trash of %s: %v", info.Workspace.RootPath, err)
+	} else if purged > 0 {
+		m.logger.Infof("Purged %d notes from the trash of %s", purged, info.Workspace.RootPath)
+	}
+
+	if err := session.LoadTypeSchemas(); err != nil {
+		m.logger.Warnf("failed to load note type schemas for %s: %v", info.Workspace.RootPath, err)
+	}
+	return nil
+}
+
+// applyConfig applies the portable settings of a workspace config to the session's services
+// and its Info.Config. The per-machine settings in Info.Config are kept.
+func (m *WorkspaceManager) applyConfig(session *WorkspaceSession, config domain.WorkspaceConfig) {
+	if err := session.Attachments.SetFolder(config.AttachmentFolder); err != nil {
+		m.logger.Warnf("failed to apply attachment folder for %s: %v", session.Info.Workspace.RootPath, err)
+	}
+	session.Attachments.SetLinkStyle(config.LinkStyle)
+	session.Importer.SetDailyNotes(config.DailyNoteFormat, config.DailyNoteFolder)
+	session.Notes.SetDefaultTags(config.DefaultTags)
+	session.Notes.SetTrashRetention(config.TrashRetention)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	config.FrontmatterPolicy = session.Info.Config.FrontmatterPolicy
+	config.AutoCommitDelay = session.Info.Config.AutoCommitDelay
+	session.Info.Config = config
+	session.Info.Workspace.IgnorePatterns = config.IgnorePatterns
+}
+
+// configChanged applies a changed config.toml to an open workspace and tells the config hook.
+func (m *WorkspaceManager) configChanged(session *WorkspaceSession, config domain.WorkspaceConfig) {
+	m.applyConfig(session, config)
+
+	m.mu.RLock()
+	hook := m.configHook
+	m.mu.RUnlock()
+
+	if hook == nil {
+		return
+	}
+	full, err := m.Config(session.ID())
+	if err != nil {
+		// The workspace was closed meanwhile.
+		return
+	}
+	hook(session.ID(), full)
+}
+
+// Config returns the settings of an open workspace, or of the active workspace for an empty ID:
+// the portable settings from its config.toml and the per-machine settings from its stores.
+func (m *WorkspaceManager) Config(id string) (domain.WorkspaceConfig, error) {
+	session, err := m.Get(id)
+	if err != nil {
+		return domain.WorkspaceConfig{}, err
+	}
+
+	config, err := session.FS.WorkspaceConfig()
+	if err != nil {
+		return domain.WorkspaceConfig{}, err
+	}
+	if config.FrontmatterPolicy, err = session.Stores.Metadata.GetFrontmatterPolicy(session.ID()); err != nil {
+		return domain.WorkspaceConfig{}, fmt.Errorf("failed to load frontmatter policy: %w", err)
+	}
+	if config.AutoCommitDelay, err = session.Stores.Metadata.GetAutoCommitDelay(session.ID()); err != nil {
+		return domain.WorkspaceConfig{}, fmt.Errorf("failed to load auto-commit delay: %w", err)
+	}
+	return config, nil
+}
+
+// SaveConfig validates and saves the settings of an open workspace, or of the active workspace
+// for an empty ID. Portable settings are written to config.toml; an empty frontmatter policy
+// keeps the current one. Returns the config as saved.
+func (m *WorkspaceManager) SaveConfig(id string, config domain.WorkspaceConfig) (domain.WorkspaceConfig, error) {
+	session, err := m.Get(id)
+	if err != nil {
+		return domain.WorkspaceConfig{}, err
+	}
+	if config, err = ValidateWorkspaceConfig(config); err != nil {
+		return domain.WorkspaceConfig{}, err
+	}
+
+	metadata := session.Stores.Metadata
+	if config.FrontmatterPolicy != "" {
+		if err := metadata.SetFrontmatterPolicy(session.ID(), config.FrontmatterPolicy); err != nil {
+			return domain.WorkspaceConfig{}, fmt.Errorf("failed to save frontmatter policy: %w", err)
+		}
+		session.Notes.SetFrontmatterPolicy(config.FrontmatterPolicy)
+	}
+	if err := metadata.SetAutoCommitDelay(session.ID(), config.AutoCommitDelay); err != nil {
+		return domain.WorkspaceConfig{}, fmt.Errorf("failed to save auto-commit delay: %w", err)
+	}
+	session.History.SetAutoCommit(time.Duration(config.AutoCommitDelay) * time.Second)
+
+	m.mu.Lock()
+	if config.FrontmatterPolicy != "" {
+		session.Info.Config.FrontmatterPolicy = config.FrontmatterPolicy
+	}
+	session.Info.Config.AutoCommitDelay = config.AutoCommitDelay
+	m.mu.Unlock()
+
+	if _, err := session.FS.SaveWorkspaceConfig(config); err != nil {
+		return domain.WorkspaceConfig{}, err
+	}
+	return m.Config(session.ID())
+}
+
+// Get returns the open workspace with the given ID, or the active workspace for an empty ID.
+func (m *WorkspaceManager) Get(id string) (*WorkspaceSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if id == "" {
+		id = m.active
+		if id == "" {
+			return nil, &domain.ErrWorkspaceNotOpen{}
+		}
+	}
+
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, &domain.ErrNotFound{Resource: "workspace", ID: id}
+	}
+	return session, nil
+}
+
+// Active returns the ID of the active workspace, or "" when no workspace is open.
+func (m *WorkspaceManager) Active() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.active
+}
+
+// SetActive makes an open workspace the active one.
+func (m *WorkspaceManager) SetActive(id string) error {
+	if m.activate(id) == nil {
+		return &domain.ErrNotFound{Resource: "workspace", ID: id}
+	}
+	return nil
+}
+
+// List returns the open workspaces in the order they were opened.
+func (m *WorkspaceManager) List() []domain.WorkspaceInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	infos := make([]domain.WorkspaceInfo, 0, len(m.order))
+	for _, id := range m.order {
+		infos = append(infos, *m.sessions[id].Info)
+	}
+	return infos
+}
+
+// Close commits pending history, stops the watcher and closes the stores of an open workspace.
+// If it was the active workspace, the most recently opened remaining workspace becomes active.
+func (m *WorkspaceManager) Close(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id == "" {
+		id = m.active
+	}
+	session, ok := m.sessions[id]
+	if !ok {
+		return &domain.ErrNotFound{Resource: "workspace", ID: id}
+	}
+
+	delete(m.sessions, id)
+	m.order = slices.DeleteFunc(m.order, func(open string) bool { return open == id })
+	if m.active == id {
+		m.active = ""
+		if len(m.order) > 0 {
+			m.active = m.order[len(m.order)-1]
+		}
+	}
+	return m.closeSession(session)
+}
+
+// CloseAll closes every open workspace.
+func (m *WorkspaceManager) CloseAll() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var firstErr error
+	for _, id := range m.order {
+		if err := m.closeSession(m.sessions[id]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	m.sessions = make(map[string]*WorkspaceSession)
+	m.order = nil
+	m.active = ""
+	return firstErr
+}
+
+// closeSession releases a session's resources, stopping its index build and flushing pending automatic commits first.
+func (m *WorkspaceManager) closeSession(session *WorkspaceSession) error {
+	session.Indexer.Cancel()
+
+	hash, err := session.History.CommitPending()
+	if m.commitHook != nil {
+		m.commitHook(hash, err)
+	}
+
+	if err := session.FS.Close(); err != nil {
+		m.logger.Warnf("failed to close filesystem of %s: %v", session.Info.Workspace.RootPath, err)
+	}
+	if err := session.Stores.Close(nil); err != nil {
+		return fmt.Errorf("failed to close workspace stores: %w", err)
+	}
+	return nil
+}
+
+// ServeHTTP serves attachment files of any open workspace, routed on the workspace ID in the
+// URL, so the manager can be registered as the Wails asset server handler.
+func (m *WorkspaceManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	workspaceID, _, ok := ParseAttachmentURL(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	session, err := m.Get(workspaceID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	session.Attachments.ServeHTTP(w, r)
+}
+
+// Search runs a search in each of the given open workspaces, or in all of them when workspaceIDs
+// is empty, and merges the results by score. The query's limit applies to the merged results.
+func (m *WorkspaceManager) Search(query SearchQuery, workspaceIDs []string) ([]WorkspaceSearchResult, error) {
+	m.mu.RLock()
+	if len(workspaceIDs) == 0 {
+		workspaceIDs = slices.Clone(m.order)
+	}
+	sessions := make([]*WorkspaceSession, 0, len(workspaceIDs))
+	for _, id := range workspaceIDs {
+		session, ok := m.sessions[id]
+		if !ok {
+			m.mu.RUnlock()
+			return nil, &domain.ErrNotFound{Resource: "workspace", ID: id}
+		}
+		sessions = append(sessions, session)
+	}
+	m.mu.RUnlock()
+
+	results := []WorkspaceSearchResult{}
+	for _, session := range sessions {
+		found, err := session.Search.Search(query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search %s: %w", session.Info.Workspace.Name, err)
+		}
+		for _, result := range found {
+			results = append(results, WorkspaceSearchResult{
+				WorkspaceID:   session.ID(),
+				WorkspaceName: session.Info.Workspace.Name,
+				SearchResult:  result,
+			})
+		}
+	}
+
+	// A stable sort keeps each workspace's own order among equal scores, e.g. for filter-only queries.
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if query.Limit > 0 && len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results, nil
+}
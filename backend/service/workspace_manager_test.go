@@ -0,0 +1,227 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"notes/backend/domain"
+)
+
+// newTestWorkspaceManager returns a manager whose stores live under a temporary app name,
+// closing its workspaces and removing their stores when the test ends.
+func newTestWorkspaceManager(t testing.TB) *WorkspaceManager {
+	t.Helper()
+
+	appName := filepath.Join(t.TempDir(), "testapp")
+	m := NewWorkspaceManager(appName, NewThemeService())
+	opened := map[string]bool{}
+	t.Cleanup(func() {
+		for _, info := range m.List() {
+			opened[info.Workspace.ID] = true
+		}
+		m.CloseAll()
+		for id := range opened {
+			cleanupTestWorkspace(t, appName, id)
+		}
+		cleanupTestWorkspace(t, appName, DefaultWorkspaceName)
+	})
+	return m
+}
+
+// indexTestSession loads every note of a session into its search index.
+func indexTestSession(t *testing.T, session *WorkspaceSession) {
+	t.Helper()
+
+	summaries, err := session.Notes.ListNotes()
+	if err != nil {
+		t.Fatalf("ListNotes() error = %v", err)
+	}
+	notes := []domain.Note{}
+	for _, summary := range summaries {
+		note, err := session.Notes.GetNote(summary.ID)
+		if err != nil {
+			t.Fatalf("GetNote(%s) error = %v", summary.ID, err)
+		}
+		notes = append(notes, *note)
+	}
+	if err := session.Search.IndexAll(notes); err != nil {
+		t.Fatalf("IndexAll() error = %v", err)
+	}
+}
+
+func TestWorkspaceManager_OpenGetAndClose(t *testing.T) {
+	m := newTestWorkspaceManager(t)
+
+	var notOpen *domain.ErrWorkspaceNotOpen
+	if _, err := m.Get(""); !errors.As(err, &notOpen) {
+		t.Errorf("Get(\"\") with nothing open error = %v, want ErrWorkspaceNotOpen", err)
+	}
+
+	personalRoot, teamRoot := t.TempDir(), t.TempDir()
+	writeTestTree(t, personalRoot, map[string]string{"diary.md": "# Diary\n"})
+	writeTestTree(t, teamRoot, map[string]string{"roadmap.md": "# Roadmap\n", "team.md": "# Team\n"})
+
+	personal, opened, err := m.Open(personalRoot)
+	if err != nil || !opened {
+		t.Fatalf("Open(personal) = %v, %v; want a newly opened workspace", opened, err)
+	}
+	team, opened, err := m.Open(teamRoot)
+	if err != nil || !opened {
+		t.Fatalf("Open(team) = %v, %v; want a newly opened workspace", opened, err)
+	}
+	if personal.ID() == team.ID() || personal.FS == team.FS || personal.Stores == team.Stores {
+		t.Fatal("workspaces share services or stores")
+	}
+	if m.Active() != team.ID() {
+		t.Errorf("Active() = %q, want the last opened workspace", m.Active())
+	}
+
+	again, opened, err := m.Open(personalRoot)
+	if err != nil || opened || again != personal {
+		t.Errorf("Open(personal) again = %v, %v; want the open session", opened, err)
+	}
+	if m.Active() != personal.ID() {
+		t.Errorf("Active() = %q, want the reopened workspace", m.Active())
+	}
+
+	if got, err := m.Get(team.ID()); err != nil || got != team {
+		t.Errorf("Get(team) = %v, %v", got, err)
+	}
+	if summaries, _ := team.Notes.ListNotes(); len(summaries) != 2 {
+		t.Errorf("team workspace lists %d notes, want 2", len(summaries))
+	}
+	if infos := m.List(); len(infos) != 2 || infos[0].Workspace.ID != personal.ID() || infos[1].Workspace.ID != team.ID() {
+		t.Errorf("List() = %+v, want both workspaces in open order", infos)
+	}
+
+	var notFound *domain.ErrNotFound
+	if err := m.SetActive("unknown"); !errors.As(err, &notFound) {
+		t.Errorf("SetActive(unknown) error = %v, want ErrNotFound", err)
+	}
+
+	if err := m.Close(personal.ID()); err != nil {
+		t.Fatalf("Close(personal) error = %v", err)
+	}
+	if m.Active() != team.ID() {
+		t.Errorf("Active() after closing the active workspace = %q, want the remaining one", m.Active())
+	}
+	if _, err := m.Get(personal.ID()); !errors.As(err, &notFound) {
+		t.Errorf("Get(closed) error = %v, want ErrNotFound", err)
+	}
+	if _, err := team.Notes.GetNote("roadmap.md"); err != nil {
+		t.Errorf("remaining workspace GetNote() error = %v", err)
+	}
+}
+
+func TestWorkspaceManager_ServeHTTP(t *testing.T) {
+	m := newTestWorkspaceManager(t)
+
+	personalRoot, teamRoot := t.TempDir(), t.TempDir()
+	writeTestTree(t, personalRoot, map[string]string{"img/logo.png": "personal-logo"})
+	writeTestTree(t, teamRoot, map[string]string{"img/logo.png": "team-logo", "plan.md": "![[logo.png]]\n"})
+
+	personal, _, err := m.Open(personalRoot)
+	if err != nil {
+		t.Fatalf("Open(personal) error = %v", err)
+	}
+	team, _, err := m.Open(teamRoot)
+	if err != nil {
+		t.Fatalf("Open(team) error = %v", err)
+	}
+	if err := team.Attachments.Refresh(); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if err := m.SetActive(personal.ID()); err != nil {
+		t.Fatalf("SetActive() error = %v", err)
+	}
+
+	// A note of the inactive workspace renders and serves its own attachments.
+	html, err := team.Notes.RenderNoteMarkdown("plan.md", "![[logo.png]]\n")
+	if err != nil {
+		t.Fatalf("RenderNoteMarkdown() error = %v", err)
+	}
+	url := AttachmentURL(team.ID(), "img/logo.png")
+	if !strings.Contains(html, url) {
+		t.Errorf("RenderNoteMarkdown() = %s, want it to reference %s", html, url)
+	}
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{url, http.StatusOK, "team-logo"},
+		{AttachmentURL(personal.ID(), "img/logo.png"), http.StatusOK, "personal-logo"},
+		{AttachmentURL("unknown", "img/logo.png"), http.StatusNotFound, ""},
+		{AttachmentURLPrefix + "img/logo.png", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.wantStatus || (tt.wantBody != "" && rec.Body.String() != tt.wantBody) {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.path, rec.Code, rec.Body.String(), tt.wantStatus, tt.wantBody)
+		}
+	}
+}
+
+func TestWorkspaceManager_Search(t *testing.T) {
+	m := newTestWorkspaceManager(t)
+
+	personalRoot, teamRoot := t.TempDir(), t.TempDir()
+	writeTestTree(t, personalRoot, map[string]string{
+		"garden.md": "# Garden\n\nPlant tomatoes in spring.\n",
+	})
+	writeTestTree(t, teamRoot, map[string]string{
+		"launch.md":  "# Launch\n\nTomatoes are the code name of the spring launch.\n",
+		"meeting.md": "# Meeting\n\nNothing about vegetables.\n",
+	})
+
+	personal, _, err := m.Open(personalRoot)
+	if err != nil {
+		t.Fatalf("Open(personal) error = %v", err)
+	}
+	team, _, err := m.Open(teamRoot)
+	if err != nil {
+		t.Fatalf("Open(team) error = %v", err)
+	}
+	indexTestSession(t, personal)
+	indexTestSession(t, team)
+
+	if results, _ := personal.Search.Search(SearchQuery{Query: "launch"}); len(results) != 0 {
+		t.Errorf("personal search found team notes: %+v", results)
+	}
+
+	results, err := m.Search(SearchQuery{Query: "tomatoes"}, nil)
+	if err != nil {
+		t.Fatalf("Search(all) error = %v", err)
+	}
+	found := map[string]string{}
+	for i, result := range results {
+		found[result.NoteID] = result.WorkspaceID
+		if i > 0 && result.Score > results[i-1].Score {
+			t.Errorf("results not sorted by score: %+v", results)
+		}
+	}
+	if len(results) != 2 || found["garden.md"] != personal.ID() || found["launch.md"] != team.ID() {
+		t.Errorf("Search(all) = %+v, want one note from each workspace", results)
+	}
+
+	results, err = m.Search(SearchQuery{Query: "tomatoes"}, []string{team.ID()})
+	if err != nil || len(results) != 1 || results[0].NoteID != "launch.md" || results[0].WorkspaceName != filepath.Base(teamRoot) {
+		t.Errorf("Search(team) = %+v, %v", results, err)
+	}
+
+	results, err = m.Search(SearchQuery{Query: "tomatoes", Limit: 1}, nil)
+	if err != nil || len(results) != 1 {
+		t.Errorf("Search(limit 1) = %+v, %v; want one result", results, err)
+	}
+
+	var notFound *domain.ErrNotFound
+	if _, err := m.Search(SearchQuery{Query: "tomatoes"}, []string{"unknown"}); !errors.As(err, &notFound) {
+		t.Errorf("Search(unknown workspace) error = %v, want ErrNotFound", err)
+	}
+}
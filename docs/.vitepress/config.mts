@@ -25,6 +25,7 @@ export default defineConfig({
           { text: "Markdown Dialect", link: "/markdown-dialect" },
           { text: "Markdown Examples", link: "/md-examples" },
           { text: "Daily Notes", link: "/daily-notes" },
+          { text: "Note Types", link: "/note-types" },
         ],
       },
       {
@@ -39,12 +40,24 @@ export default defineConfig({
         items: [
           { text: "Graph Database", link: "/graph" },
           { text: "Search", link: "/search" },
+          { text: "Metadata Queries", link: "/queries" },
         ],
       },
       {
         text: "Importing",
         items: [{ text: "Import Guides", link: "/importing" }],
       },
+      {
+        text: "Exporting",
+        items: [
+          { text: "Static Site", link: "/exporting#static-site" },
+          { text: "Documents", link: "/exporting#documents" },
+        ],
+      },
+      {
+        text: "History",
+        items: [{ text: "Version History", link: "/history" }],
+      },
     ],
     socialLinks: [{ icon: "github", link: "https://github.com/stormlightlabs/notes" }],
   },
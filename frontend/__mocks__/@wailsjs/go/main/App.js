@@ -42,7 +42,7 @@ export const OpenWorkspace = (path) =>
 
 export const ListNotes = () => Promise.resolve([]);
 
-export const GetNote = (id) =>
+export const GetNote = (workspaceId, id) =>
   Promise.resolve({
     Id: id,
     Title: "Test Note",
@@ -56,11 +56,47 @@ export const GetNote = (id) =>
     ModifiedAt: new Date().toISOString(),
   });
 
-export const SaveNote = () => Promise.resolve();
+export const SaveNote = (workspaceId, note) =>
+  Promise.resolve({
+    note: {
+      id: note.Id,
+      title: note.Title,
+      path: note.Path,
+      content: note.Content,
+      frontmatter: {},
+      aliases: [],
+      type: note.Type,
+      blocks: [],
+      links: [],
+      tags: [],
+      createdAt: new Date().toISOString(),
+      modifiedAt: new Date().toISOString(),
+      hash: "mock-hash",
+    },
+    merged: false,
+    conflict: null,
+  });
 
-export const DeleteNote = () => Promise.resolve();
+export const IndexStatus = (workspaceId) =>
+  Promise.resolve({ workspaceId, state: "done", done: 0, total: 0, current: "", failed: 0, partial: false });
+export const ReindexWorkspace = () => Promise.resolve();
 
-export const CreateNote = (title, folder) =>
+export const DeleteNote = () => Promise.resolve();
+export const ListTrash = () => Promise.resolve([]);
+export const RestoreNote = (workspaceId, entryId) =>
+  Promise.resolve({ entry: { id: entryId, originalPath: "", backlinks: [] }, path: "" });
+export const EmptyTrash = () => Promise.resolve(0);
+export const ListFolderTree = () =>
+  Promise.resolve({ name: "", path: "", noteCount: 0, totalNoteCount: 0, children: [] });
+export const CreateFolder = (workspaceId, folder) => Promise.resolve(folder);
+export const RenameFolder = (workspaceId, folder, name, dryRun) =>
+  Promise.resolve({ oldPath: folder, newPath: name, moved: [], changes: [], applied: !dryRun });
+export const MoveFolder = (workspaceId, oldPath, newPath, dryRun) =>
+  Promise.resolve({ oldPath, newPath, moved: [], changes: [], applied: !dryRun });
+export const DeleteFolder = (workspaceId, folder) =>
+  Promise.resolve({ id: "", originalPath: folder, folder: true, notes: [], backlinks: [] });
+
+export const CreateNote = (workspaceId, title, folder) =>
   Promise.resolve({
     Id: `${folder}/${title}.md`,
     Title: title,
@@ -78,8 +114,8 @@ export const GetBacklinks = () => Promise.resolve([]);
 
 export const GetGraph = () =>
   Promise.resolve({
-    Nodes: [],
-    Edges: [],
+    nodes: [],
+    edges: [],
   });
 
 export const Search = () => Promise.resolve([]);
@@ -94,7 +130,7 @@ export const GetAllTagsWithCounts = () =>
     { Name: "another-tag", Count: 1, NoteIds: ["note4"] },
   ]);
 
-export const GetTagInfo = (tagName) =>
+export const GetTagInfo = (workspaceId, tagName) =>
   Promise.resolve({
     Name: tagName,
     Count: 2,
@@ -179,6 +215,21 @@ export const GetUserConfigDir = () => "/mock/user/config/KnowledgeLab";
 export const InitWorkspaceConfigDir = (workspaceRoot) =>
   Promise.resolve(`${workspaceRoot}/.knowledgelab`);
 
+export const GetWorkspaceConfig = () =>
+  Promise.resolve({
+    version: 1,
+    dailyNoteFormat: "2006-01-02",
+    dailyNoteFolder: "",
+    defaultTags: [],
+    ignorePatterns: [".git", "node_modules"],
+    attachmentFolder: "attachments",
+    templateFolder: "",
+    linkStyle: "wikilink",
+    trashRetention: 30,
+  });
+
+export const SaveWorkspaceConfig = (workspaceId, config) => Promise.resolve(config);
+
 export const GetAllTasks = () =>
   Promise.resolve({
     tasks: [],
@@ -14,41 +14,71 @@ module Raw =
   [<Import("OpenWorkspace", from = "@wailsjs/go/main/App")>]
   let openWorkspace (path : string) : JS.Promise<obj> = jsNative
 
+  [<Import("IndexStatus", from = "@wailsjs/go/main/App")>]
+  let indexStatus (workspaceId : string) : JS.Promise<obj> = jsNative
+
+  [<Import("ReindexWorkspace", from = "@wailsjs/go/main/App")>]
+  let reindexWorkspace (workspaceId : string) : JS.Promise<unit> = jsNative
+
   [<Import("ListNotes", from = "@wailsjs/go/main/App")>]
-  let listNotes () : JS.Promise<obj> = jsNative
+  let listNotes (workspaceId : string) : JS.Promise<obj> = jsNative
 
   [<Import("GetNote", from = "@wailsjs/go/main/App")>]
-  let getNote (id : string) : JS.Promise<obj> = jsNative
+  let getNote (workspaceId : string) (id : string) : JS.Promise<obj> = jsNative
 
   [<Import("SaveNote", from = "@wailsjs/go/main/App")>]
-  let saveNote (note : Note) : JS.Promise<unit> = jsNative
+  let saveNote (workspaceId : string) (note : Note) : JS.Promise<obj> = jsNative
 
   [<Import("DeleteNote", from = "@wailsjs/go/main/App")>]
-  let deleteNote (id : string) : JS.Promise<unit> = jsNative
+  let deleteNote (workspaceId : string) (id : string) : JS.Promise<unit> = jsNative
+
+  [<Import("ListTrash", from = "@wailsjs/go/main/App")>]
+  let listTrash (workspaceId : string) : JS.Promise<obj> = jsNative
+
+  [<Import("RestoreNote", from = "@wailsjs/go/main/App")>]
+  let restoreNote (workspaceId : string) (entryId : string) : JS.Promise<obj> = jsNative
+
+  [<Import("EmptyTrash", from = "@wailsjs/go/main/App")>]
+  let emptyTrash (workspaceId : string) : JS.Promise<int> = jsNative
+
+  [<Import("ListFolderTree", from = "@wailsjs/go/main/App")>]
+  let listFolderTree (workspaceId : string) : JS.Promise<obj> = jsNative
+
+  [<Import("CreateFolder", from = "@wailsjs/go/main/App")>]
+  let createFolder (workspaceId : string) (folder : string) : JS.Promise<string> = jsNative
+
+  [<Import("RenameFolder", from = "@wailsjs/go/main/App")>]
+  let renameFolder (workspaceId : string) (folder : string) (name : string) (dryRun : bool) : JS.Promise<obj> = jsNative
+
+  [<Import("MoveFolder", from = "@wailsjs/go/main/App")>]
+  let moveFolder (workspaceId : string) (oldPath : string) (newPath : string) (dryRun : bool) : JS.Promise<obj> = jsNative
+
+  [<Import("DeleteFolder", from = "@wailsjs/go/main/App")>]
+  let deleteFolder (workspaceId : string) (folder : string) : JS.Promise<obj> = jsNative
 
   [<Import("CreateNote", from = "@wailsjs/go/main/App")>]
-  let createNote (title : string) (folder : string) : JS.Promise<obj> = jsNative
+  let createNote (workspaceId : string) (title : string) (folder : string) : JS.Promise<obj> = jsNative
 
   [<Import("GetBacklinks", from = "@wailsjs/go/main/App")>]
-  let getBacklinks (noteId : string) : JS.Promise<obj> = jsNative
+  let getBacklinks (workspaceId : string) (noteId : string) : JS.Promise<obj> = jsNative
 
   [<Import("GetGraph", from = "@wailsjs/go/main/App")>]
-  let getGraph () : JS.Promise<obj> = jsNative
+  let getGraph (workspaceId : string) : JS.Promise<obj> = jsNative
 
   [<Import("Search", from = "@wailsjs/go/main/App")>]
-  let search (query : SearchQuery) : JS.Promise<obj> = jsNative
+  let search (workspaceId : string) (query : SearchQuery) : JS.Promise<obj> = jsNative
 
   [<Import("GetNotesWithTag", from = "@wailsjs/go/main/App")>]
-  let getNotesWithTag (tagName : string) : JS.Promise<obj> = jsNative
+  let getNotesWithTag (workspaceId : string) (tagName : string) : JS.Promise<obj> = jsNative
 
   [<Import("GetAllTags", from = "@wailsjs/go/main/App")>]
-  let getAllTags () : JS.Promise<obj> = jsNative
+  let getAllTags (workspaceId : string) : JS.Promise<obj> = jsNative
 
   [<Import("GetAllTagsWithCounts", from = "@wailsjs/go/main/App")>]
-  let getAllTagsWithCounts () : JS.Promise<obj> = jsNative
+  let getAllTagsWithCounts (workspaceId : string) : JS.Promise<obj> = jsNative
 
   [<Import("GetTagInfo", from = "@wailsjs/go/main/App")>]
-  let getTagInfo (tagName : string) : JS.Promise<obj> = jsNative
+  let getTagInfo (workspaceId : string) (tagName : string) : JS.Promise<obj> = jsNative
 
   [<Import("RenderMarkdown", from = "@wailsjs/go/main/App")>]
   let renderMarkdown (markdown : string) : JS.Promise<string> = jsNative
@@ -75,13 +105,13 @@ module Raw =
   let saveSettings (settings : Settings) : JS.Promise<unit> = jsNative
 
   [<Import("LoadWorkspaceSnapshot", from = "@wailsjs/go/main/App")>]
-  let loadWorkspaceSnapshot () : JS.Promise<obj> = jsNative
+  let loadWorkspaceSnapshot (workspaceId : string) : JS.Promise<obj> = jsNative
 
   [<Import("SaveWorkspaceSnapshot", from = "@wailsjs/go/main/App")>]
-  let saveWorkspaceSnapshot (snapshot : WorkspaceSnapshot) : JS.Promise<unit> = jsNative
+  let saveWorkspaceSnapshot (workspaceId : string) (snapshot : WorkspaceSnapshot) : JS.Promise<unit> = jsNative
 
   [<Import("ClearRecentFiles", from = "@wailsjs/go/main/App")>]
-  let clearRecentFiles () : JS.Promise<obj> = jsNative
+  let clearRecentFiles (workspaceId : string) : JS.Promise<obj> = jsNative
 
   [<Import("LoadAppSnapshot", from = "@wailsjs/go/main/App")>]
   let loadAppSnapshot () : JS.Promise<obj> = jsNative
@@ -90,7 +120,7 @@ module Raw =
   let saveAppSnapshot (snapshot : AppSnapshot) : JS.Promise<unit> = jsNative
 
   [<Import("CloseWorkspace", from = "@wailsjs/go/main/App")>]
-  let closeWorkspace () : JS.Promise<unit> = jsNative
+  let closeWorkspace (workspaceId : string) : JS.Promise<unit> = jsNative
 
   [<Import("GetUserConfigDir", from = "@wailsjs/go/main/App")>]
   let getUserConfigDir () : string = jsNative
@@ -98,14 +128,20 @@ module Raw =
   [<Import("InitWorkspaceConfigDir", from = "@wailsjs/go/main/App")>]
   let initWorkspaceConfigDir (workspaceRoot : string) : JS.Promise<string> = jsNative
 
+  [<Import("GetWorkspaceConfig", from = "@wailsjs/go/main/App")>]
+  let getWorkspaceConfig (workspaceId : string) : JS.Promise<obj> = jsNative
+
+  [<Import("SaveWorkspaceConfig", from = "@wailsjs/go/main/App")>]
+  let saveWorkspaceConfig (workspaceId : string) (config : obj) : JS.Promise<obj> = jsNative
+
   [<Import("GetAllTasks", from = "@wailsjs/go/main/App")>]
-  let getAllTasks (filter : TaskFilter) : JS.Promise<obj> = jsNative
+  let getAllTasks (workspaceId : string) (filter : TaskFilter) : JS.Promise<obj> = jsNative
 
   [<Import("GetTasksForNote", from = "@wailsjs/go/main/App")>]
-  let getTasksForNote (noteId : string) : JS.Promise<obj> = jsNative
+  let getTasksForNote (workspaceId : string) (noteId : string) : JS.Promise<obj> = jsNative
 
   [<Import("ToggleTaskInNote", from = "@wailsjs/go/main/App")>]
-  let toggleTaskInNote (noteId : string) (lineNumber : int) : JS.Promise<unit> = jsNative
+  let toggleTaskInNote (workspaceId : string) (noteId : string) (lineNumber : int) : JS.Promise<unit> = jsNative
 
   [<Import("ListThemes", from = "@wailsjs/go/main/App")>]
   let listThemes () : JS.Promise<string array> = jsNative
@@ -127,6 +163,9 @@ let decodeResponse<'T> (decoder : Decoder<'T>) (response : obj) : 'T =
   | Ok value -> value
   | Error err -> failwith $"JSON decode error: {err}"
 
+/// Workspace ID that makes workspace-scoped bindings act on the active workspace
+let activeWorkspace = ""
+
 /// Typed API wrappers that use Thoth.Json decoders
 let createNewWorkspace () : JS.Promise<WorkspaceInfo option> =
   Raw.createNewWorkspace ()
@@ -140,7 +179,7 @@ let openWorkspace (path : string) : JS.Promise<WorkspaceInfo> =
   Raw.openWorkspace path |> Promise.map (decodeResponse Json.workspaceInfoDecoder)
 
 let listNotes () : JS.Promise<NoteSummary array> =
-  Raw.listNotes ()
+  Raw.listNotes activeWorkspace
   |> Promise.map (fun response ->
     let json = JS.JSON.stringify response
 
@@ -149,16 +188,52 @@ let listNotes () : JS.Promise<NoteSummary array> =
     | Error err -> failwith $"JSON decode error: {err}")
 
 let getNote (id : string) : JS.Promise<Note> =
-  Raw.getNote id |> Promise.map (decodeResponse Json.noteDecoder)
+  Raw.getNote activeWorkspace id |> Promise.map (decodeResponse Json.noteDecoder)
 
-let saveNote = Raw.saveNote
-let deleteNote = Raw.deleteNote
+/// Gets how far the workspace's index build has got: its state and done/total notes
+let indexStatus () = Raw.indexStatus activeWorkspace
+
+/// Rebuilds the workspace's indexes in the background, reporting index:progress events
+let reindexWorkspace () = Raw.reindexWorkspace activeWorkspace
+
+/// Saves a note based on the file version its Hash identifies. Changes made on disk since are
+/// merged into the result; a save that cannot be merged comes back with a Conflict instead
+let saveNote (note : Note) : JS.Promise<SaveNoteResult> =
+  Raw.saveNote activeWorkspace note |> Promise.map (decodeResponse Json.saveNoteResultDecoder)
+
+let deleteNote (id : string) = Raw.deleteNote activeWorkspace id
+
+/// Lists the deleted notes in the trash, most recently deleted first
+let listTrash () = Raw.listTrash activeWorkspace
+
+/// Restores a deleted note, returning the trash entry and the path it was restored to
+let restoreNote (entryId : string) = Raw.restoreNote activeWorkspace entryId
+
+/// Permanently deletes every note in the trash, returning how many were deleted
+let emptyTrash () = Raw.emptyTrash activeWorkspace
+
+/// Gets the workspace folders as a tree with note counts
+let listFolderTree () = Raw.listFolderTree activeWorkspace
+
+/// Creates a folder, returning its cleaned path
+let createFolder (folder : string) = Raw.createFolder activeWorkspace folder
+
+/// Renames a folder, rewriting the links that depend on its path
+let renameFolder (folder : string) (name : string) (dryRun : bool) =
+  Raw.renameFolder activeWorkspace folder name dryRun
+
+/// Moves a folder, rewriting the links that depend on its path
+let moveFolder (oldPath : string) (newPath : string) (dryRun : bool) =
+  Raw.moveFolder activeWorkspace oldPath newPath dryRun
+
+/// Moves a folder with everything in it to the trash
+let deleteFolder (folder : string) = Raw.deleteFolder activeWorkspace folder
 
 let createNote (title : string) (folder : string) : JS.Promise<Note> =
-  Raw.createNote title folder |> Promise.map (decodeResponse Json.noteDecoder)
+  Raw.createNote activeWorkspace title folder |> Promise.map (decodeResponse Json.noteDecoder)
 
 let getBacklinks (noteId : string) : JS.Promise<Link array> =
-  Raw.getBacklinks noteId
+  Raw.getBacklinks activeWorkspace noteId
   |> Promise.map (fun response ->
     let json = JS.JSON.stringify response
 
@@ -167,10 +242,10 @@ let getBacklinks (noteId : string) : JS.Promise<Link array> =
     | Error err -> failwith $"JSON decode error: {err}")
 
 let getGraph () : JS.Promise<Graph> =
-  Raw.getGraph () |> Promise.map (decodeResponse Json.graphDecoder)
+  Raw.getGraph activeWorkspace |> Promise.map (decodeResponse Json.graphDecoder)
 
 let search (query : SearchQuery) : JS.Promise<SearchResult array> =
-  Raw.search query
+  Raw.search activeWorkspace query
   |> Promise.map (fun response ->
     let json = JS.JSON.stringify response
 
@@ -179,7 +254,7 @@ let search (query : SearchQuery) : JS.Promise<SearchResult array> =
     | Error err -> failwith $"JSON decode error: {err}")
 
 let getNotesWithTag (tagName : string) : JS.Promise<string array> =
-  Raw.getNotesWithTag tagName
+  Raw.getNotesWithTag activeWorkspace tagName
   |> Promise.map (fun response ->
     let json = JS.JSON.stringify response
 
@@ -188,7 +263,7 @@ let getNotesWithTag (tagName : string) : JS.Promise<string array> =
     | Error err -> failwith $"JSON decode error: {err}")
 
 let getAllTags () : JS.Promise<string array> =
-  Raw.getAllTags ()
+  Raw.getAllTags activeWorkspace
   |> Promise.map (fun response ->
     let json = JS.JSON.stringify response
 
@@ -197,7 +272,7 @@ let getAllTags () : JS.Promise<string array> =
     | Error err -> failwith $"JSON decode error: {err}")
 
 let getAllTagsWithCounts () : JS.Promise<TagInfo array> =
-  Raw.getAllTagsWithCounts ()
+  Raw.getAllTagsWithCounts activeWorkspace
   |> Promise.map (fun response ->
     let json = JS.JSON.stringify response
 
@@ -206,7 +281,7 @@ let getAllTagsWithCounts () : JS.Promise<TagInfo array> =
     | Error err -> failwith $"JSON decode error: {err}")
 
 let getTagInfo (tagName : string) : JS.Promise<TagInfo> =
-  Raw.getTagInfo tagName |> Promise.map (decodeResponse Json.tagInfoDecoder)
+  Raw.getTagInfo activeWorkspace tagName |> Promise.map (decodeResponse Json.tagInfoDecoder)
 
 let renderMarkdown = Raw.renderMarkdown
 let selectDirectory = Raw.selectDirectory
@@ -221,13 +296,14 @@ let loadSettings () : JS.Promise<Settings> =
 let saveSettings = Raw.saveSettings
 
 let loadWorkspaceSnapshot () : JS.Promise<WorkspaceSnapshot> =
-  Raw.loadWorkspaceSnapshot ()
+  Raw.loadWorkspaceSnapshot activeWorkspace
   |> Promise.map (decodeResponse Json.workspaceSnapshotDecoder)
 
-let saveWorkspaceSnapshot = Raw.saveWorkspaceSnapshot
+let saveWorkspaceSnapshot (snapshot : WorkspaceSnapshot) =
+  Raw.saveWorkspaceSnapshot activeWorkspace snapshot
 
 let clearRecentFiles () : JS.Promise<WorkspaceSnapshot> =
-  Raw.clearRecentFiles ()
+  Raw.clearRecentFiles activeWorkspace
   |> Promise.map (decodeResponse Json.workspaceSnapshotDecoder)
 
 let loadAppSnapshot () : JS.Promise<AppSnapshot> =
@@ -235,7 +311,7 @@ let loadAppSnapshot () : JS.Promise<AppSnapshot> =
 
 let saveAppSnapshot = Raw.saveAppSnapshot
 
-let closeWorkspace = Raw.closeWorkspace
+let closeWorkspace () = Raw.closeWorkspace activeWorkspace
 
 /// Gets the user configuration directory path (synchronous)
 let getUserConfigDir () : string = Raw.getUserConfigDir ()
@@ -243,13 +319,23 @@ let getUserConfigDir () : string = Raw.getUserConfigDir ()
 /// Initializes and returns the workspace configuration directory
 let initWorkspaceConfigDir = Raw.initWorkspaceConfigDir
 
+/// Gets the workspace settings from .knowledgelab/config.toml
+let getWorkspaceConfig () : JS.Promise<WorkspaceConfig> =
+  Raw.getWorkspaceConfig activeWorkspace
+  |> Promise.map (decodeResponse Json.workspaceConfigDecoder)
+
+/// Validates and saves the workspace settings, returning them as saved
+let saveWorkspaceConfig (config : obj) : JS.Promise<WorkspaceConfig> =
+  Raw.saveWorkspaceConfig activeWorkspace config
+  |> Promise.map (decodeResponse Json.workspaceConfigDecoder)
+
 /// Gets all tasks matching the provided filter
 let getAllTasks (filter : TaskFilter) : JS.Promise<TaskInfo> =
-  Raw.getAllTasks filter |> Promise.map (decodeResponse Json.taskInfoDecoder)
+  Raw.getAllTasks activeWorkspace filter |> Promise.map (decodeResponse Json.taskInfoDecoder)
 
 /// Gets all tasks for a specific note
 let getTasksForNote (noteId : string) : JS.Promise<Task array> =
-  Raw.getTasksForNote noteId
+  Raw.getTasksForNote activeWorkspace noteId
   |> Promise.map (fun response ->
     let json = JS.JSON.stringify response
 
@@ -258,7 +344,8 @@ let getTasksForNote (noteId : string) : JS.Promise<Task array> =
     | Error err -> failwith $"JSON decode error: {err}")
 
 /// Toggles the completion status of a task at the specified line number
-let toggleTaskInNote = Raw.toggleTaskInNote
+let toggleTaskInNote (noteId : string) (lineNumber : int) =
+  Raw.toggleTaskInNote activeWorkspace noteId lineNumber
 
 /// Lists all available theme slugs
 let listThemes = Raw.listThemes
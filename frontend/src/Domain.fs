@@ -16,6 +16,8 @@ type Note = {
   Tags : Tag list
   CreatedAt : DateTime
   ModifiedAt : DateTime
+  /// Hash of the file as loaded or last saved; saves based on a stale hash are merged or refused
+  Hash : string
 }
 
 /// Block represents an outline-style content block within a note
@@ -58,6 +60,23 @@ and Tag = { Name : string; NoteId : string }
 /// TagInfo provides aggregated information about a tag across the workspace
 and TagInfo = { Name : string; Count : int; NoteIds : string list }
 
+/// NoteConflict describes a save refused because the note changed on disk and could not be merged
+type NoteConflict = {
+  NoteId : string
+  /// Conflict copy beside the note that holds the refused content
+  CopyPath : string
+  /// Number of regions changed differently locally and on disk
+  HunkCount : int
+}
+
+/// SaveNoteResult is the outcome of a save: the note as saved, or a conflict
+type SaveNoteResult = {
+  Note : Note option
+  /// The note changed on disk and Note is the clean merge of both versions
+  Merged : bool
+  Conflict : NoteConflict option
+}
+
 /// NoteSummary provides a lightweight note representation for lists
 type NoteSummary = {
   id : string
@@ -94,10 +113,36 @@ type WorkspaceInfo = {
 }
 
 /// Graph represents the complete note graph structure
-type Graph = { Nodes : string list; Edges : GraphEdge list }
+type Graph = { Nodes : GraphNodeInfo list; Edges : GraphEdge list }
+
+/// GraphNodeInfo describes a note or attachment in the graph, as sent by the backend
+and GraphNodeInfo = {
+  Id : string
+  Title : string
+  Path : string
+  Folder : string
+  Tags : string list
+  Type : string
+  WordCount : int
+  Kind : string
+  Exists : bool
+} with
+
+  /// A node known only by its ID, titled after it
+  static member OfId(id : string) : GraphNodeInfo = {
+    Id = id
+    Title = id
+    Path = id
+    Folder = ""
+    Tags = []
+    Type = ""
+    WordCount = 0
+    Kind = "note"
+    Exists = true
+  }
 
-/// GraphEdge represents a connection between two notes
-and GraphEdge = { Source : string; Target : string; Type : string }
+/// GraphEdge represents all links from one note to another; Weight is how many there are
+and GraphEdge = { Source : string; Target : string; Type : string; Weight : int }
 
 /// SearchQuery represents a search request with filters
 type SearchQuery = {
@@ -126,6 +171,8 @@ type GraphNode = {
   Label : string
   Group : int
   Degree : int
+  WordCount : int
+  Exists : bool
   mutable X : float option
   mutable Y : float option
   mutable Vx : float option
@@ -82,11 +82,13 @@ let graphToGraphData (graph : Graph) : GraphData =
 
   let nodes =
     safeNodes
-    |> List.mapi (fun i id -> {
-      Id = id
-      Label = id
+    |> List.map (fun node -> {
+      Id = node.Id
+      Label = node.Title
       Group = 0
-      Degree = nodeDegrees |> Map.tryFind id |> Option.defaultValue 0
+      Degree = nodeDegrees |> Map.tryFind node.Id |> Option.defaultValue 0
+      WordCount = node.WordCount
+      Exists = node.Exists
       X = None
       Y = None
       Vx = None
@@ -95,10 +97,17 @@ let graphToGraphData (graph : Graph) : GraphData =
 
   let links =
     safeEdges
-    |> List.map (fun edge -> { Source = edge.Source; Target = edge.Target; Value = 1.0 })
+    |> List.map (fun edge -> { Source = edge.Source; Target = edge.Target; Value = float (max edge.Weight 1) })
 
   { Nodes = nodes; Links = links }
 
+/// Radius of a node: larger for notes with more links and longer notes
+let nodeRadius (node : GraphNode) : float =
+  5.0 + float node.Degree + min 5.0 (sqrt (float node.WordCount) / 10.0)
+
+/// Stroke width of a link: wider for notes linked several times
+let linkWidth (link : GraphLink) : float = min 4.0 (1.0 + 0.5 * link.Value)
+
 /// Build a neighbor lookup map for fast neighbor checking
 let buildNeighborMap (links : GraphLink list) : Map<string, Set<string>> =
   links
@@ -303,7 +312,7 @@ let SvgGraph (state : State) (dispatch : Msg -> unit) =
                                 "key" ==> $"{link.Source}-{link.Target}"
                                 "className" ==> getLinkClass link
                                 "stroke" ==> CssVars.base03
-                                "strokeWidth" ==> 1.5
+                                "strokeWidth" ==> linkWidth link
                                 "data-source" ==> link.Source
                                 "data-target" ==> link.Target
                               ]
@@ -315,8 +324,8 @@ let SvgGraph (state : State) (dispatch : Msg -> unit) =
                               createObj [
                                 "key" ==> $"node-{node.Id}"
                                 "className" ==> getNodeClass node.Id
-                                "r" ==> (5.0 + float node.Degree)
-                                "fill" ==> CssVars.base0D
+                                "r" ==> nodeRadius node
+                                "fill" ==> (if node.Exists then CssVars.base0D else CssVars.base03)
                                 "stroke" ==> CssVars.base05
                                 "strokeWidth" ==> 1.5
                                 "data-id" ==> node.Id
@@ -398,7 +407,7 @@ let CanvasGraph (state : State) (dispatch : Msg -> unit) =
       | Some nx, Some ny ->
         let dx = x - nx
         let dy = y - ny
-        let radius = 5.0 + float node.Degree
+        let radius = nodeRadius node
         (dx * dx + dy * dy) <= (radius * radius)
       | _ -> false)
 
@@ -437,7 +446,7 @@ let CanvasGraph (state : State) (dispatch : Msg -> unit) =
           ctx.moveTo (sx, sy)
           ctx.lineTo (tx, ty)
           ctx.strokeStyle <- U3.Case1 CssVars.base03
-          ctx.lineWidth <- if isHighlighted then 2.5 else 1.5
+          ctx.lineWidth <- linkWidth link + (if isHighlighted then 1.0 else 0.0)
 
           ctx.globalAlpha <-
             if isDimmed then 0.1
@@ -461,7 +470,7 @@ let CanvasGraph (state : State) (dispatch : Msg -> unit) =
           | Some hoveredId when hoveredId <> node.Id -> not (areNeighbors neighborMap hoveredId node.Id)
           | _ -> false
 
-        let radius = 5.0 + float node.Degree
+        let radius = nodeRadius node
 
         ctx.beginPath ()
         ctx.arc (x, y, radius, 0.0, 2.0 * System.Math.PI)
@@ -112,21 +112,63 @@ let noteDecoder : Decoder<Note> =
       Tags = get.Required.Field "tags" (Decode.list tagDecoder)
       CreatedAt = get.Required.Field "createdAt" Decode.datetimeUtc
       ModifiedAt = get.Required.Field "modifiedAt" Decode.datetimeUtc
+      Hash = get.Optional.Field "hash" Decode.string |> Option.defaultValue ""
     })
 
+/// Decodes a NoteConflict from JSON
+let noteConflictDecoder : Decoder<NoteConflict> =
+  Decode.object (fun get -> {
+    NoteId = get.Required.Field "noteId" Decode.string
+    CopyPath = get.Required.Field "copyPath" Decode.string
+    HunkCount =
+      get.Optional.Field "hunks" (Decode.oneOf [ Decode.list Decode.value; Decode.nil [] ])
+      |> Option.map List.length
+      |> Option.defaultValue 0
+  })
+
+/// Decodes a SaveNoteResult from JSON; `note` is null on conflict and `conflict` null otherwise
+let saveNoteResultDecoder : Decoder<SaveNoteResult> =
+  Decode.object (fun get -> {
+    Note = get.Optional.Field "note" noteDecoder
+    Merged = get.Optional.Field "merged" Decode.bool |> Option.defaultValue false
+    Conflict = get.Optional.Field "conflict" noteConflictDecoder
+  })
+
 /// Decodes a GraphEdge from JSON
 let graphEdgeDecoder : Decoder<GraphEdge> =
   Decode.object (fun get -> {
     Source = get.Required.Field "source" Decode.string
     Target = get.Required.Field "target" Decode.string
     Type = get.Required.Field "type" Decode.string
+    Weight = get.Optional.Field "weight" Decode.int |> Option.defaultValue 1
   })
 
+/// Decodes a graph node from JSON.
+/// The backend sends node objects with metadata; bare string IDs are still accepted and titled after the ID.
+let graphNodeDecoder : Decoder<GraphNodeInfo> =
+  Decode.oneOf [
+    Decode.string |> Decode.map GraphNodeInfo.OfId
+    Decode.object (fun get ->
+      let id = get.Required.Field "id" Decode.string
+
+      {
+        Id = id
+        Title = get.Optional.Field "title" Decode.string |> Option.filter ((<>) "") |> Option.defaultValue id
+        Path = get.Optional.Field "path" Decode.string |> Option.defaultValue id
+        Folder = get.Optional.Field "folder" Decode.string |> Option.defaultValue ""
+        Tags = get.Optional.Field "tags" (Decode.oneOf [ Decode.list Decode.string; Decode.nil [] ]) |> Option.defaultValue []
+        Type = get.Optional.Field "type" Decode.string |> Option.defaultValue ""
+        WordCount = get.Optional.Field "wordCount" Decode.int |> Option.defaultValue 0
+        Kind = get.Optional.Field "kind" Decode.string |> Option.defaultValue "note"
+        Exists = get.Optional.Field "exists" Decode.bool |> Option.defaultValue true
+      })
+  ]
+
 /// Decodes a Graph from JSON
 let graphDecoder : Decoder<Graph> =
   Decode.object (fun get -> {
-    Nodes = get.Required.Field "nodes" (Decode.list Decode.string)
-    Edges = get.Required.Field "edges" (Decode.list graphEdgeDecoder)
+    Nodes = get.Required.Field "nodes" (Decode.oneOf [ Decode.list graphNodeDecoder; Decode.nil [] ])
+    Edges = get.Required.Field "edges" (Decode.oneOf [ Decode.list graphEdgeDecoder; Decode.nil [] ])
   })
 
 /// Decodes a SearchResult from JSON
@@ -262,9 +262,9 @@ type Msg =
   | RecentFilesCleared of Result<WorkspaceSnapshot, string>
   | NoteLoaded of Result<Note, string>
   | SaveNote of Note
-  | NoteSaved of Result<unit, string>
+  | NoteSaved of Result<SaveNoteResult, string>
   | SaveNoteExplicitly
-  | ExplicitSaveCompleted of Result<unit, string>
+  | ExplicitSaveCompleted of Result<SaveNoteResult, string>
   | CreateNote of title : string * folder : string
   | NoteCreated of Result<Note, string>
   | DeleteNote of noteId : string
@@ -759,6 +759,19 @@ let private updateRecentPage (noteId : string) (state : State) : State * Cmd<Msg
       { state with WorkspaceSnapshot = Some updatedSnapshot }, Cmd.ofMsg (WorkspaceSnapshotChanged updatedSnapshot)
     | None -> state, Cmd.none
 
+/// Describes a refused save for the error banner
+let private conflictMessage (conflict : NoteConflict) : string =
+  $"{conflict.NoteId} changed on disk and {conflict.HunkCount} region(s) could not be merged. Your version was saved to {conflict.CopyPath}"
+
+/// Adopts the outcome of a save into the open note: its new hash, and the merged content when the
+/// save was merged with changes made on disk. Edits made while the save ran are kept otherwise.
+let private applySavedNote (result : SaveNoteResult) (state : State) : State =
+  match result.Note, state.CurrentNote with
+  | Some saved, Some current when saved.Id = current.Id ->
+    let note = if result.Merged then saved else { current with Hash = saved.Hash }
+    { state with CurrentNote = Some note }
+  | _ -> state
+
 let Init () =
   let currentUrl = Router.currentUrl ()
   let currentRoute = parseUrl currentUrl
@@ -968,18 +981,25 @@ let Update (msg : Msg) (state : State) : (State * Cmd<Msg>) =
     { state with Loading = false; Error = Some err }, Cmd.none
   | SaveNote note ->
     { state with Loading = true },
-    Cmd.OfPromise.either Api.saveNote note (fun _ -> NoteSaved(Ok())) (fun ex -> NoteSaved(Error ex.Message))
-  | NoteSaved(Ok()) ->
-    { state with Loading = false; Error = None }, Cmd.batch [ Cmd.ofMsg LoadNotes; Cmd.ofMsg LoadGraph ]
+    Cmd.OfPromise.either Api.saveNote note (Ok >> NoteSaved) (fun ex -> NoteSaved(Error ex.Message))
+  | NoteSaved(Ok { Conflict = Some conflict }) ->
+    { state with Loading = false; Error = Some(conflictMessage conflict) }, Cmd.none
+  | NoteSaved(Ok result) ->
+    { applySavedNote result state with Loading = false; Error = None },
+    Cmd.batch [ Cmd.ofMsg LoadNotes; Cmd.ofMsg LoadGraph ]
   | NoteSaved(Error err) -> { state with Loading = false; Error = Some err }, Cmd.none
   | SaveNoteExplicitly ->
     match state.CurrentNote with
     | Some note ->
       { state with Loading = true },
-      Cmd.OfPromise.either Api.saveNote note (fun _ -> ExplicitSaveCompleted(Ok())) (fun ex ->
+      Cmd.OfPromise.either Api.saveNote note (Ok >> ExplicitSaveCompleted) (fun ex ->
         ExplicitSaveCompleted(Error ex.Message))
     | None -> state, Cmd.none
-  | ExplicitSaveCompleted(Ok()) ->
+  | ExplicitSaveCompleted(Ok { Conflict = Some conflict }) ->
+    { state with Loading = false; Error = Some(conflictMessage conflict) }, Cmd.none
+  | ExplicitSaveCompleted(Ok result) ->
+    let state = applySavedNote result state
+
     let clearedEditorState = {
       state.EditorState with
           UndoStack = []
@@ -992,7 +1012,13 @@ let Update (msg : Msg) (state : State) : (State * Cmd<Msg>) =
       state with
           Loading = false
           Error = None
-          Success = Some "Note saved"
+          Success =
+            Some(
+              if result.Merged then
+                "Note saved and merged with changes made on disk"
+              else
+                "Note saved"
+            )
           EditorState = clearedEditorState
     },
     Cmd.batch [ Cmd.ofMsg LoadNotes; Cmd.ofMsg LoadGraph ]
@@ -183,12 +183,44 @@ Jest.describe (
         match result with
         | Ok graph ->
           Jest.expect(graph.Nodes.Length).toEqual 3
+          Jest.expect(graph.Nodes.[0].Title).toEqual "note1"
           Jest.expect(graph.Edges.Length).toEqual 2
           Jest.expect(graph.Edges.[0].Source).toEqual "note1"
           Jest.expect(graph.Edges.[0].Target).toEqual "note2"
         | Error err -> failwith $"Decode failed: {err}"
     )
 
+    Jest.test (
+      "Graph decoder handles node objects and weighted edges",
+      fun () ->
+        let json =
+          """
+    {
+      "nodes": [
+        {"id": "note1.md", "title": "Note 1", "path": "note1.md", "folder": "", "tags": ["a"], "wordCount": 120, "kind": "note", "exists": true},
+        {"id": "note2.md", "title": "note2", "path": "note2.md", "folder": "", "tags": [], "exists": false}
+      ],
+      "edges": [
+        {"source": "note1.md", "target": "note2.md", "type": "wiki", "weight": 3, "counts": {"wiki": 2, "embed": 1}}
+      ]
+    }
+    """
+
+        let result = Decode.fromString graphDecoder json
+
+        match result with
+        | Ok graph ->
+          Jest.expect(graph.Nodes.Length).toEqual 2
+          Jest.expect(graph.Nodes.[0].Id).toEqual "note1.md"
+          Jest.expect(graph.Nodes.[0].Title).toEqual "Note 1"
+          Jest.expect(graph.Nodes.[0].Tags).toEqual [ "a" ]
+          Jest.expect(graph.Nodes.[1].Exists).toEqual false
+          Jest.expect(graph.Edges.Length).toEqual 1
+          Jest.expect(graph.Edges.[0].Type).toEqual "wiki"
+          Jest.expect(graph.Edges.[0].Weight).toEqual 3
+        | Error err -> failwith $"Decode failed: {err}"
+    )
+
     Jest.test (
       "Link decoder handles all link types",
       fun () ->
@@ -243,6 +275,65 @@ Jest.describe (
         | Error err -> failwith $"Decode failed: {err}"
     )
 
+    Jest.test (
+      "SaveNoteResult decoder reads the saved note's hash and conflicts",
+      fun () ->
+        let saved =
+          """
+    {
+      "note": {
+        "id": "note-1",
+        "title": "Saved",
+        "path": "note-1.md",
+        "content": "Content",
+        "frontmatter": {},
+        "aliases": [],
+        "type": "",
+        "blocks": [],
+        "links": [],
+        "tags": [],
+        "createdAt": "2025-01-28T12:00:00Z",
+        "modifiedAt": "2025-01-28T12:05:00Z",
+        "hash": "abc123"
+      },
+      "merged": true,
+      "conflict": null
+    }
+    """
+
+        match Decode.fromString saveNoteResultDecoder saved with
+        | Ok result ->
+          Jest.expect(result.Merged).toEqual true
+          Jest.expect(result.Conflict.IsNone).toEqual true
+          Jest.expect(result.Note.Value.Hash).toEqual "abc123"
+        | Error err -> failwith $"Decode failed: {err}"
+
+        let refused =
+          """
+    {
+      "note": null,
+      "merged": false,
+      "conflict": {
+        "noteId": "note-1.md",
+        "baseHash": "abc123",
+        "diskHash": "def456",
+        "local": "mine",
+        "disk": "theirs",
+        "merged": "theirs",
+        "hunks": [{ "line": 1, "base": ["base"], "local": ["mine"], "disk": ["theirs"] }],
+        "copyPath": "note-1 (conflict 2026-10-18).md"
+      }
+    }
+    """
+
+        match Decode.fromString saveNoteResultDecoder refused with
+        | Ok result ->
+          Jest.expect(result.Note.IsNone).toEqual true
+          Jest.expect(result.Conflict.Value.CopyPath).toEqual "note-1 (conflict 2026-10-18).md"
+          Jest.expect(result.Conflict.Value.HunkCount).toEqual 1
+        | Error err -> failwith $"Decode failed: {err}"
+    )
+
     Jest.test (
       "SearchResult decoder handles valid JSON",
       fun () ->
@@ -56,6 +56,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let initialState = {
@@ -96,6 +97,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let snapshot = {
@@ -135,6 +137,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let recentTimestamp = System.DateTime.Now.AddMilliseconds(-500.0)
@@ -169,6 +172,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let initialState = {
@@ -209,6 +213,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let initialState = {
@@ -246,6 +251,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let initialState = {
@@ -281,6 +287,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let initialState = {
@@ -316,6 +323,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let initialState = {
@@ -351,6 +359,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let initialState = {
@@ -382,6 +391,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let initialState = {
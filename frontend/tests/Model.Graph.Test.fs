@@ -13,10 +13,10 @@ Jest.describe (
         let initialState = State.Default
 
         let testGraph = {
-          Nodes = [ "note1"; "note2"; "note3" ]
+          Nodes = [ "note1"; "note2"; "note3" ] |> List.map GraphNodeInfo.OfId
           Edges = [
-            { Source = "note1"; Target = "note2"; Type = "wiki" }
-            { Source = "note2"; Target = "note3"; Type = "wiki" }
+            { Source = "note1"; Target = "note2"; Type = "wiki"; Weight = 1 }
+            { Source = "note2"; Target = "note3"; Type = "wiki"; Weight = 1 }
           ]
         }
 
@@ -130,11 +130,11 @@ Jest.describe (
       "converts Graph to GraphData with correct degree calculation",
       fun () ->
         let graph = {
-          Nodes = [ "note1"; "note2"; "note3" ]
+          Nodes = [ "note1"; "note2"; "note3" ] |> List.map GraphNodeInfo.OfId
           Edges = [
-            { Source = "note1"; Target = "note2"; Type = "wiki" }
-            { Source = "note2"; Target = "note3"; Type = "wiki" }
-            { Source = "note1"; Target = "note3"; Type = "wiki" }
+            { Source = "note1"; Target = "note2"; Type = "wiki"; Weight = 1 }
+            { Source = "note2"; Target = "note3"; Type = "wiki"; Weight = 1 }
+            { Source = "note1"; Target = "note3"; Type = "wiki"; Weight = 1 }
           ]
         }
 
@@ -156,7 +156,7 @@ Jest.describe (
     Jest.test (
       "handles nodes with no connections",
       fun () ->
-        let graph = { Nodes = [ "note1"; "note2" ]; Edges = [] }
+        let graph = { Nodes = [ "note1"; "note2" ] |> List.map GraphNodeInfo.OfId; Edges = [] }
         let graphData = GraphView.graphToGraphData graph
 
         Jest.expect(graphData.Nodes.Length).toEqual (2)
@@ -165,4 +165,25 @@ Jest.describe (
         let note1 = graphData.Nodes |> List.find (fun n -> n.Id = "note1")
         Jest.expect(note1.Degree).toEqual (0)
     )
+
+    Jest.test (
+      "labels nodes by title and sizes them by word count and link weight",
+      fun () ->
+        let graph = {
+          Nodes = [
+            { GraphNodeInfo.OfId "plan.md" with Title = "Plan"; WordCount = 400 }
+            { GraphNodeInfo.OfId "missing.md" with Exists = false }
+          ]
+          Edges = [ { Source = "plan.md"; Target = "missing.md"; Type = "wiki"; Weight = 3 } ]
+        }
+
+        let graphData = GraphView.graphToGraphData graph
+        let plan = graphData.Nodes |> List.find (fun n -> n.Id = "plan.md")
+        let missing = graphData.Nodes |> List.find (fun n -> n.Id = "missing.md")
+
+        Jest.expect(plan.Label).toEqual ("Plan")
+        Jest.expect(missing.Exists).toEqual (false)
+        Jest.expect(GraphView.nodeRadius plan > GraphView.nodeRadius missing).toEqual (true)
+        Jest.expect(graphData.Links.[0].Value).toEqual (3.0)
+    )
 )
@@ -23,6 +23,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let previousSnapshot = {
@@ -72,6 +73,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let previousSnapshot = {
@@ -116,6 +118,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let snapshot1 = {
@@ -162,6 +165,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let initialState = {
@@ -219,6 +223,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let nextSnapshot = {
@@ -265,6 +270,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let nextSnapshot = {
@@ -309,6 +315,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let initialState = {
@@ -366,6 +373,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let snapshot1 = {
@@ -420,6 +428,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let createSnapshot i = {
@@ -461,6 +470,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let undoSnapshot = {
@@ -514,6 +524,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let savedUndoSnapshot = {
@@ -560,6 +571,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let initialState = { State.Default with NoteHistories = Map.empty }
@@ -586,6 +598,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let note2 = {
@@ -601,6 +614,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let note1UndoSnapshot = {
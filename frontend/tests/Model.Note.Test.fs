@@ -156,6 +156,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let initialState = { State.Default with CurrentNote = Some testNote }
@@ -187,12 +188,64 @@ Jest.describe (
       "NoteSaved success triggers graph reload",
       fun () ->
         let initialState = { State.Default with Loading = true }
-        let newState, cmd = Update (NoteSaved(Ok())) initialState
+        let newState, cmd =
+          Update (NoteSaved(Ok { Note = None; Merged = false; Conflict = None })) initialState
 
         Jest.expect(newState.Loading).toEqual false
         Jest.expect(newState.Error).toEqual None
     )
 
+    Jest.test (
+      "NoteSaved adopts the saved hash and keeps newer edits",
+      fun () ->
+        let testNote = {
+          Id = "note-1"
+          Title = "Note"
+          Path = "note-1.md"
+          Content = "Typed after the save started"
+          Frontmatter = Map.empty
+          Aliases = []
+          Type = ""
+          Blocks = []
+          Links = []
+          Tags = []
+          CreatedAt = System.DateTime.Now
+          ModifiedAt = System.DateTime.Now
+          Hash = "old-hash"
+        }
+
+        let saved = { testNote with Content = "Saved content"; Hash = "new-hash" }
+        let initialState = { State.Default with CurrentNote = Some testNote; Loading = true }
+
+        let newState, _ =
+          Update (NoteSaved(Ok { Note = Some saved; Merged = false; Conflict = None })) initialState
+
+        match newState.CurrentNote with
+        | Some note ->
+          Jest.expect(note.Hash).toEqual "new-hash"
+          Jest.expect(note.Content).toEqual "Typed after the save started"
+        | None -> failwith "Expected note to be present"
+    )
+
+    Jest.test (
+      "NoteSaved with a conflict reports an error instead of success",
+      fun () ->
+        let initialState = { State.Default with Loading = true }
+
+        let conflict = {
+          NoteId = "note-1.md"
+          CopyPath = "note-1 (conflict 2026-10-18).md"
+          HunkCount = 1
+        }
+
+        let newState, _ =
+          Update (NoteSaved(Ok { Note = None; Merged = false; Conflict = Some conflict })) initialState
+
+        Jest.expect(newState.Loading).toEqual false
+        Jest.expect(newState.Error.IsSome).toEqual true
+        Jest.expect(newState.Error.Value.Contains "note-1 (conflict 2026-10-18).md").toEqual true
+    )
+
     Jest.test (
       "NoteLoaded updates recent pages in workspace snapshot",
       fun () ->
@@ -209,6 +262,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let testSnapshot = {
@@ -254,6 +308,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let testSnapshot = {
@@ -298,6 +353,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let testSnapshot = {
@@ -344,6 +400,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let initialState = {
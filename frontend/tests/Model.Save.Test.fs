@@ -5,6 +5,9 @@ open Elmish
 open Model
 open Domain
 
+/// A save that went through without merging
+let private savedResult = { Note = None; Merged = false; Conflict = None }
+
 Jest.describe (
   "Model.Update (Save)",
   fun () ->
@@ -24,6 +27,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let initialState = {
@@ -85,7 +89,7 @@ Jest.describe (
               }
         }
 
-        let newState, _ = Update (ExplicitSaveCompleted(Ok())) initialState
+        let newState, _ = Update (ExplicitSaveCompleted(Ok savedResult)) initialState
 
         Jest.expect(newState.EditorState.UndoStack.Length).toEqual 0
         Jest.expect(newState.EditorState.RedoStack.Length).toEqual 0
@@ -94,6 +98,42 @@ Jest.describe (
         Jest.expect(newState.Success).toEqual (Some "Note saved")
     )
 
+    Jest.test (
+      "ExplicitSaveCompleted with a conflict keeps the undo history and reports an error",
+      fun () ->
+        let undoSnapshot = {
+          Content = "Old content 1"
+          CursorPosition = Some 5
+          SelectionStart = None
+          SelectionEnd = None
+        }
+
+        let initialState = {
+          State.Default with
+              Loading = true
+              EditorState = {
+                State.Default.EditorState with
+                    UndoStack = [ undoSnapshot ]
+                    IsDirty = true
+              }
+        }
+
+        let conflict = {
+          NoteId = "note.md"
+          CopyPath = "note (conflict 2026-10-18).md"
+          HunkCount = 2
+        }
+
+        let newState, _ =
+          Update (ExplicitSaveCompleted(Ok { savedResult with Conflict = Some conflict })) initialState
+
+        Jest.expect(newState.Loading).toEqual false
+        Jest.expect(newState.Success).toEqual None
+        Jest.expect(newState.Error.IsSome).toEqual true
+        Jest.expect(newState.EditorState.UndoStack.Length).toEqual 1
+        Jest.expect(newState.EditorState.IsDirty).toEqual true
+    )
+
     Jest.test (
       "ExplicitSaveCompleted handles errors",
       fun () ->
@@ -121,6 +161,7 @@ Jest.describe (
           Tags = []
           CreatedAt = System.DateTime.Now
           ModifiedAt = System.DateTime.Now
+          Hash = ""
         }
 
         let undoSnapshot = {
@@ -146,7 +187,7 @@ Jest.describe (
         Jest.expect(stateAfterSave.EditorState.UndoStack.Length).toEqual 2
         Jest.expect(stateAfterSave.EditorState.RedoStack.Length).toEqual 1
 
-        let stateAfterCompleted, _ = Update (ExplicitSaveCompleted(Ok())) stateAfterSave
+        let stateAfterCompleted, _ = Update (ExplicitSaveCompleted(Ok savedResult)) stateAfterSave
 
         Jest.expect(stateAfterCompleted.EditorState.UndoStack.Length).toEqual 0
         Jest.expect(stateAfterCompleted.EditorState.RedoStack.Length).toEqual 0